@@ -0,0 +1,327 @@
+package litedb
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// pbkdf2Iterations is the work factor for stored passwords. Clients making
+// many requests should prefer API keys, which are cheap to check.
+const pbkdf2Iterations = 100_000
+
+var (
+	// ErrUnauthenticated is returned for requests to a server under
+	// access control that carry no valid API key or password.
+	ErrUnauthenticated = errors.New("missing or invalid credentials")
+
+	// ErrPermissionDenied is returned for operations the caller's roles do
+	// not allow.
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// Access is a level of permission on a collection. Each level includes
+// the ones below it.
+type Access int
+
+const (
+	// NoAccess allows nothing.
+	NoAccess Access = iota
+	// ReadAccess allows reading, listing, querying and watching records.
+	ReadAccess
+	// WriteAccess also allows writing and deleting records.
+	WriteAccess
+	// AdminAccess also allows deleting the whole collection. Granted on
+	// "*", it opens the stats and the admin dashboard too.
+	AdminAccess
+)
+
+func (a Access) String() string {
+	switch a {
+	case NoAccess:
+		return "none"
+	case ReadAccess:
+		return "read"
+	case WriteAccess:
+		return "write"
+	case AdminAccess:
+		return "admin"
+	}
+	return fmt.Sprintf("Access(%d)", int(a))
+}
+
+// Role grants access to collections by name, "*" standing for every
+// collection.
+type Role struct {
+	Name        string
+	Collections map[string]Access
+}
+
+type aclUser struct {
+	salt, hash []byte
+	roles      []string
+}
+
+// AccessControl holds the users and roles of a database served over HTTP
+// or gRPC. Once installed with UseAccessControl, every request must carry
+// a user's API key, in an "X-API-Key" header or as an "Authorization:
+// Bearer" token, or the user's name and password with basic auth, and is
+// refused what the user's roles do not allow. It is safe for concurrent
+// use, so users can be added and removed while serving.
+type AccessControl struct {
+	mutex sync.RWMutex
+	roles map[string]Role
+	users map[string]*aclUser
+	keys  map[[sha256.Size]byte]string
+}
+
+// NewAccessControl returns an AccessControl without users or roles.
+func NewAccessControl() *AccessControl {
+	return &AccessControl{
+		roles: make(map[string]Role),
+		users: make(map[string]*aclUser),
+		keys:  make(map[[sha256.Size]byte]string),
+	}
+}
+
+// AddRole adds r, replacing any role of the same name.
+func (a *AccessControl) AddRole(r Role) error {
+	if r.Name == "" {
+		return fmt.Errorf("role name cannot be empty")
+	}
+	collections := make(map[string]Access, len(r.Collections))
+	for collection, access := range r.Collections {
+		if access < NoAccess || access > AdminAccess {
+			return fmt.Errorf("unknown access %v for collection '%s'", access, collection)
+		}
+		collections[collection] = access
+	}
+	r.Collections = collections
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.roles[r.Name] = r
+	return nil
+}
+
+// AddUser adds a user with roles, replacing any user of the same name. An
+// empty password turns basic auth off for the user, leaving API keys.
+func (a *AccessControl) AddUser(name, password string, roles ...string) error {
+	if name == "" {
+		return fmt.Errorf("user name cannot be empty")
+	}
+
+	u := &aclUser{roles: append([]string(nil), roles...)}
+	if password != "" {
+		u.salt = make([]byte, 16)
+		if _, err := rand.Read(u.salt); err != nil {
+			return err
+		}
+		hash, err := pbkdf2.Key(sha256.New, password, u.salt, pbkdf2Iterations, sha256.Size)
+		if err != nil {
+			return err
+		}
+		u.hash = hash
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for _, role := range roles {
+		if _, ok := a.roles[role]; !ok {
+			return fmt.Errorf("unknown role '%s'", role)
+		}
+	}
+	a.users[name] = u
+	return nil
+}
+
+// AddAPIKey lets key authenticate as user. Keys should be long and
+// random; only their hashes are kept.
+func (a *AccessControl) AddAPIKey(key, user string) error {
+	if key == "" {
+		return fmt.Errorf("API key cannot be empty")
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if _, ok := a.users[user]; !ok {
+		return fmt.Errorf("unknown user '%s'", user)
+	}
+	a.keys[sha256.Sum256([]byte(key))] = user
+	return nil
+}
+
+// RemoveUser removes a user along with their API keys.
+func (a *AccessControl) RemoveUser(name string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	delete(a.users, name)
+	for key, user := range a.keys {
+		if user == name {
+			delete(a.keys, key)
+		}
+	}
+}
+
+// Allowed reports whether user's roles give at least need on collection.
+func (a *AccessControl) Allowed(user, collection string, need Access) bool {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	u, ok := a.users[user]
+	if !ok {
+		return false
+	}
+	for _, name := range u.roles {
+		role := a.roles[name]
+		if role.Collections[collection] >= need || role.Collections["*"] >= need {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns an error matching ErrPermissionDenied unless user has need
+// on collection.
+func (a *AccessControl) check(user, collection string, need Access) error {
+	if a.Allowed(user, collection, need) {
+		return nil
+	}
+	if collection == "*" {
+		return fmt.Errorf("user '%s' needs %s access to every collection: %w", user, need, ErrPermissionDenied)
+	}
+	return fmt.Errorf("user '%s' needs %s access to collection '%s': %w", user, need, collection, ErrPermissionDenied)
+}
+
+// authenticate returns the user named by an API key, or by an
+// "Authorization" header value carrying a bearer token or basic auth.
+func (a *AccessControl) authenticate(apiKey, authorization string) (string, error) {
+	if apiKey == "" {
+		if token, ok := strings.CutPrefix(authorization, "Bearer "); ok {
+			apiKey = token
+		}
+	}
+	if apiKey != "" {
+		a.mutex.RLock()
+		user, ok := a.keys[sha256.Sum256([]byte(apiKey))]
+		a.mutex.RUnlock()
+		if ok {
+			return user, nil
+		}
+		return "", ErrUnauthenticated
+	}
+
+	encoded, ok := strings.CutPrefix(authorization, "Basic ")
+	if !ok {
+		return "", ErrUnauthenticated
+	}
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrUnauthenticated
+	}
+	name, password, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return "", ErrUnauthenticated
+	}
+
+	a.mutex.RLock()
+	u, ok := a.users[name]
+	a.mutex.RUnlock()
+	if !ok || u.hash == nil {
+		return "", ErrUnauthenticated
+	}
+	hash, err := pbkdf2.Key(sha256.New, password, u.salt, pbkdf2Iterations, sha256.Size)
+	if err != nil || subtle.ConstantTimeCompare(hash, u.hash) != 1 {
+		return "", ErrUnauthenticated
+	}
+	return name, nil
+}
+
+// authenticateHTTP is authenticate for a request's headers.
+func (a *AccessControl) authenticateHTTP(r *http.Request) (string, error) {
+	return a.authenticate(r.Header.Get("X-API-Key"), r.Header.Get("Authorization"))
+}
+
+// middleware enforces the roles of the user in an operation's context.
+// Operations without one come from the embedding program and are allowed.
+func (a *AccessControl) middleware() Middleware {
+	return func(next Op) Op {
+		return func(ctx context.Context, op *Operation) error {
+			user, ok := UserFromContext(ctx)
+			if !ok {
+				return next(ctx, op)
+			}
+
+			need := ReadAccess
+			switch op.Kind {
+			case OpWrite:
+				need = WriteAccess
+			case OpDelete:
+				need = WriteAccess
+				if op.Resource == "" {
+					need = AdminAccess
+				}
+			}
+			if err := a.check(user, op.Collection, need); err != nil {
+				return err
+			}
+			return next(ctx, op)
+		}
+	}
+}
+
+type userKey struct{}
+
+// ContextWithUser returns ctx carrying user, whose roles then limit the
+// driver operations run with it under UseAccessControl. The servers do
+// this for each authenticated request.
+func ContextWithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the user ContextWithUser put in ctx.
+func UserFromContext(ctx context.Context) (string, bool) {
+	user, ok := ctx.Value(userKey{}).(string)
+	return user, ok
+}
+
+// UseAccessControl puts the database's HTTP and gRPC servers under a:
+// requests must authenticate as one of its users, and operations run with
+// a user in their context, through the servers or ContextWithUser, are
+// limited to what the user's roles allow. Operations without a user are
+// not checked, so the embedding program keeps full access.
+func (d *Driver) UseAccessControl(a *AccessControl) {
+	d.Use(a.middleware())
+
+	d.middlewareMutex.Lock()
+	defer d.middlewareMutex.Unlock()
+	d.acl = a
+}
+
+// authorize checks the user in ctx for need on collection, for operations
+// that do not pass through the middleware chain.
+func (d *Driver) authorize(ctx context.Context, collection string, need Access) error {
+	a := d.accessControl()
+	if a == nil {
+		return nil
+	}
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return a.check(user, collection, need)
+}
+
+func (d *Driver) accessControl() *AccessControl {
+	d.middlewareMutex.RLock()
+	defer d.middlewareMutex.RUnlock()
+	return d.acl
+}
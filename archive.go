@@ -0,0 +1,281 @@
+package litedb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const archiveDir = "_archive"
+
+// SetArchivePolicy moves records not updated for maxAge into the archive
+// tier whenever Archive runs. A zero maxAge disables archiving.
+func (d *Driver) SetArchivePolicy(collection string, maxAge time.Duration) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if maxAge < 0 {
+		return fmt.Errorf("archive age cannot be negative")
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	d.configFor(collection).archiveAfter = maxAge
+
+	return nil
+}
+
+// Archive compresses the collection's stale records into the archive tier
+// and reports how many were moved. Archived records remain readable through
+// Read but no longer appear in ReadAll.
+func (d *Driver) Archive(collection string) (int, error) {
+	if err := d.validCollection(collection); err != nil {
+		return 0, err
+	}
+	if err := d.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	d.configMutex.RLock()
+	var maxAge time.Duration
+	if c, ok := d.configs[collection]; ok {
+		maxAge = c.archiveAfter
+	}
+	d.configMutex.RUnlock()
+
+	if maxAge == 0 {
+		return 0, nil
+	}
+
+	var dicts *archiveDicts
+	if d.archiveDictEnabled(collection) {
+		var err error
+		if dicts, err = d.archiveDictsFor(collection); err != nil {
+			return 0, err
+		}
+		dicts.mutex.RLock()
+		untrained := dicts.latest == 0
+		dicts.mutex.RUnlock()
+		if untrained {
+			// Until there are enough records to train on, gzip will do.
+			if _, err := d.TrainArchiveDictionary(collection); err != nil {
+				d.log.Debug("Archiving without a dictionary", "collection", collection, "err", err)
+			}
+		}
+	}
+
+	keys, err := d.keys(collection)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := d.now().Add(-maxAge)
+	archived := 0
+	for _, resource := range keys {
+		ok, err := d.archiveRecord(collection, resource, cutoff, dicts)
+		if err != nil {
+			return archived, err
+		}
+		if ok {
+			archived++
+		}
+	}
+
+	return archived, nil
+}
+
+// StartArchiver runs Archive over every collection with an archive policy
+// each interval until the returned function is called.
+func (d *Driver) StartArchiver(interval time.Duration) func() {
+	return d.every(interval, d.archiveOnce)
+}
+
+// archiveOnce is one pass of the archiver, logging what it did.
+func (d *Driver) archiveOnce() {
+	d.configMutex.RLock()
+	var collections []string
+	for name, c := range d.configs {
+		if c.archiveAfter > 0 {
+			collections = append(collections, name)
+		}
+	}
+	d.configMutex.RUnlock()
+
+	for _, collection := range collections {
+		n, err := d.Archive(collection)
+		if err != nil {
+			d.log.Error("Archiving failed", "collection", collection, "err", err)
+			continue
+		}
+		if n > 0 {
+			d.log.Debug("Archived records", "collection", collection, "records", n)
+		}
+	}
+}
+
+// Archived records are gzipped, or compressed with zstd and the
+// collection's dictionary under SetArchiveDictionary.
+const (
+	archiveExt     = ".json.gz"
+	dictArchiveExt = ".json.zst"
+)
+
+func (d *Driver) archivePath(collection, resource string) string {
+	return filepath.Join(d.dir, archiveDir, collection, d.fileStem(resource)+archiveExt)
+}
+
+func (d *Driver) dictArchivePath(collection, resource string) string {
+	return filepath.Join(d.dir, archiveDir, collection, d.fileStem(resource)+dictArchiveExt)
+}
+
+// archivedResource returns the resource an archive directory entry holds.
+func (d *Driver) archivedResource(name string) (string, bool) {
+	for _, ext := range []string{archiveExt, dictArchiveExt} {
+		if strings.HasSuffix(name, ext) {
+			return d.resourceOf(name, ext), true
+		}
+	}
+	return "", false
+}
+
+// isArchived reports whether resource has an archived copy.
+func (d *Driver) isArchived(collection, resource string) bool {
+	if _, err := os.Stat(d.dictArchivePath(collection, resource)); err == nil {
+		return true
+	}
+	_, err := os.Stat(d.archivePath(collection, resource))
+	return err == nil
+}
+
+func (d *Driver) archiveRecord(collection, resource string, cutoff time.Time, dicts *archiveDicts) (bool, error) {
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+
+	fi, err := os.Stat(d.recordPath(collection, resource))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	updated := fi.ModTime()
+	if m, err := d.readMeta(collection, resource); err != nil {
+		return false, err
+	} else if m != nil && !m.UpdatedAt.IsZero() {
+		updated = m.UpdatedAt
+	}
+	if !updated.Before(cutoff) {
+		return false, nil
+	}
+
+	b, err := d.readRecord(collection, resource)
+	if err != nil {
+		return false, err
+	}
+
+	// Only one copy is kept, in whichever format is current.
+	path, stale := d.archivePath(collection, resource), d.dictArchivePath(collection, resource)
+	var compressed []byte
+	if dicts != nil {
+		if zb, ok := dicts.compress(b); ok {
+			compressed = zb
+			path, stale = stale, path
+		}
+	}
+	if compressed == nil {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Name = resource + recordExt
+		zw.ModTime = updated
+		if _, err := zw.Write(b); err != nil {
+			return false, err
+		}
+		if err := zw.Close(); err != nil {
+			return false, err
+		}
+		compressed = buf.Bytes()
+	}
+
+	tempPath := path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(path), dirMode(d.fileMode)); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(tempPath, compressed, d.fileMode); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		return false, err
+	}
+	if err := os.Remove(stale); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	return true, d.removeRecord(collection, resource)
+}
+
+func (d *Driver) readArchived(collection, resource string) ([]byte, error) {
+	zb, err := os.ReadFile(d.dictArchivePath(collection, resource))
+	if err == nil {
+		dicts, err := d.archiveDictsFor(collection)
+		if err != nil {
+			return nil, err
+		}
+		return dicts.decompress(zb)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	f, err := os.Open(d.archivePath(collection, resource))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return io.ReadAll(zr)
+}
+
+// removeArchived drops the archived copy of a record, or of the whole
+// collection when resource is empty.
+func (d *Driver) removeArchived(collection, resource string) error {
+	if resource == "" {
+		defer d.dropArchiveDicts(collection)
+		return os.RemoveAll(filepath.Join(d.dir, archiveDir, collection))
+	}
+	for _, path := range []string{d.archivePath(collection, resource), d.dictArchivePath(collection, resource)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Driver) archivedCount(collection string) (int, error) {
+	files, err := os.ReadDir(filepath.Join(d.dir, archiveDir, collection))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	n := 0
+	for _, file := range files {
+		if _, ok := d.archivedResource(file.Name()); ok {
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,248 @@
+package litedb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/dict"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// dictSamples is the most documents a dictionary is trained on.
+	dictSamples = 1000
+
+	// minDictSamples is the fewest documents worth training on; smaller
+	// collections are archived with plain gzip.
+	minDictSamples = 32
+
+	maxDictSize = 32 << 10
+
+	dictPrefix = "_dict-"
+	dictExt    = ".zdict"
+)
+
+// archiveDicts holds a collection's archive dictionaries. Each is
+// numbered with the version it was trained as, and zstd writes that
+// number into every frame's header as the dictionary ID, so archives stay
+// readable after retraining: the decoder picks the dictionary each file
+// was compressed with. New archives use the latest version.
+type archiveDicts struct {
+	mutex  sync.RWMutex
+	latest uint32
+	dicts  map[uint32][]byte
+	enc    *zstd.Encoder
+	dec    *zstd.Decoder
+}
+
+type dictionaries struct {
+	mutex       sync.Mutex
+	collections map[string]*archiveDicts
+}
+
+// SetArchiveDictionary makes Archive compress the collection's records
+// with zstd and a dictionary trained on its own documents, which shrinks
+// small, similar documents far more than compressing each on its own.
+// The dictionary is trained on the first Archive; TrainArchiveDictionary
+// retrains it. The setting overrides the driver's Compression either way.
+func (d *Driver) SetArchiveDictionary(collection string, enabled bool) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	d.configFor(collection).archiveDict = &enabled
+
+	return nil
+}
+
+func (d *Driver) archiveDictEnabled(collection string) bool {
+	d.configMutex.RLock()
+	defer d.configMutex.RUnlock()
+	if c, ok := d.configs[collection]; ok && c.archiveDict != nil {
+		return *c.archiveDict
+	}
+	return d.compression == CompressionZstd
+}
+
+// archiveDictsFor returns the collection's dictionaries, loading them
+// from the archive directory the first time.
+func (d *Driver) archiveDictsFor(collection string) (*archiveDicts, error) {
+	d.dicts.mutex.Lock()
+	defer d.dicts.mutex.Unlock()
+
+	if a, ok := d.dicts.collections[collection]; ok {
+		return a, nil
+	}
+
+	a := &archiveDicts{dicts: make(map[uint32][]byte)}
+	files, err := os.ReadDir(filepath.Join(d.dir, archiveDir, collection))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, file := range files {
+		version, ok := dictVersion(file.Name())
+		if !ok {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(d.dir, archiveDir, collection, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		a.dicts[version] = b
+		a.latest = max(a.latest, version)
+	}
+	if err := a.rebuild(); err != nil {
+		return nil, err
+	}
+
+	if d.dicts.collections == nil {
+		d.dicts.collections = make(map[string]*archiveDicts)
+	}
+	d.dicts.collections[collection] = a
+	return a, nil
+}
+
+func dictVersion(name string) (uint32, bool) {
+	if !strings.HasPrefix(name, dictPrefix) || !strings.HasSuffix(name, dictExt) {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, dictPrefix), dictExt), 10, 32)
+	return uint32(n), err == nil && n > 0
+}
+
+// rebuild recreates the encoder and decoder for the current dictionaries.
+// The caller must hold a.mutex, or be its only user.
+func (a *archiveDicts) rebuild() error {
+	if a.dec != nil {
+		a.dec.Close()
+	}
+	a.enc, a.dec = nil, nil
+	if len(a.dicts) == 0 {
+		return nil
+	}
+
+	all := make([][]byte, 0, len(a.dicts))
+	for _, b := range a.dicts {
+		all = append(all, b)
+	}
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderDicts(all...), zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return err
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderDict(a.dicts[a.latest]), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		dec.Close()
+		return err
+	}
+	a.enc, a.dec = enc, dec
+	return nil
+}
+
+// compress encodes b with the latest dictionary, reporting false if
+// there is none yet.
+func (a *archiveDicts) compress(b []byte) ([]byte, bool) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	if a.enc == nil {
+		return nil, false
+	}
+	return a.enc.EncodeAll(b, nil), true
+}
+
+func (a *archiveDicts) decompress(b []byte) ([]byte, error) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	if a.dec == nil {
+		return nil, fmt.Errorf("no archive dictionaries to decompress with")
+	}
+	return a.dec.DecodeAll(b, nil)
+}
+
+// TrainArchiveDictionary trains a new dictionary version on a sample of
+// the collection's records and returns its version. Later archives use
+// it; earlier ones keep the version they were written with.
+func (d *Driver) TrainArchiveDictionary(collection string) (uint32, error) {
+	if err := d.validCollection(collection); err != nil {
+		return 0, err
+	}
+	if err := d.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	a, err := d.archiveDictsFor(collection)
+	if err != nil {
+		return 0, err
+	}
+
+	keys, err := d.keys(collection)
+	if err != nil {
+		return 0, err
+	}
+	if len(keys) < minDictSamples {
+		return 0, fmt.Errorf("collection '%s' has %d records, too few to train a dictionary on", collection, len(keys))
+	}
+
+	// Spread the sample over the whole collection rather than its first
+	// names.
+	step := max(1, len(keys)/dictSamples)
+	var samples [][]byte
+	for i := 0; i < len(keys) && len(samples) < dictSamples; i += step {
+		b, err := d.readRecord(collection, keys[i])
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		samples = append(samples, b)
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	version := a.latest + 1
+	b, err := dict.BuildZstdDict(samples, dict.Options{MaxDictSize: maxDictSize, HashBytes: 6, ZstdDictID: version})
+	if err != nil {
+		return 0, fmt.Errorf("training dictionary for collection '%s': %w", collection, err)
+	}
+
+	path := filepath.Join(d.dir, archiveDir, collection, dictPrefix+strconv.FormatUint(uint64(version), 10)+dictExt)
+	if err := os.MkdirAll(filepath.Dir(path), dirMode(d.fileMode)); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(path+".tmp", b, d.fileMode); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return 0, err
+	}
+
+	a.dicts[version] = b
+	a.latest = version
+	if err := a.rebuild(); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// dropArchiveDicts forgets the collection's loaded dictionaries, after
+// its archive directory is removed.
+func (d *Driver) dropArchiveDicts(collection string) {
+	d.dicts.mutex.Lock()
+	defer d.dicts.mutex.Unlock()
+	if a, ok := d.dicts.collections[collection]; ok {
+		a.mutex.Lock()
+		if a.dec != nil {
+			a.dec.Close()
+		}
+		a.enc, a.dec = nil, nil
+		a.mutex.Unlock()
+		delete(d.dicts.collections, collection)
+	}
+}
@@ -0,0 +1,140 @@
+package litedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+const defaultWriteQueue = 1024
+
+// asyncWrite is a queued WriteAsync, or with done set, a marker that is
+// closed once everything queued before it has been written.
+type asyncWrite struct {
+	collection string
+	resource   string
+	doc        json.RawMessage
+	done       chan struct{}
+}
+
+// asyncWriter applies queued writes in order from one goroutine.
+type asyncWriter struct {
+	queue   chan asyncWrite
+	stopped chan struct{}
+
+	// Senders hold mutex for reading so Close cannot close the queue
+	// under them.
+	mutex  sync.RWMutex
+	closed bool
+
+	errMutex sync.Mutex
+	failed   int
+	firstErr error
+}
+
+func (d *Driver) asyncWriter() *asyncWriter {
+	d.asyncOnce.Do(func() {
+		w := &asyncWriter{
+			queue:   make(chan asyncWrite, d.writeQueue),
+			stopped: make(chan struct{}),
+		}
+		go w.run(d)
+		d.async.Store(w)
+	})
+	return d.async.Load()
+}
+
+func (w *asyncWriter) run(d *Driver) {
+	defer close(w.stopped)
+	for op := range w.queue {
+		if op.done != nil {
+			close(op.done)
+			continue
+		}
+		if err := d.Write(op.collection, op.resource, op.doc); err != nil {
+			d.log.Warn("Async write failed", "collection", op.collection, "resource", op.resource, "err", err)
+			w.errMutex.Lock()
+			if w.failed == 0 {
+				w.firstErr = err
+			}
+			w.failed++
+			w.errMutex.Unlock()
+		}
+	}
+}
+
+// send queues op, blocking while the queue is full.
+func (w *asyncWriter) send(ctx context.Context, op asyncWrite) error {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	if w.closed {
+		return ErrClosed
+	}
+	select {
+	case w.queue <- op:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// takeErr returns and clears the failures since it was last called.
+func (w *asyncWriter) takeErr() error {
+	w.errMutex.Lock()
+	defer w.errMutex.Unlock()
+
+	failed, err := w.failed, w.firstErr
+	w.failed, w.firstErr = 0, nil
+	switch failed {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("async write failed: %w", err)
+	}
+	return fmt.Errorf("%d async writes failed, the first with: %w", failed, err)
+}
+
+// WriteAsync queues a write for a background goroutine and returns
+// without waiting for it, for logging and telemetry where throughput
+// matters more than knowing each write landed. Writes are applied in the
+// order they were queued. When Options.WriteQueue writes are already
+// waiting, WriteAsync blocks until there is room.
+//
+// v is encoded before WriteAsync returns, so the caller may reuse it.
+// Errors from the write itself are logged and returned by the next Flush
+// or Close, which also wait for the queue to drain.
+func (d *Driver) WriteAsync(collection, resource string, v interface{}) error {
+	return d.WriteAsyncContext(context.Background(), collection, resource, v)
+}
+
+// WriteAsyncContext is WriteAsync, giving up on a full queue when ctx is
+// done. ctx does not apply to the write itself.
+func (d *Driver) WriteAsyncContext(ctx context.Context, collection, resource string, v interface{}) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if err := validResource(resource); err != nil {
+		return err
+	}
+
+	b, err := d.marshal(v)
+	if err != nil {
+		return err
+	}
+	return d.asyncWriter().send(ctx, asyncWrite{collection: collection, resource: resource, doc: b})
+}
+
+// drainAsync waits for the writes queued so far and returns their errors.
+func (d *Driver) drainAsync() error {
+	w := d.async.Load()
+	if w == nil {
+		return nil
+	}
+
+	done := make(chan struct{})
+	if err := w.send(context.Background(), asyncWrite{done: done}); err == nil {
+		<-done
+	}
+	return w.takeErr()
+}
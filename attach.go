@@ -0,0 +1,196 @@
+package litedb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// attachLockFile is the file in an attached collection's directory that
+// every database writing to it locks, so writers in different databases
+// and processes take turns.
+const attachLockFile = ".litedb.lock"
+
+// attachments holds the collections attached from outside the database.
+type attachments struct {
+	mutex sync.RWMutex
+	dirs  map[string]*attachment
+}
+
+type attachment struct {
+	dir  string
+	lock *os.File
+	stop func()
+}
+
+// Attach makes collection the directory dir, outside the database, so
+// that several databases can share read-mostly data such as a product
+// catalogue. dir is created if needed, or must exist on a driver from
+// OpenReadOnly, and collection must not already exist in the database.
+//
+// Records in dir are read and written as any others, with each write and
+// delete also holding dir's lock file so databases sharing it never write
+// at once. Since others may change dir at any time, listings and records
+// of the collection are not cached. Its metadata, such as TTLs and
+// archived records, stays with the database that wrote it, and Stats,
+// quotas, Backup and Export cover only the database's own collections.
+// Deleting the whole collection is refused; Detach instead.
+func (d *Driver) Attach(collection, dir string) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if strings.Contains(collection, "/") {
+		return fmt.Errorf("cannot attach nested collection '%s'", collection)
+	}
+	if err := d.checkOpen(); err != nil {
+		return err
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	root, err := filepath.Abs(d.dir)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(root, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("attached directory '%s' is inside the database", dir)
+	}
+	if _, err := os.Stat(filepath.Join(d.dir, collection)); !os.IsNotExist(err) {
+		if err == nil {
+			err = fmt.Errorf("collection '%s' already exists in the database", collection)
+		}
+		return err
+	}
+
+	a := &attachment{dir: dir, stop: func() {}}
+	if d.readOnly {
+		if _, err := os.Stat(dir); err != nil {
+			return err
+		}
+	} else {
+		if err := os.MkdirAll(dir, dirMode(d.fileMode)); err != nil {
+			return err
+		}
+		if a.lock, err = os.OpenFile(filepath.Join(dir, attachLockFile), os.O_RDWR|os.O_CREATE, d.fileMode); err != nil {
+			return err
+		}
+	}
+
+	d.attached.mutex.Lock()
+	if _, ok := d.attached.dirs[collection]; ok {
+		d.attached.mutex.Unlock()
+		if a.lock != nil {
+			a.lock.Close()
+		}
+		return fmt.Errorf("collection '%s' is already attached", collection)
+	}
+	if d.attached.dirs == nil {
+		d.attached.dirs = make(map[string]*attachment)
+	}
+	d.attached.dirs[collection] = a
+	d.attached.mutex.Unlock()
+
+	if a.lock != nil {
+		var once sync.Once
+		a.stop = d.onClose(func() { once.Do(func() { a.lock.Close() }) })
+	}
+	d.invalidate(collection, "")
+	return nil
+}
+
+// Detach undoes Attach, leaving the attached directory as it is. Writes
+// to the collection in progress finish first.
+func (d *Driver) Detach(collection string) error {
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+
+	d.attached.mutex.Lock()
+	a, ok := d.attached.dirs[collection]
+	delete(d.attached.dirs, collection)
+	d.attached.mutex.Unlock()
+	if !ok {
+		return fmt.Errorf("collection '%s' is not attached", collection)
+	}
+
+	a.stop()
+	d.invalidate(collection, "")
+	return nil
+}
+
+// Attachments maps each attached collection to its directory.
+func (d *Driver) Attachments() map[string]string {
+	d.attached.mutex.RLock()
+	defer d.attached.mutex.RUnlock()
+
+	dirs := make(map[string]string, len(d.attached.dirs))
+	for collection, a := range d.attached.dirs {
+		dirs[collection] = a.dir
+	}
+	return dirs
+}
+
+// collectionDir returns the directory holding collection's records.
+func (d *Driver) collectionDir(collection string) string {
+	if a, rest := d.attachmentOf(collection); a != nil {
+		return filepath.Join(a.dir, rest)
+	}
+	return filepath.Join(d.dir, collection)
+}
+
+// isAttached reports whether collection is, or is nested in, an attached
+// collection.
+func (d *Driver) isAttached(collection string) bool {
+	a, _ := d.attachmentOf(collection)
+	return a != nil
+}
+
+// attachmentOf returns the attachment holding collection and the path of
+// collection within it.
+func (d *Driver) attachmentOf(collection string) (*attachment, string) {
+	d.attached.mutex.RLock()
+	defer d.attached.mutex.RUnlock()
+	if len(d.attached.dirs) == 0 {
+		return nil, ""
+	}
+
+	top, rest, _ := strings.Cut(collection, "/")
+	return d.attached.dirs[top], rest
+}
+
+// lockAttached takes the lock file of collection's attachment, if it has
+// one, returning the function that releases it. The caller must hold the
+// collection lock.
+func (d *Driver) lockAttached(collection string) (func(), error) {
+	a, _ := d.attachmentOf(collection)
+	if a == nil || a.lock == nil {
+		return func() {}, nil
+	}
+	if err := lockFile(a.lock); err != nil {
+		return nil, fmt.Errorf("locking attached collection '%s': %w", collection, err)
+	}
+	return func() {
+		if err := unlockFile(a.lock); err != nil {
+			d.log.Error("Unlocking attached collection failed", "collection", collection, "err", err)
+		}
+	}, nil
+}
+
+// withAttached adds the attached collections to the sorted list names.
+func (d *Driver) withAttached(names []string) []string {
+	d.attached.mutex.RLock()
+	defer d.attached.mutex.RUnlock()
+	if len(d.attached.dirs) == 0 {
+		return names
+	}
+
+	for collection := range d.attached.dirs {
+		names = append(names, collection)
+	}
+	sort.Strings(names)
+	return names
+}
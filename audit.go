@@ -0,0 +1,149 @@
+package litedb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const auditFile = "_audit.log"
+
+type actorKey struct{}
+
+// WithActor returns a context that attributes mutations made with it to
+// actor in the audit log.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set with WithActor, or "".
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// AuditEntry is one line of the audit log. Hash is the SHA-256 of the
+// document written; it is empty for removals. Expirations and evictions
+// are recorded with an empty actor.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Actor      string    `json:"actor,omitempty"`
+	Op         string    `json:"op"`
+	Collection string    `json:"collection"`
+	Resource   string    `json:"resource,omitempty"`
+	Hash       string    `json:"hash,omitempty"`
+}
+
+// AuditFilter selects audit entries. Empty fields match every entry; Since
+// is inclusive and Until exclusive.
+type AuditFilter struct {
+	Actor      string
+	Op         string
+	Collection string
+	Resource   string
+	Since      time.Time
+	Until      time.Time
+}
+
+func (f AuditFilter) match(e AuditEntry) bool {
+	return (f.Actor == "" || f.Actor == e.Actor) &&
+		(f.Op == "" || f.Op == e.Op) &&
+		(f.Collection == "" || f.Collection == e.Collection) &&
+		(f.Resource == "" || f.Resource == e.Resource) &&
+		(f.Since.IsZero() || !e.Time.Before(f.Since)) &&
+		(f.Until.IsZero() || e.Time.Before(f.Until))
+}
+
+type auditLog struct {
+	mutex sync.Mutex
+	path  string
+	mode  fs.FileMode
+}
+
+func (d *Driver) audit(ctx context.Context, op, collection, resource, hash string) {
+	if d.auditLog == nil {
+		return
+	}
+
+	e := AuditEntry{
+		Time:       d.now(),
+		Actor:      ActorFromContext(ctx),
+		Op:         op,
+		Collection: collection,
+		Resource:   resource,
+		Hash:       hash,
+	}
+
+	if err := d.auditLog.append(e); err != nil {
+		d.log.Error("Writing audit entry failed", "collection", collection, "resource", resource, "err", err)
+	}
+}
+
+func (a *auditLog) append(e AuditEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, a.mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// AuditLog returns the recorded entries matching filter, oldest first.
+func (d *Driver) AuditLog(filter AuditFilter) ([]AuditEntry, error) {
+	f, err := os.Open(filepath.Join(d.dir, auditFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, err
+		}
+		if filter.match(e) {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, scanner.Err()
+}
+
+// ExportAudit copies the audit log to w as newline-delimited JSON.
+func (d *Driver) ExportAudit(w io.Writer) error {
+	f, err := os.Open(filepath.Join(d.dir, auditFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,36 @@
+package litedb
+
+import (
+	"sync"
+	"time"
+)
+
+// every calls fn on its own goroutine each interval until the returned
+// function is called or the driver is closed.
+func (d *Driver) every(interval time.Duration, fn func()) func() {
+	if d.readOnly {
+		// Every background job changes the database.
+		d.log.Warn("Not starting background job on a read-only database", "dir", d.dir)
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fn()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return d.onClose(func() {
+		once.Do(func() { close(stop) })
+	})
+}
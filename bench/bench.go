@@ -0,0 +1,371 @@
+// Package bench holds reproducible benchmarks for the driver, and
+// baselines to compare runs against so performance regressions show up in
+// routine runs. The litedb CLI runs them with "litedb bench".
+//
+// Every benchmark works on a fresh database in a temporary directory,
+// populated with the same records on every run.
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"regexp"
+	"runtime"
+	"sync/atomic"
+	"testing"
+)
+
+// Store is the part of the driver the benchmarks exercise.
+type Store interface {
+	Write(collection, resource string, v interface{}) error
+	Read(collection, resource string, v interface{}) error
+	ReadAll(collection string) ([]string, error)
+	Delete(collection, resource string) error
+}
+
+// Open opens a database in dir, which exists and is empty.
+type Open func(dir string) (Store, error)
+
+// Doc is the record every benchmark writes: small, but with the nesting
+// and mix of types of a typical document.
+type Doc struct {
+	Name    string            `json:"name"`
+	Age     int               `json:"age"`
+	Email   string            `json:"email"`
+	Active  bool              `json:"active"`
+	Score   float64           `json:"score"`
+	Tags    []string          `json:"tags"`
+	Address map[string]string `json:"address"`
+}
+
+func doc(rng *rand.Rand, i int) Doc {
+	return Doc{
+		Name:    fmt.Sprintf("user-%d", i),
+		Age:     18 + rng.Intn(60),
+		Email:   fmt.Sprintf("user-%d@example.com", i),
+		Active:  rng.Intn(2) == 0,
+		Score:   rng.Float64() * 100,
+		Tags:    []string{"a", "b", "c"}[:1+rng.Intn(3)],
+		Address: map[string]string{"city": "Bangalore", "country": "India", "pincode": fmt.Sprint(560000 + rng.Intn(100))},
+	}
+}
+
+// LargeDoc is a Doc carrying a history of earlier versions, several
+// kilobytes encoded.
+type LargeDoc struct {
+	Doc
+	History []Doc `json:"history"`
+}
+
+func largeDoc(rng *rand.Rand, i int) LargeDoc {
+	d := LargeDoc{Doc: doc(rng, i), History: make([]Doc, 32)}
+	for j := range d.History {
+		d.History[j] = doc(rng, i)
+	}
+	return d
+}
+
+func key(i int) string {
+	return fmt.Sprintf("%08d", i)
+}
+
+// Benchmark is one named measurement. Setup populates the database once;
+// the testing package then calls Run as often as it needs to settle on
+// b.N, against the same database.
+type Benchmark struct {
+	Name  string
+	Setup func(store Store)
+	Run   func(b *testing.B, store Store)
+}
+
+// Benchmarks lists every benchmark, in the order Run reports them.
+var Benchmarks = []Benchmark{
+	{"Write", nil, benchWrite()},
+	{"Overwrite", populate(100, "users"), benchOverwrite},
+	{"Read", populate(1000, "users"), benchRead},
+	{"ReadMissing", populate(1000, "users"), benchReadMissing},
+	{"ReadAll/1k", populate(1000, "users"), benchReadAll(1000)},
+	{"ReadAll/10k", populate(10000, "users"), benchReadAll(10000)},
+	{"ReadAll/100k", populate(100000, "users"), benchReadAll(100000)},
+	{"Mixed/Parallel", populate(mixedSize, mixedCollections...), benchMixed},
+	{"Sustained/Write", nil, benchSustainedWrite},
+	{"Sustained/ReadWrite", nil, benchSustainedReadWrite},
+}
+
+// Result is a benchmark's measurement.
+type Result struct {
+	Name        string  `json:"name"`
+	N           int     `json:"n"`
+	NsPerOp     float64 `json:"nsPerOp"`
+	AllocsPerOp int64   `json:"allocsPerOp"`
+	BytesPerOp  int64   `json:"bytesPerOp"`
+
+	// GCs counts the garbage collections during the N operations.
+	GCs uint32 `json:"gcs"`
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%-20s %10d %14.0f ns/op %10d B/op %8d allocs/op %6d GCs", r.Name, r.N, r.NsPerOp, r.BytesPerOp, r.AllocsPerOp, r.GCs)
+}
+
+// Run runs the benchmarks whose names match pattern, or all of them for a
+// nil pattern. Each gets its own temporary directory, removed afterwards.
+func Run(open Open, pattern *regexp.Regexp) ([]Result, error) {
+	var results []Result
+	for _, bm := range Benchmarks {
+		if pattern != nil && !pattern.MatchString(bm.Name) {
+			continue
+		}
+
+		r, err := run(open, bm)
+		if err != nil {
+			return results, fmt.Errorf("%s: %w", bm.Name, err)
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+func run(open Open, bm Benchmark) (result Result, err error) {
+	dir, err := os.MkdirTemp("", "litedb-bench-")
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := open(dir)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Benchmarks panic on store errors, from this goroutine in Setup and
+	// from the benchmark's own in Run.
+	var failure atomic.Value
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%v", p)
+		}
+	}()
+	if bm.Setup != nil {
+		bm.Setup(store)
+	}
+
+	// The testing package calls the function with growing b.N; the last
+	// call is the one it reports, so its GC count is the one kept.
+	var gcs uint32
+	r := testing.Benchmark(func(b *testing.B) {
+		defer func() {
+			if p := recover(); p != nil {
+				failure.Store(fmt.Errorf("%v", p))
+				b.SkipNow()
+			}
+		}()
+		b.ReportAllocs()
+
+		var before, after runtime.MemStats
+		b.StopTimer()
+		runtime.ReadMemStats(&before)
+		b.StartTimer()
+		bm.Run(b, store)
+		b.StopTimer()
+		runtime.ReadMemStats(&after)
+		gcs = after.NumGC - before.NumGC
+	})
+	if f, ok := failure.Load().(error); ok {
+		return Result{}, f
+	}
+	if r.N == 0 {
+		return Result{}, fmt.Errorf("benchmark did not run")
+	}
+
+	return Result{
+		Name:        bm.Name,
+		N:           r.N,
+		NsPerOp:     float64(r.T.Nanoseconds()) / float64(r.N),
+		AllocsPerOp: r.AllocsPerOp(),
+		BytesPerOp:  r.AllocedBytesPerOp(),
+		GCs:         gcs,
+	}, nil
+}
+
+// populate returns a Setup writing n records to each collection, with the
+// same contents on every run.
+func populate(n int, collections ...string) func(Store) {
+	return func(store Store) {
+		for _, c := range collections {
+			rng := rand.New(rand.NewSource(1))
+			for i := 0; i < n; i++ {
+				must(store.Write(c, key(i), doc(rng, i)))
+			}
+		}
+	}
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+// benchWrite writes new records, numbering on from earlier calls so every
+// write creates a record.
+func benchWrite() func(*testing.B, Store) {
+	next := 0
+	return func(b *testing.B, store Store) {
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < b.N; i++ {
+			must(store.Write("users", key(next), doc(rng, next)))
+			next++
+		}
+	}
+}
+
+func benchOverwrite(b *testing.B, store Store) {
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < b.N; i++ {
+		must(store.Write("users", key(i%100), doc(rng, i)))
+	}
+}
+
+func benchRead(b *testing.B, store Store) {
+	for i := 0; i < b.N; i++ {
+		var d Doc
+		must(store.Read("users", key(i%1000), &d))
+	}
+}
+
+func benchReadMissing(b *testing.B, store Store) {
+	for i := 0; i < b.N; i++ {
+		var d Doc
+		if store.Read("users", "missing-"+key(i), &d) == nil {
+			panic("read of a missing record succeeded")
+		}
+	}
+}
+
+func benchReadAll(n int) func(*testing.B, Store) {
+	return func(b *testing.B, store Store) {
+		for i := 0; i < b.N; i++ {
+			records, err := store.ReadAll("users")
+			must(err)
+			if len(records) != n {
+				panic(fmt.Sprintf("read %d records, want %d", len(records), n))
+			}
+		}
+	}
+}
+
+const sustainedSize = 1000
+
+// benchSustainedWrite keeps overwriting a working set of large records,
+// the load where per-write garbage turns into collector time.
+func benchSustainedWrite(b *testing.B, store Store) {
+	rng := rand.New(rand.NewSource(3))
+	docs := make([]LargeDoc, 64)
+	for i := range docs {
+		docs[i] = largeDoc(rng, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		must(store.Write("history", key(i%sustainedSize), docs[i%len(docs)]))
+	}
+}
+
+// benchSustainedReadWrite reads back each large record after writing it.
+func benchSustainedReadWrite(b *testing.B, store Store) {
+	rng := rand.New(rand.NewSource(3))
+	docs := make([]LargeDoc, 64)
+	for i := range docs {
+		docs[i] = largeDoc(rng, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k := key(i % sustainedSize)
+		must(store.Write("history", k, docs[i%len(docs)]))
+		var d LargeDoc
+		must(store.Read("history", k, &d))
+	}
+}
+
+const mixedSize = 250
+
+var mixedCollections = []string{"orders", "events", "sessions", "carts"}
+
+// benchMixed runs four reads to every write across several collections
+// from parallel goroutines.
+func benchMixed(b *testing.B, store Store) {
+	var seq int64
+	b.RunParallel(func(pb *testing.PB) {
+		rng := rand.New(rand.NewSource(atomic.AddInt64(&seq, 1)))
+		for i := 0; pb.Next(); i++ {
+			c := mixedCollections[rng.Intn(len(mixedCollections))]
+			k := key(rng.Intn(mixedSize))
+			if i%5 == 0 {
+				must(store.Write(c, k, doc(rng, i)))
+				continue
+			}
+			var d Doc
+			must(store.Read(c, k, &d))
+		}
+	})
+}
+
+// Baseline is the ns/op each benchmark is expected to stay within.
+type Baseline map[string]float64
+
+// Regression is a benchmark slower than its baseline by more than the
+// tolerance.
+type Regression struct {
+	Name     string
+	Baseline float64
+	Result   float64
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s: %.0f ns/op, baseline %.0f ns/op (%+.0f%%)", r.Name, r.Result, r.Baseline, (r.Result/r.Baseline-1)*100)
+}
+
+// Compare returns the results slower than baseline by more than
+// tolerance, a fraction such as 0.2 for 20%. Benchmarks missing from the
+// baseline are not compared.
+func (bl Baseline) Compare(results []Result, tolerance float64) []Regression {
+	var regressions []Regression
+	for _, r := range results {
+		base, ok := bl[r.Name]
+		if ok && base > 0 && r.NsPerOp > base*(1+tolerance) {
+			regressions = append(regressions, Regression{Name: r.Name, Baseline: base, Result: r.NsPerOp})
+		}
+	}
+	return regressions
+}
+
+// NewBaseline records results as a baseline.
+func NewBaseline(results []Result) Baseline {
+	bl := make(Baseline, len(results))
+	for _, r := range results {
+		bl[r.Name] = r.NsPerOp
+	}
+	return bl
+}
+
+func LoadBaseline(path string) (Baseline, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var bl Baseline
+	if err := json.Unmarshal(b, &bl); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return bl, nil
+}
+
+func (bl Baseline) Save(path string) error {
+	b, err := json.MarshalIndent(bl, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
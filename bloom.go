@@ -0,0 +1,160 @@
+package litedb
+
+import (
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	bloomHashes  = 7
+	bloomMinKeys = 1024
+)
+
+// bloom is a Bloom filter over resource names: a miss means the name is
+// certainly absent, a hit that it may be present.
+type bloom struct {
+	bits     []uint64
+	keys     int
+	capacity int
+}
+
+func newBloom(capacity int) *bloom {
+	// About ten bits a key for a 1% false-positive rate at capacity.
+	m := int(math.Ceil(-float64(capacity) * math.Log(0.01) / (math.Ln2 * math.Ln2)))
+	return &bloom{bits: make([]uint64, (m+63)/64), capacity: capacity}
+}
+
+func (b *bloom) positions(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	return sum, sum>>33 | sum<<31 | 1
+}
+
+func (b *bloom) add(key string) {
+	h1, h2 := b.positions(key)
+	n := uint64(len(b.bits) * 64)
+	for i := uint64(0); i < bloomHashes; i++ {
+		bit := (h1 + i*h2) % n
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+	b.keys++
+}
+
+func (b *bloom) test(key string) bool {
+	h1, h2 := b.positions(key)
+	n := uint64(len(b.bits) * 64)
+	for i := uint64(0); i < bloomHashes; i++ {
+		bit := (h1 + i*h2) % n
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// blooms holds a filter per collection, built on first lookup. Filters
+// only grow: deletes leave their names behind as false positives until the
+// filter fills up and is rebuilt.
+type blooms struct {
+	mutex   sync.Mutex
+	filters map[string]*bloom
+}
+
+// mightExist reports whether collection may hold resource, live or
+// archived. Like the key cache it trusts the driver's own writes, plus
+// WatchExternal when running, to keep it current.
+func (d *Driver) mightExist(collection, resource string) bool {
+	if d.readOnly || d.isAttached(collection) {
+		// Filters only learn of this driver's writes.
+		return true
+	}
+
+	d.blooms.mutex.Lock()
+	f, ok := d.blooms.filters[collection]
+	if ok {
+		hit := f.test(resource)
+		d.blooms.mutex.Unlock()
+		return hit
+	}
+	d.blooms.mutex.Unlock()
+
+	f = d.buildBloom(collection)
+	if f == nil {
+		return true
+	}
+	return f.test(resource)
+}
+
+// buildBloom lists collection under its lock, so no write can land between
+// the listing and the filter taking over.
+func (d *Driver) buildBloom(collection string) *bloom {
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+
+	d.blooms.mutex.Lock()
+	f, ok := d.blooms.filters[collection]
+	d.blooms.mutex.Unlock()
+	if ok {
+		return f
+	}
+
+	keys, err := d.keys(collection)
+	if err != nil {
+		return nil
+	}
+	archived, err := os.ReadDir(filepath.Join(d.dir, archiveDir, collection))
+	if err != nil && !os.IsNotExist(err) {
+		return nil
+	}
+
+	capacity := 2 * (len(keys) + len(archived))
+	if capacity < bloomMinKeys {
+		capacity = bloomMinKeys
+	}
+	f = newBloom(capacity)
+	for _, key := range keys {
+		f.add(key)
+	}
+	for _, file := range archived {
+		if resource, ok := d.archivedResource(file.Name()); ok {
+			f.add(resource)
+		}
+	}
+
+	d.blooms.mutex.Lock()
+	defer d.blooms.mutex.Unlock()
+	if d.blooms.filters == nil {
+		d.blooms.filters = make(map[string]*bloom)
+	}
+	d.blooms.filters[collection] = f
+	return f
+}
+
+// bloomAdd records a name about to be written. The caller must hold the
+// collection lock.
+func (d *Driver) bloomAdd(collection, resource string) {
+	d.blooms.mutex.Lock()
+	defer d.blooms.mutex.Unlock()
+
+	f, ok := d.blooms.filters[collection]
+	if !ok {
+		return
+	}
+	if f.keys >= f.capacity {
+		// Past capacity the false-positive rate climbs; rebuild on the
+		// next lookup instead.
+		delete(d.blooms.filters, collection)
+		return
+	}
+	f.add(resource)
+}
+
+func (d *Driver) bloomDrop(collection string) {
+	d.blooms.mutex.Lock()
+	defer d.blooms.mutex.Unlock()
+	delete(d.blooms.filters, collection)
+}
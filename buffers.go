@@ -0,0 +1,102 @@
+package litedb
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"sync"
+)
+
+// defaultMmapThreshold is the record size from which reads map the file.
+const defaultMmapThreshold = 64 << 20
+
+var errMmapUnsupported = errors.New("memory-mapped reads are not available")
+
+// readBuffers pools the buffers files are read into when their bytes do
+// not outlive the operation, the read-side counterpart of recordWriters.
+var readBuffers = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// readPooled reads the file at path into a pooled buffer. Return it with
+// putReadBuffer once nothing refers to its bytes.
+func readPooled(path string) (*bytes.Buffer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	size := int64(0)
+	if fi, err := f.Stat(); err == nil {
+		size = fi.Size()
+	}
+	return readInto(f, size)
+}
+
+func readInto(f *os.File, size int64) (*bytes.Buffer, error) {
+	buf := readBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	buf.Grow(int(size) + bytes.MinRead)
+	if _, err := buf.ReadFrom(f); err != nil {
+		putReadBuffer(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+func putReadBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBytes {
+		return
+	}
+	readBuffers.Put(buf)
+}
+
+// fileBytes is the contents of a file read for the length of one
+// operation: mapped if it is at least Options.MmapThreshold, otherwise in
+// a pooled buffer.
+type fileBytes struct {
+	b      []byte
+	buf    *bytes.Buffer
+	mapped bool
+}
+
+// readTransient reads the file at path into a fileBytes, which the caller
+// must release once nothing refers to its bytes.
+//
+// Mapping is safe against concurrent writes because they replace records
+// by renaming a new file over the old one: the mapping keeps the old file's
+// contents until it is released.
+func (d *Driver) readTransient(path string) (fileBytes, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return fileBytes{}, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return fileBytes{}, err
+	}
+	if d.mmapThreshold > 0 && fi.Size() >= d.mmapThreshold {
+		// Where mapping fails, the file is read as usual.
+		if b, err := mmap(f, fi.Size()); err == nil {
+			return fileBytes{b: b, mapped: true}, nil
+		}
+	}
+
+	buf, err := readInto(f, fi.Size())
+	if err != nil {
+		return fileBytes{}, err
+	}
+	return fileBytes{b: buf.Bytes(), buf: buf}, nil
+}
+
+func (fb fileBytes) release() {
+	switch {
+	case fb.mapped:
+		munmap(fb.b)
+	case fb.buf != nil:
+		putReadBuffer(fb.buf)
+	}
+}
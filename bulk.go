@@ -0,0 +1,273 @@
+package litedb
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// BulkLoader writes many records into one collection far faster than
+// Write, for initial loads and large imports. It holds the collection lock
+// from BulkLoad until Close, encodes documents on the caller's goroutine
+// while ReadWorkers goroutines write the files, and leaves the per-record
+// bookkeeping Write does to one pass in Close:
+//
+//   - with group commit or DurabilitySync, files are synced once at Close
+//     instead of per write; otherwise syncing is left to the OS as for
+//     Write;
+//   - key listings, bloom filters and the hot cache are rebuilt after the
+//     load rather than updated per record;
+//   - only records that already had metadata get it updated, and new
+//     records get none, so Metadata reports their file times, unless
+//     records are signed, when every record gets metadata holding its
+//     signature;
+//   - watchers and CDC sinks see the changes when Close emits them.
+//
+// Documents are validated and references checked as for Write, but
+// middleware, hooks and quotas do not apply. Other readers may not see
+// the records until Close returns.
+type BulkLoader struct {
+	d          *Driver
+	collection string
+	dir        string
+	mutex      *sync.Mutex
+	release    func()
+
+	// existed holds the resources present when the load began, to tell
+	// created records from updated ones.
+	existed map[string]bool
+
+	jobs chan bulkJob
+	wg   sync.WaitGroup
+
+	// written maps each loaded resource to its checksum; errMutex also
+	// guards err.
+	errMutex sync.Mutex
+	written  map[string]string
+	computed map[string]map[string]json.RawMessage
+	err      error
+
+	closed bool
+}
+
+type bulkJob struct {
+	resource string
+	checksum string
+	computed map[string]json.RawMessage
+	buf      *bytes.Buffer
+}
+
+// BulkLoad starts a bulk load into collection. Close must be called to
+// finish it and release the collection.
+func (d *Driver) BulkLoad(collection string) (*BulkLoader, error) {
+	if err := d.validCollection(collection); err != nil {
+		return nil, err
+	}
+	if err := d.checkOpen(); err != nil {
+		return nil, err
+	}
+	if err := d.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	dir := d.collectionDir(collection)
+	if err := os.MkdirAll(dir, dirMode(d.fileMode)); err != nil {
+		return nil, err
+	}
+
+	mutex := d.lock(collection)
+	release, err := d.lockAttached(collection)
+	if err != nil {
+		mutex.Unlock()
+		return nil, err
+	}
+	keys, err := d.keys(collection)
+	if err != nil {
+		release()
+		mutex.Unlock()
+		return nil, err
+	}
+
+	l := &BulkLoader{
+		d:          d,
+		collection: collection,
+		dir:        dir,
+		mutex:      mutex,
+		release:    release,
+		existed:    make(map[string]bool, len(keys)),
+		jobs:       make(chan bulkJob, 2*d.readWorkers),
+		written:    make(map[string]string),
+		computed:   make(map[string]map[string]json.RawMessage),
+	}
+	for _, key := range keys {
+		l.existed[key] = true
+	}
+	for i := 0; i < d.readWorkers; i++ {
+		l.wg.Add(1)
+		go l.work()
+	}
+	return l, nil
+}
+
+func (l *BulkLoader) work() {
+	defer l.wg.Done()
+	for job := range l.jobs {
+		err := l.writeFile(job)
+		putReadBuffer(job.buf)
+
+		l.errMutex.Lock()
+		if err != nil && l.err == nil {
+			l.err = fmt.Errorf("writing '%s': %w", job.resource, err)
+		}
+		if err == nil {
+			l.written[job.resource] = job.checksum
+			l.computed[job.resource] = job.computed
+		}
+		l.errMutex.Unlock()
+	}
+}
+
+func (l *BulkLoader) writeFile(job bulkJob) error {
+	path := filepath.Join(l.dir, l.d.recordFile(job.resource))
+	l.d.markSelf(path)
+	if err := os.WriteFile(path+".tmp", job.buf.Bytes(), l.d.fileMode); err != nil {
+		os.Remove(path + ".tmp")
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Add queues v to be written as resource. It returns an error if v is
+// invalid, or if an earlier record failed to write, after which the load
+// should be closed. Records are written concurrently, so a resource added
+// twice in one load ends up with either document.
+func (l *BulkLoader) Add(resource string, v interface{}) error {
+	if l.closed {
+		return fmt.Errorf("bulk load of collection '%s' is closed", l.collection)
+	}
+	if err := validResource(resource); err != nil {
+		return err
+	}
+
+	l.errMutex.Lock()
+	err := l.err
+	l.errMutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := l.d.validate(l.collection, resource, v); err != nil {
+		return err
+	}
+
+	w := l.d.documentWriter(l.collection)
+	defer putRecordWriter(w)
+
+	buf := readBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	if err := w.encode(buf, v, false); err == nil {
+		err = w.buf.Flush()
+	}
+	if err != nil {
+		putReadBuffer(buf)
+		return err
+	}
+	if err := l.d.checkReferences(l.collection, resource, buf.Bytes()); err != nil {
+		putReadBuffer(buf)
+		return err
+	}
+	computed, err := l.d.compute(l.collection, resource, buf.Bytes())
+	if err != nil {
+		putReadBuffer(buf)
+		return err
+	}
+
+	l.jobs <- bulkJob{resource: resource, checksum: hex.EncodeToString(w.hash.Sum(nil)), computed: computed, buf: buf}
+	return nil
+}
+
+// Close waits for the queued records, syncs them to disk, brings metadata
+// and in-memory state up to date, emits change events and releases the
+// collection. It returns the first error of the load.
+func (l *BulkLoader) Close() error {
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	close(l.jobs)
+	l.wg.Wait()
+	defer l.mutex.Unlock()
+	defer l.release()
+
+	d, collection := l.d, l.collection
+	err := l.err
+
+	// Whatever was written is in place, so the bookkeeping runs even if
+	// the load failed part way.
+	d.keyCache.removed(collection, "")
+	d.bloomDrop(collection)
+	d.hot.drop(collection, "")
+
+	setErr := func(e error) {
+		if err == nil {
+			err = e
+		}
+	}
+
+	if d.commits != nil || d.syncWrites {
+		for resource := range l.written {
+			setErr(syncPath(filepath.Join(l.dir, d.recordFile(resource))))
+		}
+		setErr(syncPath(l.dir))
+	}
+
+	hasMeta, e := dirNames(filepath.Join(d.dir, metaDir, collection))
+	setErr(e)
+	archived, e := dirNames(filepath.Join(d.dir, archiveDir, collection))
+	setErr(e)
+
+	ctx := context.Background()
+	signs := d.signs()
+	for resource, checksum := range l.written {
+		stem := d.fileStem(resource)
+		if computed := l.computed[resource]; signs || hasMeta[stem+".json"] || computed != nil {
+			setErr(d.updateMeta(collection, resource, checksum, 0, computed))
+		}
+		if archived[stem+archiveExt] || archived[stem+dictArchiveExt] {
+			setErr(d.removeArchived(collection, resource))
+		}
+
+		event := Created
+		if l.existed[resource] {
+			event = Updated
+		}
+		d.emit(ctx, event, collection, resource, nil)
+	}
+	d.audit(ctx, "bulk_load", collection, "", "")
+
+	return err
+}
+
+// dirNames lists the names in dir. A missing dir has none.
+func dirNames(dir string) (map[string]bool, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+	return names, nil
+}
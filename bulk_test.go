@@ -0,0 +1,141 @@
+package litedb_test
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	litedb "github.com/SagarDas211/LiteDB-Go"
+	"github.com/SagarDas211/LiteDB-Go/litedbtest"
+)
+
+func TestBulkLoad(t *testing.T) {
+	db, err := litedb.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	litedbtest.Seed(t, db, "items", map[string]interface{}{"i0": map[string]int{"n": -1}})
+
+	events, cancel := db.Watch("items")
+	defer cancel()
+
+	l, err := db.BulkLoad("items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Few enough records that every event fits in the watch buffer.
+	const n = 50
+	for i := 0; i < n; i++ {
+		if err := l.Add(fmt.Sprintf("i%d", i), map[string]int{"n": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Add("late", map[string]int{}); err == nil {
+		t.Fatal("Add after Close succeeded")
+	}
+
+	keys, err := db.Keys("items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != n {
+		t.Fatalf("Keys returned %d records, want %d", len(keys), n)
+	}
+	for _, i := range []int{0, 1, n / 2, n - 1} {
+		litedbtest.AssertRecord(t, db, "items", fmt.Sprintf("i%d", i), map[string]int{"n": i})
+	}
+
+	// The record that was there before has its metadata brought up to date.
+	m, err := db.Metadata("items", "i0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Version != 2 {
+		t.Fatalf("Version of updated record = %d, want 2", m.Version)
+	}
+
+	counts := map[litedb.EventType]int{}
+	for i := 0; i < n; i++ {
+		counts[(<-events).Type]++
+	}
+	if counts[litedb.Created] != n-1 || counts[litedb.Updated] != 1 {
+		t.Fatalf("events = %v, want %d created and 1 updated", counts, n-1)
+	}
+}
+
+func TestBulkLoadValidates(t *testing.T) {
+	db, err := litedb.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	errOdd := errors.New("odd")
+	if err := db.AddValidator("items", func(resource string, v interface{}) error {
+		if v.(map[string]int)["n"]%2 == 1 {
+			return errOdd
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := db.BulkLoad("items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Add("i1", map[string]int{"n": 1}); !errors.Is(err, errOdd) {
+		t.Fatalf("Add of invalid document = %v, want the validator's error", err)
+	}
+	if err := l.Add("i2", map[string]int{"n": 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Add("../i3", map[string]int{"n": 2}); err == nil {
+		t.Fatal("Add of invalid resource name succeeded")
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := db.Keys("items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if len(keys) != 1 || keys[0] != "i2" {
+		t.Fatalf("Keys = %v, want [i2]", keys)
+	}
+}
+
+func TestBulkLoadSigned(t *testing.T) {
+	db, err := litedb.New(t.TempDir(), litedb.WithSigningKey([]byte("key")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	litedbtest.Seed(t, db, "items", map[string]interface{}{"old": map[string]int{"n": 0}})
+
+	l, err := db.BulkLoad("items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, resource := range []string{"old", "new"} {
+		if err := l.Add(resource, map[string]int{"n": 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	litedbtest.AssertRecord(t, db, "items", "old", map[string]int{"n": 1})
+	litedbtest.AssertRecord(t, db, "items", "new", map[string]int{"n": 1})
+	if _, err := db.Find("items", nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,306 @@
+// Package cache layers a cache over a LiteDB collection: entries that
+// expire, a cap on how many are kept with the least recently used evicted
+// first, and loads of a missing key shared by every caller waiting on it,
+// so a burst of requests for one expired entry computes it once. Entries
+// are records, so the cache survives restarts, which suits HTTP responses
+// and results that are slow to compute.
+//
+//	c, err := cache.New(db, "responses", cache.Options{TTL: time.Minute, MaxEntries: 10000})
+//	var page Page
+//	err = c.GetOrLoad(url, &page, func() (interface{}, error) { return render(url) })
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// Store is the part of the driver the cache needs. Reads of a missing or
+// expired record must fail with an error matching fs.ErrNotExist, as the
+// driver's ErrNotFound does.
+type Store interface {
+	Write(collection, resource string, v interface{}) error
+	WriteWithTTL(collection, resource string, v interface{}, ttl time.Duration) error
+	ReadBytes(collection, resource string) ([]byte, error)
+	Delete(collection, resource string) error
+	Keys(collection string) ([]string, error)
+}
+
+// Options sets how long entries last and how many are kept. The zero
+// value keeps every entry until it is deleted.
+type Options struct {
+	// TTL is how long an entry lasts unless SetWithTTL says otherwise.
+	// Zero keeps entries until they are evicted or deleted.
+	TTL time.Duration
+
+	// MaxEntries caps the entries kept, evicting the least recently used
+	// first. Zero means no cap.
+	MaxEntries int
+}
+
+// Cache is a cache kept in one collection. It is safe for concurrent use,
+// but assumes nothing else writes to the collection.
+type Cache struct {
+	store      Store
+	collection string
+	opts       Options
+
+	mutex   sync.Mutex
+	recency *list.List // of resource names, most recently used first
+	entries map[string]*list.Element
+	loads   map[string]*load
+
+	hits, misses uint64
+}
+
+// entry is a cached value as stored. The record is named by a hash of
+// Key, so Key is kept to tell a collision from a hit.
+type entry struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// load is a GetOrLoad in progress, which other callers for the key wait
+// on rather than loading it again.
+type load struct {
+	done  chan struct{}
+	value json.RawMessage
+	err   error
+}
+
+// Stats counts the lookups a Cache answered.
+type Stats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// New returns the cache kept in collection of store, taking up the entries
+// already there. Their order of use was not kept, so after a restart they
+// are evicted in name order.
+func New(store Store, collection string, opts Options) (*Cache, error) {
+	if opts.TTL < 0 || opts.MaxEntries < 0 {
+		return nil, fmt.Errorf("cache TTL and MaxEntries cannot be negative")
+	}
+
+	keys, err := store.Keys(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	c := &Cache{
+		store:      store,
+		collection: collection,
+		opts:       opts,
+		recency:    list.New(),
+		entries:    make(map[string]*list.Element, len(keys)),
+		loads:      make(map[string]*load),
+	}
+	for _, resource := range keys {
+		c.entries[resource] = c.recency.PushFront(resource)
+	}
+	if err := c.evict(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Get decodes the entry for key into v, reporting false if there is none.
+func (c *Cache) Get(key string, v interface{}) (bool, error) {
+	value, ok, err := c.get(key)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, json.Unmarshal(value, v)
+}
+
+func (c *Cache) get(key string) (json.RawMessage, bool, error) {
+	resource := resourceOf(key)
+	b, err := c.store.ReadBytes(c.collection, resource)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if errors.Is(err, fs.ErrNotExist) {
+		c.forget(resource)
+		c.misses++
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var e entry
+	if err := json.Unmarshal(b, &e); err != nil || e.Key != key {
+		c.misses++
+		return nil, false, nil
+	}
+	if el, ok := c.entries[resource]; ok {
+		c.recency.MoveToFront(el)
+	}
+	c.hits++
+	return e.Value, true, nil
+}
+
+// Set stores v, encoded as JSON, as the entry for key, for the cache's
+// TTL.
+func (c *Cache) Set(key string, v interface{}) error {
+	return c.SetWithTTL(key, v, c.opts.TTL)
+}
+
+// SetWithTTL is Set for an entry that lasts ttl. Zero keeps it until it
+// is evicted or deleted.
+func (c *Cache) SetWithTTL(key string, v interface{}, ttl time.Duration) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("cache entry '%s': %w", key, err)
+	}
+	return c.set(key, value, ttl)
+}
+
+func (c *Cache) set(key string, value json.RawMessage, ttl time.Duration) error {
+	resource := resourceOf(key)
+	e := entry{Key: key, Value: value}
+
+	var err error
+	if ttl > 0 {
+		err = c.store.WriteWithTTL(c.collection, resource, e, ttl)
+	} else {
+		err = c.store.Write(c.collection, resource, e)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if el, ok := c.entries[resource]; ok {
+		c.recency.MoveToFront(el)
+	} else {
+		c.entries[resource] = c.recency.PushFront(resource)
+	}
+	return c.evict()
+}
+
+// GetOrLoad decodes the entry for key into v, calling fn to make it if
+// there is none and storing what fn returns for the cache's TTL. Callers
+// asking for the same key while fn runs wait for it and share its result,
+// so fn runs once however many ask. An error from fn is returned to them
+// all and nothing is stored.
+func (c *Cache) GetOrLoad(key string, v interface{}, fn func() (interface{}, error)) error {
+	value, ok, err := c.get(key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if value, err = c.load(key, fn); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(value, v)
+}
+
+func (c *Cache) load(key string, fn func() (interface{}, error)) (json.RawMessage, error) {
+	c.mutex.Lock()
+	if l, ok := c.loads[key]; ok {
+		c.mutex.Unlock()
+		<-l.done
+		return l.value, l.err
+	}
+	l := &load{done: make(chan struct{})}
+	c.loads[key] = l
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.loads, key)
+		c.mutex.Unlock()
+		close(l.done)
+	}()
+
+	// Another caller may have stored the entry between the miss and
+	// taking up the load.
+	value, ok, err := c.get(key)
+	if err != nil || ok {
+		l.value, l.err = value, err
+		return value, err
+	}
+
+	v, err := fn()
+	if err != nil {
+		l.err = err
+		return nil, err
+	}
+	if l.value, l.err = json.Marshal(v); l.err != nil {
+		l.err = fmt.Errorf("cache entry '%s': %w", key, l.err)
+		return nil, l.err
+	}
+	l.err = c.set(key, l.value, c.opts.TTL)
+	return l.value, l.err
+}
+
+// Delete removes the entry for key, if there is one.
+func (c *Cache) Delete(key string) error {
+	resource := resourceOf(key)
+	if err := c.store.Delete(c.collection, resource); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.forget(resource)
+	return nil
+}
+
+// Len returns the number of entries, counting any that have expired but
+// not yet been looked up or swept.
+func (c *Cache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.entries)
+}
+
+// Stats returns the cache's hits and misses since New.
+func (c *Cache) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return Stats{Hits: c.hits, Misses: c.misses, Entries: len(c.entries)}
+}
+
+// evict deletes the least recently used entries over MaxEntries. The
+// caller must hold the mutex.
+func (c *Cache) evict() error {
+	if c.opts.MaxEntries == 0 {
+		return nil
+	}
+	for len(c.entries) > c.opts.MaxEntries {
+		resource := c.recency.Back().Value.(string)
+		if err := c.store.Delete(c.collection, resource); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		c.forget(resource)
+	}
+	return nil
+}
+
+// forget drops resource from the recency list. The caller must hold the
+// mutex.
+func (c *Cache) forget(resource string) {
+	if el, ok := c.entries[resource]; ok {
+		c.recency.Remove(el)
+		delete(c.entries, resource)
+	}
+}
+
+// resourceOf names the record for key. Keys such as URLs may hold
+// characters a resource name cannot, so records are named by a hash.
+func resourceOf(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
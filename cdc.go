@@ -0,0 +1,152 @@
+package litedb
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/SagarDas211/LiteDB-Go/cdc"
+)
+
+// CDCSink receives the mutations streamed by a change-data-capture feed.
+type CDCSink = cdc.Sink
+
+const (
+	cdcBuffer   = 1024
+	cdcAttempts = 3
+
+	// cdcWait bounds how long a writer waits for a sink with a full
+	// buffer before the mutation is dropped.
+	cdcWait = 10 * time.Second
+)
+
+type cdcFeed struct {
+	sink CDCSink
+	ch   chan cdc.Mutation
+	done chan struct{}
+}
+
+type cdcFeeds struct {
+	mutex sync.Mutex
+	seq   uint64
+	next  int
+	feeds map[int]*cdcFeed
+
+	dropped atomic.Uint64
+}
+
+// AddCDCSink delivers every mutation committed from now on to sink, in
+// commit order, from a dedicated goroutine. Once the sink falls a full
+// buffer behind, writers wait for it to catch up, for up to ten seconds,
+// and only then drop the mutation, or drop it at once if
+// Options.CDCDropWhenBehind is set. Dropped mutations leave a gap in Seq,
+// are logged, and are counted in Stats.CDCDropped. The CancelFunc drains
+// pending mutations and closes the sink.
+func (d *Driver) AddCDCSink(sink CDCSink) CancelFunc {
+	feed := &cdcFeed{sink: sink, ch: make(chan cdc.Mutation, cdcBuffer), done: make(chan struct{})}
+
+	d.cdc.mutex.Lock()
+	if d.cdc.feeds == nil {
+		d.cdc.feeds = make(map[int]*cdcFeed)
+	}
+	id := d.cdc.next
+	d.cdc.next++
+	d.cdc.feeds[id] = feed
+	d.cdc.mutex.Unlock()
+
+	go d.deliver(feed)
+
+	var once sync.Once
+	return d.onClose(func() {
+		once.Do(func() {
+			d.cdc.mutex.Lock()
+			delete(d.cdc.feeds, id)
+			d.cdc.mutex.Unlock()
+			close(feed.ch)
+			<-feed.done
+			if err := sink.Close(); err != nil {
+				d.log.Error("Closing CDC sink failed", "err", err)
+			}
+		})
+	})
+}
+
+func (d *Driver) deliver(feed *cdcFeed) {
+	defer close(feed.done)
+
+	for m := range feed.ch {
+		var err error
+		for attempt := 1; attempt <= cdcAttempts; attempt++ {
+			if err = feed.sink.Publish(context.Background(), m); err == nil || attempt == cdcAttempts {
+				break
+			}
+			time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+		}
+		if err != nil {
+			d.log.Error("CDC sink dropped mutation", "seq", m.Seq, "collection", m.Collection, "resource", m.Resource, "err", err)
+		}
+	}
+}
+
+// capturing reports whether any CDC sink is attached, so writers know to
+// keep the encoded document for capture.
+func (d *Driver) capturing() bool {
+	d.cdc.mutex.Lock()
+	defer d.cdc.mutex.Unlock()
+	return len(d.cdc.feeds) > 0
+}
+
+// capture hands a committed mutation to every sink. Callers hold the
+// collection lock, so sequence numbers follow commit order.
+func (d *Driver) capture(ctx context.Context, t EventType, collection, resource string, data []byte, at time.Time) {
+	d.cdc.mutex.Lock()
+	defer d.cdc.mutex.Unlock()
+
+	if len(d.cdc.feeds) == 0 {
+		return
+	}
+
+	d.cdc.seq++
+	m := cdc.Mutation{
+		Seq:        d.cdc.seq,
+		Op:         cdc.OpWrite,
+		Collection: collection,
+		Resource:   resource,
+		Actor:      ActorFromContext(ctx),
+		Time:       at,
+	}
+	if t == Deleted {
+		m.Op = cdc.OpDelete
+	} else if data == nil {
+		// A sink attached after the writer checked capturing; the
+		// record is still locked, so the file holds what was written.
+		m.Data, _ = os.ReadFile(d.recordPath(collection, resource))
+	} else {
+		m.Data = append([]byte(nil), data...)
+	}
+
+	var timeout <-chan time.Time
+	for _, feed := range d.cdc.feeds {
+		select {
+		case feed.ch <- m:
+			continue
+		default:
+		}
+		if !d.opts.CDCDropWhenBehind {
+			if timeout == nil {
+				timer := time.NewTimer(cdcWait)
+				defer timer.Stop()
+				timeout = timer.C
+			}
+			select {
+			case feed.ch <- m:
+				continue
+			case <-timeout:
+			}
+		}
+		d.cdc.dropped.Add(1)
+		d.log.Error("CDC sink fell behind, dropped mutation", "seq", m.Seq, "collection", m.Collection, "resource", m.Resource)
+	}
+}
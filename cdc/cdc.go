@@ -0,0 +1,36 @@
+// Package cdc defines the change-data-capture contract between a LiteDB
+// driver and the systems it feeds.
+package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type Op string
+
+const (
+	OpWrite  Op = "write"
+	OpDelete Op = "delete"
+)
+
+// Mutation is one committed change. Seq increases by one for every
+// mutation a driver commits, so consumers can detect gaps and apply
+// changes in order. Data holds the document for writes.
+type Mutation struct {
+	Seq        uint64          `json:"seq"`
+	Op         Op              `json:"op"`
+	Collection string          `json:"collection"`
+	Resource   string          `json:"resource"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	Actor      string          `json:"actor,omitempty"`
+	Time       time.Time       `json:"time"`
+}
+
+// Sink receives every mutation a driver commits, in commit order. Publish
+// is called from a single goroutine per sink.
+type Sink interface {
+	Publish(ctx context.Context, m Mutation) error
+	Close() error
+}
@@ -0,0 +1,42 @@
+// Package kafkasink publishes LiteDB mutations to a Kafka topic.
+package kafkasink
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/SagarDas211/LiteDB-Go/cdc"
+	"github.com/segmentio/kafka-go"
+)
+
+// Sink writes each mutation as a JSON message keyed by
+// "<collection>/<resource>", so all changes to a record land on the same
+// partition and keep their order.
+type Sink struct {
+	w *kafka.Writer
+}
+
+func New(w *kafka.Writer) *Sink {
+	return &Sink{w: w}
+}
+
+func (s *Sink) Publish(ctx context.Context, m cdc.Mutation) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return s.w.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(m.Collection + "/" + m.Resource),
+		Value: b,
+		Headers: []kafka.Header{
+			{Key: "litedb-seq", Value: []byte(strconv.FormatUint(m.Seq, 10))},
+			{Key: "litedb-op", Value: []byte(m.Op)},
+		},
+	})
+}
+
+func (s *Sink) Close() error {
+	return s.w.Close()
+}
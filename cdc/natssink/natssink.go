@@ -0,0 +1,39 @@
+// Package natssink publishes LiteDB mutations to NATS subjects.
+package natssink
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/SagarDas211/LiteDB-Go/cdc"
+	"github.com/nats-io/nats.go"
+)
+
+// Sink publishes each mutation as JSON on "<prefix>.<collection>".
+type Sink struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+func New(conn *nats.Conn, prefix string) *Sink {
+	return &Sink{conn: conn, prefix: prefix}
+}
+
+func (s *Sink) Publish(ctx context.Context, m cdc.Mutation) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(s.prefix + "." + m.Collection)
+	msg.Data = b
+	msg.Header.Set("Litedb-Seq", strconv.FormatUint(m.Seq, 10))
+	return s.conn.PublishMsg(msg)
+}
+
+// Close flushes buffered publishes; the connection stays owned by the
+// caller.
+func (s *Sink) Close() error {
+	return s.conn.Flush()
+}
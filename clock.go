@@ -0,0 +1,29 @@
+package litedb
+
+import "time"
+
+// Clock tells the driver the time. Every time the driver stores or
+// compares records against comes from it: TTLs, metadata timestamps,
+// archive and retention cutoffs, ULIDs, event, audit and export times.
+// Intervals of background work, timeouts and rate limits run on the
+// system clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock the driver uses unless Options.Clock is set.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// WithClock takes the time from c, so tests can move it forward instead
+// of sleeping. See Options.Clock.
+func WithClock(c Clock) Option {
+	return optionFunc(func(o *Options) { o.Clock = c })
+}
+
+func (d *Driver) now() time.Time {
+	return d.clock.Now()
+}
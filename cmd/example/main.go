@@ -0,0 +1,125 @@
+// Command example walks through the basic driver API: it writes a few
+// users, reads them back, and deletes them again.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	litedb "github.com/SagarDas211/LiteDB-Go"
+)
+
+type Address struct {
+	City    string
+	State   string
+	Country string
+	Pincode json.Number
+}
+
+type User struct {
+	Name    string
+	Age     json.Number
+	Contact string
+	Company string
+	Address Address
+}
+
+func main() {
+	dir := flag.String("dir", "./", "database directory")
+	flag.Parse()
+
+	db, err := litedb.New(*dir)
+	if err != nil {
+		fmt.Println("Error creating DB:", err)
+	}
+
+	employee := []User{
+		{
+			Name:    "John Doe",
+			Age:     "30",
+			Contact: "123-456-7890",
+			Company: "TechCorp",
+			Address: Address{
+				City:    "San Francisco",
+				State:   "CA",
+				Country: "USA",
+				Pincode: "94105",
+			},
+		},
+		{
+			Name:    "Jane Smith",
+			Age:     "28",
+			Contact: "987-654-3210",
+			Company: "Innovatech",
+			Address: Address{
+				City:    "New York",
+				State:   "NY",
+				Country: "USA",
+				Pincode: "10001",
+			},
+		},
+		{
+			Name:    "Alice Johnson",
+			Age:     "35",
+			Contact: "555-123-4567",
+			Company: "WebSolutions",
+			Address: Address{
+				City:    "Los Angeles",
+				State:   "CA",
+				Country: "USA",
+				Pincode: "90001",
+			},
+		},
+		{
+			Name:    "Bob Brown",
+			Age:     "40",
+			Contact: "444-555-6666",
+			Company: "DataAnalytics",
+			Address: Address{
+				City:    "Chicago",
+				State:   "IL",
+				Country: "USA",
+				Pincode: "60601",
+			},
+		},
+	}
+
+	for _, value := range employee {
+		db.Write("users", value.Name, User{
+			Name:    value.Name,
+			Age:     value.Age,
+			Contact: value.Contact,
+			Company: value.Company,
+			Address: value.Address,
+		})
+	}
+
+	records, err := db.ReadAll("users")
+	if err != nil {
+		fmt.Println("Error reading records:", err)
+	}
+
+	fmt.Println("All User Records:", records)
+
+	allusers := []User{}
+	for _, record := range records {
+		employeeFound := User{}
+		err := json.Unmarshal([]byte(record), &employeeFound)
+		if err != nil {
+			fmt.Println("Error unmarshaling record:", err)
+		}
+		allusers = append(allusers, employeeFound)
+	}
+
+	fmt.Println("All Users Structs:", allusers)
+
+	if err := db.Delete("users", "Alice Johnson"); err != nil {
+		fmt.Println("Error deleting record:", err)
+	}
+
+	if err := db.Delete("users", ""); err != nil {
+		fmt.Println("Error deleting all records:", err)
+	}
+
+}
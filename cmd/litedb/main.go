@@ -0,0 +1,482 @@
+// Command litedb reads and writes a LiteDB-Go database from the command
+// line, and serves it over HTTP, gRPC and the Redis protocol.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	litedb "github.com/SagarDas211/LiteDB-Go"
+	"github.com/SagarDas211/LiteDB-Go/bench"
+	"github.com/SagarDas211/LiteDB-Go/litedbsync"
+	"github.com/SagarDas211/LiteDB-Go/stress"
+	"google.golang.org/grpc"
+)
+
+type command struct {
+	usage string
+	run   func(db *litedb.Driver, args []string) error
+}
+
+var commands = map[string]command{
+	"get":     {"get <collection> <resource>", cmdGet},
+	"put":     {"put <collection> <resource> [json]  (reads stdin without json)", cmdPut},
+	"delete":  {"delete <collection> [resource]", cmdDelete},
+	"ls":      {"ls [collection]", cmdList},
+	"find":    {"find <collection> [field=value...]", cmdFind},
+	"export":  {"export <collection>  (one {\"id\",\"data\"} object per line on stdout)", cmdExport},
+	"import":  {"import <collection>  (reads export output from stdin)", cmdImport},
+	"backup":  {"backup <dest>", cmdBackup},
+	"compact": {"compact", cmdCompact},
+	"check":   {"check", cmdCheck},
+	"bench":   {"bench [-run regexp] [-baseline file [-save] [-tolerance 0.2]]", cmdBench},
+	"stress":  {"stress [-duration 10s] [-workers n] [-collections 4] [-keys 32] [-seed n]", cmdStress},
+	"sync":    {"sync <url>  (a peer served with serve -sync, e.g. http://host:8080/sync)", cmdSync},
+	"serve":   {"serve [-http addr [-admin] [-primary] [-sync] [-tenants keys.json]] [-grpc addr] [-resp addr] [-replica-of url] [-rate n] [-max-request bytes]", cmdServe},
+}
+
+var commandOrder = []string{"get", "put", "delete", "ls", "find", "export", "import", "backup", "compact", "check", "bench", "stress", "sync", "serve"}
+
+var (
+	dir      = flag.String("dir", "./", "database directory")
+	redact   = flag.String("redact", "", "comma-separated `fields` that get and find show as [REDACTED]")
+	readOnly = flag.Bool("read-only", false, "open the database read-only, refusing any change")
+	logger   = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+)
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	os.Exit(run(*dir, flag.Args()))
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s [-dir dir] <command> [args]\n\ncommands:\n", os.Args[0])
+	for _, name := range commandOrder {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nflags:")
+	flag.PrintDefaults()
+}
+
+// run executes a CLI command against the database in dir and returns the
+// process exit code.
+func run(dir string, args []string) int {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "litedb: unknown command '%s'\n\n", args[0])
+		usage()
+		return 2
+	}
+
+	open := litedb.New
+	if *readOnly {
+		open = litedb.OpenReadOnly
+	}
+	db, err := open(dir, litedb.WithLogger(logger))
+	if err != nil && !os.IsExist(err) {
+		fmt.Fprintln(os.Stderr, "litedb:", err)
+		return 1
+	}
+
+	if err := cmd.run(db, args[1:]); err != nil {
+		if err == errUsage {
+			fmt.Fprintf(os.Stderr, "usage: %s %s\n", os.Args[0], cmd.usage)
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, "litedb:", err)
+		return 1
+	}
+	return 0
+}
+
+var errUsage = fmt.Errorf("usage")
+
+func cmdGet(db *litedb.Driver, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	doc, err := db.ReadBytes(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if doc, err = redacted(db, args[0], doc); err != nil {
+		return err
+	}
+	_, err = fmt.Println(string(doc))
+	return err
+}
+
+// redacted applies the -redact flag to a document of collection.
+func redacted(db *litedb.Driver, collection string, doc []byte) ([]byte, error) {
+	if *redact == "" {
+		return doc, nil
+	}
+	if err := db.SetSensitive(collection, strings.Split(*redact, ",")...); err != nil {
+		return nil, err
+	}
+	return db.Redact(collection, doc)
+}
+
+func cmdPut(db *litedb.Driver, args []string) error {
+	var b []byte
+	switch len(args) {
+	case 2:
+		var err error
+		if b, err = io.ReadAll(os.Stdin); err != nil {
+			return err
+		}
+	case 3:
+		b = []byte(args[2])
+	default:
+		return errUsage
+	}
+
+	if !json.Valid(b) {
+		return fmt.Errorf("document is not valid JSON")
+	}
+	return db.Write(args[0], args[1], json.RawMessage(b))
+}
+
+func cmdDelete(db *litedb.Driver, args []string) error {
+	switch len(args) {
+	case 1:
+		return db.Delete(args[0], "")
+	case 2:
+		return db.Delete(args[0], args[1])
+	}
+	return errUsage
+}
+
+func cmdList(db *litedb.Driver, args []string) error {
+	var names []string
+	switch len(args) {
+	case 0:
+		var err error
+		if names, err = db.Collections(); err != nil {
+			return err
+		}
+	case 1:
+		records, err := db.Find(args[0], nil)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			names = append(names, r.ID)
+		}
+	default:
+		return errUsage
+	}
+
+	for _, name := range names {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+func cmdFind(db *litedb.Driver, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	filter := litedb.Filter{}
+	for _, arg := range args[1:] {
+		field, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return errUsage
+		}
+		// Values that parse as JSON match numbers, booleans and so on;
+		// anything else is matched as a string.
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			v = value
+		}
+		filter[field] = v
+	}
+
+	records, err := db.Find(args[0], filter)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		if records[i].Data, err = redacted(db, args[0], records[i].Data); err != nil {
+			return err
+		}
+	}
+	return writeRecords(os.Stdout, records)
+}
+
+func cmdExport(db *litedb.Driver, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	_, err := db.ExportNDJSON(args[0], os.Stdout, nil)
+	return err
+}
+
+func writeRecords(w io.Writer, records []litedb.Record) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func cmdImport(db *litedb.Driver, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	_, err := db.ImportNDJSON(args[0], os.Stdin, func(records int, bytes int64) {
+		fmt.Fprintf(os.Stderr, "\rimported %d records (%d bytes)", records, bytes)
+	})
+	fmt.Fprintln(os.Stderr)
+	return err
+}
+
+func cmdBackup(db *litedb.Driver, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	return db.Backup(args[0])
+}
+
+func cmdCompact(db *litedb.Driver, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	report, err := db.Compact()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("removed %d temp files, %d orphaned metadata files, %d empty collections\n", report.TempFiles, report.OrphanedMeta, report.EmptyCollections)
+	return nil
+}
+
+func cmdCheck(db *litedb.Driver, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	report, err := db.Check()
+	if err != nil {
+		return err
+	}
+	for _, p := range report.Problems {
+		fmt.Println(p)
+	}
+	fmt.Printf("checked %d records in %d collections: %d problems\n", report.Records, report.Collections, len(report.Problems))
+	if len(report.Problems) > 0 {
+		return fmt.Errorf("database has problems")
+	}
+	return nil
+}
+
+func cmdBench(db *litedb.Driver, args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	pattern := fs.String("run", "", "run only the benchmarks matching this regexp")
+	baseline := fs.String("baseline", "", "compare against the baseline in this file")
+	save := fs.Bool("save", false, "write the results to the baseline file instead of comparing")
+	tolerance := fs.Float64("tolerance", 0.2, "slowdown over the baseline to report, as a fraction")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 || (*save && *baseline == "") {
+		return errUsage
+	}
+
+	var re *regexp.Regexp
+	if *pattern != "" {
+		var err error
+		if re, err = regexp.Compile(*pattern); err != nil {
+			return err
+		}
+	}
+
+	// Benchmarks use their own temporary databases, not the one in -dir.
+	open := func(dir string) (bench.Store, error) {
+		return litedb.New(dir, litedb.WithLogger(logger))
+	}
+	results, err := bench.Run(open, re)
+	for _, r := range results {
+		fmt.Println(r)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *save:
+		return bench.NewBaseline(results).Save(*baseline)
+	case *baseline != "":
+		bl, err := bench.LoadBaseline(*baseline)
+		if err != nil {
+			return err
+		}
+		regressions := bl.Compare(results, *tolerance)
+		for _, r := range regressions {
+			fmt.Fprintln(os.Stderr, "regression:", r)
+		}
+		if len(regressions) > 0 {
+			return fmt.Errorf("%d benchmarks regressed", len(regressions))
+		}
+	}
+	return nil
+}
+
+func cmdStress(db *litedb.Driver, args []string) error {
+	fs := flag.NewFlagSet("stress", flag.ContinueOnError)
+	duration := fs.Duration("duration", 10*time.Second, "how long to run")
+	workers := fs.Int("workers", 0, "concurrent workers (default twice GOMAXPROCS)")
+	collections := fs.Int("collections", 4, "collections to spread records over")
+	keys := fs.Int("keys", 32, "records per collection; fewer means more contention")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "seed for the operations and documents")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return errUsage
+	}
+
+	// Like bench, stress uses its own temporary database, not the one in
+	// -dir.
+	tmp, err := os.MkdirTemp("", "litedb-stress-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	store, err := litedb.New(tmp, litedb.WithLogger(logger))
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	fmt.Fprintf(os.Stderr, "stressing %s with seed %d\n", tmp, *seed)
+	report := stress.Run(context.Background(), store, stress.Options{
+		Duration:    *duration,
+		Workers:     *workers,
+		Collections: *collections,
+		Keys:        *keys,
+		Seed:        *seed,
+	})
+	fmt.Println(report)
+	for _, v := range report.Violations {
+		fmt.Println("violation:", v)
+	}
+	if !report.OK() {
+		return fmt.Errorf("%d violations", report.ViolationCount)
+	}
+	return nil
+}
+
+func cmdSync(db *litedb.Driver, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	report, err := db.Sync(context.Background(), litedbsync.NewClient(args[0], nil), nil)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("pushed %d, pulled %d, resolved %d conflicts\n", report.Pushed, report.Pulled, report.Conflicts)
+	return nil
+}
+
+func cmdServe(db *litedb.Driver, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("http", "", "serve the database over HTTP on this address")
+	grpcAddr := fs.String("grpc", "", "serve the database over gRPC on this address")
+	admin := fs.Bool("admin", false, "serve the admin dashboard at /admin/ on the HTTP address")
+	respAddr := fs.String("resp", "", "serve the database over the Redis protocol on this address")
+	primary := fs.Bool("primary", false, "serve replication at /replication/ on the HTTP address")
+	syncPeer := fs.Bool("sync", false, "serve sync at /sync/ on the HTTP address")
+	tenantKeys := fs.String("tenants", "", "host a database per tenant under -dir on the HTTP address, with API keys from this JSON file")
+	replicaOf := fs.String("replica-of", "", "follow the primary replication endpoint at this URL")
+	rate := fs.Float64("rate", 0, "limit each client to this many `requests` a second")
+	maxRequest := fs.Int64("max-request", 0, "refuse request bodies and gRPC messages over this many `bytes`")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 || (*addr == "" && *grpcAddr == "" && *respAddr == "") {
+		return errUsage
+	}
+
+	limits := litedb.ServerLimits{Rate: *rate, MaxRequestBytes: *maxRequest}
+	if err := db.SetServerLimits(limits); err != nil {
+		return err
+	}
+
+	errs := make(chan error, 4)
+	if *replicaOf != "" {
+		r := db.Replicate(*replicaOf)
+		fmt.Fprintln(os.Stderr, "replicating from", *replicaOf)
+		go func() { errs <- r.Run(context.Background()) }()
+	}
+	if *respAddr != "" {
+		lis, err := net.Listen("tcp", *respAddr)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(os.Stderr, "serving Redis protocol on", lis.Addr())
+		go func() { errs <- db.RESPServer().Serve(lis) }()
+	}
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			return err
+		}
+
+		s := grpc.NewServer(limits.GRPCServerOptions()...)
+		db.RegisterGRPC(s)
+		fmt.Fprintln(os.Stderr, "serving gRPC on", lis.Addr())
+		go func() { errs <- s.Serve(lis) }()
+	}
+	if *addr != "" {
+		handler := db.Handler()
+		if *admin {
+			handler = db.AdminHandler()
+		}
+		if *tenantKeys != "" {
+			tenants, err := litedb.NewTenants(*dir, litedb.WithLogger(logger))
+			if err != nil {
+				return err
+			}
+			if err := tenants.Load(*tenantKeys); err != nil {
+				return err
+			}
+			handler = tenants.Handler()
+		}
+		if *primary || *syncPeer {
+			mux := http.NewServeMux()
+			mux.Handle("/", handler)
+			if *primary {
+				p, cancel := db.ReplicationPrimary()
+				defer cancel()
+				mux.Handle("/replication/", http.StripPrefix("/replication", p))
+			}
+			if *syncPeer {
+				mux.Handle("/sync/", http.StripPrefix("/sync", db.SyncHandler()))
+			}
+			handler = mux
+		}
+		fmt.Fprintln(os.Stderr, "serving HTTP on", *addr)
+		go func() { errs <- http.ListenAndServe(*addr, handler) }()
+	}
+
+	return <-errs
+}
@@ -0,0 +1,83 @@
+package litedb
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+type collectionConfig struct {
+	defaults   map[string]json.RawMessage
+	validators []Validator
+	computed   map[string]ComputeFunc
+
+	references   []Reference
+	referencedBy []Reference
+
+	view      *View
+	viewsFrom []string
+	triggers  []Trigger
+
+	idStrategy IDStrategy
+	quota      *Quota
+	docType    reflect.Type
+
+	archiveAfter time.Duration
+	archiveDict  *bool
+	retention    *RetentionPolicy
+	format       *Format
+	history      *int
+	series       *SeriesOptions
+
+	sensitive     []string
+	typeSensitive []string
+
+	hooks [hookKinds][]Hook
+}
+
+// Collections lists the collections stored in the database.
+func (d *Driver) Collections() ([]string, error) {
+	names, err := d.collectionNames()
+	if err != nil {
+		return nil, err
+	}
+	return d.withAttached(names), nil
+}
+
+// Keys lists the resource names in collection without reading the
+// documents. Expired records are left out.
+func (d *Driver) Keys(collection string) ([]string, error) {
+	if err := d.validCollection(collection); err != nil {
+		return nil, err
+	}
+
+	keys, err := d.keys(collection)
+	if err != nil {
+		return nil, err
+	}
+	expiries, err := d.expiriesFor(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	now := d.now()
+	live := keys[:0]
+	for _, resource := range keys {
+		if expiresAt, ok := expiries[resource]; ok && !now.Before(expiresAt) {
+			continue
+		}
+		live = append(live, resource)
+	}
+	return live, nil
+}
+
+// configFor returns the config for collection, creating it if needed.
+// The caller must hold d.configMutex for writing.
+func (d *Driver) configFor(collection string) *collectionConfig {
+	c, ok := d.configs[collection]
+	if !ok {
+		c = &collectionConfig{}
+		d.configs[collection] = c
+	}
+	return c
+}
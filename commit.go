@@ -0,0 +1,135 @@
+package litedb
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// groupCommit tracks files written since the last fsync so a burst of
+// writes pays for one round of syncs instead of one each.
+type groupCommit struct {
+	window time.Duration
+
+	mutex   sync.Mutex
+	pending map[string]struct{}
+	timer   *time.Timer
+
+	// syncing serializes flushes so Flush returns only once everything
+	// pending when it was called is on disk.
+	syncing sync.Mutex
+}
+
+// commitLater queues path, and the directory entry naming it, for the
+// next group commit. It is a no-op unless CommitWindow is set.
+func (d *Driver) commitLater(path string) {
+	gc := d.commits
+	if gc == nil {
+		return
+	}
+
+	gc.mutex.Lock()
+	defer gc.mutex.Unlock()
+
+	if gc.pending == nil {
+		gc.pending = make(map[string]struct{})
+	}
+	gc.pending[path] = struct{}{}
+	gc.pending[filepath.Dir(path)] = struct{}{}
+
+	if gc.timer == nil {
+		gc.timer = time.AfterFunc(gc.window, func() {
+			if err := d.syncPending(); err != nil {
+				d.log.Warn("Group commit failed", "err", err)
+			}
+		})
+	}
+}
+
+// commit makes path, and the directory entry naming it, durable as the
+// Durability option asks: before returning under DurabilitySync, with the
+// next group commit otherwise.
+func (d *Driver) commit(path string) error {
+	if !d.syncWrites {
+		d.commitLater(path)
+		return nil
+	}
+	if err := d.storage.Sync(path); err != nil {
+		return err
+	}
+	return d.storage.Sync(filepath.Dir(path))
+}
+
+// Flush waits for queued WriteAsync calls, then syncs every write made so
+// far to stable storage. With a CommitWindow, writes are only guaranteed
+// durable once the window passes or Flush returns. It returns an error if
+// any async write since the last Flush failed.
+func (d *Driver) Flush() error {
+	err := d.drainAsync()
+	if serr := d.syncPending(); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// syncPending runs the group commit now; without a CommitWindow there is
+// nothing to do.
+func (d *Driver) syncPending() error {
+	gc := d.commits
+	if gc == nil {
+		return nil
+	}
+
+	gc.syncing.Lock()
+	defer gc.syncing.Unlock()
+
+	gc.mutex.Lock()
+	pending := gc.pending
+	gc.pending = nil
+	if gc.timer != nil {
+		gc.timer.Stop()
+		gc.timer = nil
+	}
+	gc.mutex.Unlock()
+
+	// Files first, then the directories whose entries name them.
+	var dirs []string
+	var firstErr error
+	for path := range pending {
+		fi, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if fi.IsDir() {
+			dirs = append(dirs, path)
+			continue
+		}
+		if err := d.storage.Sync(path); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, dir := range dirs {
+		if err := d.storage.Sync(dir); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func syncPath(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Replaced or deleted since it was queued; whatever took its
+		// place is queued too.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
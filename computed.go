@@ -0,0 +1,177 @@
+package litedb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ComputeFunc derives a field's value from a document as it is written.
+// doc is the document decoded with numbers as json.Number, or nil if it is
+// not a JSON object. An error refuses the write.
+type ComputeFunc func(resource string, doc map[string]interface{}) (interface{}, error)
+
+// AddComputed makes field a computed field of collection: fn is run on
+// every document written and its result kept in the record's metadata
+// beside the document, which is stored as written. Computed fields are
+// read with Metadata and matched by Find, and by equality conditions in
+// SQL, like the document's own fields, so a lowercased name or a full
+// name built from its parts can be searched without storing it twice. A
+// computed field hides a document field of the same name from Find.
+// Records written before the field was added have no value until
+// rewritten or Recompute is run.
+func (d *Driver) AddComputed(collection, field string, fn ComputeFunc) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if field == "" {
+		return fmt.Errorf("computed field name cannot be empty")
+	}
+	if fn == nil {
+		return fmt.Errorf("compute function cannot be nil")
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	// Writers range over the map without the lock, so it is replaced
+	// rather than changed.
+	c := d.configFor(collection)
+	computed := make(map[string]ComputeFunc, len(c.computed)+1)
+	for name, f := range c.computed {
+		computed[name] = f
+	}
+	computed[field] = fn
+	c.computed = computed
+
+	return nil
+}
+
+func (d *Driver) computedFor(collection string) map[string]ComputeFunc {
+	d.configMutex.RLock()
+	defer d.configMutex.RUnlock()
+	if c, ok := d.configs[collection]; ok {
+		return c.computed
+	}
+	return nil
+}
+
+func (d *Driver) hasComputed(collection string) bool {
+	return len(d.computedFor(collection)) > 0
+}
+
+// compute runs collection's computed fields on the document b, returning
+// nil if it has none.
+func (d *Driver) compute(collection, resource string, b []byte) (map[string]json.RawMessage, error) {
+	fns := d.computedFor(collection)
+	if len(fns) == 0 {
+		return nil, nil
+	}
+
+	doc, _ := decodeDocument(b)
+	values := make(map[string]json.RawMessage, len(fns))
+	for field, fn := range fns {
+		v, err := fn(resource, doc)
+		if err != nil {
+			return nil, fmt.Errorf("computing '%s' for '%s' in collection '%s': %w", field, resource, collection, err)
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("computing '%s' for '%s' in collection '%s': %w", field, resource, collection, err)
+		}
+		values[field] = value
+	}
+	return values, nil
+}
+
+// Recompute runs collection's computed fields again on every record, as
+// after one was added or changed. The documents are left as they are.
+func (d *Driver) Recompute(collection string) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+
+	keys, err := d.keys(collection)
+	if err != nil {
+		return err
+	}
+	sort.Strings(keys)
+
+	for _, resource := range keys {
+		var computed map[string]json.RawMessage
+		err := d.withRecord(collection, resource, func(b []byte) error {
+			var err error
+			computed, err = d.compute(collection, resource, b)
+			return err
+		})
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+
+		fi, err := os.Stat(d.recordPath(collection, resource))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		m, err := d.readMeta(collection, resource)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			m = &Metadata{CreatedAt: fi.ModTime(), UpdatedAt: fi.ModTime()}
+		}
+		m.Computed = computed
+		if err := d.writeMeta(collection, resource, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitComputed moves the fields of want that are computed fields of
+// collection into a filter of their own.
+func (d *Driver) splitComputed(collection string, want map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	fns := d.computedFor(collection)
+	if len(fns) == 0 {
+		return want, nil
+	}
+
+	fields := make(map[string]interface{}, len(want))
+	var computed map[string]interface{}
+	for field, value := range want {
+		if _, ok := fns[field]; !ok {
+			fields[field] = value
+			continue
+		}
+		if computed == nil {
+			computed = make(map[string]interface{})
+		}
+		computed[field] = value
+	}
+	return fields, computed
+}
+
+// matchComputed reports whether the computed values in m satisfy want.
+func matchComputed(m *Metadata, want map[string]interface{}) bool {
+	if m == nil {
+		return false
+	}
+	values := make(map[string]interface{}, len(want))
+	for field := range want {
+		if raw, ok := m.Computed[field]; ok && len(raw) > 0 {
+			values[field] = decodeValue(raw)
+		}
+	}
+	return matches(values, want)
+}
@@ -0,0 +1,193 @@
+package litedb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// csvKeyColumn is the column ExportCSV writes resource names to.
+const csvKeyColumn = "id"
+
+// ImportCSV writes a record for every row of r, whose first row is the
+// header. The keyColumn names the column holding each record's resource
+// name; without one, IDs come from the collection's ID strategy. Cells
+// holding integers, floats or booleans are stored as such, empty cells
+// are left out, and a header such as "address.city" builds a nested
+// object. It returns how many records were written.
+func (d *Driver) ImportCSV(collection string, r io.Reader, keyColumn string) (int, error) {
+	if err := d.validCollection(collection); err != nil {
+		return 0, err
+	}
+
+	cr := csv.NewReader(skipBOM(r))
+	header, err := cr.Read()
+	if err == io.EOF {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	key := -1
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		if name == "" {
+			return 0, fmt.Errorf("column %d has no name", i+1)
+		}
+		if seen[name] {
+			return 0, fmt.Errorf("duplicate column '%s'", name)
+		}
+		seen[name] = true
+		if name == keyColumn {
+			key = i
+		}
+	}
+	if keyColumn != "" && key < 0 {
+		return 0, fmt.Errorf("key column '%s' is not in the header", keyColumn)
+	}
+
+	n := 0
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		doc := make(map[string]interface{}, len(row))
+		for i, cell := range row {
+			if cell == "" {
+				continue
+			}
+			setField(doc, header[i], inferCSV(cell))
+		}
+
+		if key < 0 {
+			_, err = d.Insert(collection, doc)
+		} else if row[key] == "" {
+			err = fmt.Errorf("empty key column '%s'", keyColumn)
+		} else {
+			err = d.Write(collection, row[key], doc)
+		}
+		if err != nil {
+			return n, fmt.Errorf("line %d: %w", line, err)
+		}
+		n++
+	}
+}
+
+// ExportCSV writes the collection to w with a header row. The first
+// column is "id", the resource name, followed by fields, or by every
+// top-level field found when none are given. A document's own "id" field
+// is left out, as it would collide with the resource name's column and
+// the file could not be imported again. Objects and arrays are written as
+// JSON.
+func (d *Driver) ExportCSV(collection string, w io.Writer, fields ...string) error {
+	records, err := d.Find(collection, nil)
+	if err != nil {
+		return err
+	}
+
+	docs := make([]map[string]interface{}, len(records))
+	for i, r := range records {
+		// Documents that are not objects export with only an id.
+		docs[i], _ = decodeDocument(r.Data)
+	}
+
+	for _, field := range fields {
+		if field == csvKeyColumn {
+			return fmt.Errorf("field '%s' collides with the resource name column", field)
+		}
+	}
+	if len(fields) == 0 {
+		seen := map[string]bool{csvKeyColumn: true}
+		for _, doc := range docs {
+			for field := range doc {
+				if !seen[field] {
+					seen[field] = true
+					fields = append(fields, field)
+				}
+			}
+		}
+		sort.Strings(fields)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{csvKeyColumn}, fields...)); err != nil {
+		return err
+	}
+
+	row := make([]string, len(fields)+1)
+	for i, r := range records {
+		row[0] = r.ID
+		for j, field := range fields {
+			v, _ := lookupField(docs[i], field)
+			if row[j+1], err = formatCSV(v); err != nil {
+				return err
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func inferCSV(cell string) interface{} {
+	switch cell {
+	case "true", "TRUE", "True":
+		return true
+	case "false", "FALSE", "False":
+		return false
+	}
+
+	// Numbers with leading zeros, like postal codes, stay strings.
+	digits := strings.TrimPrefix(cell, "-")
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+		return cell
+	}
+	if n, err := strconv.ParseInt(cell, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(cell, 64); err == nil && !strings.ContainsAny(cell, "xXnN") {
+		return f
+	}
+	return cell
+}
+
+func formatCSV(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// skipBOM drops the byte order mark spreadsheet programs put at the start
+// of UTF-8 CSV files.
+func skipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(3); err == nil && bytes.Equal(b, []byte("\xef\xbb\xbf")) {
+		br.Discard(3)
+	}
+	return br
+}
@@ -0,0 +1,144 @@
+package litedb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	dbMetaFile = "_litedb.meta"
+
+	// formatVersion is the on-disk layout this driver writes. Directories
+	// created before the layout was versioned count as version 0.
+	formatVersion = 1
+
+	encryptionNone = "none"
+)
+
+// formatUpgrades moves a database from the version it is indexed by to the
+// next one.
+var formatUpgrades = [formatVersion]func(*Driver) error{
+	// Version 1 only adds _litedb.meta itself.
+	func(*Driver) error { return nil },
+}
+
+// dbMeta is the content of _litedb.meta, describing how the database in
+// the directory was written.
+type dbMeta struct {
+	FormatVersion int    `json:"formatVersion"`
+	DriverVersion string `json:"driverVersion"`
+	Codec         string `json:"codec"`
+	Compression   string `json:"compression"`
+	Encryption    string `json:"encryption"`
+	PortableNames bool   `json:"portableNames,omitempty"`
+}
+
+func (c Compression) String() string {
+	switch c {
+	case CompressionGzip:
+		return "gzip"
+	case CompressionZstd:
+		return "zstd"
+	}
+	return fmt.Sprintf("Compression(%d)", int(c))
+}
+
+// codecName names the driver's codec for _litedb.meta and
+// DescribeCollection.
+func (d *Driver) codecName() string {
+	if d.codec == nil {
+		return "json"
+	}
+	return fmt.Sprintf("%T", d.codec)
+}
+
+// checkFormat reads _litedb.meta and refuses a database written in a
+// format this driver does not understand. Older formats are upgraded in
+// place, and the file is rewritten whenever what it records has changed;
+// a driver from OpenReadOnly does neither.
+func (d *Driver) checkFormat() error {
+	path := filepath.Join(d.dir, dbMetaFile)
+
+	var m dbMeta
+	b, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &m); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	default:
+		// Nothing to upgrade in a new database.
+		if entries, err := os.ReadDir(d.dir); err == nil && len(entries) == 0 {
+			m.FormatVersion = formatVersion
+		}
+	}
+
+	if m.FormatVersion > formatVersion {
+		return fmt.Errorf("database format version %d is newer than this driver supports (%d); it was written by driver %s", m.FormatVersion, formatVersion, m.DriverVersion)
+	}
+	if m.Encryption != "" && m.Encryption != encryptionNone {
+		return fmt.Errorf("database is encrypted with %s, which this driver does not support", m.Encryption)
+	}
+
+	// Version 0 lacks only this file, so it can be read as it is.
+	if d.readOnly && m.FormatVersion > 0 && m.FormatVersion < formatVersion {
+		return fmt.Errorf("database format version %d must be upgraded to %d, which needs opening it for writing", m.FormatVersion, formatVersion)
+	}
+	for v := m.FormatVersion; v < formatVersion && !d.readOnly; v++ {
+		d.log.Info("Upgrading database format", "dir", d.dir, "from", v, "to", v+1)
+		if err := formatUpgrades[v](d); err != nil {
+			return fmt.Errorf("upgrading database format from version %d: %w", v, err)
+		}
+	}
+
+	// File names are only decoded right with the encoding they were
+	// written in, so a database holding any keeps its setting.
+	switch {
+	case m.PortableNames && !d.portable:
+		d.log.Info("Using portable file names, as the database was created with them", "dir", d.dir)
+		d.portable = true
+	case d.portable && !m.PortableNames:
+		collections, err := d.collectionNames()
+		if err != nil {
+			return err
+		}
+		if len(collections) > 0 {
+			return fmt.Errorf("database was created without portable names, which cannot be turned on once it holds collections")
+		}
+	}
+
+	// Records are JSON whatever the codec, and archived records of either
+	// compression can be read, so neither stops the database opening.
+	if m.Codec != "" && m.Codec != d.codecName() {
+		d.log.Debug("Database codec changed", "dir", d.dir, "was", m.Codec, "now", d.codecName())
+	}
+
+	current := dbMeta{
+		FormatVersion: formatVersion,
+		DriverVersion: Version,
+		Codec:         d.codecName(),
+		Compression:   d.compression.String(),
+		Encryption:    encryptionNone,
+		PortableNames: d.portable,
+	}
+	if m == current || d.readOnly {
+		return nil
+	}
+
+	if b, err = json.MarshalIndent(current, "", "\t"); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", append(b, '\n'), d.fileMode); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	return d.commit(path)
+}
@@ -0,0 +1,69 @@
+package litedb
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SetDefaults sets values for top-level fields, filled into documents
+// missing them when they are read. Stored files are left as written.
+func (d *Driver) SetDefaults(collection string, defaults map[string]interface{}) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+
+	encoded := make(map[string]json.RawMessage, len(defaults))
+	for field, value := range defaults {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("invalid default for field '%s': %w", field, err)
+		}
+		encoded[field] = b
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	d.configFor(collection).defaults = encoded
+
+	return nil
+}
+
+// applyDefaults injects the collection's defaults for any top-level field
+// missing from the document. Documents that are not JSON objects are
+// returned untouched so the caller's decode reports the real problem.
+func (d *Driver) applyDefaults(collection string, b []byte) ([]byte, error) {
+	d.configMutex.RLock()
+	var defaults map[string]json.RawMessage
+	if c, ok := d.configs[collection]; ok {
+		defaults = c.defaults
+	}
+	d.configMutex.RUnlock()
+
+	if len(defaults) == 0 {
+		return b, nil
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(b, &doc); err != nil || doc == nil {
+		return b, nil
+	}
+
+	injected := false
+	for field, value := range defaults {
+		if _, ok := doc[field]; !ok {
+			doc[field] = value
+			injected = true
+		}
+	}
+
+	if !injected {
+		return b, nil
+	}
+
+	out, err := json.MarshalIndent(doc, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(out, byte('\n')), nil
+}
@@ -0,0 +1,115 @@
+package litedb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// CollectionInfo describes a collection: its settings, and its records
+// on disk.
+type CollectionInfo struct {
+	Name         string
+	Codec        string
+	IDStrategy   IDStrategy
+	Quota        *Quota
+	Records      int
+	Size         int64
+	Expiring     int
+	Archived     int
+	ArchiveAfter time.Duration
+	ArchiveDict  bool
+	Format       Format
+	Sensitive    []string
+	Retention    *RetentionPolicy
+	Defaults     map[string]json.RawMessage
+	Validators   int
+	Computed     []string
+	Triggers     int
+	Hooks        map[string]int
+	References   []Reference
+	ReferencedBy []Reference
+}
+
+// DescribeCollection reports a collection's settings along with its record
+// count and size.
+func (d *Driver) DescribeCollection(collection string) (*CollectionInfo, error) {
+	if err := d.validCollection(collection); err != nil {
+		return nil, err
+	}
+
+	info := &CollectionInfo{Name: collection, Codec: d.codecName()}
+
+	d.configMutex.RLock()
+	c, configured := d.configs[collection]
+	if configured {
+		info.Defaults = make(map[string]json.RawMessage, len(c.defaults))
+		for field, value := range c.defaults {
+			info.Defaults[field] = value
+		}
+		info.IDStrategy = c.idStrategy
+		info.ArchiveAfter = c.archiveAfter
+		if c.archiveDict != nil {
+			info.ArchiveDict = *c.archiveDict
+		}
+		if c.retention != nil {
+			p := *c.retention
+			info.Retention = &p
+		}
+		if c.quota != nil {
+			q := *c.quota
+			info.Quota = &q
+		}
+		info.Validators = len(c.validators)
+		for field := range c.computed {
+			info.Computed = append(info.Computed, field)
+		}
+		sort.Strings(info.Computed)
+		info.Triggers = len(c.triggers)
+		for kind, hooks := range c.hooks {
+			if len(hooks) == 0 {
+				continue
+			}
+			if info.Hooks == nil {
+				info.Hooks = make(map[string]int)
+			}
+			info.Hooks[hookKind(kind).String()] = len(hooks)
+		}
+		info.References = append([]Reference(nil), c.references...)
+		info.ReferencedBy = append([]Reference(nil), c.referencedBy...)
+	}
+	d.configMutex.RUnlock()
+	info.Format = d.formatOf(collection)
+	info.Sensitive = d.sensitiveFields(collection)
+
+	files, err := readDirInfo(d.collectionDir(collection))
+	switch {
+	case os.IsNotExist(err) && !configured:
+		return nil, fmt.Errorf("collection '%s' does not exist", collection)
+	case err != nil && !os.IsNotExist(err):
+		return nil, err
+	}
+
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != recordExt {
+			continue
+		}
+		info.Records++
+		info.Size += file.Size()
+	}
+
+	expiries, err := d.expiriesFor(collection)
+	if err != nil {
+		return nil, err
+	}
+	info.Expiring = len(expiries)
+
+	if info.Archived, err = d.archivedCount(collection); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
@@ -0,0 +1,175 @@
+package litedb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change is one field-level difference between two documents. Path is a
+// JSON Pointer and Op one of "add", "remove" and "replace", so a diff
+// encoded as JSON is a JSON Patch that Patch can apply; Old is extra and
+// ignored by Patch.
+type Change struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+	Old   json.RawMessage `json:"old,omitempty"`
+}
+
+// Diff returns what writing v to a record would change, as for a preview
+// before saving or an audit trail of edits. v is encoded as Write would
+// encode it. A missing record yields a single add of the whole document,
+// at the empty path.
+func (d *Driver) Diff(collection, resource string, v interface{}) ([]Change, error) {
+	if err := d.validCollection(collection); err != nil {
+		return nil, err
+	}
+	if err := validResource(resource); err != nil {
+		return nil, err
+	}
+	if err := d.checkOpen(); err != nil {
+		return nil, err
+	}
+	if err := d.expireIfDue(collection, resource); err != nil {
+		return nil, err
+	}
+
+	var old interface{}
+	err := d.withRecord(collection, resource, func(b []byte) error {
+		var err error
+		old, err = decodeJSON(b)
+		return err
+	})
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return []Change{{Op: "add", Path: "", Value: b}}, nil
+	case err != nil:
+		return nil, err
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	return diffValues(nil, "", old, doc)
+}
+
+// DiffValues returns the changes that turn document a into document b.
+// Each is encoded as JSON first, so structs and maps compare alike.
+func DiffValues(a, b interface{}) ([]Change, error) {
+	var docs [2]interface{}
+	for i, v := range []interface{}{a, b} {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if docs[i], err = decodeJSON(raw); err != nil {
+			return nil, err
+		}
+	}
+	return diffValues(nil, "", docs[0], docs[1])
+}
+
+// diffValues appends to changes the differences between a and b, found at
+// path. Objects are compared member by member in name order and arrays
+// element by element; elements past the end of the shorter array are
+// added, or removed from the last, so the changes apply in order.
+func diffValues(changes []Change, path string, a, b interface{}) ([]Change, error) {
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		names := make([]string, 0, len(x)+len(y))
+		for name := range x {
+			names = append(names, name)
+		}
+		for name := range y {
+			if _, ok := x[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			p := path + "/" + escapePointer(name)
+			va, inA := x[name]
+			vb, inB := y[name]
+			var err error
+			switch {
+			case !inB:
+				changes, err = appendChange(changes, "remove", p, nil, va)
+			case !inA:
+				changes, err = appendChange(changes, "add", p, vb, nil)
+			default:
+				changes, err = diffValues(changes, p, va, vb)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return changes, nil
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		var err error
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if changes, err = diffValues(changes, path+"/"+strconv.Itoa(i), x[i], y[i]); err != nil {
+				return nil, err
+			}
+		}
+		for i := len(x); i < len(y); i++ {
+			if changes, err = appendChange(changes, "add", path+"/"+strconv.Itoa(i), y[i], nil); err != nil {
+				return nil, err
+			}
+		}
+		for i := len(x) - 1; i >= len(y); i-- {
+			if changes, err = appendChange(changes, "remove", path+"/"+strconv.Itoa(i), nil, x[i]); err != nil {
+				return nil, err
+			}
+		}
+		return changes, nil
+	}
+
+	if jsonEqual(a, b) {
+		return changes, nil
+	}
+	return appendChange(changes, "replace", path, b, a)
+}
+
+func appendChange(changes []Change, op, path string, value, old interface{}) ([]Change, error) {
+	c := Change{Op: op, Path: path}
+	var err error
+	if op != "remove" {
+		if c.Value, err = json.Marshal(value); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if op != "add" {
+		if c.Old, err = json.Marshal(old); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return append(changes, c), nil
+}
+
+// escapePointer escapes a member name as a JSON Pointer reference token.
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
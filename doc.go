@@ -0,0 +1,26 @@
+// Package litedb is a file-based document database. Each collection is a
+// directory and each record a JSON file in it, written atomically through
+// a temporary file and a rename, so the data stays readable with ordinary
+// tools.
+//
+// Open a database with New and use the Driver's methods:
+//
+//	db, err := litedb.New("./data")
+//	if err != nil {
+//		return err
+//	}
+//	if err := db.Write("users", "john", user); err != nil {
+//		return err
+//	}
+//	var u User
+//	err = db.Read("users", "john", &u)
+//
+// Beyond reads and writes, collections can carry validators, hooks,
+// defaults, quotas, references, TTLs and retention and archive policies.
+// Find, List and SQL select documents by field, and Watch streams
+// changes. Handler and RegisterGRPC serve a Driver to other processes,
+// which reach it with Connect.
+//
+// The litedb command in cmd/litedb runs the same operations from the
+// shell and serves a database over HTTP, gRPC and the Redis protocol.
+package litedb
@@ -0,0 +1,394 @@
+package litedb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const edgesDir = "_edges"
+
+// Node is a record at one end of an edge.
+type Node struct {
+	Collection string `json:"collection"`
+	Resource   string `json:"resource"`
+}
+
+func (n Node) String() string {
+	return n.Collection + "/" + n.Resource
+}
+
+// Edge is a labelled link from one record to another, made with Link.
+type Edge struct {
+	From  Node
+	Label string
+	To    Node
+}
+
+// Direction is which edges of a record a traversal follows.
+type Direction int
+
+const (
+	// Outgoing follows edges from the record, as linked.
+	Outgoing Direction = iota
+	// Incoming follows edges to the record, backwards.
+	Incoming
+	// Both follows edges either way.
+	Both
+)
+
+func (dir Direction) String() string {
+	switch dir {
+	case Outgoing:
+		return "outgoing"
+	case Incoming:
+		return "incoming"
+	case Both:
+		return "both"
+	}
+	return fmt.Sprintf("Direction(%d)", int(dir))
+}
+
+// Hop is a record Walk reached and the fewest edges it took.
+type Hop struct {
+	Node
+	Depth int
+}
+
+// edgeRef is one end of an edge seen from the other.
+type edgeRef struct {
+	Label      string `json:"label"`
+	Collection string `json:"collection"`
+	Resource   string `json:"resource"`
+}
+
+func (e edgeRef) node() Node {
+	return Node{Collection: e.Collection, Resource: e.Resource}
+}
+
+func (e edgeRef) less(o edgeRef) bool {
+	if e.Label != o.Label {
+		return e.Label < o.Label
+	}
+	if e.Collection != o.Collection {
+		return e.Collection < o.Collection
+	}
+	return e.Resource < o.Resource
+}
+
+// nodeEdges is the sidecar holding a record's edges in both directions,
+// so either end can be listed without a scan.
+type nodeEdges struct {
+	Out []edgeRef `json:"out,omitempty"`
+	In  []edgeRef `json:"in,omitempty"`
+}
+
+// Link adds an edge labelled label from resource in collection to
+// toResource in toCollection. Both records must exist. Linking twice is
+// not an error; there is still one edge. Edges go when either record is
+// deleted or expires, but stay with archived records.
+func (d *Driver) Link(collection, resource, label, toCollection, toResource string) error {
+	from, to, err := d.edgeEnds(collection, resource, label, toCollection, toResource)
+	if err != nil {
+		return err
+	}
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	for _, n := range []Node{from, to} {
+		ok, err := d.Exists(n.Collection, n.Resource)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errNoRecord(n.Collection, n.Resource)
+		}
+	}
+
+	d.edgesMutex.Lock()
+	defer d.edgesMutex.Unlock()
+
+	if err := d.updateEdges(from, func(e *nodeEdges) { e.Out = addEdge(e.Out, edgeRef{label, to.Collection, to.Resource}) }); err != nil {
+		return err
+	}
+	return d.updateEdges(to, func(e *nodeEdges) { e.In = addEdge(e.In, edgeRef{label, from.Collection, from.Resource}) })
+}
+
+// Unlink removes the edge Link added. Removing an edge that does not
+// exist is not an error.
+func (d *Driver) Unlink(collection, resource, label, toCollection, toResource string) error {
+	from, to, err := d.edgeEnds(collection, resource, label, toCollection, toResource)
+	if err != nil {
+		return err
+	}
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	d.edgesMutex.Lock()
+	defer d.edgesMutex.Unlock()
+
+	if err := d.updateEdges(from, func(e *nodeEdges) { e.Out = dropEdge(e.Out, edgeRef{label, to.Collection, to.Resource}) }); err != nil {
+		return err
+	}
+	return d.updateEdges(to, func(e *nodeEdges) { e.In = dropEdge(e.In, edgeRef{label, from.Collection, from.Resource}) })
+}
+
+func (d *Driver) edgeEnds(collection, resource, label, toCollection, toResource string) (Node, Node, error) {
+	for _, n := range [][2]string{{collection, resource}, {toCollection, toResource}} {
+		if err := d.validCollection(n[0]); err != nil {
+			return Node{}, Node{}, err
+		}
+		if err := validResource(n[1]); err != nil {
+			return Node{}, Node{}, err
+		}
+	}
+	if label == "" {
+		return Node{}, Node{}, fmt.Errorf("edge label cannot be empty")
+	}
+	return Node{collection, resource}, Node{toCollection, toResource}, nil
+}
+
+// Edges lists the edges from and to resource in collection, outgoing ones
+// first, each sorted by label and then by the other end.
+func (d *Driver) Edges(collection, resource string) ([]Edge, error) {
+	e, err := d.nodeEdgesOf(collection, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	self := Node{collection, resource}
+	edges := make([]Edge, 0, len(e.Out)+len(e.In))
+	for _, ref := range e.Out {
+		edges = append(edges, Edge{From: self, Label: ref.Label, To: ref.node()})
+	}
+	for _, ref := range e.In {
+		edges = append(edges, Edge{From: ref.node(), Label: ref.Label, To: self})
+	}
+	return edges, nil
+}
+
+// Neighbors lists the records one edge away from resource in collection,
+// following edges in direction dir that have the given label, or any
+// label if it is empty. They are sorted and listed once each.
+func (d *Driver) Neighbors(collection, resource, label string, dir Direction) ([]Node, error) {
+	if dir < Outgoing || dir > Both {
+		return nil, fmt.Errorf("unknown direction %v", dir)
+	}
+	e, err := d.nodeEdgesOf(collection, resource)
+	if err != nil {
+		return nil, err
+	}
+	return neighbors(e, label, dir), nil
+}
+
+// Walk lists the records reachable from resource in collection within
+// hops edges, following edges as Neighbors does, each with the fewest
+// edges it took. Records are listed once, nearest first, and the start is
+// not among them.
+func (d *Driver) Walk(collection, resource, label string, dir Direction, hops int) ([]Hop, error) {
+	if hops < 1 {
+		return nil, fmt.Errorf("walk must take at least one hop")
+	}
+	if dir < Outgoing || dir > Both {
+		return nil, fmt.Errorf("unknown direction %v", dir)
+	}
+
+	start := Node{collection, resource}
+	seen := map[Node]bool{start: true}
+	frontier := []Node{start}
+	var walked []Hop
+	for depth := 1; depth <= hops && len(frontier) > 0; depth++ {
+		var next []Node
+		for _, n := range frontier {
+			e, err := d.nodeEdgesOf(n.Collection, n.Resource)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range neighbors(e, label, dir) {
+				if seen[m] {
+					continue
+				}
+				seen[m] = true
+				next = append(next, m)
+				walked = append(walked, Hop{Node: m, Depth: depth})
+			}
+		}
+		frontier = next
+	}
+	return walked, nil
+}
+
+func neighbors(e *nodeEdges, label string, dir Direction) []Node {
+	var refs []edgeRef
+	if dir != Incoming {
+		refs = append(refs, e.Out...)
+	}
+	if dir != Outgoing {
+		refs = append(refs, e.In...)
+	}
+
+	seen := make(map[Node]bool, len(refs))
+	var nodes []Node
+	for _, ref := range refs {
+		if label != "" && ref.Label != label {
+			continue
+		}
+		if n := ref.node(); !seen[n] {
+			seen[n] = true
+			nodes = append(nodes, n)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Collection != nodes[j].Collection {
+			return nodes[i].Collection < nodes[j].Collection
+		}
+		return nodes[i].Resource < nodes[j].Resource
+	})
+	return nodes
+}
+
+func (d *Driver) nodeEdgesOf(collection, resource string) (*nodeEdges, error) {
+	if err := d.validCollection(collection); err != nil {
+		return nil, err
+	}
+	if err := validResource(resource); err != nil {
+		return nil, err
+	}
+
+	d.edgesMutex.Lock()
+	defer d.edgesMutex.Unlock()
+	return d.readEdges(Node{collection, resource})
+}
+
+func (d *Driver) edgesPath(n Node) string {
+	return filepath.Join(d.dir, edgesDir, n.Collection, d.fileStem(n.Resource)+recordExt)
+}
+
+// readEdges returns n's edges, empty if it has none. The caller must hold
+// edgesMutex.
+func (d *Driver) readEdges(n Node) (*nodeEdges, error) {
+	b, err := os.ReadFile(d.edgesPath(n))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &nodeEdges{}, nil
+		}
+		return nil, err
+	}
+	var e nodeEdges
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, fmt.Errorf("edges of '%s' in collection '%s': %w", n.Resource, n.Collection, err)
+	}
+	return &e, nil
+}
+
+// updateEdges rewrites n's edges with fn applied, removing the file once
+// none are left. The caller must hold edgesMutex.
+func (d *Driver) updateEdges(n Node, fn func(e *nodeEdges)) error {
+	e, err := d.readEdges(n)
+	if err != nil {
+		return err
+	}
+	fn(e)
+
+	path := d.edgesPath(n)
+	if len(e.Out) == 0 && len(e.In) == 0 {
+		if err := d.storage.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := d.storage.MkdirAll(filepath.Dir(path), dirMode(d.fileMode)); err != nil {
+		return err
+	}
+	tempPath := path + ".tmp"
+	w := getRecordWriter()
+	defer putRecordWriter(w)
+	w.mode = d.fileMode
+	w.storage = d.storage
+	if _, _, _, err := w.encodeFile(tempPath, e, false); err != nil {
+		return err
+	}
+	if err := d.storage.Rename(tempPath, path); err != nil {
+		d.storage.Remove(tempPath)
+		return err
+	}
+	return d.commit(path)
+}
+
+// removeEdges drops every edge of resource in collection, or of every
+// record in it when resource is empty, from both ends. It is called with
+// the collection lock held as records go.
+func (d *Driver) removeEdges(collection, resource string) error {
+	d.edgesMutex.Lock()
+	defer d.edgesMutex.Unlock()
+
+	nodes := []Node{{collection, resource}}
+	if resource == "" {
+		files, err := os.ReadDir(filepath.Join(d.dir, edgesDir, collection))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		nodes = nodes[:0]
+		for _, file := range files {
+			if !file.IsDir() && filepath.Ext(file.Name()) == recordExt {
+				nodes = append(nodes, Node{collection, d.resourceOf(file.Name(), recordExt)})
+			}
+		}
+	}
+
+	for _, n := range nodes {
+		e, err := d.readEdges(n)
+		if err != nil {
+			return err
+		}
+		gone := edgeRef{Collection: n.Collection, Resource: n.Resource}
+		for _, ref := range e.Out {
+			gone.Label = ref.Label
+			if err := d.updateEdges(ref.node(), func(e *nodeEdges) { e.In = dropEdge(e.In, gone) }); err != nil {
+				return err
+			}
+		}
+		for _, ref := range e.In {
+			gone.Label = ref.Label
+			if err := d.updateEdges(ref.node(), func(e *nodeEdges) { e.Out = dropEdge(e.Out, gone) }); err != nil {
+				return err
+			}
+		}
+		if err := d.storage.Remove(d.edgesPath(n)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if resource == "" {
+		return os.RemoveAll(filepath.Join(d.dir, edgesDir, collection))
+	}
+	return nil
+}
+
+// addEdge inserts ref into refs, kept sorted, unless it is there already.
+func addEdge(refs []edgeRef, ref edgeRef) []edgeRef {
+	i := sort.Search(len(refs), func(i int) bool { return !refs[i].less(ref) })
+	if i < len(refs) && refs[i] == ref {
+		return refs
+	}
+	refs = append(refs, edgeRef{})
+	copy(refs[i+1:], refs[i:])
+	refs[i] = ref
+	return refs
+}
+
+func dropEdge(refs []edgeRef, ref edgeRef) []edgeRef {
+	for i, r := range refs {
+		if r == ref {
+			return append(refs[:i], refs[i+1:]...)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,135 @@
+package litedb
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+// maxPooledBytes caps the captured bytes a pooled recordWriter keeps, so
+// one huge record does not pin its buffer for the life of the process.
+const maxPooledBytes = 1 << 20
+
+// recordWriter encodes documents in the on-disk format straight to a
+// file, hashing and counting the bytes on the way. Instances are pooled so
+// the encoder and buffers are reused across writes.
+type recordWriter struct {
+	buf  *bufio.Writer
+	hash hash.Hash
+	enc  *json.Encoder
+	size int64
+
+	// codec, when set, marshals in place of enc; the result is indented,
+	// or compacted, into indented to keep the on-disk format.
+	codec    Codec
+	indented bytes.Buffer
+
+	// compact writes documents on one line instead of indented.
+	compact bool
+
+	mode    fs.FileMode
+	storage Storage
+
+	// kept holds a copy of the encoded bytes when the caller asked for
+	// them.
+	keep bool
+	kept []byte
+}
+
+var recordWriters = sync.Pool{
+	New: func() interface{} {
+		w := &recordWriter{buf: bufio.NewWriterSize(nil, 32<<10), hash: sha256.New(), mode: defaultFileMode, storage: DiskStorage{}}
+		w.enc = json.NewEncoder(w)
+		w.enc.SetIndent("", "\t")
+		return w
+	},
+}
+
+func getRecordWriter() *recordWriter {
+	return recordWriters.Get().(*recordWriter)
+}
+
+func putRecordWriter(w *recordWriter) {
+	w.buf.Reset(nil)
+	if cap(w.kept) > maxPooledBytes {
+		w.kept = nil
+	}
+	if w.indented.Cap() > maxPooledBytes {
+		w.indented = bytes.Buffer{}
+	}
+	w.codec, w.mode, w.storage, w.compact = nil, defaultFileMode, DiskStorage{}, false
+	recordWriters.Put(w)
+}
+
+func (w *recordWriter) Write(p []byte) (int, error) {
+	w.size += int64(len(p))
+	w.hash.Write(p)
+	if w.keep {
+		w.kept = append(w.kept, p...)
+	}
+	return w.buf.Write(p)
+}
+
+// encodeFile writes v to a new file at path with a trailing newline. The
+// returned bytes are only set when keep is, and are only valid until w is
+// put back in the pool. On error the file is removed.
+func (w *recordWriter) encodeFile(path string, v interface{}, keep bool) (checksum string, size int64, b []byte, err error) {
+	f, err := w.storage.Create(path, w.mode)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	if err = w.encode(f, v, keep); err == nil {
+		err = w.buf.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		w.storage.Remove(path)
+		return "", 0, nil, err
+	}
+
+	return hex.EncodeToString(w.hash.Sum(nil)), w.size, w.kept, nil
+}
+
+func (w *recordWriter) encode(out io.Writer, v interface{}, keep bool) error {
+	w.buf.Reset(out)
+	w.hash.Reset()
+	w.size = 0
+	w.keep = keep
+	w.kept = w.kept[:0]
+	if s, ok := v.(*streamDocument); ok {
+		return w.copyJSON(s.r)
+	}
+	if w.codec == nil {
+		if w.compact {
+			w.enc.SetIndent("", "")
+			defer w.enc.SetIndent("", "\t")
+		}
+		return w.enc.Encode(v)
+	}
+
+	b, err := w.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.indented.Reset()
+	if w.compact {
+		err = json.Compact(&w.indented, b)
+	} else {
+		err = json.Indent(&w.indented, b, "", "\t")
+	}
+	if err != nil {
+		return err
+	}
+	w.indented.WriteByte('\n')
+	_, err = w.Write(w.indented.Bytes())
+	return err
+}
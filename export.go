@@ -0,0 +1,265 @@
+package litedb
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	archiveFormat   = "litedb-archive"
+	archiveVersion  = 1
+	archiveManifest = "manifest.json"
+	archiveData     = "data/"
+)
+
+// ArchiveManifest is the first entry of a .litedb archive. It carries the
+// collection settings that live in the Driver rather than on disk, so
+// Import can restore them. Validators, hooks and references are code and
+// must be registered again.
+type ArchiveManifest struct {
+	Format        string              `json:"format"`
+	Version       int                 `json:"version"`
+	DriverVersion string              `json:"driverVersion"`
+	Created       time.Time           `json:"created"`
+	Collections   []ArchiveCollection `json:"collections"`
+}
+
+// ArchiveCollection is a collection's entry in an ArchiveManifest.
+type ArchiveCollection struct {
+	Name         string                     `json:"name"`
+	Records      int                        `json:"records"`
+	IDStrategy   IDStrategy                 `json:"idStrategy,omitempty"`
+	Quota        *Quota                     `json:"quota,omitempty"`
+	Retention    *RetentionPolicy           `json:"retention,omitempty"`
+	ArchiveAfter time.Duration              `json:"archiveAfter,omitempty"`
+	ArchiveDict  bool                       `json:"archiveDict,omitempty"`
+	Format       Format                     `json:"format,omitempty"`
+	Defaults     map[string]json.RawMessage `json:"defaults,omitempty"`
+}
+
+// Export writes the whole database as a .litedb archive: a gzipped tar of
+// a manifest followed by every record, metadata sidecar, archived record
+// and the audit log. Every collection is locked for the duration so the
+// archive is consistent. Sync state stays behind, since the copy is a new
+// node.
+func (d *Driver) Export(w io.Writer) error {
+	collections, err := d.allCollections()
+	if err != nil {
+		return err
+	}
+	for _, collection := range collections {
+		mutex := d.lock(collection)
+		defer mutex.Unlock()
+	}
+
+	manifest := ArchiveManifest{Format: archiveFormat, Version: archiveVersion, DriverVersion: Version, Created: d.now().UTC()}
+	names, err := d.collectionNames()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		info, err := d.DescribeCollection(name)
+		if err != nil {
+			return err
+		}
+		manifest.Collections = append(manifest.Collections, ArchiveCollection{
+			Name:         name,
+			Records:      info.Records,
+			IDStrategy:   info.IDStrategy,
+			Quota:        info.Quota,
+			Retention:    info.Retention,
+			ArchiveAfter: info.ArchiveAfter,
+			ArchiveDict:  info.ArchiveDict,
+			Format:       info.Format,
+			Defaults:     info.Defaults,
+		})
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	b, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: archiveManifest, Mode: 0644, Size: int64(len(b)), ModTime: manifest.Created}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(b); err != nil {
+		return err
+	}
+
+	err = filepath.WalkDir(d.dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(d.dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if !entry.Type().IsRegular() || filepath.Ext(p) == ".tmp" || rel == syncFile || strings.HasPrefix(entry.Name(), syncFile+".") || rel == hotFile {
+			return nil
+		}
+
+		fi, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return addToArchive(tw, p, archiveData+filepath.ToSlash(rel), fi)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func addToArchive(tw *tar.Writer, src, name string, fi fs.FileInfo) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: int64(fi.Mode().Perm()), Size: fi.Size(), ModTime: fi.ModTime()}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// Import unpacks a .litedb archive written by Export into dir, which must
+// not exist yet, and opens it with options, applying the collection
+// settings from the manifest.
+func Import(r io.Reader, dir string, options ...Option) (*Driver, error) {
+	if _, err := os.Stat(dir); err == nil {
+		return nil, fmt.Errorf("import destination '%s' already exists", dir)
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a .litedb archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("not a .litedb archive: %w", err)
+	}
+	var manifest ArchiveManifest
+	if hdr.Name != archiveManifest || json.NewDecoder(tr).Decode(&manifest) != nil || manifest.Format != archiveFormat {
+		return nil, fmt.Errorf("not a .litedb archive: missing manifest")
+	}
+	if manifest.Version > archiveVersion {
+		return nil, fmt.Errorf("archive version %d is newer than this driver supports (%d)", manifest.Version, archiveVersion)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := extractArchive(tr, dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	d, err := New(dir, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range manifest.Collections {
+		if err := d.applyArchiveSettings(c); err != nil {
+			return nil, fmt.Errorf("collection '%s': %w", c.Name, err)
+		}
+	}
+	return d, nil
+}
+
+func extractArchive(tr *tar.Reader, dir string) error {
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || !strings.HasPrefix(name, archiveData) || strings.Contains(name, "..") {
+			return fmt.Errorf("unexpected archive entry '%s'", hdr.Name)
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(name, archiveData)))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fs.FileMode(hdr.Mode).Perm()|0600)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, tr)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+		os.Chtimes(target, hdr.ModTime, hdr.ModTime)
+	}
+}
+
+func (d *Driver) applyArchiveSettings(c ArchiveCollection) error {
+	if c.IDStrategy != 0 {
+		if err := d.SetIDStrategy(c.Name, c.IDStrategy); err != nil {
+			return err
+		}
+	}
+	if c.Quota != nil {
+		if err := d.SetQuota(c.Name, *c.Quota); err != nil {
+			return err
+		}
+	}
+	if c.Retention != nil {
+		if err := d.SetRetention(c.Name, *c.Retention); err != nil {
+			return err
+		}
+	}
+	if c.ArchiveAfter > 0 {
+		if err := d.SetArchivePolicy(c.Name, c.ArchiveAfter); err != nil {
+			return err
+		}
+	}
+	if c.ArchiveDict {
+		if err := d.SetArchiveDictionary(c.Name, true); err != nil {
+			return err
+		}
+	}
+	if c.Format != FormatPretty {
+		if err := d.SetFormat(c.Name, c.Format); err != nil {
+			return err
+		}
+	}
+	if len(c.Defaults) > 0 {
+		defaults := make(map[string]interface{}, len(c.Defaults))
+		for field, value := range c.Defaults {
+			defaults[field] = value
+		}
+		return d.SetDefaults(c.Name, defaults)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package litedb
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// selfWriteWindow is how long a path this Driver touched is attributed to
+// it when the matching filesystem notification arrives.
+const selfWriteWindow = 2 * time.Second
+
+type externalWatch struct {
+	mutex  sync.Mutex
+	active int
+	marks  map[string]time.Time
+}
+
+// WatchExternal watches the database directory so changes made by other
+// processes or by hand also reach Watch subscribers, flagged as External,
+// and invalidate in-memory state derived from disk.
+func (d *Driver) WatchExternal() (CancelFunc, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fw.Add(d.dir); err != nil {
+		fw.Close()
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		fw.Close()
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !reservedDir(entry.Name()) {
+			if err := fw.Add(filepath.Join(d.dir, entry.Name())); err != nil {
+				fw.Close()
+				return nil, err
+			}
+		}
+	}
+
+	d.external.mutex.Lock()
+	d.external.active++
+	if d.external.marks == nil {
+		d.external.marks = make(map[string]time.Time)
+	}
+	d.external.mutex.Unlock()
+
+	// Listings cached before the watch began may already be stale.
+	d.keyCache.reset()
+	d.hot.reset()
+	d.blooms.mutex.Lock()
+	d.blooms.filters = nil
+	d.blooms.mutex.Unlock()
+
+	done := make(chan struct{})
+	go d.watchFS(fw, done)
+
+	var once sync.Once
+	return d.onClose(func() {
+		once.Do(func() {
+			close(done)
+			fw.Close()
+			d.external.mutex.Lock()
+			d.external.active--
+			d.external.mutex.Unlock()
+		})
+	}), nil
+}
+
+func (d *Driver) watchFS(fw *fsnotify.Watcher, done chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return
+			}
+			d.log.Warn("Watching for external changes failed", "dir", d.dir, "err", err)
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return
+			}
+			d.handleFSEvent(fw, ev)
+		}
+	}
+}
+
+func (d *Driver) handleFSEvent(fw *fsnotify.Watcher, ev fsnotify.Event) {
+	rel, err := filepath.Rel(d.dir, ev.Name)
+	if err != nil {
+		return
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+
+	if len(parts) == 1 {
+		if ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+			d.keyCache.removed(parts[0], "")
+			d.hot.drop(parts[0], "")
+			d.bloomDrop(parts[0])
+		}
+		if ev.Has(fsnotify.Create) && !reservedDir(parts[0]) {
+			if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+				if err := fw.Add(ev.Name); err != nil {
+					d.log.Warn("Watching new collection failed", "collection", parts[0], "err", err)
+				}
+			}
+		}
+		return
+	}
+
+	if len(parts) != 2 || reservedDir(parts[0]) || filepath.Ext(parts[1]) != recordExt {
+		return
+	}
+	if d.selfWrite(ev.Name) {
+		return
+	}
+
+	var t EventType
+	switch {
+	case ev.Has(fsnotify.Create):
+		t = Created
+	case ev.Has(fsnotify.Write):
+		t = Updated
+	case ev.Has(fsnotify.Remove), ev.Has(fsnotify.Rename):
+		t = Deleted
+	default:
+		return
+	}
+
+	collection, resource := parts[0], d.resourceOf(parts[1], recordExt)
+	d.invalidate(collection, resource)
+	d.publish(Event{Type: t, Collection: collection, Resource: resource, Time: d.now(), External: true})
+}
+
+// markSelf attributes the next notification for path to this Driver. It is
+// a no-op unless WatchExternal is running.
+func (d *Driver) markSelf(path string) {
+	d.external.mutex.Lock()
+	defer d.external.mutex.Unlock()
+	if d.external.active == 0 {
+		return
+	}
+	d.external.marks[path] = time.Now()
+}
+
+// selfWrite reports whether path, or the directory it lived in, was
+// recently changed by this Driver. File marks are consumed; directory marks
+// cover every entry removed with the directory until they age out.
+func (d *Driver) selfWrite(path string) bool {
+	d.external.mutex.Lock()
+	defer d.external.mutex.Unlock()
+
+	now := time.Now()
+	for p, t := range d.external.marks {
+		if now.Sub(t) > selfWriteWindow {
+			delete(d.external.marks, p)
+		}
+	}
+
+	if _, ok := d.external.marks[path]; ok {
+		delete(d.external.marks, path)
+		return true
+	}
+	_, ok := d.external.marks[filepath.Dir(path)]
+	return ok
+}
+
+// invalidate drops in-memory state derived from a record that changed
+// behind the Driver's back.
+func (d *Driver) invalidate(collection, resource string) {
+	d.expiryMutex.Lock()
+	delete(d.expiries, collection)
+	d.expiryMutex.Unlock()
+	d.keyCache.removed(collection, "")
+	d.hot.drop(collection, resource)
+	d.bloomDrop(collection)
+	d.forget(collection, resource)
+}
+
+// reservedDir reports whether a top-level entry holds driver bookkeeping
+// rather than a collection.
+func reservedDir(name string) bool {
+	return strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".")
+}
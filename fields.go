@@ -0,0 +1,228 @@
+package litedb
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// fieldPaths prepares dotted field paths for extractFields.
+type fieldPaths struct {
+	want     map[string]bool
+	prefixes map[string]bool // every proper prefix of a wanted path
+}
+
+func newFieldPaths(paths []string) *fieldPaths {
+	fp := &fieldPaths{want: make(map[string]bool, len(paths)), prefixes: make(map[string]bool)}
+	for _, p := range paths {
+		fp.want[p] = true
+		for i := 0; i < len(p); i++ {
+			if p[i] == '.' {
+				fp.prefixes[p[:i]] = true
+			}
+		}
+	}
+	return fp
+}
+
+// extractFields returns the values at the wanted paths of a JSON object,
+// decoded as decodeDocument would, without decoding the rest of the
+// document: other values are skipped over byte by byte without allocating.
+// b must be valid JSON; it reports false if b is not an object.
+func (fp *fieldPaths) extractFields(b []byte) (map[string]interface{}, bool) {
+	s := fieldScanner{b: b, fp: fp, found: make(map[string]interface{}, len(fp.want))}
+	s.ws()
+	if s.i >= len(b) || b[s.i] != '{' {
+		return nil, false
+	}
+	s.object("")
+
+	// A path below a value captured whole, such as "a.b" when "a" is
+	// wanted too, is looked up in that value.
+	for p := range fp.want {
+		if _, ok := s.found[p]; ok {
+			continue
+		}
+		for i := strings.LastIndexByte(p, '.'); i >= 0; i = strings.LastIndexByte(p[:i], '.') {
+			parent, ok := s.found[p[:i]].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if v, ok := lookupField(parent, p[i+1:]); ok {
+				s.found[p] = v
+			}
+			break
+		}
+	}
+	return s.found, true
+}
+
+type fieldScanner struct {
+	b     []byte
+	i     int
+	fp    *fieldPaths
+	found map[string]interface{}
+}
+
+func (s *fieldScanner) ws() {
+	for s.i < len(s.b) {
+		switch s.b[s.i] {
+		case ' ', '\t', '\n', '\r':
+			s.i++
+		default:
+			return
+		}
+	}
+}
+
+// object scans the object at s.i, whose keys sit under prefix.
+func (s *fieldScanner) object(prefix string) {
+	s.i++ // {
+	s.ws()
+	if s.i < len(s.b) && s.b[s.i] == '}' {
+		s.i++
+		return
+	}
+
+	for s.i < len(s.b) {
+		s.ws()
+		key := s.key()
+		s.ws()
+		s.i++ // :
+		s.ws()
+
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		// Dotted paths cannot address keys with dots in them.
+		dotted := strings.IndexByte(key, '.') >= 0
+
+		// A repeated key replaces the earlier value, and everything found
+		// beneath it, as it does for encoding/json.
+		if !dotted && s.fp.prefixes[path] {
+			s.forget(path)
+		}
+
+		switch {
+		case dotted:
+			s.skip()
+		case s.fp.want[path]:
+			start := s.i
+			s.skip()
+			s.found[path] = decodeValue(s.b[start:s.i])
+		case s.fp.prefixes[path] && s.i < len(s.b) && s.b[s.i] == '{':
+			s.object(path)
+		default:
+			s.skip()
+		}
+
+		s.ws()
+		if s.i < len(s.b) && s.b[s.i] == ',' {
+			s.i++
+			continue
+		}
+		s.i++ // }
+		return
+	}
+}
+
+// forget drops the values found beneath path.
+func (s *fieldScanner) forget(path string) {
+	for p := range s.found {
+		if len(p) > len(path) && p[len(path)] == '.' && strings.HasPrefix(p, path) {
+			delete(s.found, p)
+		}
+	}
+}
+
+// key reads an object key, unescaping it only if it has escapes.
+func (s *fieldScanner) key() string {
+	start := s.i
+	s.skipString()
+	raw := s.b[start:s.i]
+	if len(raw) < 2 {
+		return ""
+	}
+	if bytes.IndexByte(raw, '\\') < 0 {
+		return string(raw[1 : len(raw)-1])
+	}
+	var key string
+	json.Unmarshal(raw, &key)
+	return key
+}
+
+func (s *fieldScanner) skipString() {
+	s.i++ // opening quote
+	for s.i < len(s.b) {
+		switch s.b[s.i] {
+		case '\\':
+			s.i += 2
+		case '"':
+			s.i++
+			return
+		default:
+			s.i++
+		}
+	}
+}
+
+// skip moves past the value at s.i.
+func (s *fieldScanner) skip() {
+	if s.i >= len(s.b) {
+		return
+	}
+	switch s.b[s.i] {
+	case '"':
+		s.skipString()
+	case '{', '[':
+		depth := 0
+		for s.i < len(s.b) {
+			switch s.b[s.i] {
+			case '"':
+				s.skipString()
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+			s.i++
+			if depth == 0 {
+				return
+			}
+		}
+	default:
+		for s.i < len(s.b) {
+			switch s.b[s.i] {
+			case ',', '}', ']', ' ', '\t', '\n', '\r':
+				return
+			}
+			s.i++
+		}
+	}
+}
+
+// decodeValue decodes one JSON value as decodeDocument would, taking a
+// shortcut for the scalars filters mostly compare against.
+func decodeValue(raw []byte) interface{} {
+	switch c := raw[0]; {
+	case c == '"' && bytes.IndexByte(raw, '\\') < 0:
+		return string(raw[1 : len(raw)-1])
+	case c == '-' || (c >= '0' && c <= '9'):
+		return json.Number(raw)
+	case c == 't':
+		return true
+	case c == 'f':
+		return false
+	case c == 'n':
+		return nil
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	dec.Decode(&v)
+	return v
+}
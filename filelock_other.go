@@ -0,0 +1,14 @@
+//go:build !(linux || darwin || freebsd || windows)
+
+package litedb
+
+import "os"
+
+// Without file locks, only writers in this process exclude each other.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
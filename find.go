@@ -0,0 +1,182 @@
+package litedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+)
+
+// Filter selects documents whose fields equal the given values. Keys are
+// field names, with dots to reach into nested objects.
+type Filter map[string]interface{}
+
+// Record is a document returned by Find, with its resource name as ID.
+type Record struct {
+	ID   string          `json:"id"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Find returns the records in collection matching filter. A nil filter
+// matches every record.
+func (d *Driver) Find(collection string, filter Filter) ([]Record, error) {
+	return d.FindContext(context.Background(), collection, filter)
+}
+
+// FindContext is Find with a context.
+func (d *Driver) FindContext(ctx context.Context, collection string, filter Filter) ([]Record, error) {
+	op := &Operation{Kind: OpFind, Collection: collection, Filter: filter}
+	if err := d.run(ctx, op, d.findOp); err != nil {
+		return nil, err
+	}
+	return op.Found, nil
+}
+
+func (d *Driver) findOp(ctx context.Context, op *Operation) (err error) {
+	collection := op.Collection
+
+	ctx, end := d.instrument(ctx, OpFind, collection, "")
+	defer end(&err)
+
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+
+	want, err := normalizeFilter(op.Filter)
+	if err != nil {
+		return err
+	}
+	want, wantComputed := d.splitComputed(collection, want)
+
+	keys, err := d.keys(collection)
+	if err != nil {
+		return err
+	}
+
+	fields := make([]string, 0, len(want))
+	for field := range want {
+		fields = append(fields, field)
+	}
+	paths := newFieldPaths(fields)
+
+	var found []Record
+	size := 0
+	for _, resource := range keys {
+		expired, err := d.isExpired(collection, resource)
+		if err != nil {
+			return err
+		}
+		if expired {
+			if _, err := d.expire(collection, resource); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if len(wantComputed) > 0 {
+			m, err := d.readMeta(collection, resource)
+			if err != nil {
+				return err
+			}
+			if !matchComputed(m, wantComputed) {
+				continue
+			}
+		}
+
+		b, err := d.readRecord(collection, resource)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := d.verifyRecord(collection, resource, b); err != nil {
+			return err
+		}
+		if b, err = d.applyDefaults(collection, b); err != nil {
+			return err
+		}
+
+		if len(want) > 0 {
+			if !json.Valid(b) {
+				return fmt.Errorf("decoding '%s' in collection '%s': invalid JSON", resource, collection)
+			}
+			// Documents that are not objects have no fields to match.
+			values, ok := paths.extractFields(b)
+			if !ok || !matches(values, want) {
+				continue
+			}
+		}
+
+		found = append(found, Record{ID: resource, Data: b})
+		size += len(b)
+	}
+
+	recordCount(ctx, len(keys))
+	recordBytes(ctx, size)
+	op.Found = found
+	return nil
+}
+
+// Match reports whether doc, a JSON document, matches f as Find would
+// match it.
+func (f Filter) Match(doc []byte) (bool, error) {
+	want, err := normalizeFilter(f)
+	if err != nil || len(want) == 0 {
+		return err == nil, err
+	}
+	if !json.Valid(doc) {
+		return false, fmt.Errorf("invalid JSON")
+	}
+
+	fields := make([]string, 0, len(want))
+	for field := range want {
+		fields = append(fields, field)
+	}
+	values, ok := newFieldPaths(fields).extractFields(doc)
+	return ok && matches(values, want), nil
+}
+
+// normalizeFilter round-trips the filter through JSON so its values compare
+// like the decoded documents they are matched against.
+func normalizeFilter(filter Filter) (map[string]interface{}, error) {
+	want := make(map[string]interface{}, len(filter))
+	for field, value := range filter {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter value for '%s': %w", field, err)
+		}
+		doc, err := decodeDocument([]byte(`{"v":` + string(b) + `}`))
+		if err != nil {
+			return nil, err
+		}
+		want[field] = doc["v"]
+	}
+	return want, nil
+}
+
+// matches reports whether the values from extractFields satisfy want.
+func matches(values map[string]interface{}, want map[string]interface{}) bool {
+	for field, value := range want {
+		got, ok := values[field]
+		if !ok || !equalValues(got, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalValues(a, b interface{}) bool {
+	an, aok := a.(json.Number)
+	bn, bok := b.(json.Number)
+	if aok && bok {
+		af, aerr := an.Float64()
+		bf, berr := bn.Float64()
+		if aerr == nil && berr == nil {
+			return af == bf
+		}
+		return an == bn
+	}
+	return reflect.DeepEqual(a, b)
+}
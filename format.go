@@ -0,0 +1,127 @@
+package litedb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format is how a collection's records are laid out on disk.
+type Format int
+
+const (
+	// FormatPretty indents records with tabs, for documents people read
+	// and edit by hand. It is the default.
+	FormatPretty Format = iota
+
+	// FormatCompact writes each record on a single line, which is smaller
+	// and quicker to write for high-volume data.
+	FormatCompact
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatPretty:
+		return "pretty"
+	case FormatCompact:
+		return "compact"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// parseFormat is the inverse of Format.String.
+func parseFormat(s string) (Format, error) {
+	switch s {
+	case "pretty":
+		return FormatPretty, nil
+	case "compact":
+		return FormatCompact, nil
+	}
+	return 0, fmt.Errorf("unknown format '%s'", s)
+}
+
+// SetFormat sets how records written to collection from now on are laid
+// out. The setting is saved with the collection's metadata, so it holds
+// when the database is next opened, until the collection is deleted.
+// Existing records keep their layout until rewritten.
+func (d *Driver) SetFormat(collection string, f Format) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if f < FormatPretty || f > FormatCompact {
+		return fmt.Errorf("unknown format %v", f)
+	}
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+
+	path := d.formatPath(collection)
+	if err := os.MkdirAll(filepath.Dir(path), dirMode(d.fileMode)); err != nil {
+		return err
+	}
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, []byte(f.String()+"\n"), d.fileMode); err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		return err
+	}
+	if err := d.commit(path); err != nil {
+		return err
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	d.configFor(collection).format = &f
+	return nil
+}
+
+func (d *Driver) formatPath(collection string) string {
+	return filepath.Join(d.dir, metaDir, collection, ".format")
+}
+
+// formatOf returns collection's format, loading the saved setting on first
+// use.
+func (d *Driver) formatOf(collection string) Format {
+	d.configMutex.RLock()
+	if c, ok := d.configs[collection]; ok && c.format != nil {
+		f := *c.format
+		d.configMutex.RUnlock()
+		return f
+	}
+	d.configMutex.RUnlock()
+
+	f := FormatPretty
+	b, err := os.ReadFile(d.formatPath(collection))
+	switch {
+	case err == nil:
+		if f, err = parseFormat(strings.TrimSpace(string(b))); err != nil {
+			d.log.Warn("Ignoring saved format", "collection", collection, "err", err)
+			f = FormatPretty
+		}
+	case !os.IsNotExist(err):
+		d.log.Warn("Reading saved format failed", "collection", collection, "err", err)
+		return f
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	c := d.configFor(collection)
+	if c.format == nil {
+		c.format = &f
+	}
+	return *c.format
+}
+
+// forgetFormat drops the cached format of a deleted collection.
+func (d *Driver) forgetFormat(collection string) {
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	if c, ok := d.configs[collection]; ok {
+		c.format = nil
+	}
+}
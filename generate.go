@@ -0,0 +1,309 @@
+package litedb
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Generate writes n synthetic records to collection and returns their
+// resource names, which are chosen as Insert chooses them. The records are
+// values of the type given to RegisterType, filled in by Fake from a
+// source seeded with seed, so a seed gives the same documents every time.
+// Fields a reference is declared on name random existing records of its
+// target, so the records pass the reference checks.
+func (d *Driver) Generate(collection string, n int, seed int64) ([]string, error) {
+	if err := d.validCollection(collection); err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("record count cannot be negative")
+	}
+
+	d.configMutex.RLock()
+	var t reflect.Type
+	var refs []Reference
+	if c, ok := d.configs[collection]; ok {
+		t, refs = c.docType, c.references
+	}
+	d.configMutex.RUnlock()
+	if t == nil {
+		return nil, fmt.Errorf("collection '%s' has no registered type", collection)
+	}
+
+	targets := make(map[string][]string)
+	for _, ref := range refs {
+		if _, ok := targets[ref.Target]; ok {
+			continue
+		}
+		keys, err := d.keys(ref.Target)
+		if err != nil {
+			return nil, err
+		}
+		targets[ref.Target] = keys
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	names := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		v := reflect.New(t)
+		fakeValue(rng, v.Elem(), "", make(map[reflect.Type]bool), 0)
+
+		var doc interface{} = v.Interface()
+		if len(refs) > 0 {
+			b, err := d.marshal(doc)
+			if err != nil {
+				return names, err
+			}
+			m, err := decodeDocument(b)
+			if err != nil {
+				return names, err
+			}
+			for _, ref := range refs {
+				var key interface{}
+				if keys := targets[ref.Target]; len(keys) > 0 {
+					key = keys[rng.Intn(len(keys))]
+				}
+				setField(m, ref.Field, key)
+			}
+			doc = m
+		}
+
+		name, err := d.Insert(collection, doc)
+		if err != nil {
+			return names, err
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// Fake fills the value v points to with synthetic data drawn from rng, for
+// demos, benchmarks and fixtures. Fields get values that look like what
+// their names suggest, such as an address for Email and a plausible number
+// for Age; slices and maps get one to three elements. Interfaces, and
+// fields that would nest a struct inside one of its own type, are left
+// zero.
+func Fake(v interface{}, rng *rand.Rand) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("fake value must be a non-nil pointer, got %T", v)
+	}
+	fakeValue(rng, rv.Elem(), "", make(map[reflect.Type]bool), 0)
+	return nil
+}
+
+// maxFakeDepth bounds how far fakeValue follows nested slices and maps.
+const maxFakeDepth = 6
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+	fakeFirstNames = []string{"Ada", "Alan", "Grace", "Linus", "Margaret", "Dennis", "Barbara", "Ken", "Frances", "Edsger", "Radia", "Donald"}
+	fakeLastNames  = []string{"Lovelace", "Turing", "Hopper", "Torvalds", "Hamilton", "Ritchie", "Liskov", "Thompson", "Allen", "Dijkstra", "Perlman", "Knuth"}
+	fakeCities     = []string{"Bangalore", "Mumbai", "London", "Paris", "Berlin", "Tokyo", "Toronto", "Nairobi", "Lima", "Sydney"}
+	fakeCountries  = []string{"India", "United Kingdom", "France", "Germany", "Japan", "Canada", "Kenya", "Peru", "Australia"}
+	fakeStreets    = []string{"Main Street", "MG Road", "High Street", "Park Avenue", "Station Road", "Church Lane"}
+	fakeCompanies  = []string{"Acme", "Globex", "Initech", "Umbrella", "Hooli", "Stark Industries"}
+	fakeColors     = []string{"red", "green", "blue", "yellow", "purple", "orange", "black", "white"}
+	fakeWords      = []string{"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do", "eiusmod", "tempor", "incididunt", "labore", "magna", "aliqua"}
+)
+
+func pick(rng *rand.Rand, words []string) string {
+	return words[rng.Intn(len(words))]
+}
+
+// fakeValue fills v, named name in its parent, with synthetic data. seen
+// holds the struct types being filled further up.
+func fakeValue(rng *rand.Rand, v reflect.Value, name string, seen map[reflect.Type]bool, depth int) {
+	if depth > maxFakeDepth || !v.CanSet() {
+		return
+	}
+
+	t := v.Type()
+	switch {
+	case t == timeType:
+		v.Set(reflect.ValueOf(fakeTime(rng)))
+		return
+	case t == rawMessageType:
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(fakeString(rng, strings.ToLower(name)))
+	case reflect.Bool:
+		v.SetBool(rng.Intn(2) == 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := fakeInt(rng, strings.ToLower(name))
+		if v.OverflowInt(n) {
+			n = rng.Int63n(100)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := uint64(fakeInt(rng, strings.ToLower(name)))
+		if v.OverflowUint(n) {
+			n = uint64(rng.Intn(100))
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(fakeFloat(rng, strings.ToLower(name)))
+	case reflect.Ptr:
+		if seen[t.Elem()] {
+			return
+		}
+		p := reflect.New(t.Elem())
+		fakeValue(rng, p.Elem(), name, seen, depth+1)
+		v.Set(p)
+	case reflect.Struct:
+		if seen[t] {
+			return
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			field, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if field == "-" {
+				continue
+			}
+			if field == "" {
+				field = f.Name
+			}
+			fakeValue(rng, v.Field(i), field, seen, depth+1)
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, 8+rng.Intn(24))
+			rng.Read(b)
+			v.SetBytes(b)
+			return
+		}
+		n := 1 + rng.Intn(3)
+		s := reflect.MakeSlice(t, n, n)
+		for i := 0; i < n; i++ {
+			fakeValue(rng, s.Index(i), singular(name), seen, depth+1)
+		}
+		v.Set(s)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fakeValue(rng, v.Index(i), singular(name), seen, depth+1)
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return
+		}
+		m := reflect.MakeMap(t)
+		for i, n := 0, 1+rng.Intn(3); i < n; i++ {
+			key := reflect.New(t.Key()).Elem()
+			key.SetString(pick(rng, fakeWords))
+			elem := reflect.New(t.Elem()).Elem()
+			fakeValue(rng, elem, key.String(), seen, depth+1)
+			m.SetMapIndex(key, elem)
+		}
+		v.Set(m)
+	}
+}
+
+// singular names the elements of a slice field, so Tags holds tags.
+func singular(name string) string {
+	if len(name) > 1 && strings.HasSuffix(name, "s") {
+		return name[:len(name)-1]
+	}
+	return name
+}
+
+func fakeString(rng *rand.Rand, name string) string {
+	first, last := pick(rng, fakeFirstNames), pick(rng, fakeLastNames)
+	switch {
+	case strings.Contains(name, "email"):
+		return fmt.Sprintf("%s.%s%d@example.com", strings.ToLower(first), strings.ToLower(last), rng.Intn(100))
+	case strings.Contains(name, "first"):
+		return first
+	case strings.Contains(name, "last") || strings.Contains(name, "surname"):
+		return last
+	case strings.Contains(name, "user") || strings.Contains(name, "login") || strings.Contains(name, "handle"):
+		return fmt.Sprintf("%s%d", strings.ToLower(first), rng.Intn(1000))
+	case strings.Contains(name, "company") || strings.Contains(name, "org"):
+		return pick(rng, fakeCompanies)
+	case strings.Contains(name, "name"):
+		return first + " " + last
+	case strings.Contains(name, "phone") || strings.Contains(name, "mobile"):
+		return fmt.Sprintf("+1-555-%03d-%04d", rng.Intn(1000), rng.Intn(10000))
+	case strings.Contains(name, "city"):
+		return pick(rng, fakeCities)
+	case strings.Contains(name, "country"):
+		return pick(rng, fakeCountries)
+	case strings.Contains(name, "zip") || strings.Contains(name, "postal") || strings.Contains(name, "pincode"):
+		return fmt.Sprintf("%06d", 100000+rng.Intn(900000))
+	case strings.Contains(name, "street") || strings.Contains(name, "address"):
+		return fmt.Sprintf("%d %s", 1+rng.Intn(500), pick(rng, fakeStreets))
+	case strings.Contains(name, "url") || strings.Contains(name, "website") || strings.Contains(name, "link"):
+		return fmt.Sprintf("https://example.com/%s/%d", pick(rng, fakeWords), rng.Intn(10000))
+	case strings.Contains(name, "color") || strings.Contains(name, "colour"):
+		return pick(rng, fakeColors)
+	case name == "id" || strings.HasSuffix(name, "id") || strings.Contains(name, "uuid"):
+		b := make([]byte, 16)
+		rng.Read(b)
+		b[6], b[8] = b[6]&0x0f|0x40, b[8]&0x3f|0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	case strings.Contains(name, "title") || strings.Contains(name, "description") || strings.Contains(name, "bio") ||
+		strings.Contains(name, "text") || strings.Contains(name, "body") || strings.Contains(name, "comment") || strings.Contains(name, "note"):
+		words := make([]string, 4+rng.Intn(8))
+		for i := range words {
+			words[i] = pick(rng, fakeWords)
+		}
+		s := strings.Join(words, " ")
+		return strings.ToUpper(s[:1]) + s[1:]
+	}
+	return pick(rng, fakeWords)
+}
+
+func fakeInt(rng *rand.Rand, name string) int64 {
+	switch {
+	case strings.Contains(name, "age"):
+		return 18 + rng.Int63n(63)
+	case strings.Contains(name, "year"):
+		return 1970 + rng.Int63n(56)
+	case strings.Contains(name, "count") || strings.Contains(name, "quantity") || strings.Contains(name, "qty"):
+		return rng.Int63n(100)
+	case strings.Contains(name, "port"):
+		return 1024 + rng.Int63n(64511)
+	}
+	return rng.Int63n(1000)
+}
+
+func fakeFloat(rng *rand.Rand, name string) float64 {
+	round := func(f float64) float64 { return math.Round(f*100) / 100 }
+	switch {
+	case strings.Contains(name, "price") || strings.Contains(name, "amount") || strings.Contains(name, "cost") ||
+		strings.Contains(name, "total") || strings.Contains(name, "balance"):
+		return round(1 + rng.Float64()*999)
+	case strings.Contains(name, "lat"):
+		return round(rng.Float64()*180 - 90)
+	case strings.Contains(name, "lon") || strings.Contains(name, "lng"):
+		return round(rng.Float64()*360 - 180)
+	case strings.Contains(name, "rating"):
+		return round(1 + rng.Float64()*4)
+	}
+	return round(rng.Float64() * 100)
+}
+
+// fakeEpoch ends the span of fake times. It is fixed, rather than now, so
+// a seed always gives the same documents.
+var fakeEpoch = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// fakeTime returns a time, to the second, in the two years before
+// fakeEpoch.
+func fakeTime(rng *rand.Rand) time.Time {
+	return fakeEpoch.Add(-time.Duration(rng.Int63n(int64(2 * 365 * 24 * time.Hour)))).Truncate(time.Second)
+}
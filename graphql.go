@@ -0,0 +1,37 @@
+package litedb
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/SagarDas211/LiteDB-Go/litedbgraphql"
+)
+
+// GraphQLHandler serves a GraphQL API over the collections registered with
+// RegisterType; see package litedbgraphql. Types registered afterwards
+// need a new handler.
+func (d *Driver) GraphQLHandler() (http.Handler, error) {
+	return litedbgraphql.New(graphqlStore{serverStore{d}})
+}
+
+type graphqlStore struct {
+	serverStore
+}
+
+func (s graphqlStore) Types() map[string]reflect.Type {
+	return s.d.registeredTypes()
+}
+
+func (s graphqlStore) Query(ctx context.Context, collection string, filter map[string]interface{}) ([]litedbgraphql.Record, error) {
+	found, err := s.d.FindContext(ctx, collection, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]litedbgraphql.Record, len(found))
+	for i, r := range found {
+		records[i] = litedbgraphql.Record{ID: r.ID, Data: r.Data}
+	}
+	return records, nil
+}
@@ -0,0 +1,224 @@
+package litedb
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/SagarDas211/LiteDB-Go/litedbgrpc"
+)
+
+// RegisterGRPC registers the LiteDB gRPC service on s; see package
+// litedbgrpc. Under UseAccessControl calls must authenticate with an
+// "x-api-key" or "authorization" metadata entry, as HTTP requests do with
+// headers. SetServerLimits applies to calls too, but a message is only
+// measured once it has been received and decoded; create the server with
+// ServerLimits.GRPCServerOptions to refuse larger ones before they are
+// read into memory.
+func (d *Driver) RegisterGRPC(s grpc.ServiceRegistrar) {
+	srv := litedbgrpc.NewServer(grpcStore{serverStore{d}})
+	srv.Code = grpcCode
+	litedbgrpc.RegisterLiteDBServer(s, grpcAccess{srv, d})
+}
+
+// GRPCServerOptions returns the options for grpc.NewServer that make the
+// transport refuse messages over MaxRequestBytes before decoding them.
+func (l ServerLimits) GRPCServerOptions() []grpc.ServerOption {
+	if l.MaxRequestBytes == 0 {
+		return nil
+	}
+	size := l.MaxRequestBytes
+	if size > math.MaxInt32 {
+		size = math.MaxInt32
+	}
+	return []grpc.ServerOption{grpc.MaxRecvMsgSize(int(size))}
+}
+
+// grpcAccess authenticates calls to the service for UseAccessControl and
+// applies SetServerLimits.
+type grpcAccess struct {
+	*litedbgrpc.Server
+	d *Driver
+}
+
+// admit returns ctx with the caller's user, or ctx itself without access
+// control, once req has passed SetServerLimits.
+func (g grpcAccess) admit(ctx context.Context, req proto.Message) (context.Context, error) {
+	var addr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+
+	a := g.d.accessControl()
+	if a == nil {
+		return ctx, g.limit(clientOf("", false, addr), req)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	user, err := a.authenticate(first("x-api-key"), first("authorization"))
+	if err != nil {
+		// Failed attempts count against the address they come from.
+		if err := g.limit(clientOf("", false, addr), nil); err != nil {
+			return nil, err
+		}
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err := g.limit(clientOf(user, true, addr), req); err != nil {
+		return nil, err
+	}
+	return ContextWithUser(ctx, user), nil
+}
+
+// limit applies SetServerLimits to req from client.
+func (g grpcAccess) limit(client string, req proto.Message) error {
+	l := g.d.serverLimiter()
+	if l == nil {
+		return nil
+	}
+	if ok, _ := l.allow(client); !ok {
+		return status.Error(codes.ResourceExhausted, ErrRateLimited.Error())
+	}
+	if req != nil && l.tooLarge(int64(proto.Size(req))) {
+		return status.Error(codes.ResourceExhausted, ErrRequestTooLarge.Error())
+	}
+	return nil
+}
+
+func (g grpcAccess) ListCollections(ctx context.Context, req *litedbgrpc.ListCollectionsRequest) (*litedbgrpc.ListCollectionsResponse, error) {
+	ctx, err := g.admit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return g.Server.ListCollections(ctx, req)
+}
+
+func (g grpcAccess) Get(ctx context.Context, req *litedbgrpc.GetRequest) (*litedbgrpc.GetResponse, error) {
+	ctx, err := g.admit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return g.Server.Get(ctx, req)
+}
+
+func (g grpcAccess) Put(ctx context.Context, req *litedbgrpc.PutRequest) (*litedbgrpc.PutResponse, error) {
+	ctx, err := g.admit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return g.Server.Put(ctx, req)
+}
+
+func (g grpcAccess) Delete(ctx context.Context, req *litedbgrpc.DeleteRequest) (*litedbgrpc.DeleteResponse, error) {
+	ctx, err := g.admit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return g.Server.Delete(ctx, req)
+}
+
+func (g grpcAccess) Find(ctx context.Context, req *litedbgrpc.FindRequest) (*litedbgrpc.FindResponse, error) {
+	ctx, err := g.admit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return g.Server.Find(ctx, req)
+}
+
+// Watch needs read access to the collection watched, or to every
+// collection when it is empty.
+func (g grpcAccess) Watch(req *litedbgrpc.WatchRequest, stream litedbgrpc.LiteDB_WatchServer) error {
+	ctx, err := g.admit(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	if user, ok := UserFromContext(ctx); ok {
+		collection := req.Collection
+		if collection == "" {
+			collection = "*"
+		}
+		if err := g.d.accessControl().check(user, collection, ReadAccess); err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+	return g.Server.Watch(req, stream)
+}
+
+func grpcCode(err error) codes.Code {
+	if errors.Is(err, ErrQuotaExceeded) {
+		return codes.ResourceExhausted
+	}
+	if errors.Is(err, ErrReadOnlyReplica) || errors.Is(err, ErrReadOnly) {
+		return codes.FailedPrecondition
+	}
+	if errors.Is(err, ErrPermissionDenied) {
+		return codes.PermissionDenied
+	}
+	return litedbgrpc.DefaultCode(err)
+}
+
+type grpcStore struct {
+	serverStore
+}
+
+func (s grpcStore) Query(ctx context.Context, collection string, filter map[string]interface{}) ([]*litedbgrpc.Record, error) {
+	found, err := s.d.FindContext(ctx, collection, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*litedbgrpc.Record, len(found))
+	for i, r := range found {
+		records[i] = &litedbgrpc.Record{Id: r.ID, Data: r.Data}
+	}
+	return records, nil
+}
+
+var grpcEventTypes = map[EventType]litedbgrpc.Event_Type{
+	Created: litedbgrpc.Event_TYPE_CREATED,
+	Updated: litedbgrpc.Event_TYPE_UPDATED,
+	Deleted: litedbgrpc.Event_TYPE_DELETED,
+}
+
+func (s grpcStore) Watch(collection string) (<-chan *litedbgrpc.Event, func()) {
+	events, cancel := s.d.Watch(collection)
+
+	out := make(chan *litedbgrpc.Event)
+	done := make(chan struct{})
+	go func() {
+		defer close(out)
+		for ev := range events {
+			select {
+			case out <- &litedbgrpc.Event{
+				Type:         grpcEventTypes[ev.Type],
+				Collection:   ev.Collection,
+				Resource:     ev.Resource,
+				TimeUnixNano: ev.Time.UnixNano(),
+				External:     ev.External,
+			}:
+			case <-done:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return out, func() {
+		once.Do(func() {
+			close(done)
+			cancel()
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package litedb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var errDiskFreeUnsupported = errors.New("free disk space is not available on this platform")
+
+const healthLockTimeout = time.Second
+
+// HealthReport is the outcome of Healthy's checks.
+type HealthReport struct {
+	Writable       bool
+	LocksAvailable bool
+	FreeBytes      uint64
+	MinFreeBytes   uint64
+	Problems       []string
+}
+
+// OK reports whether every check passed.
+func (r *HealthReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Ping returns an error describing the first failed health check.
+func (d *Driver) Ping() error {
+	r := d.Healthy()
+	if !r.OK() {
+		return fmt.Errorf("database unhealthy: %s", r.Problems[0])
+	}
+	return nil
+}
+
+// Healthy checks that the database directory accepts writes, unless it
+// was opened with OpenReadOnly, that the driver's locks can all be taken
+// within a second, and that free disk space is above Options.MinFreeBytes.
+func (d *Driver) Healthy() *HealthReport {
+	r := &HealthReport{MinFreeBytes: d.minFreeBytes}
+
+	probe := filepath.Join(d.dir, "_health.tmp")
+	if !d.readOnly {
+		if err := os.WriteFile(probe, []byte("ok\n"), 0644); err != nil {
+			r.Problems = append(r.Problems, fmt.Sprintf("directory is not writable: %s", err))
+		} else if err := os.Remove(probe); err != nil {
+			r.Problems = append(r.Problems, fmt.Sprintf("directory is not writable: %s", err))
+		} else {
+			r.Writable = true
+		}
+	}
+
+	// One deadline covers every lock, so busy collections cannot add up
+	// to more than healthLockTimeout between them.
+	deadline := time.Now().Add(healthLockTimeout)
+	r.LocksAvailable = true
+	if entries, ok := d.locks.snapshot(deadline); !ok {
+		r.LocksAvailable = false
+		r.Problems = append(r.Problems, "driver lock table is not obtainable")
+	} else {
+		for collection, m := range entries {
+			if !tryLockUntil(&m.Mutex, deadline) {
+				r.LocksAvailable = false
+				r.Problems = append(r.Problems, fmt.Sprintf("lock for collection '%s' is not obtainable", collection))
+				continue
+			}
+			m.Unlock()
+		}
+	}
+
+	free, err := diskFree(d.dir)
+	switch {
+	case errors.Is(err, errDiskFreeUnsupported):
+	case err != nil:
+		r.Problems = append(r.Problems, fmt.Sprintf("cannot determine free disk space: %s", err))
+	default:
+		r.FreeBytes = free
+		if free < d.minFreeBytes {
+			r.Problems = append(r.Problems, fmt.Sprintf("free disk space %d bytes is below the %d byte threshold", free, d.minFreeBytes))
+		}
+	}
+
+	return r
+}
+
+func tryLockUntil(m *sync.Mutex, deadline time.Time) bool {
+	for {
+		if m.TryLock() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
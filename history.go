@@ -0,0 +1,281 @@
+package litedb
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const historyDir = "_history"
+
+// SetHistory keeps the last keep versions of each record in collection as
+// writes replace them, under _history, for Versions and DiffVersions. Zero
+// stops keeping new ones. Versions are numbered as Metadata.Version
+// numbers them, and go with the record when it is deleted. The setting is
+// saved with the collection's metadata, as SetFormat's is.
+func (d *Driver) SetHistory(collection string, keep int) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if keep < 0 {
+		return fmt.Errorf("history length cannot be negative")
+	}
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+
+	path := d.historySettingPath(collection)
+	if err := os.MkdirAll(filepath.Dir(path), dirMode(d.fileMode)); err != nil {
+		return err
+	}
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, []byte(strconv.Itoa(keep)+"\n"), d.fileMode); err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		return err
+	}
+	if err := d.commit(path); err != nil {
+		return err
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	d.configFor(collection).history = &keep
+	return nil
+}
+
+// Versions lists the versions of a record that can be diffed, oldest
+// first. The last is the record as it stands.
+func (d *Driver) Versions(collection, resource string) ([]int64, error) {
+	if err := d.validCollection(collection); err != nil {
+		return nil, err
+	}
+	if err := validResource(resource); err != nil {
+		return nil, err
+	}
+	if err := d.checkOpen(); err != nil {
+		return nil, err
+	}
+	if err := d.expireIfDue(collection, resource); err != nil {
+		return nil, err
+	}
+
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+
+	m, err := d.readMeta(collection, resource)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(d.recordPath(collection, resource)); err != nil {
+		if os.IsNotExist(err) {
+			return nil, errNoRecord(collection, resource)
+		}
+		return nil, err
+	}
+
+	versions, err := d.savedVersions(collection, resource)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		versions = append(versions, m.Version)
+	}
+	return versions, nil
+}
+
+// DiffVersions returns the changes that turn version v1 of a record into
+// version v2, either of which may be the current one, as for an audit
+// trail of edits. Versions older than the current one are only there if
+// the collection keeps history; see SetHistory. A version that is not
+// there returns an error matching ErrNotFound.
+func (d *Driver) DiffVersions(collection, resource string, v1, v2 int64) ([]Change, error) {
+	if err := d.validCollection(collection); err != nil {
+		return nil, err
+	}
+	if err := validResource(resource); err != nil {
+		return nil, err
+	}
+	if err := d.checkOpen(); err != nil {
+		return nil, err
+	}
+	if err := d.expireIfDue(collection, resource); err != nil {
+		return nil, err
+	}
+
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+
+	m, err := d.readMeta(collection, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs [2]interface{}
+	for i, version := range []int64{v1, v2} {
+		var b []byte
+		if m != nil && version == m.Version {
+			err = d.withRecord(collection, resource, func(rb []byte) error {
+				b = append([]byte(nil), rb...)
+				return nil
+			})
+		} else {
+			b, err = os.ReadFile(d.versionPath(collection, resource, version))
+			if errors.Is(err, fs.ErrNotExist) {
+				err = fmt.Errorf("version %d of record '%s' in collection '%s': %w", version, resource, collection, ErrNotFound)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if docs[i], err = decodeJSON(b); err != nil {
+			return nil, fmt.Errorf("version %d of record '%s' in collection '%s': %w", version, resource, collection, err)
+		}
+	}
+	return diffValues(nil, "", docs[0], docs[1])
+}
+
+// saveVersion keeps the record as it stands before a write replaces it,
+// if the collection keeps history, and drops versions past the number
+// kept. The caller must hold the collection lock.
+func (d *Driver) saveVersion(collection, resource string) error {
+	keep := d.historyOf(collection)
+	if keep == 0 {
+		return nil
+	}
+
+	m, err := d.readMeta(collection, resource)
+	if err != nil || m == nil {
+		return err
+	}
+	b, err := d.readRecord(collection, resource)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	path := d.versionPath(collection, resource, m.Version)
+	if err := os.MkdirAll(filepath.Dir(path), dirMode(d.fileMode)); err != nil {
+		return err
+	}
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, b, d.fileMode); err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := d.commit(path); err != nil {
+		return err
+	}
+
+	versions, err := d.savedVersions(collection, resource)
+	if err != nil {
+		return err
+	}
+	for _, version := range versions {
+		if version > m.Version-int64(keep) {
+			break
+		}
+		if err := os.Remove(d.versionPath(collection, resource, version)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// savedVersions lists the versions of a record kept under _history, oldest
+// first.
+func (d *Driver) savedVersions(collection, resource string) ([]int64, error) {
+	files, err := os.ReadDir(d.historyPath(collection, resource))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var versions []int64
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != recordExt {
+			continue
+		}
+		version, err := strconv.ParseInt(strings.TrimSuffix(file.Name(), recordExt), 10, 64)
+		if err == nil {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, nil
+}
+
+// removeHistory drops the versions kept of a record, or of the whole
+// collection and its setting when resource is empty.
+func (d *Driver) removeHistory(collection, resource string) error {
+	if resource == "" {
+		d.configMutex.Lock()
+		if c, ok := d.configs[collection]; ok {
+			c.history = nil
+		}
+		d.configMutex.Unlock()
+		return os.RemoveAll(filepath.Join(d.dir, historyDir, collection))
+	}
+	return os.RemoveAll(d.historyPath(collection, resource))
+}
+
+// historyOf returns how many versions collection keeps, loading the saved
+// setting on first use.
+func (d *Driver) historyOf(collection string) int {
+	d.configMutex.RLock()
+	if c, ok := d.configs[collection]; ok && c.history != nil {
+		keep := *c.history
+		d.configMutex.RUnlock()
+		return keep
+	}
+	d.configMutex.RUnlock()
+
+	keep := 0
+	b, err := os.ReadFile(d.historySettingPath(collection))
+	switch {
+	case err == nil:
+		if keep, err = strconv.Atoi(strings.TrimSpace(string(b))); err != nil || keep < 0 {
+			d.log.Warn("Ignoring saved history length", "collection", collection, "err", err)
+			keep = 0
+		}
+	case !os.IsNotExist(err):
+		d.log.Warn("Reading saved history length failed", "collection", collection, "err", err)
+		return keep
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	c := d.configFor(collection)
+	if c.history == nil {
+		c.history = &keep
+	}
+	return *c.history
+}
+
+func (d *Driver) historySettingPath(collection string) string {
+	return filepath.Join(d.dir, metaDir, collection, ".history")
+}
+
+func (d *Driver) historyPath(collection, resource string) string {
+	return filepath.Join(d.dir, historyDir, collection, d.fileStem(resource))
+}
+
+func (d *Driver) versionPath(collection, resource string, version int64) string {
+	return filepath.Join(d.historyPath(collection, resource), strconv.FormatInt(version, 10)+recordExt)
+}
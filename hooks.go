@@ -0,0 +1,89 @@
+package litedb
+
+import "fmt"
+
+// Hook observes a mutation. For writes v is the value being written; for
+// deletes it is the stored document as json.RawMessage, or nil when the
+// whole collection is deleted. An error from a Before hook aborts the
+// operation; errors from After hooks are logged.
+type Hook func(collection, resource string, v interface{}) error
+
+type hookKind int
+
+const (
+	beforeWrite hookKind = iota
+	afterWrite
+	beforeDelete
+	afterDelete
+	hookKinds
+)
+
+func (k hookKind) String() string {
+	return [...]string{"BeforeWrite", "AfterWrite", "BeforeDelete", "AfterDelete"}[k]
+}
+
+// BeforeWrite registers fn to run before every write to collection.
+func (d *Driver) BeforeWrite(collection string, fn Hook) error {
+	return d.addHook(beforeWrite, collection, fn)
+}
+
+// AfterWrite registers fn to run after every write to collection.
+func (d *Driver) AfterWrite(collection string, fn Hook) error {
+	return d.addHook(afterWrite, collection, fn)
+}
+
+// BeforeDelete registers fn to run before every delete from collection.
+func (d *Driver) BeforeDelete(collection string, fn Hook) error {
+	return d.addHook(beforeDelete, collection, fn)
+}
+
+// AfterDelete registers fn to run after every delete from collection.
+func (d *Driver) AfterDelete(collection string, fn Hook) error {
+	return d.addHook(afterDelete, collection, fn)
+}
+
+func (d *Driver) addHook(kind hookKind, collection string, fn Hook) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if fn == nil {
+		return fmt.Errorf("hook cannot be nil")
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	c := d.configFor(collection)
+	c.hooks[kind] = append(c.hooks[kind], fn)
+
+	return nil
+}
+
+func (d *Driver) hooksFor(kind hookKind, collection string) []Hook {
+	d.configMutex.RLock()
+	defer d.configMutex.RUnlock()
+	if c, ok := d.configs[collection]; ok {
+		return c.hooks[kind]
+	}
+	return nil
+}
+
+func (d *Driver) hasHooks(collection string) bool {
+	return len(d.hooksFor(beforeDelete, collection)) > 0 || len(d.hooksFor(afterDelete, collection)) > 0
+}
+
+func (d *Driver) runHooks(kind hookKind, collection, resource string, v interface{}) error {
+	for _, fn := range d.hooksFor(kind, collection) {
+		if err := fn(collection, resource, v); err != nil {
+			return fmt.Errorf("%s hook for '%s' in collection '%s': %w", kind, resource, collection, err)
+		}
+	}
+	return nil
+}
+
+func (d *Driver) runAfterHooks(kind hookKind, collection, resource string, v interface{}) {
+	for _, fn := range d.hooksFor(kind, collection) {
+		if err := fn(collection, resource, v); err != nil {
+			d.log.Error("Hook failed", "hook", kind.String(), "collection", collection, "resource", resource, "err", err)
+		}
+	}
+}
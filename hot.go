@@ -0,0 +1,366 @@
+package litedb
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// hotFile lists the records cached at Close, for Options.Prewarm.
+const hotFile = "_hot.json"
+
+const (
+	// hotThreshold is how many recent reads make a record hot enough to
+	// cache.
+	hotThreshold = 2
+
+	// evictionSample is how many entries are compared to pick one to
+	// evict, which approximates least-frequently-used without keeping
+	// entries ordered.
+	evictionSample = 5
+
+	// minAgingInterval is the fewest reads between halvings of every
+	// read count, so counts reflect recent rather than all-time use.
+	minAgingInterval = 1024
+)
+
+type hotKey struct {
+	collection, resource string
+}
+
+// hotCache keeps the documents of frequently read records in memory, up
+// to a byte budget, so Read skips the disk for them. Records are admitted
+// once read hotThreshold times and only displace colder ones; pinned
+// records stay regardless of budget and frequency. Like keyCache, it only
+// sees other processes' changes while WatchExternal is running.
+type hotCache struct {
+	// active is set once there is a budget or a pin, so reads skip the
+	// mutex otherwise.
+	active atomic.Bool
+
+	mutex   sync.Mutex
+	budget  int64
+	bytes   int64
+	entries map[hotKey][]byte
+	pins    map[hotKey]bool
+	counts  map[hotKey]uint32
+	reads   int
+
+	// epoch changes whenever entries are dropped, so a read that raced
+	// with a write does not cache what it read.
+	epoch uint64
+
+	hits, misses uint64
+}
+
+func (c *hotCache) init(budget int64) {
+	c.budget = budget
+	c.entries = make(map[hotKey][]byte)
+	c.pins = make(map[hotKey]bool)
+	c.counts = make(map[hotKey]uint32)
+	c.active.Store(budget > 0)
+}
+
+// get counts a read of the record and returns its cached document, which
+// the caller must not modify. The epoch is for a later admit.
+func (c *hotCache) get(collection, resource string) ([]byte, uint64, bool) {
+	if !c.active.Load() {
+		return nil, 0, false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := hotKey{collection, resource}
+	c.counts[key]++
+	c.reads++
+	if c.reads >= max(minAgingInterval, 8*len(c.counts)) {
+		c.age()
+	}
+
+	if b, ok := c.entries[key]; ok {
+		c.hits++
+		return b, c.epoch, true
+	}
+	c.misses++
+	return nil, c.epoch, false
+}
+
+// age halves every read count, forgetting records no longer read.
+func (c *hotCache) age() {
+	c.reads = 0
+	for key, n := range c.counts {
+		if n /= 2; n == 0 {
+			delete(c.counts, key)
+		} else {
+			c.counts[key] = n
+		}
+	}
+}
+
+// admit caches a copy of the document just read from disk if the record
+// is hot enough and nothing was dropped since epoch.
+func (c *hotCache) admit(collection, resource string, b []byte, epoch uint64) {
+	if !c.active.Load() {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := hotKey{collection, resource}
+	if epoch != c.epoch {
+		return
+	}
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	if !c.pins[key] && !c.makeRoom(int64(len(b)), c.counts[key]) {
+		return
+	}
+	c.entries[key] = append([]byte(nil), b...)
+	c.bytes += int64(len(b))
+}
+
+// makeRoom evicts colder entries until size more bytes fit the budget,
+// and reports false, evicting nothing more, if a sampled entry is at least
+// as hot as count.
+func (c *hotCache) makeRoom(size int64, count uint32) bool {
+	if count < hotThreshold || size > c.budget {
+		return false
+	}
+	for c.bytes+size > c.budget {
+		var victim hotKey
+		found := false
+		sampled := 0
+		for key := range c.entries {
+			if c.pins[key] {
+				continue
+			}
+			if !found || c.counts[key] < c.counts[victim] {
+				victim, found = key, true
+			}
+			if sampled++; sampled == evictionSample {
+				break
+			}
+		}
+		if !found || c.counts[victim] >= count {
+			return false
+		}
+		c.remove(victim)
+	}
+	return true
+}
+
+func (c *hotCache) remove(key hotKey) {
+	if b, ok := c.entries[key]; ok {
+		c.bytes -= int64(len(b))
+		delete(c.entries, key)
+	}
+}
+
+// drop uncaches a record, or every record in collection if resource is
+// empty. Pins survive, to be reloaded by the next read.
+func (c *hotCache) drop(collection, resource string) {
+	if !c.active.Load() {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.epoch++
+	if resource != "" {
+		c.remove(hotKey{collection, resource})
+		return
+	}
+	for key := range c.entries {
+		if key.collection == collection {
+			c.remove(key)
+		}
+	}
+}
+
+// reset uncaches everything.
+func (c *hotCache) reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.epoch++
+	c.entries = make(map[hotKey][]byte)
+	c.bytes = 0
+}
+
+// CacheEntry describes a record the hot cache holds or has pinned.
+type CacheEntry struct {
+	Collection string
+	Resource   string
+
+	// Reads is the record's recent read count; it halves periodically.
+	Reads  uint32
+	Size   int
+	Cached bool
+	Pinned bool
+}
+
+// CacheStats is the hot cache's occupancy and hit counts.
+type CacheStats struct {
+	Entries int
+	Pinned  int
+	Bytes   int64
+	Budget  int64
+	Hits    uint64
+	Misses  uint64
+}
+
+// CacheStats reports the hot cache's size and hit rate.
+func (d *Driver) CacheStats() CacheStats {
+	c := &d.hot
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return CacheStats{
+		Entries: len(c.entries),
+		Pinned:  len(c.pins),
+		Bytes:   c.bytes,
+		Budget:  c.budget,
+		Hits:    c.hits,
+		Misses:  c.misses,
+	}
+}
+
+// CacheEntries lists the records in the hot cache and the pinned ones,
+// most read first.
+func (d *Driver) CacheEntries() []CacheEntry {
+	c := &d.hot
+	c.mutex.Lock()
+	entries := make([]CacheEntry, 0, len(c.entries)+len(c.pins))
+	for key, b := range c.entries {
+		entries = append(entries, CacheEntry{Collection: key.collection, Resource: key.resource, Reads: c.counts[key], Size: len(b), Cached: true, Pinned: c.pins[key]})
+	}
+	for key := range c.pins {
+		if _, ok := c.entries[key]; !ok {
+			entries = append(entries, CacheEntry{Collection: key.collection, Resource: key.resource, Reads: c.counts[key], Pinned: true})
+		}
+	}
+	c.mutex.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Reads != entries[j].Reads {
+			return entries[i].Reads > entries[j].Reads
+		}
+		if entries[i].Collection != entries[j].Collection {
+			return entries[i].Collection < entries[j].Collection
+		}
+		return entries[i].Resource < entries[j].Resource
+	})
+	return entries
+}
+
+// Pin loads a record into the hot cache and keeps it there, outside the
+// budget, until Unpin. After a write the new document is loaded on the
+// next read. Pinning works without Options.HotCacheBytes.
+func (d *Driver) Pin(collection, resource string) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if err := validResource(resource); err != nil {
+		return err
+	}
+
+	c := &d.hot
+	c.mutex.Lock()
+	c.pins[hotKey{collection, resource}] = true
+	epoch := c.epoch
+	c.mutex.Unlock()
+	c.active.Store(true)
+
+	err := d.withRecord(collection, resource, func(b []byte) error {
+		c.admit(collection, resource, b, epoch)
+		return nil
+	})
+	if err != nil {
+		d.Unpin(collection, resource)
+	}
+	return err
+}
+
+// Unpin lets a pinned record be evicted like any other.
+func (d *Driver) Unpin(collection, resource string) {
+	c := &d.hot
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := hotKey{collection, resource}
+	delete(c.pins, key)
+	if c.bytes > c.budget || c.counts[key] < hotThreshold {
+		c.remove(key)
+	}
+}
+
+// saveHot records the cached records for the next Prewarm.
+func (d *Driver) saveHot() error {
+	if d.hot.budget <= 0 {
+		return nil
+	}
+
+	entries := d.CacheEntries()
+	keys := make([][2]string, 0, len(entries))
+	for _, e := range entries {
+		if e.Cached {
+			keys = append(keys, [2]string{e.Collection, e.Resource})
+		}
+	}
+
+	b, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(d.dir, hotFile)
+	if err := os.WriteFile(path+".tmp", b, d.fileMode); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// prewarm loads the records cached when the database was last closed,
+// hottest first, as if each had just been read hotThreshold times.
+func (d *Driver) prewarm() error {
+	b, err := os.ReadFile(filepath.Join(d.dir, hotFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var keys [][2]string
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return err
+	}
+
+	c := &d.hot
+	for _, key := range keys {
+		collection, resource := key[0], key[1]
+		c.mutex.Lock()
+		if c.counts[hotKey{collection, resource}] < hotThreshold {
+			c.counts[hotKey{collection, resource}] = hotThreshold
+		}
+		epoch := c.epoch
+		c.mutex.Unlock()
+
+		err := d.withRecord(collection, resource, func(b []byte) error {
+			c.admit(collection, resource, b, epoch)
+			return nil
+		})
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
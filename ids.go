@@ -0,0 +1,198 @@
+package litedb
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IDStrategy is how Insert names new records.
+type IDStrategy int
+
+const (
+	// UUID names records with random version 4 UUIDs, the default.
+	UUID IDStrategy = iota
+	// ULID names records with ULIDs, which sort in creation order.
+	ULID
+	// AutoIncrement names records with a per-collection counter.
+	AutoIncrement
+)
+
+func (s IDStrategy) String() string {
+	switch s {
+	case UUID:
+		return "uuid"
+	case ULID:
+		return "ulid"
+	case AutoIncrement:
+		return "auto-increment"
+	}
+	return fmt.Sprintf("IDStrategy(%d)", int(s))
+}
+
+// SetIDStrategy sets how Insert names new records in collection.
+func (d *Driver) SetIDStrategy(collection string, strategy IDStrategy) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if strategy < UUID || strategy > AutoIncrement {
+		return fmt.Errorf("unknown id strategy %v", strategy)
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	d.configFor(collection).idStrategy = strategy
+
+	return nil
+}
+
+// Insert writes v under a freshly generated resource name and returns it.
+func (d *Driver) Insert(collection string, v interface{}) (string, error) {
+	if err := d.validCollection(collection); err != nil {
+		return "", err
+	}
+	if err := d.checkWritable(); err != nil {
+		return "", err
+	}
+
+	d.configMutex.RLock()
+	strategy := UUID
+	if c, ok := d.configs[collection]; ok {
+		strategy = c.idStrategy
+	}
+	d.configMutex.RUnlock()
+
+	var id string
+	var err error
+	for {
+		switch strategy {
+		case ULID:
+			id, err = d.ulids.next(d.now())
+		case AutoIncrement:
+			id, err = d.nextSequence(collection)
+		default:
+			id, err = newUUID()
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(d.recordPath(collection, id)); os.IsNotExist(err) {
+			break
+		}
+	}
+
+	return id, d.Write(collection, id, v)
+}
+
+func (d *Driver) sequencePath(collection string) string {
+	return filepath.Join(d.dir, metaDir, collection, ".sequence")
+}
+
+// nextSequence advances the collection's persisted counter, skipping any
+// value already taken by a hand-named record.
+func (d *Driver) nextSequence(collection string) (string, error) {
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+
+	path := d.sequencePath(collection)
+
+	var n uint64
+	b, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if n, err = strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64); err != nil {
+			return "", fmt.Errorf("corrupt sequence for collection '%s': %w", collection, err)
+		}
+	case !os.IsNotExist(err):
+		return "", err
+	}
+
+	for {
+		n++
+		if _, err := os.Stat(d.recordPath(collection, strconv.FormatUint(n, 10))); os.IsNotExist(err) {
+			break
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), dirMode(d.fileMode)); err != nil {
+		return "", err
+	}
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, []byte(strconv.FormatUint(n, 10)+"\n"), d.fileMode); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		return "", err
+	}
+
+	return strconv.FormatUint(n, 10), nil
+}
+
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidSource hands out monotonic ULIDs: IDs minted in the same millisecond
+// increment the random part instead of drawing a new one, so they still
+// sort in creation order.
+type ulidSource struct {
+	mutex   sync.Mutex
+	lastMs  uint64
+	entropy [10]byte
+}
+
+func (s *ulidSource) next(now time.Time) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ms := uint64(now.UnixMilli())
+	if ms == s.lastMs {
+		i := len(s.entropy) - 1
+		for ; i >= 0; i-- {
+			s.entropy[i]++
+			if s.entropy[i] != 0 {
+				break
+			}
+		}
+		if i < 0 {
+			return "", fmt.Errorf("ulid entropy exhausted for this millisecond")
+		}
+	} else {
+		if _, err := rand.Read(s.entropy[:]); err != nil {
+			return "", err
+		}
+		s.lastMs = ms
+	}
+
+	var raw [16]byte
+	binary.BigEndian.PutUint16(raw[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(raw[2:6], uint32(ms))
+	copy(raw[6:], s.entropy[:])
+
+	// 128 bits encode to 26 base32 characters, the first carrying 3 bits.
+	hi := binary.BigEndian.Uint64(raw[0:8])
+	lo := binary.BigEndian.Uint64(raw[8:16])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+
+	return string(out[:]), nil
+}
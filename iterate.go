@@ -0,0 +1,78 @@
+package litedb
+
+import (
+	"context"
+	"errors"
+	"iter"
+)
+
+// Memory use of the bulk reads:
+//
+//   - ReadAll returns every document in the collection at once, so it
+//     needs memory for the whole collection. It suits small collections.
+//   - Records streams documents, holding at most a couple per read worker
+//     at a time, whatever the collection's size.
+//   - List returns only the names, as RecordRefs that read their
+//     document when asked.
+
+// errStopIteration ends a ReadEach early when the consumer of Records
+// stops ranging.
+var errStopIteration = errors.New("iteration stopped")
+
+// Records ranges over the documents in collection in name order, reading
+// ahead with the driver's ReadWorkers. An error ends the sequence after
+// it is yielded.
+func (d *Driver) Records(ctx context.Context, collection string) iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		err := d.ReadEach(ctx, collection, ReadEachOptions{}, func(resource string, doc []byte) error {
+			if !yield(Record{ID: resource, Data: doc}, nil) {
+				return errStopIteration
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopIteration) {
+			yield(Record{}, err)
+		}
+	}
+}
+
+// RecordRef names a record without holding its document.
+type RecordRef struct {
+	Collection string
+	Resource   string
+
+	d *Driver
+}
+
+// Decode reads the record into v, as Read does.
+func (r RecordRef) Decode(v interface{}) error {
+	return r.d.Read(r.Collection, r.Resource, v)
+}
+
+// Bytes reads the record's document.
+func (r RecordRef) Bytes() ([]byte, error) {
+	b, err := r.d.loadRecord(r.Collection, r.Resource)
+	if err != nil {
+		return nil, err
+	}
+	return r.d.applyDefaults(r.Collection, b)
+}
+
+// List returns a handle per record in collection, in name order, without
+// reading any documents.
+func (d *Driver) List(collection string) ([]RecordRef, error) {
+	if err := d.validCollection(collection); err != nil {
+		return nil, err
+	}
+
+	names, err := d.liveFiles(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]RecordRef, 0, len(names))
+	for _, name := range names {
+		refs = append(refs, RecordRef{Collection: collection, Resource: d.resourceOf(name, recordExt), d: d})
+	}
+	return refs, nil
+}
@@ -0,0 +1,160 @@
+package litedb
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"sync"
+)
+
+// keyCache remembers each collection's resource names once listed, and
+// is kept current by the driver's own writes and deletes, so listings
+// skip ReadDir. Changes made by other processes are only seen while
+// WatchExternal is running.
+type keyCache struct {
+	mutex       sync.Mutex
+	collections map[string]*keySet
+
+	hits, misses uint64
+}
+
+type keySet struct {
+	names  map[string]struct{}
+	sorted []string // nil once names has changed
+}
+
+// cachedKeys returns a copy of collection's names, and false if they are
+// not cached.
+func (c *keyCache) cachedKeys(collection string) ([]string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	set, ok := c.collections[collection]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	if set.sorted == nil {
+		set.sorted = make([]string, 0, len(set.names))
+		for name := range set.names {
+			set.sorted = append(set.sorted, name)
+		}
+		sort.Strings(set.sorted)
+	}
+	if len(set.sorted) == 0 {
+		return nil, true
+	}
+	return append([]string(nil), set.sorted...), true
+}
+
+// has reports whether collection holds resource, and false if the
+// collection is not cached.
+func (c *keyCache) has(collection, resource string) (exists, ok bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	set, ok := c.collections[collection]
+	if !ok {
+		c.misses++
+		return false, false
+	}
+	c.hits++
+	_, exists = set.names[resource]
+	return exists, true
+}
+
+// counts returns how many lookups the cache answered and how many it
+// could not.
+func (c *keyCache) counts() (hits, misses uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.hits, c.misses
+}
+
+func (c *keyCache) fill(collection string, keys []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.collections == nil {
+		c.collections = make(map[string]*keySet)
+	}
+	set := &keySet{names: make(map[string]struct{}, len(keys)), sorted: append([]string{}, keys...)}
+	for _, key := range keys {
+		set.names[key] = struct{}{}
+	}
+	c.collections[collection] = set
+}
+
+// added records a new resource, if the collection is cached.
+func (c *keyCache) added(collection, resource string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if set, ok := c.collections[collection]; ok {
+		if _, exists := set.names[resource]; !exists {
+			set.names[resource] = struct{}{}
+			set.sorted = nil
+		}
+	}
+}
+
+// removed forgets a resource, or the whole collection when resource is
+// empty.
+func (c *keyCache) removed(collection, resource string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if resource == "" {
+		delete(c.collections, collection)
+		return
+	}
+	if set, ok := c.collections[collection]; ok {
+		if _, exists := set.names[resource]; exists {
+			delete(set.names, resource)
+			set.sorted = nil
+		}
+	}
+}
+
+// reset forgets every collection.
+func (c *keyCache) reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.collections = nil
+}
+
+// Count returns how many records collection holds, expired ones aside.
+func (d *Driver) Count(collection string) (int, error) {
+	keys, err := d.Keys(collection)
+	return len(keys), err
+}
+
+// Exists reports whether collection holds a live record named resource.
+func (d *Driver) Exists(collection, resource string) (bool, error) {
+	if err := d.validCollection(collection); err != nil {
+		return false, err
+	}
+	if err := validResource(resource); err != nil {
+		return false, err
+	}
+
+	if err := d.expireIfDue(collection, resource); errors.Is(err, ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if !d.mightExist(collection, resource) {
+		return false, nil
+	}
+	if exists, ok := d.keyCache.has(collection, resource); ok {
+		return exists, nil
+	}
+
+	_, err := os.Stat(d.recordPath(collection, resource))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,84 @@
+package litedb
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyProvider supplies the record signing key, Options.SigningKey, through
+// Options.SigningKeyProvider, so that it can live in the environment, a
+// file or a key management service rather than in application source.
+// Signing is the only use the driver has for a key; it does not encrypt
+// records.
+type KeyProvider interface {
+	Key(ctx context.Context) ([]byte, error)
+}
+
+// KeyProviderFunc is a function that is a KeyProvider.
+type KeyProviderFunc func(ctx context.Context) ([]byte, error)
+
+// Key returns f(ctx).
+func (f KeyProviderFunc) Key(ctx context.Context) ([]byte, error) { return f(ctx) }
+
+// EnvKey reads a base64-encoded key from the environment variable name.
+func EnvKey(name string) KeyProvider {
+	return KeyProviderFunc(func(context.Context) ([]byte, error) {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set", name)
+		}
+		return decodeKey(value, "environment variable "+name)
+	})
+}
+
+// FileKey reads a base64-encoded key from the file at path, as written by
+// "openssl rand -base64 32". The file is read each time the key is needed,
+// so it can be replaced without changing the program.
+func FileKey(path string) KeyProvider {
+	return KeyProviderFunc(func(context.Context) ([]byte, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return decodeKey(string(b), path)
+	})
+}
+
+// KMSKey unwraps a data key kept encrypted by a cloud key management
+// service: it takes the ciphertext from wrapped, typically an EnvKey or
+// FileKey, and passes it to decrypt, which calls the service, for example
+// the Decrypt method of an AWS, Google Cloud or Azure KMS client. Only the
+// service can then reveal the key.
+func KMSKey(wrapped KeyProvider, decrypt func(ctx context.Context, ciphertext []byte) ([]byte, error)) KeyProvider {
+	return KeyProviderFunc(func(ctx context.Context) ([]byte, error) {
+		ciphertext, err := wrapped.Key(ctx)
+		if err != nil {
+			return nil, err
+		}
+		key, err := decrypt(ctx, ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("decrypting key: %w", err)
+		}
+		return key, nil
+	})
+}
+
+// WithSigningKeyFrom signs records with the key p supplies when the
+// database is opened. See Options.SigningKeyProvider.
+func WithSigningKeyFrom(p KeyProvider) Option {
+	return optionFunc(func(o *Options) { o.SigningKeyProvider = p })
+}
+
+func decodeKey(s, source string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("key in %s is not valid base64: %w", source, err)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("key in %s is empty", source)
+	}
+	return key, nil
+}
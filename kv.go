@@ -0,0 +1,68 @@
+package litedb
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+)
+
+// kvCollection is the collection KV keeps its keys in. The name is
+// reserved, so it cannot clash with an application's collection.
+const kvCollection = "_kv"
+
+// KV is a flat key-value namespace for settings, flags and other values
+// that need no collection of their own, returned by Driver.KV. Each key
+// is a record of the reserved collection "_kv" holding the value as
+// stored, so keys are backed up, exported and expired like any other
+// record, but _kv is not listed among the collections.
+type KV struct {
+	d *Driver
+}
+
+// KV returns the database's key-value namespace.
+func (d *Driver) KV() *KV {
+	return &KV{d: d}
+}
+
+// Set stores value, encoded as JSON, under key.
+func (kv *KV) Set(key string, value interface{}) error {
+	return kv.d.Write(kvCollection, key, value)
+}
+
+// SetWithTTL is Set for a key that expires after ttl.
+func (kv *KV) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+	return kv.d.WriteWithTTL(kvCollection, key, value, ttl)
+}
+
+// Get decodes the value under key into v. It returns an error matching
+// ErrNotFound if there is no such key, or it has expired.
+func (kv *KV) Get(key string, v interface{}) error {
+	return kv.d.Read(kvCollection, key, v)
+}
+
+// Has reports whether key is set.
+func (kv *KV) Has(key string) (bool, error) {
+	var value interface{}
+	err := kv.d.Read(kvCollection, key, &value)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Del removes key. Removing a key that is not set is not an error.
+func (kv *KV) Del(key string) error {
+	if err := kv.d.Delete(kvCollection, key); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Keys lists the keys that are set.
+func (kv *KV) Keys() ([]string, error) {
+	keys, err := kv.d.Keys(kvCollection)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return keys, err
+}
@@ -0,0 +1,109 @@
+package litedb
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned by a Driver's operations after Close.
+var ErrClosed = errors.New("driver is closed")
+
+// closers holds the stop functions of background work started through the
+// driver, for Close to run.
+type closers struct {
+	mutex sync.Mutex
+	next  int
+	stops map[int]func()
+}
+
+// onClose registers stop to run at Close, returning it wrapped so that
+// calling it earlier also deregisters it. stop must be safe to call more
+// than once. After Close it runs at once.
+func (d *Driver) onClose(stop func()) func() {
+	c := &d.closers
+	c.mutex.Lock()
+	if d.closed.Load() {
+		c.mutex.Unlock()
+		stop()
+		return stop
+	}
+	if c.stops == nil {
+		c.stops = make(map[int]func())
+	}
+	id := c.next
+	c.next++
+	c.stops[id] = stop
+	c.mutex.Unlock()
+
+	return func() {
+		c.mutex.Lock()
+		delete(c.stops, id)
+		c.mutex.Unlock()
+		stop()
+	}
+}
+
+// Close shuts the driver down, for a server's graceful exit. It drains the
+// WriteAsync queue, waits for mutations in progress, including BulkLoads,
+// and then stops the background work started through the driver: the
+// goroutines of StartReaper, StartArchiver, StartRetention, WatchExternal,
+// AddCDCSink and RotateKey, and every Watch, whose channels close. Finally
+// it syncs everything written and saves the hot cache's contents for
+// Options.Prewarm.
+//
+// Afterwards reads, writes and the other operations return ErrClosed, as
+// does Close itself.
+func (d *Driver) Close() error {
+	if !d.closing.CompareAndSwap(false, true) {
+		return ErrClosed
+	}
+
+	d.asyncOnce.Do(func() {
+		// WriteAsync was never called; leave a writer that is already
+		// closed rather than start one.
+		stopped := make(chan struct{})
+		close(stopped)
+		d.async.Store(&asyncWriter{stopped: stopped, closed: true})
+	})
+
+	w := d.async.Load()
+	w.mutex.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mutex.Unlock()
+	<-w.stopped
+
+	// Operations started from here on fail; taking each collection lock
+	// in turn waits out the mutations already under way.
+	d.closed.Store(true)
+	locks, _ := d.locks.snapshot(time.Now().Add(healthLockTimeout))
+	for _, mutex := range locks {
+		mutex.Lock()
+		mutex.Unlock()
+	}
+
+	d.closers.mutex.Lock()
+	stops := d.closers.stops
+	d.closers.stops = nil
+	d.closers.mutex.Unlock()
+	for _, stop := range stops {
+		stop()
+	}
+
+	err := d.Flush()
+	if herr := d.saveHot(); err == nil {
+		err = herr
+	}
+	return err
+}
+
+// checkOpen returns ErrClosed once Close has begun failing operations.
+func (d *Driver) checkOpen() error {
+	if d.closed.Load() {
+		return ErrClosed
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package litedb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+)
+
+// limiterSweep is how often idle clients are dropped from the rate
+// limiter.
+const limiterSweep = time.Minute
+
+var (
+	// ErrRateLimited is returned for requests from a client that has used
+	// up its ServerLimits.Rate.
+	ErrRateLimited = errors.New("rate limit exceeded")
+
+	// ErrRequestTooLarge is returned for requests larger than
+	// ServerLimits.MaxRequestBytes.
+	ErrRequestTooLarge = errors.New("request too large")
+)
+
+// ServerLimits protects the HTTP and gRPC servers from clients that send
+// too much. Zero fields are unlimited.
+type ServerLimits struct {
+	// Rate is the requests a second each client may make on average.
+	// Clients are told apart by their user under UseAccessControl, and by
+	// their IP address otherwise. Over the limit, requests are refused
+	// with 429 Too Many Requests or ResourceExhausted.
+	Rate float64
+
+	// Burst is how many requests a client may make at once above Rate.
+	// It defaults to Rate rounded up.
+	Burst int
+
+	// MaxRequestBytes bounds request bodies and gRPC messages; larger
+	// ones are refused with 413 Request Entity Too Large or
+	// ResourceExhausted. gRPC servers must be created with
+	// GRPCServerOptions for messages to be refused before they are read.
+	MaxRequestBytes int64
+}
+
+// SetServerLimits applies l to the database's HTTP and gRPC servers,
+// including those already serving, replacing any limits set before.
+func (d *Driver) SetServerLimits(l ServerLimits) error {
+	if l.Rate < 0 || l.Burst < 0 || l.MaxRequestBytes < 0 {
+		return fmt.Errorf("server limits cannot be negative")
+	}
+	if l.Burst == 0 {
+		l.Burst = int(math.Ceil(l.Rate))
+	}
+
+	d.middlewareMutex.Lock()
+	defer d.middlewareMutex.Unlock()
+	d.limiter = &serverLimiter{limits: l, buckets: make(map[string]*bucket)}
+	return nil
+}
+
+func (d *Driver) serverLimiter() *serverLimiter {
+	d.middlewareMutex.RLock()
+	defer d.middlewareMutex.RUnlock()
+	return d.limiter
+}
+
+// serverLimiter keeps a token bucket per client.
+type serverLimiter struct {
+	limits ServerLimits
+
+	mutex   sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// allow takes a token from client's bucket, or returns how long until one
+// is available.
+func (l *serverLimiter) allow(client string) (bool, time.Duration) {
+	if l.limits.Rate == 0 {
+		return true, 0
+	}
+	burst := float64(l.limits.Burst)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.swept) > limiterSweep {
+		// A bucket that has refilled holds nothing worth keeping.
+		for name, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*l.limits.Rate >= burst {
+				delete(l.buckets, name)
+			}
+		}
+		l.swept = now
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*l.limits.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.limits.Rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// tooLarge reports whether a request of size bytes is over the limit.
+func (l *serverLimiter) tooLarge(size int64) bool {
+	return l.limits.MaxRequestBytes > 0 && size > l.limits.MaxRequestBytes
+}
+
+// clientOf names the client for rate limiting: the user if there is one,
+// or the host part of addr.
+func clientOf(user string, authenticated bool, addr string) string {
+	if authenticated {
+		return "user:" + user
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,384 @@
+// Package litedbgraphql serves a GraphQL API generated from the Go types
+// registered for a database's collections.
+//
+// For a collection "users" registered with a User struct it generates
+//
+//	type Query {
+//	  users(id: ID!): User
+//	  usersList(filter: JSON): [User!]!
+//	}
+//	type Mutation {
+//	  putUsers(id: ID!, input: UserInput!): User
+//	  deleteUsers(id: ID!): Boolean!
+//	}
+//
+// where User has an "id" field holding the record's resource name (or
+// "_id" if the struct already has an id field) plus one field per
+// exported struct field, named as encoding/json would name it.
+package litedbgraphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+type Record struct {
+	ID   string
+	Data json.RawMessage
+}
+
+// Store is the part of the driver the GraphQL API needs.
+type Store interface {
+	// Types returns the document type registered for each collection.
+	Types() map[string]reflect.Type
+
+	Get(ctx context.Context, collection, resource string) (json.RawMessage, error)
+	Put(ctx context.Context, collection, resource string, doc json.RawMessage) error
+	Delete(ctx context.Context, collection, resource string) error
+	Query(ctx context.Context, collection string, filter map[string]interface{}) ([]Record, error)
+}
+
+// JSON is a scalar for maps, interfaces and filters, passed through as
+// arbitrary JSON values.
+var JSON = graphql.NewScalar(graphql.ScalarConfig{
+	Name:         "JSON",
+	Description:  "An arbitrary JSON value.",
+	Serialize:    func(v interface{}) interface{} { return v },
+	ParseValue:   func(v interface{}) interface{} { return v },
+	ParseLiteral: parseLiteral,
+})
+
+func parseLiteral(v ast.Value) interface{} {
+	switch v := v.(type) {
+	case *ast.ObjectValue:
+		obj := make(map[string]interface{}, len(v.Fields))
+		for _, f := range v.Fields {
+			obj[f.Name.Value] = parseLiteral(f.Value)
+		}
+		return obj
+	case *ast.ListValue:
+		list := make([]interface{}, len(v.Values))
+		for i, item := range v.Values {
+			list[i] = parseLiteral(item)
+		}
+		return list
+	case *ast.IntValue:
+		return json.Number(v.Value)
+	case *ast.FloatValue:
+		return json.Number(v.Value)
+	case *ast.StringValue:
+		return v.Value
+	case *ast.BooleanValue:
+		return v.Value
+	case *ast.EnumValue:
+		return v.Value
+	}
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// builder turns Go types into GraphQL output and input types, reusing the
+// type generated for each Go struct.
+type builder struct {
+	outputs map[reflect.Type]*graphql.Object
+	inputs  map[reflect.Type]*graphql.InputObject
+	ids     map[reflect.Type]string
+}
+
+// Schema generates the GraphQL schema for store's registered types.
+func Schema(store Store) (graphql.Schema, error) {
+	b := &builder{
+		outputs: map[reflect.Type]*graphql.Object{},
+		inputs:  map[reflect.Type]*graphql.InputObject{},
+		ids:     map[reflect.Type]string{},
+	}
+
+	types := store.Types()
+	collections := make([]string, 0, len(types))
+	for collection := range types {
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+
+	if len(collections) == 0 {
+		return graphql.Schema{}, fmt.Errorf("no collection types are registered")
+	}
+
+	query := graphql.Fields{}
+	mutation := graphql.Fields{}
+	for _, collection := range collections {
+		t := types[collection]
+		if t.Kind() != reflect.Struct || t.Name() == "" {
+			return graphql.Schema{}, fmt.Errorf("type for collection '%s' must be a named struct, got %s", collection, t)
+		}
+
+		name := graphqlName(collection)
+		idField := "id"
+		if _, ok := jsonFields(t)["id"]; ok {
+			idField = "_id"
+		}
+		output := b.collectionObject(t, idField)
+		input := b.input(t)
+
+		decode := func(id string, data json.RawMessage) (interface{}, error) {
+			var doc map[string]interface{}
+			if err := json.Unmarshal(data, &doc); err != nil {
+				return nil, err
+			}
+			if doc == nil {
+				doc = map[string]interface{}{}
+			}
+			doc[idField] = id
+			return doc, nil
+		}
+
+		collection := collection
+		query[name] = &graphql.Field{
+			Type: output,
+			Args: graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id := p.Args["id"].(string)
+				data, err := store.Get(p.Context, collection, id)
+				if err != nil {
+					return nil, err
+				}
+				return decode(id, data)
+			},
+		}
+		query[name+"List"] = &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(output))),
+			Args: graphql.FieldConfigArgument{"filter": {Type: JSON}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				filter, _ := p.Args["filter"].(map[string]interface{})
+				records, err := store.Query(p.Context, collection, filter)
+				if err != nil {
+					return nil, err
+				}
+				docs := make([]interface{}, len(records))
+				for i, r := range records {
+					if docs[i], err = decode(r.ID, r.Data); err != nil {
+						return nil, err
+					}
+				}
+				return docs, nil
+			},
+		}
+
+		mutation["put"+exported(name)] = &graphql.Field{
+			Type: output,
+			Args: graphql.FieldConfigArgument{
+				"id":    {Type: graphql.NewNonNull(graphql.ID)},
+				"input": {Type: graphql.NewNonNull(input)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id := p.Args["id"].(string)
+				data, err := json.Marshal(p.Args["input"])
+				if err != nil {
+					return nil, err
+				}
+				if err := store.Put(p.Context, collection, id, data); err != nil {
+					return nil, err
+				}
+				return decode(id, data)
+			},
+		}
+		mutation["delete"+exported(name)] = &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Boolean),
+			Args: graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if err := store.Delete(p.Context, collection, p.Args["id"].(string)); err != nil {
+					return false, err
+				}
+				return true, nil
+			},
+		}
+	}
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: query}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{Name: "Mutation", Fields: mutation}),
+	})
+}
+
+// collectionObject is the output type of t with the resource name added as
+// idField.
+func (b *builder) collectionObject(t reflect.Type, idField string) *graphql.Object {
+	b.ids[t] = idField
+	return b.object(t)
+}
+
+func (b *builder) output(t reflect.Type) graphql.Output {
+	t = indirect(t)
+	if s := scalar(t); s != nil {
+		return s
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return graphql.String
+		}
+		return graphql.NewList(b.output(t.Elem()))
+	case reflect.Struct:
+		if t.Name() != "" {
+			return b.object(t)
+		}
+	}
+	return JSON
+}
+
+func (b *builder) object(t reflect.Type) *graphql.Object {
+	if obj, ok := b.outputs[t]; ok {
+		return obj
+	}
+
+	fields := jsonFields(t)
+	obj := graphql.NewObject(graphql.ObjectConfig{
+		Name: typeName(t),
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			out := graphql.Fields{}
+			for name, f := range fields {
+				out[name] = &graphql.Field{Type: b.output(f.Type)}
+			}
+			if id, ok := b.ids[t]; ok {
+				out[id] = &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Description: "The record's resource name."}
+			}
+			return out
+		}),
+	})
+	b.outputs[t] = obj
+	return obj
+}
+
+func (b *builder) inputType(t reflect.Type) graphql.Input {
+	t = indirect(t)
+	if s := scalar(t); s != nil {
+		return s
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return graphql.String
+		}
+		return graphql.NewList(b.inputType(t.Elem()))
+	case reflect.Struct:
+		if t.Name() != "" {
+			return b.input(t)
+		}
+	}
+	return JSON
+}
+
+func (b *builder) input(t reflect.Type) *graphql.InputObject {
+	if in, ok := b.inputs[t]; ok {
+		return in
+	}
+
+	fields := jsonFields(t)
+	in := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: typeName(t) + "Input",
+		Fields: (graphql.InputObjectConfigFieldMapThunk)(func() graphql.InputObjectConfigFieldMap {
+			out := graphql.InputObjectConfigFieldMap{}
+			for name, f := range fields {
+				out[name] = &graphql.InputObjectFieldConfig{Type: b.inputType(f.Type)}
+			}
+			return out
+		}),
+	})
+	b.inputs[t] = in
+	return in
+}
+
+func scalar(t reflect.Type) *graphql.Scalar {
+	if t == timeType {
+		return graphql.DateTime
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return graphql.String
+	case reflect.Bool:
+		return graphql.Boolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return graphql.Int
+	case reflect.Float32, reflect.Float64:
+		return graphql.Float
+	}
+	return nil
+}
+
+// jsonFields maps the JSON names of t's exported fields to the fields,
+// following encoding/json's tag rules and embedded structs.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" && indirect(f.Type).Kind() == reflect.Struct {
+			for n, sf := range jsonFields(indirect(f.Type)) {
+				if _, ok := fields[n]; !ok {
+					fields[n] = sf
+				}
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[graphqlName(name)] = f
+	}
+	return fields
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func typeName(t reflect.Type) string {
+	return graphqlName(exported(t.Name()))
+}
+
+// graphqlName replaces characters GraphQL does not allow in names.
+func graphqlName(s string) string {
+	var sb strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))):
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('_')
+		}
+	}
+	name := sb.String()
+	if strings.HasPrefix(name, "__") {
+		name = "x" + name
+	}
+	return name
+}
+
+func exported(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
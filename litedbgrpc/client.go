@@ -0,0 +1,84 @@
+package litedbgrpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+)
+
+// Client wraps the generated LiteDBClient, encoding documents as JSON.
+type Client struct {
+	LiteDBClient
+}
+
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{NewLiteDBClient(conn)}
+}
+
+func (c *Client) Collections(ctx context.Context) ([]string, error) {
+	res, err := c.ListCollections(ctx, &ListCollectionsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Collections, nil
+}
+
+func (c *Client) Read(ctx context.Context, collection, resource string, v interface{}) error {
+	res, err := c.Get(ctx, &GetRequest{Collection: collection, Resource: resource})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(res.Data, v)
+}
+
+func (c *Client) Write(ctx context.Context, collection, resource string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = c.Put(ctx, &PutRequest{Collection: collection, Resource: resource, Data: b})
+	return err
+}
+
+func (c *Client) Remove(ctx context.Context, collection, resource string) error {
+	_, err := c.Delete(ctx, &DeleteRequest{Collection: collection, Resource: resource})
+	return err
+}
+
+func (c *Client) Query(ctx context.Context, collection string, filter map[string]interface{}) ([]*Record, error) {
+	b, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.Find(ctx, &FindRequest{Collection: collection, Filter: b})
+	if err != nil {
+		return nil, err
+	}
+	return res.Records, nil
+}
+
+// Subscribe streams events for collection ("" for all) until ctx is done.
+func (c *Client) Subscribe(ctx context.Context, collection string) (<-chan *Event, error) {
+	stream, err := c.Watch(ctx, &WatchRequest{Collection: collection})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan *Event)
+	go func() {
+		defer close(events)
+		for {
+			ev, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
@@ -0,0 +1,5 @@
+// Package litedbgrpc serves a LiteDB database over gRPC and provides a Go
+// client for it. The service is defined in litedb.proto.
+package litedbgrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative litedb.proto
@@ -0,0 +1,119 @@
+package litedbgrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Store is the part of the driver the server needs.
+type Store interface {
+	Collections() ([]string, error)
+	Get(ctx context.Context, collection, resource string) (json.RawMessage, error)
+	Put(ctx context.Context, collection, resource string, doc json.RawMessage) error
+	Delete(ctx context.Context, collection, resource string) error
+	Query(ctx context.Context, collection string, filter map[string]interface{}) ([]*Record, error)
+
+	// Watch streams events for collection ("" for all) until cancel is
+	// called.
+	Watch(collection string) (events <-chan *Event, cancel func())
+}
+
+type Server struct {
+	UnimplementedLiteDBServer
+
+	store Store
+
+	// Code maps a store error to a gRPC status code. It defaults to
+	// DefaultCode.
+	Code func(error) codes.Code
+}
+
+func NewServer(store Store) *Server {
+	return &Server{store: store, Code: DefaultCode}
+}
+
+func (s *Server) ListCollections(ctx context.Context, req *ListCollectionsRequest) (*ListCollectionsResponse, error) {
+	names, err := s.store.Collections()
+	if err != nil {
+		return nil, s.fail(err)
+	}
+	return &ListCollectionsResponse{Collections: names}, nil
+}
+
+func (s *Server) Get(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	doc, err := s.store.Get(ctx, req.Collection, req.Resource)
+	if err != nil {
+		return nil, s.fail(err)
+	}
+	return &GetResponse{Data: doc}, nil
+}
+
+func (s *Server) Put(ctx context.Context, req *PutRequest) (*PutResponse, error) {
+	if !json.Valid(req.Data) {
+		return nil, status.Error(codes.InvalidArgument, "data is not valid JSON")
+	}
+	if err := s.store.Put(ctx, req.Collection, req.Resource, req.Data); err != nil {
+		return nil, s.fail(err)
+	}
+	return &PutResponse{}, nil
+}
+
+func (s *Server) Delete(ctx context.Context, req *DeleteRequest) (*DeleteResponse, error) {
+	if err := s.store.Delete(ctx, req.Collection, req.Resource); err != nil {
+		return nil, s.fail(err)
+	}
+	return &DeleteResponse{}, nil
+}
+
+func (s *Server) Find(ctx context.Context, req *FindRequest) (*FindResponse, error) {
+	var filter map[string]interface{}
+	if len(req.Filter) > 0 {
+		if err := json.Unmarshal(req.Filter, &filter); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %s", err)
+		}
+	}
+
+	records, err := s.store.Query(ctx, req.Collection, filter)
+	if err != nil {
+		return nil, s.fail(err)
+	}
+	return &FindResponse{Records: records}, nil
+}
+
+func (s *Server) Watch(req *WatchRequest, stream LiteDB_WatchServer) error {
+	events, cancel := s.store.Watch(req.Collection)
+	defer cancel()
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) fail(err error) error {
+	return status.Error(s.Code(err), err.Error())
+}
+
+// DefaultCode is NotFound for missing files and Internal for any other
+// error.
+func DefaultCode(err error) codes.Code {
+	if errors.Is(err, fs.ErrNotExist) {
+		return codes.NotFound
+	}
+	return codes.Internal
+}
@@ -0,0 +1,253 @@
+// Package litedbmongo imports MongoDB exports into LiteDB collections:
+// BSON files written by mongodump and Extended JSON written by
+// mongoexport. Each document's _id becomes its resource name and is
+// removed from the stored document.
+//
+// BSON types without a JSON equivalent are converted: ObjectIDs become
+// hex strings, dates and timestamps RFC 3339 strings, Decimal128 a
+// decimal string, binary data base64, and regular expressions
+// "/pattern/options".
+package litedbmongo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// maxDocument is the largest BSON document MongoDB allows.
+const maxDocument = 16 << 20
+
+// Store is the part of the driver the importer needs; *Driver satisfies
+// it.
+type Store interface {
+	Write(collection, resource string, v interface{}) error
+}
+
+// ImportDump imports a mongodump output directory. Each <name>.bson file,
+// or <name>.bson.gz when dumped with --gzip, is imported into collection
+// <name>, whether it sits in dir itself or in one of its database
+// subdirectories. It returns how many records were written.
+func ImportDump(store Store, dir string) (int, error) {
+	var files []string
+	for _, pattern := range []string{"*.bson", "*.bson.gz", "*/*.bson", "*/*.bson.gz"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return 0, err
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return 0, fmt.Errorf("no .bson files found in '%s'", dir)
+	}
+
+	total := 0
+	for _, file := range files {
+		name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(file), ".gz"), ".bson")
+		n, err := importFile(store, name, file)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("importing '%s': %w", file, err)
+		}
+	}
+	return total, nil
+}
+
+func importFile(store Store, collection, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return 0, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+	return ImportBSON(store, collection, r)
+}
+
+// ImportBSON imports a stream of BSON documents, as in a mongodump .bson
+// file, into collection.
+func ImportBSON(store Store, collection string, r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+	n := 0
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(br, size[:]); err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, fmt.Errorf("document %d: %w", n+1, err)
+		}
+
+		length := binary.LittleEndian.Uint32(size[:])
+		if length < 5 || length > maxDocument {
+			return n, fmt.Errorf("document %d: invalid length %d", n+1, length)
+		}
+		raw := make([]byte, length)
+		copy(raw, size[:])
+		if _, err := io.ReadFull(br, raw[4:]); err != nil {
+			return n, fmt.Errorf("document %d: %w", n+1, err)
+		}
+
+		var doc bson.D
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			return n, fmt.Errorf("document %d: %w", n+1, err)
+		}
+		if err := write(store, collection, doc); err != nil {
+			return n, fmt.Errorf("document %d: %w", n+1, err)
+		}
+		n++
+	}
+}
+
+// ImportJSON imports mongoexport output into collection: Extended JSON
+// documents one per line, or a single array when exported with
+// --jsonArray.
+func ImportJSON(store Store, collection string, r io.Reader) (int, error) {
+	dec := json.NewDecoder(r)
+
+	var first json.RawMessage
+	if err := dec.Decode(&first); err == io.EOF {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	var docs []json.RawMessage
+	if trimmed := bytes.TrimSpace(first); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &docs); err != nil {
+			return 0, err
+		}
+	} else {
+		docs = []json.RawMessage{first}
+	}
+
+	n := 0
+	next := func() (json.RawMessage, error) {
+		if n < len(docs) {
+			return docs[n], nil
+		}
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		return raw, err
+	}
+
+	for {
+		raw, err := next()
+		if err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, fmt.Errorf("document %d: %w", n+1, err)
+		}
+
+		var doc bson.D
+		if err := bson.UnmarshalExtJSON(raw, false, &doc); err != nil {
+			return n, fmt.Errorf("document %d: %w", n+1, err)
+		}
+		if err := write(store, collection, doc); err != nil {
+			return n, fmt.Errorf("document %d: %w", n+1, err)
+		}
+		n++
+	}
+}
+
+func write(store Store, collection string, doc bson.D) error {
+	var id interface{}
+	fields := make(map[string]interface{}, len(doc))
+	for _, e := range doc {
+		if e.Key == "_id" {
+			id = e.Value
+			continue
+		}
+		fields[e.Key] = plain(e.Value)
+	}
+	if id == nil {
+		return fmt.Errorf("document has no _id")
+	}
+
+	resource, err := resourceName(id)
+	if err != nil {
+		return err
+	}
+	return store.Write(collection, resource, fields)
+}
+
+// resourceName turns an _id into a resource name. Compound ids use their
+// canonical Extended JSON.
+func resourceName(id interface{}) (string, error) {
+	switch id := id.(type) {
+	case bson.ObjectID:
+		return id.Hex(), nil
+	case string:
+		return id, nil
+	case int32:
+		return strconv.FormatInt(int64(id), 10), nil
+	case int64:
+		return strconv.FormatInt(id, 10), nil
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64), nil
+	}
+
+	b, err := json.Marshal(plain(id))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// plain converts a decoded BSON value into one encoding/json can write.
+func plain(v interface{}) interface{} {
+	switch v := v.(type) {
+	case bson.D:
+		m := make(map[string]interface{}, len(v))
+		for _, e := range v {
+			m[e.Key] = plain(e.Value)
+		}
+		return m
+	case bson.A:
+		a := make([]interface{}, len(v))
+		for i, item := range v {
+			a[i] = plain(item)
+		}
+		return a
+	case bson.ObjectID:
+		return v.Hex()
+	case bson.DateTime:
+		return v.Time().UTC().Format(time.RFC3339Nano)
+	case bson.Timestamp:
+		return time.Unix(int64(v.T), 0).UTC().Format(time.RFC3339)
+	case bson.Decimal128:
+		return v.String()
+	case bson.Binary:
+		return base64.StdEncoding.EncodeToString(v.Data)
+	case bson.Regex:
+		return "/" + v.Pattern + "/" + v.Options
+	case bson.JavaScript:
+		return string(v)
+	case bson.Symbol:
+		return string(v)
+	case bson.Null, bson.Undefined:
+		return nil
+	case bson.MinKey, bson.MaxKey, bson.DBPointer, bson.CodeWithScope:
+		return fmt.Sprint(v)
+	}
+	return v
+}
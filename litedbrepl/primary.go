@@ -0,0 +1,208 @@
+package litedbrepl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SagarDas211/LiteDB-Go/cdc"
+)
+
+const (
+	// DefaultBacklog is how many mutations a primary keeps for replicas
+	// that reconnect.
+	DefaultBacklog = 10000
+
+	heartbeat = 15 * time.Second
+)
+
+// Primary serves a database's mutations to replicas. Register it with the
+// driver as a CDC sink and mount it as an http.Handler.
+type Primary struct {
+	store Store
+	epoch string
+
+	mutex   sync.Mutex
+	backlog []cdc.Mutation
+	size    int
+	last    uint64
+	wake    chan struct{}
+	closed  bool
+}
+
+func NewPrimary(store Store) *Primary {
+	var b [8]byte
+	rand.Read(b[:])
+	return &Primary{store: store, epoch: hex.EncodeToString(b[:]), size: DefaultBacklog, wake: make(chan struct{})}
+}
+
+// Publish implements cdc.Sink.
+func (p *Primary) Publish(ctx context.Context, m cdc.Mutation) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.backlog = append(p.backlog, m)
+	if len(p.backlog) > p.size {
+		p.backlog = append(p.backlog[:0:0], p.backlog[len(p.backlog)-p.size:]...)
+	}
+	p.last = m.Seq
+	p.notify()
+	return nil
+}
+
+// Close implements cdc.Sink and ends every open stream.
+func (p *Primary) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.closed = true
+	p.notify()
+	return nil
+}
+
+// notify wakes streams waiting for mutations. The caller must hold mutex.
+func (p *Primary) notify() {
+	close(p.wake)
+	p.wake = make(chan struct{})
+}
+
+func (p *Primary) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/snapshot":
+		p.snapshot(w, r)
+	case "/stream":
+		p.stream(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (p *Primary) snapshot(w http.ResponseWriter, r *http.Request) {
+	p.mutex.Lock()
+	seq := p.last
+	p.mutex.Unlock()
+
+	collections, err := p.store.Collections()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set(epochHeader, p.epoch)
+	w.Header().Set(seqHeader, strconv.FormatUint(seq, 10))
+
+	enc := json.NewEncoder(w)
+	for _, collection := range collections {
+		keys, err := p.store.Keys(collection)
+		if err != nil {
+			// The status is already sent; a truncated snapshot is
+			// detected by the replica as a missing trailer.
+			return
+		}
+		for _, resource := range keys {
+			doc, err := p.store.Get(r.Context(), collection, resource)
+			if err != nil {
+				// Deleted since the key was listed; the stream
+				// carries the delete.
+				continue
+			}
+			if err := enc.Encode(snapshotRecord{Collection: collection, Resource: resource, Data: doc}); err != nil {
+				return
+			}
+		}
+	}
+	enc.Encode(snapshotRecord{})
+}
+
+func (p *Primary) stream(w http.ResponseWriter, r *http.Request) {
+	from, err := strconv.ParseUint(r.URL.Query().Get("from"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid from", http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("epoch") != p.epoch {
+		http.Error(w, "epoch changed, load a snapshot", http.StatusConflict)
+		return
+	}
+
+	pending, wake, ok := p.since(from)
+	if !ok {
+		http.Error(w, "too far behind, load a snapshot", http.StatusConflict)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set(epochHeader, p.epoch)
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		for _, m := range pending {
+			if err := enc.Encode(m); err != nil {
+				return
+			}
+			from = m.Seq
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			// A bare newline keeps idle connections open.
+			if _, err := w.Write([]byte("\n")); err != nil {
+				return
+			}
+		case <-wake:
+		}
+
+		if pending, wake, ok = p.since(from); !ok || wake == nil {
+			return
+		}
+	}
+}
+
+// since returns the mutations after seq, and a channel closed when more
+// arrive. It reports false if mutations after seq have already left the
+// backlog, and a nil channel once the primary is closed.
+func (p *Primary) since(seq uint64) ([]cdc.Mutation, <-chan struct{}, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if seq > p.last {
+		return nil, nil, false
+	}
+	if seq < p.last && (len(p.backlog) == 0 || p.backlog[0].Seq > seq+1) {
+		return nil, nil, false
+	}
+
+	var pending []cdc.Mutation
+	for i, m := range p.backlog {
+		if m.Seq > seq {
+			pending = append(pending, p.backlog[i:]...)
+			break
+		}
+	}
+
+	if p.closed {
+		return pending, nil, true
+	}
+	return pending, p.wake, true
+}
@@ -0,0 +1,45 @@
+// Package litedbrepl replicates a LiteDB database from a primary to any
+// number of replicas over HTTP.
+//
+// The primary is a CDC sink that keeps a backlog of recent mutations and
+// serves two endpoints:
+//
+//	GET /snapshot                  every record, as NDJSON
+//	GET /stream?epoch=E&from=N     mutations after N, as NDJSON, until the
+//	                               client disconnects
+//
+// A replica first loads a snapshot, then follows the stream, applying
+// mutations in sequence order. If it falls further behind than the
+// backlog, or the primary restarts (changing its epoch), the stream
+// answers 409 Conflict and the replica loads a fresh snapshot. After
+// Promote the replica stops following and accepts writes.
+package litedbrepl
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Store is the part of the driver replication needs on both sides.
+type Store interface {
+	Collections() ([]string, error)
+	Keys(collection string) ([]string, error)
+	Get(ctx context.Context, collection, resource string) (json.RawMessage, error)
+
+	// Put and Delete apply replicated changes. Delete must succeed when
+	// the record is already gone.
+	Put(ctx context.Context, collection, resource string, doc json.RawMessage) error
+	Delete(ctx context.Context, collection, resource string) error
+}
+
+// snapshotRecord is one line of a snapshot.
+type snapshotRecord struct {
+	Collection string          `json:"collection"`
+	Resource   string          `json:"resource"`
+	Data       json.RawMessage `json:"data"`
+}
+
+const (
+	epochHeader = "Litedb-Epoch"
+	seqHeader   = "Litedb-Seq"
+)
@@ -0,0 +1,254 @@
+package litedbrepl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SagarDas211/LiteDB-Go/cdc"
+)
+
+var errResync = errors.New("replica must load a snapshot")
+
+// Replica follows a primary and applies its mutations to a local store.
+type Replica struct {
+	primary string
+	store   Store
+
+	// Client makes the requests to the primary. Its timeout must allow
+	// for long-lived streams.
+	Client *http.Client
+
+	// Logger receives connection errors, which Run retries.
+	Logger *slog.Logger
+
+	mutex    sync.Mutex
+	epoch    string
+	seq      uint64
+	promoted bool
+	stop     context.CancelFunc
+}
+
+// NewReplica returns a replica of the primary served at primaryURL.
+func NewReplica(primaryURL string, store Store) *Replica {
+	return &Replica{
+		primary: strings.TrimRight(primaryURL, "/"),
+		store:   store,
+		Client:  &http.Client{},
+		Logger:  slog.Default(),
+	}
+}
+
+// Run follows the primary until ctx is done or the replica is promoted,
+// reconnecting with backoff after failures.
+func (r *Replica) Run(ctx context.Context) error {
+	r.mutex.Lock()
+	if r.promoted {
+		r.mutex.Unlock()
+		return nil
+	}
+	ctx, r.stop = context.WithCancel(ctx)
+	r.mutex.Unlock()
+
+	backoff := time.Second
+	for {
+		err := r.follow(ctx)
+		if r.Promoted() {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if errors.Is(err, errResync) {
+			r.mutex.Lock()
+			r.epoch = ""
+			r.mutex.Unlock()
+			continue
+		}
+		if err != nil {
+			r.Logger.Warn("Replication interrupted", "primary", r.primary, "err", err, "retry", backoff)
+		} else {
+			backoff = time.Second
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// Promote stops following the primary so the replica can take writes.
+func (r *Replica) Promote() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.promoted = true
+	if r.stop != nil {
+		r.stop()
+	}
+}
+
+func (r *Replica) Promoted() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.promoted
+}
+
+// Position reports the primary's epoch and the last sequence number
+// applied.
+func (r *Replica) Position() (epoch string, seq uint64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.epoch, r.seq
+}
+
+func (r *Replica) follow(ctx context.Context) error {
+	epoch, seq := r.Position()
+	if epoch == "" {
+		if err := r.loadSnapshot(ctx); err != nil {
+			return err
+		}
+		epoch, seq = r.Position()
+	}
+
+	q := url.Values{"epoch": {epoch}, "from": {strconv.FormatUint(seq, 10)}}
+	res, err := r.get(ctx, "/stream?"+q.Encode())
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(res.Body))
+	for {
+		var m cdc.Mutation
+		if err := dec.Decode(&m); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if m.Seq != seq+1 {
+			return fmt.Errorf("%w: expected mutation %d, got %d", errResync, seq+1, m.Seq)
+		}
+
+		if err := r.apply(ctx, m); err != nil {
+			return err
+		}
+		seq = m.Seq
+
+		r.mutex.Lock()
+		r.seq = seq
+		r.mutex.Unlock()
+	}
+}
+
+func (r *Replica) apply(ctx context.Context, m cdc.Mutation) error {
+	switch m.Op {
+	case cdc.OpWrite:
+		return r.store.Put(ctx, m.Collection, m.Resource, m.Data)
+	case cdc.OpDelete:
+		return r.store.Delete(ctx, m.Collection, m.Resource)
+	}
+	return fmt.Errorf("unknown mutation op '%s'", m.Op)
+}
+
+// loadSnapshot replaces the local data with the primary's and records the
+// position to stream from.
+func (r *Replica) loadSnapshot(ctx context.Context) error {
+	res, err := r.get(ctx, "/snapshot")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	seq, err := strconv.ParseUint(res.Header.Get(seqHeader), 10, 64)
+	if err != nil {
+		return fmt.Errorf("snapshot has no sequence number")
+	}
+	epoch := res.Header.Get(epochHeader)
+
+	seen := make(map[string]map[string]bool)
+	dec := json.NewDecoder(bufio.NewReader(res.Body))
+	for {
+		var rec snapshotRecord
+		if err := dec.Decode(&rec); err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("snapshot was truncated")
+			}
+			return err
+		}
+		if rec.Collection == "" {
+			break
+		}
+
+		if err := r.store.Put(ctx, rec.Collection, rec.Resource, rec.Data); err != nil {
+			return err
+		}
+		if seen[rec.Collection] == nil {
+			seen[rec.Collection] = make(map[string]bool)
+		}
+		seen[rec.Collection][rec.Resource] = true
+	}
+
+	// Drop local records the primary no longer has.
+	collections, err := r.store.Collections()
+	if err != nil {
+		return err
+	}
+	for _, collection := range collections {
+		keys, err := r.store.Keys(collection)
+		if err != nil {
+			return err
+		}
+		for _, resource := range keys {
+			if !seen[collection][resource] {
+				if err := r.store.Delete(ctx, collection, resource); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	r.mutex.Lock()
+	r.epoch, r.seq = epoch, seq
+	r.mutex.Unlock()
+	return nil
+}
+
+func (r *Replica) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.primary+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := r.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return res, nil
+	case http.StatusConflict:
+		res.Body.Close()
+		return nil, errResync
+	}
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	res.Body.Close()
+	return nil, fmt.Errorf("primary returned %s: %s", res.Status, strings.TrimSpace(string(b)))
+}
@@ -0,0 +1,470 @@
+// Package litedbresp serves a LiteDB database to Redis clients over a
+// subset of the Redis protocol (RESP2).
+//
+// A key "collection:resource" names a record; keys without a colon live
+// in Server.DefaultCollection. SET stores JSON objects and arrays as
+// documents and anything else as a JSON string, and GET returns what SET
+// stored. The supported commands are PING, ECHO, GET, SET (with EX, PX, NX
+// and XX), DEL, EXISTS, KEYS, EXPIRE, PEXPIRE, TTL, PTTL, PERSIST, SELECT 0
+// and QUIT.
+package litedbresp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store is the part of the driver the server needs.
+type Store interface {
+	Collections() ([]string, error)
+	Keys(collection string) ([]string, error)
+	Get(ctx context.Context, collection, resource string) (json.RawMessage, error)
+	Put(ctx context.Context, collection, resource string, doc json.RawMessage, ttl time.Duration) error
+	Delete(ctx context.Context, collection, resource string) error
+
+	// TTL reports the time left before a record expires, or zero if it
+	// never does.
+	TTL(collection, resource string) (time.Duration, error)
+	SetTTL(collection, resource string, ttl time.Duration) error
+}
+
+type Server struct {
+	store Store
+
+	// DefaultCollection holds keys that have no "collection:" prefix.
+	DefaultCollection string
+
+	mutex     sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+}
+
+func New(store Store) *Server {
+	return &Server{store: store, DefaultCollection: "kv"}
+}
+
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l until Close is called.
+func (s *Server) Serve(l net.Listener) error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return net.ErrClosed
+	}
+	if s.listeners == nil {
+		s.listeners = make(map[net.Listener]struct{})
+	}
+	s.listeners[l] = struct{}{}
+	s.mutex.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mutex.Lock()
+			closed := s.closed
+			s.mutex.Unlock()
+			if closed {
+				return net.ErrClosed
+			}
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// Close stops every listener and closes open connections.
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	return nil
+}
+
+func (s *Server) track(conn net.Conn, add bool) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if add {
+		if s.closed {
+			return false
+		}
+		if s.conns == nil {
+			s.conns = make(map[net.Conn]struct{})
+		}
+		s.conns[conn] = struct{}{}
+	} else {
+		delete(s.conns, conn)
+	}
+	return true
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	if !s.track(conn, true) {
+		return
+	}
+	defer s.track(conn, false)
+
+	r := bufio.NewReader(conn)
+	w := writer{bufio.NewWriter(conn)}
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				w.err("ERR " + err.Error())
+				w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := s.execute(w, args)
+		if err := w.Flush(); err != nil || quit {
+			return
+		}
+	}
+}
+
+// split maps a Redis key onto a collection and resource.
+func (s *Server) split(key string) (string, string) {
+	if collection, resource, ok := strings.Cut(key, ":"); ok && collection != "" && resource != "" {
+		return collection, resource
+	}
+	return s.DefaultCollection, key
+}
+
+func (s *Server) join(collection, resource string) string {
+	if collection == s.DefaultCollection {
+		return resource
+	}
+	return collection + ":" + resource
+}
+
+func (s *Server) execute(w writer, args []string) (quit bool) {
+	ctx := context.Background()
+	cmd := strings.ToUpper(args[0])
+	args = args[1:]
+
+	arity := func(min, max int) bool {
+		if len(args) < min || (max >= 0 && len(args) > max) {
+			w.err("ERR wrong number of arguments for '" + strings.ToLower(cmd) + "' command")
+			return false
+		}
+		return true
+	}
+
+	switch cmd {
+	case "PING":
+		if !arity(0, 1) {
+			return
+		}
+		if len(args) == 1 {
+			w.bulk(args[0])
+		} else {
+			w.simple("PONG")
+		}
+
+	case "ECHO":
+		if arity(1, 1) {
+			w.bulk(args[0])
+		}
+
+	case "QUIT":
+		w.simple("OK")
+		return true
+
+	case "SELECT":
+		if !arity(1, 1) {
+			return
+		}
+		if args[0] != "0" {
+			w.err("ERR DB index is out of range")
+		} else {
+			w.simple("OK")
+		}
+
+	case "COMMAND":
+		w.array(nil)
+
+	case "CLIENT":
+		w.simple("OK")
+
+	case "GET":
+		if !arity(1, 1) {
+			return
+		}
+		collection, resource := s.split(args[0])
+		doc, err := s.store.Get(ctx, collection, resource)
+		if errors.Is(err, fs.ErrNotExist) {
+			w.null()
+			return
+		}
+		if err != nil {
+			w.err("ERR " + err.Error())
+			return
+		}
+		w.bulk(decodeValue(doc))
+
+	case "SET":
+		s.set(ctx, w, args)
+
+	case "DEL", "EXISTS":
+		if !arity(1, -1) {
+			return
+		}
+		var n int64
+		for _, key := range args {
+			collection, resource := s.split(key)
+			ok, err := s.exists(collection, resource)
+			if err != nil {
+				w.err("ERR " + err.Error())
+				return
+			}
+			if !ok {
+				continue
+			}
+			if cmd == "DEL" {
+				if err := s.store.Delete(ctx, collection, resource); err != nil {
+					w.err("ERR " + err.Error())
+					return
+				}
+			}
+			n++
+		}
+		w.integer(n)
+
+	case "KEYS":
+		if !arity(1, 1) {
+			return
+		}
+		keys, err := s.keys(args[0])
+		if err != nil {
+			w.err("ERR " + err.Error())
+			return
+		}
+		w.array(keys)
+
+	case "EXPIRE", "PEXPIRE":
+		if !arity(2, 2) {
+			return
+		}
+		n, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			w.err("ERR value is not an integer or out of range")
+			return
+		}
+		unit := time.Second
+		if cmd == "PEXPIRE" {
+			unit = time.Millisecond
+		}
+
+		collection, resource := s.split(args[0])
+		if ok, err := s.exists(collection, resource); err != nil {
+			w.err("ERR " + err.Error())
+			return
+		} else if !ok {
+			w.integer(0)
+			return
+		}
+
+		if n <= 0 {
+			err = s.store.Delete(ctx, collection, resource)
+		} else {
+			err = s.store.SetTTL(collection, resource, time.Duration(n)*unit)
+		}
+		if err != nil {
+			w.err("ERR " + err.Error())
+			return
+		}
+		w.integer(1)
+
+	case "PERSIST":
+		if !arity(1, 1) {
+			return
+		}
+		collection, resource := s.split(args[0])
+		ttl, err := s.store.TTL(collection, resource)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && ttl == 0) {
+			w.integer(0)
+			return
+		}
+		if err == nil {
+			err = s.store.SetTTL(collection, resource, 0)
+		}
+		if err != nil {
+			w.err("ERR " + err.Error())
+			return
+		}
+		w.integer(1)
+
+	case "TTL", "PTTL":
+		if !arity(1, 1) {
+			return
+		}
+		collection, resource := s.split(args[0])
+		ttl, err := s.store.TTL(collection, resource)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			w.integer(-2)
+		case err != nil:
+			w.err("ERR " + err.Error())
+		case ttl == 0:
+			w.integer(-1)
+		case cmd == "PTTL":
+			w.integer(ttl.Milliseconds())
+		default:
+			w.integer(int64((ttl + time.Second - 1) / time.Second))
+		}
+
+	default:
+		w.err("ERR unknown command '" + strings.ToLower(cmd) + "'")
+	}
+	return false
+}
+
+func (s *Server) set(ctx context.Context, w writer, args []string) {
+	if len(args) < 2 {
+		w.err("ERR wrong number of arguments for 'set' command")
+		return
+	}
+	collection, resource := s.split(args[0])
+
+	var ttl time.Duration
+	var nx, xx bool
+	for i := 2; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); opt {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "EX", "PX":
+			if i+1 >= len(args) {
+				w.err("ERR syntax error")
+				return
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				w.err("ERR invalid expire time in 'set' command")
+				return
+			}
+			ttl = time.Duration(n) * time.Second
+			if opt == "PX" {
+				ttl = time.Duration(n) * time.Millisecond
+			}
+			i++
+		default:
+			w.err("ERR syntax error")
+			return
+		}
+	}
+	if nx && xx {
+		w.err("ERR syntax error")
+		return
+	}
+
+	if nx || xx {
+		ok, err := s.exists(collection, resource)
+		if err != nil {
+			w.err("ERR " + err.Error())
+			return
+		}
+		if (nx && ok) || (xx && !ok) {
+			w.null()
+			return
+		}
+	}
+
+	if err := s.store.Put(ctx, collection, resource, encodeValue(args[1]), ttl); err != nil {
+		w.err("ERR " + err.Error())
+		return
+	}
+	w.simple("OK")
+}
+
+func (s *Server) exists(collection, resource string) (bool, error) {
+	_, err := s.store.TTL(collection, resource)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *Server) keys(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	collections, err := s.store.Collections()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for _, collection := range collections {
+		resources, err := s.store.Keys(collection)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range resources {
+			key := s.join(collection, resource)
+			if ok, _ := path.Match(pattern, key); ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// encodeValue stores JSON objects and arrays as documents and anything
+// else as a JSON string.
+func encodeValue(v string) json.RawMessage {
+	trimmed := strings.TrimSpace(v)
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+		return json.RawMessage(trimmed)
+	}
+	b, _ := json.Marshal(v)
+	return b
+}
+
+func decodeValue(doc json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(doc, &s); err == nil {
+		return s
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, doc); err != nil {
+		return string(doc)
+	}
+	return compact.String()
+}
@@ -0,0 +1,40 @@
+package litedbserver
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed admin
+var adminFiles embed.FS
+
+// StatsStore is implemented by stores that can report statistics for the
+// /stats route and the admin dashboard.
+type StatsStore interface {
+	Stats() (interface{}, error)
+}
+
+// EnableAdmin serves the embedded admin dashboard under /admin/, for
+// browsing collections, editing records, running queries and viewing
+// stats.
+func (s *Server) EnableAdmin() {
+	files, _ := fs.Sub(adminFiles, "admin")
+	s.mux.Handle("GET /admin/", http.StripPrefix("/admin/", http.FileServerFS(files)))
+	s.mux.Handle("GET /admin", http.RedirectHandler("/admin/", http.StatusMovedPermanently))
+}
+
+func (s *Server) stats(w http.ResponseWriter, r *http.Request) {
+	stats, ok := s.store.(StatsStore)
+	if !ok {
+		writeError(w, http.StatusNotFound, errNoStats)
+		return
+	}
+
+	v, err := stats.Stats()
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
@@ -0,0 +1,123 @@
+"use strict";
+
+const api = "../";
+const $ = (id) => document.getElementById(id);
+let current = null;
+
+async function call(method, path, body) {
+  const res = await fetch(api + path, {
+    method,
+    headers: body === undefined ? {} : { "Content-Type": "application/json" },
+    body,
+  });
+  if (!res.ok) {
+    let msg = res.statusText;
+    try { msg = (await res.json()).error; } catch (e) {}
+    throw new Error(msg);
+  }
+  return res.status === 204 ? null : res.json();
+}
+
+function show(...ids) {
+  for (const id of ["browser", "editor", "stats"]) $(id).hidden = !ids.includes(id);
+}
+
+async function guard(fn) {
+  $("error").textContent = "";
+  try { await fn(); } catch (e) { $("error").textContent = e.message; }
+}
+
+function path(collection, resource) {
+  let p = "collections/" + encodeURIComponent(collection);
+  if (resource !== undefined) p += "/records/" + encodeURIComponent(resource);
+  return p;
+}
+
+async function loadCollections() {
+  const { collections } = await call("GET", "collections");
+  const list = $("collections");
+  list.replaceChildren();
+  for (const name of collections) {
+    const a = document.createElement("a");
+    a.textContent = name;
+    a.className = name === current ? "active" : "";
+    a.onclick = () => guard(() => openCollection(name));
+    const li = document.createElement("li");
+    li.append(a);
+    list.append(li);
+  }
+}
+
+async function openCollection(name) {
+  current = name;
+  $("collection-name").textContent = name;
+  $("filter").value = "";
+  show("browser");
+  await Promise.all([loadCollections(), query()]);
+}
+
+async function query() {
+  const filter = $("filter").value.trim();
+  const { records } = filter
+    ? await call("POST", path(current) + "/query", filter)
+    : await call("GET", path(current) + "/records");
+
+  const body = $("records");
+  body.replaceChildren();
+  for (const r of records) {
+    const tr = document.createElement("tr");
+    tr.className = "record";
+    const id = document.createElement("td");
+    id.textContent = r.id;
+    const doc = document.createElement("td");
+    doc.className = "doc";
+    doc.textContent = JSON.stringify(r.data);
+    tr.append(id, doc);
+    tr.onclick = () => edit(r.id, r.data);
+    body.append(tr);
+  }
+}
+
+function edit(id, data) {
+  $("record-id").value = id;
+  $("record-id").readOnly = id !== "";
+  $("document").value = JSON.stringify(data, null, 2);
+  $("delete").hidden = id === "";
+  show("browser", "editor");
+}
+
+$("query").onsubmit = (e) => { e.preventDefault(); guard(query); };
+$("new-record").onclick = () => edit("", {});
+$("close").onclick = () => show("browser");
+
+$("save").onclick = () => guard(async () => {
+  const id = $("record-id").value.trim();
+  if (!id) throw new Error("record id is required");
+  JSON.parse($("document").value);
+  await call("PUT", path(current, id), $("document").value);
+  show("browser");
+  await query();
+});
+
+$("delete").onclick = () => guard(async () => {
+  const id = $("record-id").value;
+  if (!confirm(`Delete ${id}?`)) return;
+  await call("DELETE", path(current, id));
+  show("browser");
+  await query();
+});
+
+$("drop-collection").onclick = () => guard(async () => {
+  if (!confirm(`Delete every record in ${current}?`)) return;
+  await call("DELETE", path(current));
+  current = null;
+  show();
+  await loadCollections();
+});
+
+$("show-stats").onclick = () => guard(async () => {
+  $("stats-body").textContent = JSON.stringify(await call("GET", "stats"), null, 2);
+  show("stats");
+});
+
+guard(loadCollections);
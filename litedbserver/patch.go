@@ -0,0 +1,62 @@
+package litedbserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+var errNoPatch = errors.New("store does not support patches")
+
+// The media types of a JSON Patch (RFC 6902) and a JSON Merge Patch (RFC
+// 7386).
+const (
+	jsonPatchType  = "application/json-patch+json"
+	mergePatchType = "application/merge-patch+json"
+)
+
+// PatchStore is implemented by stores that apply patches to records, for
+// PATCH /collections/{c}/records/{r}.
+type PatchStore interface {
+	Patch(ctx context.Context, collection, resource string, patch json.RawMessage) error
+	MergePatch(ctx context.Context, collection, resource string, patch json.RawMessage) error
+}
+
+// patch applies a JSON Patch or a JSON Merge Patch, as the Content-Type
+// says.
+func (s *Server) patch(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.store.(PatchStore)
+	if !ok {
+		writeError(w, http.StatusMethodNotAllowed, errNoPatch)
+		return
+	}
+	apply := store.Patch
+	switch t, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); t {
+	case jsonPatchType:
+	case mergePatchType:
+		apply = store.MergePatch
+	default:
+		w.Header().Set("Accept-Patch", jsonPatchType+", "+mergePatchType)
+		writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("patches must be sent as %s or %s", jsonPatchType, mergePatchType))
+		return
+	}
+	b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+	if err != nil {
+		writeError(w, bodyStatus(err), err)
+		return
+	}
+	if !json.Valid(b) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("request body is not valid JSON"))
+		return
+	}
+
+	if err := apply(r.Context(), r.PathValue("c"), r.PathValue("r"), b); err != nil {
+		s.fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
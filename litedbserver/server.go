@@ -0,0 +1,223 @@
+// Package litedbserver exposes a LiteDB database over HTTP with JSON
+// requests and responses.
+//
+//	GET    /collections                       list collections
+//	GET    /collections/{c}/records           list records
+//	POST   /collections/{c}/query             find records matching a filter
+//	DELETE /collections/{c}                   delete a collection
+//	GET    /collections/{c}/records/{r}       read a record
+//	PUT    /collections/{c}/records/{r}       write a record
+//	PATCH  /collections/{c}/records/{r}       patch a record (JSON Patch or Merge Patch)
+//	DELETE /collections/{c}/records/{r}       delete a record
+//	GET    /collections/{c}/watch             WebSocket change feed
+//	POST   /topics/{t}/messages               publish a message
+//	PUT    /topics/{t}/subscribers/{s}        subscribe
+//	DELETE /topics/{t}/subscribers/{s}        unsubscribe
+//	GET    /topics/{t}/subscribers/{s}/messages  unacknowledged messages
+//	POST   /topics/{t}/subscribers/{s}/ack    acknowledge up to a message
+//	GET    /stats                             database statistics
+//
+// EnableAdmin adds a web dashboard under /admin/.
+package litedbserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// maxBody is the largest request body accepted.
+const maxBody = 32 << 20
+
+var errNoStats = errors.New("store does not report stats")
+
+type Record struct {
+	ID   string          `json:"id"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Store is the part of the driver the server needs.
+type Store interface {
+	Collections() ([]string, error)
+	Get(ctx context.Context, collection, resource string) (json.RawMessage, error)
+	Put(ctx context.Context, collection, resource string, doc json.RawMessage) error
+	Delete(ctx context.Context, collection, resource string) error
+	Query(ctx context.Context, collection string, filter map[string]interface{}) ([]Record, error)
+}
+
+type Server struct {
+	store Store
+	mux   *http.ServeMux
+
+	// Status maps a store error to an HTTP status. It defaults to
+	// DefaultStatus.
+	Status func(error) int
+
+	feedOnce sync.Once
+	events   *feed
+}
+
+func New(store Store) *Server {
+	s := &Server{store: store, mux: http.NewServeMux(), Status: DefaultStatus}
+
+	s.mux.HandleFunc("GET /collections", s.listCollections)
+	s.mux.HandleFunc("GET /collections/{c}/records", s.listRecords)
+	s.mux.HandleFunc("POST /collections/{c}/query", s.query)
+	s.mux.HandleFunc("DELETE /collections/{c}", s.deleteCollection)
+	s.mux.HandleFunc("GET /collections/{c}/records/{r}", s.get)
+	s.mux.HandleFunc("PUT /collections/{c}/records/{r}", s.put)
+	s.mux.HandleFunc("PATCH /collections/{c}/records/{r}", s.patch)
+	s.mux.HandleFunc("DELETE /collections/{c}/records/{r}", s.delete)
+	s.mux.HandleFunc("GET /collections/{c}/watch", s.watch)
+	s.mux.HandleFunc("POST /topics/{t}/messages", s.publish)
+	s.mux.HandleFunc("PUT /topics/{t}/subscribers/{s}", s.subscribe)
+	s.mux.HandleFunc("DELETE /topics/{t}/subscribers/{s}", s.unsubscribe)
+	s.mux.HandleFunc("GET /topics/{t}/subscribers/{s}/messages", s.fetch)
+	s.mux.HandleFunc("POST /topics/{t}/subscribers/{s}/ack", s.ack)
+	s.mux.HandleFunc("GET /stats", s.stats)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := checkPath(r.URL.EscapedPath()); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// checkPath refuses paths whose segments, once unescaped, could name a file
+// outside the database root: "." and "..", or anything holding a separator
+// or NUL. The mux only cleans the escaped path, so "..%2F" gets past it.
+func checkPath(path string) error {
+	for _, seg := range strings.Split(path, "/") {
+		name, err := url.PathUnescape(seg)
+		if err != nil {
+			return fmt.Errorf("invalid path segment '%s': %w", seg, err)
+		}
+		if name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+			return fmt.Errorf("invalid name '%s'", name)
+		}
+	}
+	return nil
+}
+
+func (s *Server) listCollections(w http.ResponseWriter, r *http.Request) {
+	names, err := s.store.Collections()
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	if names == nil {
+		names = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"collections": names})
+}
+
+func (s *Server) listRecords(w http.ResponseWriter, r *http.Request) {
+	s.find(w, r, nil)
+}
+
+func (s *Server) query(w http.ResponseWriter, r *http.Request) {
+	var filter map[string]interface{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(&filter); err != nil && err != io.EOF {
+		writeError(w, bodyStatus(err), fmt.Errorf("invalid filter: %w", err))
+		return
+	}
+	s.find(w, r, filter)
+}
+
+func (s *Server) find(w http.ResponseWriter, r *http.Request, filter map[string]interface{}) {
+	records, err := s.store.Query(r.Context(), r.PathValue("c"), filter)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	if records == nil {
+		records = []Record{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"records": records})
+}
+
+func (s *Server) deleteCollection(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.Delete(r.Context(), r.PathValue("c"), ""); err != nil {
+		s.fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request) {
+	doc, err := s.store.Get(r.Context(), r.PathValue("c"), r.PathValue("r"))
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+func (s *Server) put(w http.ResponseWriter, r *http.Request) {
+	b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+	if err != nil {
+		writeError(w, bodyStatus(err), err)
+		return
+	}
+	if !json.Valid(b) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("request body is not valid JSON"))
+		return
+	}
+
+	if err := s.store.Put(r.Context(), r.PathValue("c"), r.PathValue("r"), b); err != nil {
+		s.fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.Delete(r.Context(), r.PathValue("c"), r.PathValue("r")); err != nil {
+		s.fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// bodyStatus is 413 for a body over the size limit and 400 for any other
+// error reading it.
+func bodyStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func (s *Server) fail(w http.ResponseWriter, err error) {
+	writeError(w, s.Status(err), err)
+}
+
+// DefaultStatus is 404 for missing files and 500 for any other error.
+func DefaultStatus(err error) int {
+	if errors.Is(err, fs.ErrNotExist) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,135 @@
+package litedbserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var errNoTopics = errors.New("store does not support topics")
+
+// TopicMessage is a message published to a topic.
+type TopicMessage struct {
+	ID          string          `json:"id"`
+	Value       json.RawMessage `json:"value"`
+	PublishedAt time.Time       `json:"publishedAt"`
+}
+
+// TopicStore is implemented by stores with publish/subscribe topics, for
+// the /topics routes.
+type TopicStore interface {
+	Publish(ctx context.Context, topic string, value json.RawMessage) (string, error)
+	Subscribe(ctx context.Context, topic, subscriber string) error
+	Unsubscribe(ctx context.Context, topic, subscriber string) error
+	Fetch(ctx context.Context, topic, subscriber string, max int) ([]TopicMessage, error)
+	Ack(ctx context.Context, topic, subscriber, id string) error
+}
+
+func (s *Server) topics(w http.ResponseWriter) (TopicStore, bool) {
+	store, ok := s.store.(TopicStore)
+	if !ok {
+		writeError(w, http.StatusNotFound, errNoTopics)
+	}
+	return store, ok
+}
+
+func (s *Server) publish(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.topics(w)
+	if !ok {
+		return
+	}
+	b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+	if err != nil {
+		writeError(w, bodyStatus(err), err)
+		return
+	}
+	if !json.Valid(b) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("request body is not valid JSON"))
+		return
+	}
+
+	id, err := store.Publish(r.Context(), r.PathValue("t"), b)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"id": id})
+}
+
+func (s *Server) subscribe(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.topics(w)
+	if !ok {
+		return
+	}
+	if err := store.Subscribe(r.Context(), r.PathValue("t"), r.PathValue("s")); err != nil {
+		s.fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) unsubscribe(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.topics(w)
+	if !ok {
+		return
+	}
+	if err := store.Unsubscribe(r.Context(), r.PathValue("t"), r.PathValue("s")); err != nil {
+		s.fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// fetch returns the subscriber's unacknowledged messages, at most ?max=
+// of them.
+func (s *Server) fetch(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.topics(w)
+	if !ok {
+		return
+	}
+	max := 0
+	if v := r.URL.Query().Get("max"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid max %q", v))
+			return
+		}
+		max = n
+	}
+
+	messages, err := store.Fetch(r.Context(), r.PathValue("t"), r.PathValue("s"), max)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	if messages == nil {
+		messages = []TopicMessage{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"messages": messages})
+}
+
+// ack acknowledges the messages up to and including the body's "id".
+func (s *Server) ack(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.topics(w)
+	if !ok {
+		return
+	}
+	var body struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(&body); err != nil {
+		writeError(w, bodyStatus(err), fmt.Errorf("invalid ack: %w", err))
+		return
+	}
+
+	if err := store.Ack(r.Context(), r.PathValue("t"), r.PathValue("s"), body.ID); err != nil {
+		s.fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,199 @@
+package litedbserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// watchBacklog is how many events the server keeps for clients resuming
+// after a reconnect.
+const watchBacklog = 4096
+
+var errNoWatch = errors.New("store does not support watching")
+
+// Event is a change delivered over /collections/{c}/watch. Token resumes
+// the feed after this event when passed back as ?resume=.
+type Event struct {
+	Type       string    `json:"type"` // "create", "update", "delete" or "reset"
+	Collection string    `json:"collection"`
+	Resource   string    `json:"resource"`
+	Time       time.Time `json:"time"`
+	Token      string    `json:"token"`
+}
+
+// WatchStore is implemented by stores that can stream changes for the
+// watch route. Watch streams events for collection ("" for all) until
+// cancel is called.
+type WatchStore interface {
+	Watch(collection string) (events <-chan Event, cancel func())
+}
+
+var upgrader = websocket.Upgrader{
+	// The feed is read-only; any origin may subscribe, as with the
+	// other GET routes.
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// feed follows every collection in the store, numbers the events and keeps
+// the most recent ones so clients can resume. It starts with the first
+// watch request and runs for the life of the server.
+type feed struct {
+	epoch string
+
+	mutex   sync.Mutex
+	backlog []Event
+	seq     uint64
+	wake    chan struct{}
+}
+
+func (s *Server) feed(store WatchStore) *feed {
+	s.feedOnce.Do(func() {
+		var b [6]byte
+		rand.Read(b[:])
+		f := &feed{epoch: hex.EncodeToString(b[:]), wake: make(chan struct{})}
+
+		events, _ := store.Watch("")
+		go func() {
+			for ev := range events {
+				f.add(ev)
+			}
+		}()
+		s.events = f
+	})
+	return s.events
+}
+
+func (f *feed) add(ev Event) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.seq++
+	ev.Token = f.token(f.seq)
+	f.backlog = append(f.backlog, ev)
+	if len(f.backlog) > watchBacklog {
+		f.backlog = append(f.backlog[:0:0], f.backlog[len(f.backlog)-watchBacklog:]...)
+	}
+
+	close(f.wake)
+	f.wake = make(chan struct{})
+}
+
+// position parses a resume token, reporting false if the events after it
+// are no longer available.
+func (f *feed) position(token string) (uint64, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if token == "" {
+		return f.seq, true
+	}
+
+	epoch, n, _ := strings.Cut(token, ".")
+	seq, err := strconv.ParseUint(n, 10, 64)
+	if err != nil || epoch != f.epoch || seq > f.seq {
+		return f.seq, false
+	}
+	if seq+1 < f.first() {
+		return f.seq, false
+	}
+	return seq, true
+}
+
+// first returns the sequence number of the oldest event in the backlog.
+// The caller must hold mutex.
+func (f *feed) first() uint64 {
+	return f.seq - uint64(len(f.backlog)) + 1
+}
+
+// since returns the events after seq, the new position, and a channel
+// closed when more arrive.
+func (f *feed) since(seq uint64) ([]Event, uint64, <-chan struct{}) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if seq >= f.seq {
+		return nil, f.seq, f.wake
+	}
+	start := len(f.backlog) - int(f.seq-seq)
+	if start < 0 {
+		start = 0
+	}
+	return append([]Event(nil), f.backlog[start:]...), f.seq, f.wake
+}
+
+// watch upgrades to a WebSocket and sends the collection's events as JSON
+// text messages. A client reconnecting with ?resume=<token> first receives
+// the events it missed; if they are no longer available it receives
+// an event of type "reset" and should reload the collection.
+func (s *Server) watch(w http.ResponseWriter, r *http.Request) {
+	store, ok := s.store.(WatchStore)
+	if !ok {
+		writeError(w, http.StatusNotFound, errNoWatch)
+		return
+	}
+	f := s.feed(store)
+	collection := r.PathValue("c")
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied.
+		return
+	}
+	defer conn.Close()
+
+	// The read loop handles pings and closes; clients send nothing else.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	seq, ok := f.position(r.URL.Query().Get("resume"))
+	if !ok {
+		if err := conn.WriteJSON(Event{Type: "reset", Collection: collection, Time: time.Now(), Token: f.token(seq)}); err != nil {
+			return
+		}
+	}
+
+	ping := time.NewTicker(30 * time.Second)
+	defer ping.Stop()
+	for {
+		events, next, wake := f.since(seq)
+		for _, ev := range events {
+			if ev.Collection != collection {
+				continue
+			}
+			if err := conn.WriteJSON(ev); err != nil {
+				return
+			}
+		}
+		seq = next
+
+		select {
+		case <-wake:
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+func (f *feed) token(seq uint64) string {
+	return fmt.Sprintf("%s.%d", f.epoch, seq)
+}
@@ -0,0 +1,270 @@
+// Package litedbsqlite copies collections to and from a SQLite database
+// file, one table per collection.
+//
+// Exported tables have an "id" primary key holding the resource name, a
+// "data" column holding the JSON document, and a generated column for
+// each top-level field so SQLite tools can query the documents directly:
+//
+//	CREATE TABLE "users" (
+//	    "id" TEXT PRIMARY KEY,
+//	    "data" TEXT NOT NULL,
+//	    "name" GENERATED ALWAYS AS (json_extract("data", '$."name"')) VIRTUAL,
+//	    ...
+//	)
+//
+// The package uses github.com/mattn/go-sqlite3 and so needs cgo. It is
+// kept out of the main package so embedding LiteDB does not.
+package litedbsqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Store is the part of the driver the bridge needs; *Driver satisfies it.
+type Store interface {
+	Collections() ([]string, error)
+	Keys(collection string) ([]string, error)
+	Read(collection, resource string, v interface{}) error
+	Write(collection, resource string, v interface{}) error
+}
+
+// Export writes collections, or every collection when none are given,
+// into the SQLite file at path, creating it if needed and replacing any
+// tables of the same name.
+func Export(store Store, path string, collections ...string) error {
+	if len(collections) == 0 {
+		var err error
+		if collections, err = store.Collections(); err != nil {
+			return err
+		}
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, collection := range collections {
+		if err := exportCollection(store, tx, collection); err != nil {
+			return fmt.Errorf("exporting collection '%s': %w", collection, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func exportCollection(store Store, tx *sql.Tx, collection string) error {
+	keys, err := store.Keys(collection)
+	if err != nil {
+		return err
+	}
+
+	docs := make([]json.RawMessage, 0, len(keys))
+	seen := map[string]bool{"id": true, "data": true}
+	var fields []string
+	for _, resource := range keys {
+		var doc json.RawMessage
+		if err := store.Read(collection, resource, &doc); err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(doc, &obj) == nil {
+			for field := range obj {
+				if !seen[strings.ToLower(field)] {
+					seen[strings.ToLower(field)] = true
+					fields = append(fields, field)
+				}
+			}
+		}
+	}
+	sort.Strings(fields)
+
+	table := quoteIdent(collection)
+	columns := []string{`"id" TEXT PRIMARY KEY`, `"data" TEXT NOT NULL`}
+	for _, field := range fields {
+		path := "'$." + strings.ReplaceAll(quoteIdent(field), "'", "''") + "'"
+		columns = append(columns, fmt.Sprintf(`%s GENERATED ALWAYS AS (json_extract("data", %s)) VIRTUAL`, quoteIdent(field), path))
+	}
+
+	if _, err := tx.Exec("DROP TABLE IF EXISTS " + table); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(fmt.Sprintf("CREATE TABLE %s (%s)", table, strings.Join(columns, ", "))); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(fmt.Sprintf(`INSERT INTO %s ("id", "data") VALUES (?, ?)`, table))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i, resource := range keys {
+		if _, err := stmt.Exec(resource, string(docs[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Import writes the rows of tables, or of every table when none are
+// given, into the collection of the same name and returns how many
+// records were written. Tables with a "data" column, as written by
+// Export, are imported from it; for other tables each row becomes a
+// document of its columns. Rows are keyed by an "id" column if there is
+// one and by rowid otherwise.
+func Import(store Store, path string, tables ...string) (int, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	if len(tables) == 0 {
+		if tables, err = listTables(db); err != nil {
+			return 0, err
+		}
+	}
+
+	total := 0
+	for _, table := range tables {
+		n, err := importTable(store, db, table)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("importing table '%s': %w", table, err)
+		}
+	}
+	return total, nil
+}
+
+func listTables(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+func importTable(store Store, db *sql.DB, table string) (int, error) {
+	columns, err := tableColumns(db, table)
+	if err != nil {
+		return 0, err
+	}
+
+	key := `rowid`
+	if columns["id"] {
+		key = `"id"`
+	}
+
+	query := fmt.Sprintf(`SELECT %s, "data" FROM %s`, key, quoteIdent(table))
+	raw := columns["data"]
+	if !raw {
+		query = fmt.Sprintf(`SELECT %s, * FROM %s`, key, quoteIdent(table))
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	values := make([]interface{}, len(names))
+	ptrs := make([]interface{}, len(names))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return n, err
+		}
+		resource := fmt.Sprint(text(values[0]))
+
+		var doc interface{}
+		if raw {
+			b, _ := text(values[1]).(string)
+			if !json.Valid([]byte(b)) {
+				return n, fmt.Errorf("row '%s' has invalid JSON data", resource)
+			}
+			doc = json.RawMessage(b)
+		} else {
+			obj := make(map[string]interface{}, len(names)-1)
+			for i, name := range names[1:] {
+				if name != "id" {
+					obj[name] = text(values[i+1])
+				}
+			}
+			doc = obj
+		}
+
+		if err := store.Write(table, resource, doc); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, rows.Err()
+}
+
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(`SELECT name FROM pragma_table_xinfo(?)`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns[strings.ToLower(name)] = true
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no such table")
+	}
+	return columns, rows.Err()
+}
+
+// text turns the []byte SQLite returns for TEXT and BLOB columns into a
+// string.
+func text(v interface{}) interface{} {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
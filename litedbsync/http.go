@@ -0,0 +1,114 @@
+package litedbsync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Handler serves a peer to remote Clients:
+//
+//	GET  /entries   list entries
+//	POST /fetch     entries with data, for the keys in the body
+//	POST /apply     store the entries in the body
+func Handler(p Peer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /entries", func(w http.ResponseWriter, r *http.Request) {
+		entries, err := p.Entries(r.Context())
+		reply(w, entries, err)
+	})
+	mux.HandleFunc("POST /fetch", func(w http.ResponseWriter, r *http.Request) {
+		var keys []Entry
+		if err := json.NewDecoder(r.Body).Decode(&keys); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		entries, err := p.Fetch(r.Context(), keys)
+		reply(w, entries, err)
+	})
+	mux.HandleFunc("POST /apply", func(w http.ResponseWriter, r *http.Request) {
+		var entries []Entry
+		if err := json.NewDecoder(r.Body).Decode(&entries); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		reply(w, struct{}{}, p.Apply(r.Context(), entries))
+	})
+	return mux
+}
+
+func reply(w http.ResponseWriter, v interface{}, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// Client is a remote peer served by Handler.
+type Client struct {
+	url    string
+	client *http.Client
+}
+
+// NewClient returns a peer for the Handler at url. A nil client uses
+// http.DefaultClient.
+func NewClient(url string, client *http.Client) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{url: strings.TrimRight(url, "/"), client: client}
+}
+
+func (c *Client) Entries(ctx context.Context) ([]Entry, error) {
+	var entries []Entry
+	return entries, c.do(ctx, http.MethodGet, "/entries", nil, &entries)
+}
+
+func (c *Client) Fetch(ctx context.Context, keys []Entry) ([]Entry, error) {
+	var entries []Entry
+	return entries, c.do(ctx, http.MethodPost, "/fetch", keys, &entries)
+}
+
+func (c *Client) Apply(ctx context.Context, entries []Entry) error {
+	return c.do(ctx, http.MethodPost, "/apply", entries, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("sync peer returned %s: %s", res.Status, strings.TrimSpace(string(b)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -0,0 +1,271 @@
+package litedbsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// Store is the part of the driver a Local peer needs.
+type Store interface {
+	Collections() ([]string, error)
+	Keys(collection string) ([]string, error)
+	Get(ctx context.Context, collection, resource string) (json.RawMessage, error)
+	Put(ctx context.Context, collection, resource string, doc json.RawMessage) error
+	Delete(ctx context.Context, collection, resource string) error
+
+	// Modified reports when a record last changed.
+	Modified(collection, resource string) (time.Time, error)
+}
+
+// Local is a database on this machine. Its node ID and record versions
+// are kept in a state file; changes made to the database between syncs
+// are found by comparing checksums against it.
+type Local struct {
+	store Store
+	path  string
+
+	mutex sync.Mutex
+}
+
+func NewLocal(store Store, statePath string) *Local {
+	return &Local{store: store, path: statePath}
+}
+
+type state struct {
+	Node    string                       `json:"node"`
+	Records map[string]map[string]*entry `json:"records"`
+}
+
+type entry struct {
+	Vector  Vector    `json:"vector"`
+	Time    time.Time `json:"time"`
+	Sum     string    `json:"sum,omitempty"`
+	Deleted bool      `json:"deleted,omitempty"`
+}
+
+// Node returns this database's node ID, creating it on first use.
+func (l *Local) Node() (string, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	st, err := l.load()
+	if err != nil {
+		return "", err
+	}
+	return st.Node, l.save(st)
+}
+
+func (l *Local) Entries(ctx context.Context) ([]Entry, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	st, err := l.refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for collection, records := range st.Records {
+		for resource, e := range records {
+			entries = append(entries, Entry{Collection: collection, Resource: resource, Vector: e.Vector, Time: e.Time, Deleted: e.Deleted})
+		}
+	}
+	return entries, nil
+}
+
+func (l *Local) Fetch(ctx context.Context, keys []Entry) ([]Entry, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	st, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, len(keys))
+	for i, k := range keys {
+		e := st.Records[k.Collection][k.Resource]
+		if e == nil {
+			return nil, fmt.Errorf("no version of '%s' in collection '%s'", k.Resource, k.Collection)
+		}
+
+		entries[i] = Entry{Collection: k.Collection, Resource: k.Resource, Vector: e.Vector, Time: e.Time, Deleted: e.Deleted}
+		if e.Deleted {
+			continue
+		}
+		if entries[i].Data, err = l.store.Get(ctx, k.Collection, k.Resource); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func (l *Local) Apply(ctx context.Context, entries []Entry) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	st, err := l.load()
+	if err != nil {
+		return err
+	}
+
+	// Save what was applied even if an entry fails, or the next sync
+	// would mistake the applied changes for local ones.
+	var applyErr error
+	for _, in := range entries {
+		e := &entry{Vector: in.Vector, Time: in.Time, Deleted: in.Deleted}
+		if in.Deleted {
+			err = l.store.Delete(ctx, in.Collection, in.Resource)
+			if errors.Is(err, fs.ErrNotExist) {
+				err = nil
+			}
+		} else {
+			e.Sum = sum(in.Data)
+			err = l.store.Put(ctx, in.Collection, in.Resource, in.Data)
+		}
+		if err != nil {
+			applyErr = err
+			break
+		}
+		st.set(in.Collection, in.Resource, e)
+	}
+
+	if err := l.save(st); err != nil {
+		return err
+	}
+	return applyErr
+}
+
+// refresh brings the state up to date with the database, bumping this
+// node's counter for every record changed, created or deleted since the
+// last sync. The caller must hold mutex.
+func (l *Local) refresh(ctx context.Context) (*state, error) {
+	st, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+
+	collections, err := l.store.Collections()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]map[string]bool)
+	for _, collection := range collections {
+		keys, err := l.store.Keys(collection)
+		if err != nil {
+			return nil, err
+		}
+		seen[collection] = make(map[string]bool, len(keys))
+
+		for _, resource := range keys {
+			doc, err := l.store.Get(ctx, collection, resource)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			seen[collection][resource] = true
+
+			s := sum(doc)
+			e := st.Records[collection][resource]
+			if e != nil && !e.Deleted && e.Sum == s {
+				continue
+			}
+
+			modified, err := l.store.Modified(collection, resource)
+			if err != nil {
+				return nil, err
+			}
+			st.set(collection, resource, st.bump(e, modified, s, false))
+		}
+	}
+
+	now := time.Now()
+	for collection, records := range st.Records {
+		for resource, e := range records {
+			if !e.Deleted && !seen[collection][resource] {
+				records[resource] = st.bump(e, now, "", true)
+			}
+		}
+	}
+
+	return st, l.save(st)
+}
+
+func (st *state) bump(e *entry, at time.Time, sum string, deleted bool) *entry {
+	vector := Vector{}
+	if e != nil {
+		vector = e.Vector.Merge(nil)
+	}
+	vector[st.Node]++
+	return &entry{Vector: vector, Time: at, Sum: sum, Deleted: deleted}
+}
+
+func (st *state) set(collection, resource string, e *entry) {
+	if st.Records[collection] == nil {
+		st.Records[collection] = make(map[string]*entry)
+	}
+	st.Records[collection][resource] = e
+}
+
+func (l *Local) load() (*state, error) {
+	st := &state{Records: make(map[string]map[string]*entry)}
+
+	b, err := os.ReadFile(l.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(b, st); err != nil {
+			return nil, fmt.Errorf("sync state %s: %w", l.path, err)
+		}
+		if st.Records == nil {
+			st.Records = make(map[string]map[string]*entry)
+		}
+	}
+
+	if st.Node == "" {
+		var b [8]byte
+		rand.Read(b[:])
+		st.Node = hex.EncodeToString(b[:])
+	}
+	return st, nil
+}
+
+func (l *Local) save(st *state) error {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	tempPath := l.path + ".tmp"
+	if err := os.WriteFile(tempPath, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, l.path)
+}
+
+// sum checksums a document ignoring insignificant whitespace, so a record
+// reads back with the same sum however it was formatted on disk.
+func sum(doc json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, doc); err != nil {
+		buf.Reset()
+		buf.Write(doc)
+	}
+	h := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,220 @@
+// Package litedbsync keeps two LiteDB databases in step when both take
+// writes, such as a laptop that works offline and a server.
+//
+// Each side tracks a version vector per record: a counter per node that
+// has changed it. Sync compares the vectors to decide which side has the
+// newer copy of each record; where both changed a record since they last
+// met, a Resolver picks the outcome. Deletes are kept as tombstones so
+// they propagate instead of the record being copied back.
+package litedbsync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Peer is one side of a sync: a Local database or a Client for a remote
+// one.
+type Peer interface {
+	// Entries lists every record and tombstone with its version, without
+	// data.
+	Entries(ctx context.Context) ([]Entry, error)
+
+	// Fetch returns the given entries with their data.
+	Fetch(ctx context.Context, keys []Entry) ([]Entry, error)
+
+	// Apply stores entries received from the other side, along with their
+	// versions.
+	Apply(ctx context.Context, entries []Entry) error
+}
+
+// Entry is a record, or the tombstone of a deleted one, at some version.
+type Entry struct {
+	Collection string          `json:"collection"`
+	Resource   string          `json:"resource"`
+	Vector     Vector          `json:"vector"`
+	Time       time.Time       `json:"time"`
+	Deleted    bool            `json:"deleted,omitempty"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// Conflict is a record both sides changed since they last synced.
+type Conflict struct {
+	Collection, Resource string
+	Local, Remote        Entry
+}
+
+// A Resolver settles a conflict by returning the entry both sides should
+// keep. Only its Deleted and Data fields are used.
+type Resolver func(Conflict) (Entry, error)
+
+// LastWriteWins keeps whichever side changed the record most recently.
+func LastWriteWins(c Conflict) (Entry, error) {
+	if c.Remote.Time.After(c.Local.Time) {
+		return c.Remote, nil
+	}
+	return c.Local, nil
+}
+
+// Report summarizes a sync.
+type Report struct {
+	Pushed    int // entries sent from local to remote
+	Pulled    int // entries sent from remote to local
+	Conflicts int
+}
+
+// Sync exchanges changes between local and remote so both end with the
+// same records. A nil resolve uses LastWriteWins.
+func Sync(ctx context.Context, local, remote Peer, resolve Resolver) (*Report, error) {
+	if resolve == nil {
+		resolve = LastWriteWins
+	}
+
+	localEntries, err := local.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	remoteEntries, err := remote.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	theirs := make(map[key]Entry, len(remoteEntries))
+	for _, e := range remoteEntries {
+		theirs[keyOf(e)] = e
+	}
+
+	var push, pull, conflicts []Entry
+	for _, mine := range localEntries {
+		k := keyOf(mine)
+		other, ok := theirs[k]
+		delete(theirs, k)
+
+		if !ok {
+			push = append(push, mine)
+			continue
+		}
+		switch mine.Vector.Compare(other.Vector) {
+		case After:
+			push = append(push, mine)
+		case Before:
+			pull = append(pull, other)
+		case Concurrent:
+			conflicts = append(conflicts, mine)
+		}
+	}
+	for _, e := range remoteEntries {
+		if _, ok := theirs[keyOf(e)]; ok {
+			pull = append(pull, e)
+		}
+	}
+
+	if push, err = fetch(ctx, local, push); err != nil {
+		return nil, err
+	}
+	if pull, err = fetch(ctx, remote, pull); err != nil {
+		return nil, err
+	}
+
+	report := &Report{Conflicts: len(conflicts)}
+	if len(conflicts) > 0 {
+		mine, err := local.Fetch(ctx, conflicts)
+		if err != nil {
+			return nil, err
+		}
+		other, err := remote.Fetch(ctx, conflicts)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range mine {
+			resolved, err := settle(mine[i], other[i], resolve)
+			if err != nil {
+				return nil, err
+			}
+			push = append(push, resolved)
+			pull = append(pull, resolved)
+		}
+	}
+
+	if err := remote.Apply(ctx, push); err != nil {
+		return nil, err
+	}
+	report.Pushed = len(push) - report.Conflicts
+	if err := local.Apply(ctx, pull); err != nil {
+		return nil, err
+	}
+	report.Pulled = len(pull) - report.Conflicts
+
+	return report, nil
+}
+
+func fetch(ctx context.Context, p Peer, entries []Entry) ([]Entry, error) {
+	var wanted []Entry
+	for _, e := range entries {
+		if !e.Deleted {
+			wanted = append(wanted, e)
+		}
+	}
+	if len(wanted) == 0 {
+		return entries, nil
+	}
+
+	fetched, err := p.Fetch(ctx, wanted)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[key]Entry, len(fetched))
+	for _, e := range fetched {
+		data[keyOf(e)] = e
+	}
+
+	out := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if !e.Deleted {
+			e = data[keyOf(e)]
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// settle resolves a conflict into an entry whose vector supersedes both
+// sides, so neither sees it as a conflict again.
+func settle(mine, other Entry, resolve Resolver) (Entry, error) {
+	resolved := mine
+	if mine.Deleted != other.Deleted || !sameData(mine.Data, other.Data) {
+		chosen, err := resolve(Conflict{Collection: mine.Collection, Resource: mine.Resource, Local: mine, Remote: other})
+		if err != nil {
+			return Entry{}, err
+		}
+		resolved.Deleted, resolved.Data = chosen.Deleted, chosen.Data
+	}
+
+	resolved.Vector = mine.Vector.Merge(other.Vector)
+	if other.Time.After(resolved.Time) {
+		resolved.Time = other.Time
+	}
+	if resolved.Deleted {
+		resolved.Data = nil
+	}
+	return resolved, nil
+}
+
+func sameData(a, b json.RawMessage) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+type key struct {
+	collection, resource string
+}
+
+func keyOf(e Entry) key {
+	return key{e.Collection, e.Resource}
+}
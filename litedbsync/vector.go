@@ -0,0 +1,55 @@
+package litedbsync
+
+// Vector is a version vector: how many changes each node has made to a
+// record.
+type Vector map[string]uint64
+
+// Order is how two vectors relate.
+type Order int
+
+const (
+	Equal      Order = iota
+	Before           // every counter is at most the other's
+	After            // every counter is at least the other's
+	Concurrent       // each has changes the other lacks
+)
+
+func (v Vector) Compare(other Vector) Order {
+	less, greater := false, false
+	for node, n := range v {
+		if n > other[node] {
+			greater = true
+		} else if n < other[node] {
+			less = true
+		}
+	}
+	for node, n := range other {
+		if _, ok := v[node]; !ok && n > 0 {
+			less = true
+		}
+	}
+
+	switch {
+	case less && greater:
+		return Concurrent
+	case less:
+		return Before
+	case greater:
+		return After
+	}
+	return Equal
+}
+
+// Merge returns the counter-wise maximum of both vectors.
+func (v Vector) Merge(other Vector) Vector {
+	merged := make(Vector, len(v))
+	for node, n := range v {
+		merged[node] = n
+	}
+	for node, n := range other {
+		if n > merged[node] {
+			merged[node] = n
+		}
+	}
+	return merged
+}
@@ -0,0 +1,51 @@
+package litedbtest
+
+import (
+	"sync"
+	"time"
+
+	litedb "github.com/SagarDas211/LiteDB-Go"
+)
+
+// Clock is a litedb.Clock that only moves when told to, so tests of TTLs,
+// retention and archiving can skip ahead instead of sleeping:
+//
+//	clock := litedbtest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	db, err := litedb.New(dir, litedb.WithClock(clock))
+//	db.WriteWithTTL("sessions", "s1", session, time.Hour)
+//	clock.Advance(2 * time.Hour)
+//	// db.Read("sessions", "s1", &session) now fails with ErrNotFound.
+//
+// It is safe for concurrent use.
+type Clock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+var _ litedb.Clock = (*Clock)(nil)
+
+// NewClock returns a Clock reading start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now implements litedb.Clock.
+func (c *Clock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to t, which may be earlier than it reads now.
+func (c *Clock) Set(t time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = t
+}
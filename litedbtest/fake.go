@@ -0,0 +1,296 @@
+// Package litedbtest helps applications test code that uses LiteDB. Fake
+// keeps collections in memory, so unit tests need no directory, and the
+// helpers Seed, AssertRecord and Snapshot work with a Fake or a real
+// *litedb.Driver alike.
+//
+//	db := litedbtest.New()
+//	litedbtest.Seed(t, db, "users", map[string]interface{}{
+//		"ada": User{Name: "Ada"},
+//	})
+//	rename(db, "ada", "Ada Lovelace")
+//	litedbtest.AssertRecord(t, db, "users", "ada", User{Name: "Ada Lovelace"})
+package litedbtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	litedb "github.com/SagarDas211/LiteDB-Go"
+)
+
+// Fake is an in-memory database implementing litedb.Reader and
+// litedb.Writer. Documents are stored as the driver would store them,
+// and missing records and collections give errors matching the same
+// sentinels. It has no TTLs, hooks, indexes or background work. It is
+// safe for concurrent use; the zero value is an empty database.
+type Fake struct {
+	mutex       sync.RWMutex
+	collections map[string]map[string][]byte
+}
+
+var (
+	_ litedb.Reader = (*Fake)(nil)
+	_ litedb.Writer = (*Fake)(nil)
+)
+
+// New returns an empty Fake.
+func New() *Fake {
+	return &Fake{}
+}
+
+// Write stores v, encoded as JSON, as resource in collection, replacing
+// any record of that name.
+func (f *Fake) Write(collection, resource string, v interface{}) error {
+	return f.WriteContext(context.Background(), collection, resource, v)
+}
+
+// WriteContext is Write with a context.
+func (f *Fake) WriteContext(ctx context.Context, collection, resource string, v interface{}) error {
+	if err := checkNames(collection, resource); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.collections == nil {
+		f.collections = make(map[string]map[string][]byte)
+	}
+	records, ok := f.collections[collection]
+	if !ok {
+		records = make(map[string][]byte)
+		f.collections[collection] = records
+	}
+	records[resource] = b
+	return nil
+}
+
+// Read decodes resource in collection into v. It returns an error
+// matching litedb.ErrNotFound if there is no such record.
+func (f *Fake) Read(collection, resource string, v interface{}) error {
+	return f.ReadContext(context.Background(), collection, resource, v)
+}
+
+// ReadContext is Read with a context.
+func (f *Fake) ReadContext(ctx context.Context, collection, resource string, v interface{}) error {
+	b, err := f.readBytes(ctx, collection, resource)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// ReadBytes returns resource in collection as the JSON stored.
+func (f *Fake) ReadBytes(collection, resource string) ([]byte, error) {
+	return f.readBytes(context.Background(), collection, resource)
+}
+
+func (f *Fake) readBytes(ctx context.Context, collection, resource string) ([]byte, error) {
+	if err := checkNames(collection, resource); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	b, ok := f.collections[collection][resource]
+	if !ok {
+		return nil, notFound(collection, resource)
+	}
+	return append([]byte(nil), b...), nil
+}
+
+// ReadAll returns every document in collection, ordered by resource
+// name. It returns an error matching litedb.ErrCollectionNotFound if
+// there is no such collection.
+func (f *Fake) ReadAll(collection string) ([]string, error) {
+	return f.ReadAllContext(context.Background(), collection)
+}
+
+// ReadAllContext is ReadAll with a context.
+func (f *Fake) ReadAllContext(ctx context.Context, collection string) ([]string, error) {
+	found, err := f.FindContext(ctx, collection, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]string, len(found))
+	for i, rec := range found {
+		records[i] = string(rec.Data)
+	}
+	return records, nil
+}
+
+// Find returns the records in collection matching filter, ordered by
+// resource name. A nil filter matches every record.
+func (f *Fake) Find(collection string, filter litedb.Filter) ([]litedb.Record, error) {
+	return f.FindContext(context.Background(), collection, filter)
+}
+
+// FindContext is Find with a context.
+func (f *Fake) FindContext(ctx context.Context, collection string, filter litedb.Filter) ([]litedb.Record, error) {
+	if err := checkNames(collection, "-"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	records, ok := f.collections[collection]
+	if !ok {
+		return nil, notFound(collection, "")
+	}
+
+	found := []litedb.Record{}
+	for _, resource := range sortedKeys(records) {
+		b := records[resource]
+		ok, err := filter.Match(b)
+		if err != nil {
+			return nil, fmt.Errorf("decoding '%s' in collection '%s': %w", resource, collection, err)
+		}
+		if ok {
+			found = append(found, litedb.Record{ID: resource, Data: append(json.RawMessage(nil), b...)})
+		}
+	}
+	return found, nil
+}
+
+// Delete removes resource from collection, or the whole collection when
+// resource is empty. It returns an error matching litedb.ErrNotFound if
+// there is no such record or collection.
+func (f *Fake) Delete(collection, resource string) error {
+	return f.DeleteContext(context.Background(), collection, resource)
+}
+
+// DeleteContext is Delete with a context.
+func (f *Fake) DeleteContext(ctx context.Context, collection, resource string) error {
+	if err := checkNames(collection, "-"); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	records, ok := f.collections[collection]
+	if !ok {
+		return notFound(collection, resource)
+	}
+	if resource == "" {
+		delete(f.collections, collection)
+		return nil
+	}
+	if _, ok := records[resource]; !ok {
+		return notFound(collection, resource)
+	}
+	delete(records, resource)
+	return nil
+}
+
+// Collections lists the collections, sorted.
+func (f *Fake) Collections() ([]string, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	names := make([]string, 0, len(f.collections))
+	for name := range f.collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Keys lists the resource names in collection, sorted. A missing
+// collection has none.
+func (f *Fake) Keys(collection string) ([]string, error) {
+	if err := checkNames(collection, "-"); err != nil {
+		return nil, err
+	}
+
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return sortedKeys(f.collections[collection]), nil
+}
+
+// Count returns the number of records in collection.
+func (f *Fake) Count(collection string) (int, error) {
+	keys, err := f.Keys(collection)
+	return len(keys), err
+}
+
+// Exists reports whether collection holds a record named resource.
+func (f *Fake) Exists(collection, resource string) (bool, error) {
+	if err := checkNames(collection, resource); err != nil {
+		return false, err
+	}
+
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	_, ok := f.collections[collection][resource]
+	return ok, nil
+}
+
+// checkNames refuses the names the driver refuses whatever its policy.
+func checkNames(collection, resource string) error {
+	if collection == "" {
+		return fmt.Errorf("collection name cannot be empty")
+	}
+	switch {
+	case resource == "":
+		return fmt.Errorf("resource name cannot be empty")
+	case resource == "." || resource == "..":
+		return fmt.Errorf("resource name '%s' is not allowed", resource)
+	case strings.ContainsAny(resource, "/\x00") || strings.ContainsRune(resource, filepath.Separator):
+		return fmt.Errorf("resource name '%s' cannot contain a path separator or NUL", resource)
+	}
+	return nil
+}
+
+// notFoundError reads like the driver's and matches the same sentinels.
+type notFoundError struct {
+	collection, resource string
+}
+
+func notFound(collection, resource string) error {
+	return &notFoundError{collection: collection, resource: resource}
+}
+
+func (e *notFoundError) Error() string {
+	if e.resource == "" {
+		return fmt.Sprintf("collection '%s' does not exist", e.collection)
+	}
+	return fmt.Sprintf("resource '%s' does not exist in collection '%s'", e.resource, e.collection)
+}
+
+func (e *notFoundError) Unwrap() error {
+	if e.resource == "" {
+		return litedb.ErrCollectionNotFound
+	}
+	return litedb.ErrNotFound
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,217 @@
+package litedbtest
+
+import (
+	"io"
+	"io/fs"
+	"math/rand"
+	"sync"
+	"syscall"
+
+	litedb "github.com/SagarDas211/LiteDB-Go"
+)
+
+// Faults sets how often a FaultyStorage fails, as probabilities from 0 to
+// 1 that each operation the fault applies to fails with it.
+type Faults struct {
+	// NoSpace fails making directories, creating files and writing to
+	// them with ENOSPC.
+	NoSpace float64
+
+	// IO fails any operation with EIO, closing and syncing files included.
+	IO float64
+
+	// Permission fails making directories and creating, renaming and
+	// removing files with EACCES.
+	Permission float64
+
+	// PartialWrite makes a write store only the first half of its bytes
+	// and then fail with io.ErrShortWrite, as a full disk or a crash
+	// would leave it.
+	PartialWrite float64
+
+	// Seed seeds the choice of which operations fail, so a run that found
+	// a problem can be repeated.
+	Seed int64
+}
+
+// FaultyStorage is a litedb.Storage that passes operations on to another
+// and makes some of them fail, to test how code copes with a failing disk:
+//
+//	disk := litedbtest.NewFaultyStorage(litedb.DiskStorage{}, litedbtest.Faults{NoSpace: 0.1, Seed: 1})
+//	db, err := litedb.New(dir, litedb.WithStorage(disk))
+//
+// Failures are *fs.PathError values wrapping the errno, so errors.Is
+// matches them against syscall.ENOSPC, syscall.EIO or fs.ErrPermission.
+// It is safe for concurrent use.
+type FaultyStorage struct {
+	base litedb.Storage
+
+	mutex    sync.Mutex
+	faults   Faults
+	rand     *rand.Rand
+	injected int
+}
+
+var _ litedb.Storage = (*FaultyStorage)(nil)
+
+// NewFaultyStorage returns a FaultyStorage wrapping base, or DiskStorage
+// if base is nil, failing as faults sets.
+func NewFaultyStorage(base litedb.Storage, faults Faults) *FaultyStorage {
+	if base == nil {
+		base = litedb.DiskStorage{}
+	}
+	s := &FaultyStorage{base: base}
+	s.SetFaults(faults)
+	return s
+}
+
+// SetFaults replaces the rates of failure, and reseeds, so a test can
+// set a database up before the disk starts failing. The zero Faults turns
+// failures off.
+func (s *FaultyStorage) SetFaults(faults Faults) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults = faults
+	s.rand = rand.New(rand.NewSource(faults.Seed))
+}
+
+// Injected reports how many operations have been made to fail.
+func (s *FaultyStorage) Injected() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.injected
+}
+
+// fault is a kind of failure and the error it fails with.
+type fault int
+
+const (
+	noSpace fault = iota
+	ioError
+	permission
+	partialWrite
+)
+
+// inject decides whether an operation open to the given faults fails, and
+// with which.
+func (s *FaultyStorage) inject(faults ...fault) (fault, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, f := range faults {
+		var rate float64
+		switch f {
+		case noSpace:
+			rate = s.faults.NoSpace
+		case ioError:
+			rate = s.faults.IO
+		case permission:
+			rate = s.faults.Permission
+		case partialWrite:
+			rate = s.faults.PartialWrite
+		}
+		if rate > 0 && s.rand.Float64() < rate {
+			s.injected++
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// fail returns the error of an injected fault for op on path, or nil.
+func (s *FaultyStorage) fail(op, path string, faults ...fault) error {
+	f, ok := s.inject(faults...)
+	if !ok {
+		return nil
+	}
+	return faultError(op, path, f)
+}
+
+func faultError(op, path string, f fault) error {
+	var err error
+	switch f {
+	case noSpace:
+		err = syscall.ENOSPC
+	case ioError:
+		err = syscall.EIO
+	case permission:
+		err = syscall.EACCES
+	case partialWrite:
+		err = io.ErrShortWrite
+	}
+	return &fs.PathError{Op: op, Path: path, Err: err}
+}
+
+// Create implements litedb.Storage.
+func (s *FaultyStorage) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	if err := s.fail("open", name, noSpace, permission, ioError); err != nil {
+		return nil, err
+	}
+	f, err := s.base.Create(name, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &faultyFile{s: s, name: name, f: f}, nil
+}
+
+// Rename implements litedb.Storage.
+func (s *FaultyStorage) Rename(oldpath, newpath string) error {
+	if err := s.fail("rename", oldpath, permission, ioError); err != nil {
+		return err
+	}
+	return s.base.Rename(oldpath, newpath)
+}
+
+// Remove implements litedb.Storage.
+func (s *FaultyStorage) Remove(name string) error {
+	if err := s.fail("remove", name, permission, ioError); err != nil {
+		return err
+	}
+	return s.base.Remove(name)
+}
+
+// MkdirAll implements litedb.Storage.
+func (s *FaultyStorage) MkdirAll(path string, perm fs.FileMode) error {
+	if err := s.fail("mkdir", path, noSpace, permission, ioError); err != nil {
+		return err
+	}
+	return s.base.MkdirAll(path, perm)
+}
+
+// Sync implements litedb.Storage.
+func (s *FaultyStorage) Sync(name string) error {
+	if err := s.fail("sync", name, ioError); err != nil {
+		return err
+	}
+	return s.base.Sync(name)
+}
+
+// faultyFile is a file created through a FaultyStorage.
+type faultyFile struct {
+	s    *FaultyStorage
+	name string
+	f    io.WriteCloser
+}
+
+func (f *faultyFile) Write(p []byte) (int, error) {
+	fault, ok := f.s.inject(noSpace, ioError, partialWrite)
+	if !ok {
+		return f.f.Write(p)
+	}
+	if fault != partialWrite {
+		return 0, faultError("write", f.name, fault)
+	}
+	n, err := f.f.Write(p[:len(p)/2])
+	if err != nil {
+		return n, err
+	}
+	return n, faultError("write", f.name, fault)
+}
+
+func (f *faultyFile) Close() error {
+	err := f.s.fail("close", f.name, ioError)
+	if cerr := f.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,121 @@
+package litedbtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	litedb "github.com/SagarDas211/LiteDB-Go"
+)
+
+// fuzzSeeds are names and documents that have found, or come close to
+// finding, problems in the storage layer: path tricks, reserved names,
+// odd bytes, and documents that are nearly, but not quite, JSON.
+var fuzzSeeds = []struct {
+	collection, resource string
+	doc                  []byte
+}{
+	{"users", "alice", []byte(`{"name":"Alice"}`)},
+	{"users", "alice", []byte("{\n\t\"name\": \"Alice\"\n}\n")},
+	{"users", "", []byte(`{}`)},
+	{"", "alice", []byte(`{}`)},
+	{"users", "..", []byte(`{}`)},
+	{"users", ".", []byte(`{}`)},
+	{"users", "../escape", []byte(`{}`)},
+	{"..", "escape", []byte(`{}`)},
+	{"users/../..", "escape", []byte(`{}`)},
+	{"users", "a/b", []byte(`{}`)},
+	{"users", `a\b`, []byte(`{}`)},
+	{"users", "nul\x00byte", []byte(`{}`)},
+	{"_meta", "alice", []byte(`{}`)},
+	{"users", "alice.json", []byte(`{}`)},
+	{"users", "alice.tmp", []byte(`{}`)},
+	{"users", "CON", []byte(`{}`)},
+	{"users", "trailing. ", []byte(`{}`)},
+	{"users", "emoji 🙂", []byte(`{"emoji":"🙂"}`)},
+	{"users", "alice", []byte(`[1,2,3]`)},
+	{"users", "alice", []byte(`"string"`)},
+	{"users", "alice", []byte(`null`)},
+	{"users", "alice", []byte(`{"a":1`)},
+	{"users", "alice", []byte(`{"a":1}{"b":2}`)},
+	{"users", "alice", []byte("{\"a\":\"\xff\"}")},
+	{"users", "alice", []byte(``)},
+}
+
+// AddFuzzSeeds adds the seed corpus for CheckRoundTrip to f, as
+// FuzzRoundTrip in this package's tests does. Run it with
+//
+//	go test -fuzz=FuzzRoundTrip ./litedbtest
+func AddFuzzSeeds(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed.collection, seed.resource, seed.doc)
+	}
+}
+
+// CheckRoundTrip writes doc as resource in collection of a new database
+// opened with options, reads it back, and deletes it, failing the test if
+// the driver panics, if a document that is not valid JSON is accepted, if
+// an accepted document does not read back byte for byte, if it can still
+// be read once deleted, or if any file is created outside the database
+// directory.
+func CheckRoundTrip(t *testing.T, collection, resource string, doc []byte, options ...litedb.Option) {
+	t.Helper()
+
+	sandbox := t.TempDir()
+	dir := filepath.Join(sandbox, "db")
+	db, err := litedb.New(dir, options...)
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	defer db.Close()
+
+	defer checkSandbox(t, sandbox)
+
+	err = db.WriteFrom(collection, resource, bytes.NewReader(doc))
+	if err != nil {
+		if _, err := db.ReadBytes(collection, resource); err == nil {
+			t.Errorf("'%s' in collection '%s' is readable after a failed write", resource, collection)
+		}
+		return
+	}
+	if !json.Valid(doc) {
+		t.Errorf("'%s' in collection '%s' was written from invalid JSON %q", resource, collection, doc)
+	}
+
+	b, err := db.ReadBytes(collection, resource)
+	if err != nil {
+		t.Fatalf("reading '%s' in collection '%s': %v", resource, collection, err)
+	}
+	if !bytes.Equal(b, doc) {
+		t.Errorf("'%s' in collection '%s' read back %q, wrote %q", resource, collection, b, doc)
+	}
+
+	if err := db.Delete(collection, resource); err != nil {
+		t.Fatalf("deleting '%s' in collection '%s': %v", resource, collection, err)
+	}
+	if _, err := db.ReadBytes(collection, resource); !errors.Is(err, litedb.ErrNotFound) {
+		t.Errorf("reading '%s' in collection '%s' after deleting it: got %v, want ErrNotFound", resource, collection, err)
+	}
+}
+
+// checkSandbox fails the test if anything but the database directory was
+// created in sandbox.
+func checkSandbox(t *testing.T, sandbox string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(sandbox)
+	if err != nil {
+		t.Fatalf("listing sandbox: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "db" {
+			t.Errorf("'%s' was created outside the database directory", entry.Name())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(sandbox, "db")); err != nil {
+		t.Errorf("database directory: %v", err)
+	}
+}
@@ -0,0 +1,14 @@
+package litedbtest_test
+
+import (
+	"testing"
+
+	"github.com/SagarDas211/LiteDB-Go/litedbtest"
+)
+
+func FuzzRoundTrip(f *testing.F) {
+	litedbtest.AddFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, collection, resource string, doc []byte) {
+		litedbtest.CheckRoundTrip(t, collection, resource, doc)
+	})
+}
@@ -0,0 +1,236 @@
+package litedbtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	litedb "github.com/SagarDas211/LiteDB-Go"
+)
+
+// UpdateGoldenEnv is the environment variable that makes AssertGolden
+// write golden files instead of comparing against them:
+//
+//	LITEDB_UPDATE_GOLDEN=1 go test ./...
+const UpdateGoldenEnv = "LITEDB_UPDATE_GOLDEN"
+
+// metadataReader is the part of *litedb.Driver Dump reads metadata with.
+type metadataReader interface {
+	Metadata(collection, resource string) (*litedb.Metadata, error)
+}
+
+// Dump renders every record in db as canonical text for golden files: a
+// "== collection/resource" line per record, in name order, then the
+// document indented with tabs and its object keys sorted, so the same
+// documents always dump the same however they were written. For a
+// *litedb.Driver, a "# " line after the name gives the record's version,
+// tags and expiry; use a Clock to keep expiries the same from run to run.
+func Dump(db litedb.Reader) (string, error) {
+	collections, err := db.Collections()
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(collections)
+	meta, _ := db.(metadataReader)
+
+	var out strings.Builder
+	for _, collection := range collections {
+		keys, err := db.Keys(collection)
+		if err != nil {
+			return "", err
+		}
+		sort.Strings(keys)
+
+		for _, resource := range keys {
+			b, err := db.ReadBytes(collection, resource)
+			if err != nil {
+				return "", fmt.Errorf("reading '%s' in collection '%s': %w", resource, collection, err)
+			}
+			doc, err := canonical(b)
+			if err != nil {
+				return "", fmt.Errorf("reading '%s' in collection '%s': %w", resource, collection, err)
+			}
+
+			fmt.Fprintf(&out, "== %s/%s\n", collection, resource)
+			if meta != nil {
+				m, err := meta.Metadata(collection, resource)
+				if err != nil {
+					return "", err
+				}
+				if line := metadataLine(m); line != "" {
+					fmt.Fprintf(&out, "# %s\n", line)
+				}
+			}
+			out.Write(doc)
+		}
+	}
+	return out.String(), nil
+}
+
+// DumpDir is Dump of the database in dir, opened read-only with options
+// so that dumping it changes nothing. It may be open in a Driver at the
+// same time, as long as no write is under way. Pass the Clock the records
+// were written with, or those with a TTL may already have expired.
+func DumpDir(dir string, options ...litedb.Option) (string, error) {
+	db, err := litedb.OpenReadOnly(dir, options...)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+	return Dump(db)
+}
+
+// canonical re-encodes a document with sorted keys and tab indents.
+func canonical(b []byte) ([]byte, error) {
+	v, err := decode(b)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func metadataLine(m *litedb.Metadata) string {
+	if m == nil {
+		return ""
+	}
+	parts := []string{fmt.Sprintf("version %d", m.Version)}
+	if len(m.Tags) > 0 {
+		tags := append([]string(nil), m.Tags...)
+		sort.Strings(tags)
+		parts = append(parts, "tags "+strings.Join(tags, ","))
+	}
+	if m.ExpiresAt != nil {
+		parts = append(parts, "expires "+m.ExpiresAt.UTC().Format(time.RFC3339Nano))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// AssertGolden fails the test unless got matches the golden file at path,
+// showing the lines that differ. With UpdateGoldenEnv set, it writes got
+// to path instead, making its directory if needed.
+func AssertGolden(t testing.TB, path, got string) {
+	t.Helper()
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("updating golden file: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("updating golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %v (set %s=1 to create it)", err, UpdateGoldenEnv)
+	}
+	if string(want) == got {
+		return
+	}
+	t.Errorf("database differs from golden file %s (set %s=1 to update it):\n%s", path, UpdateGoldenEnv, lineDiff(string(want), got))
+}
+
+// AssertGoldenDir dumps the database in dir with DumpDir and compares it
+// with the golden file at path, as AssertGolden does.
+func AssertGoldenDir(t testing.TB, dir, path string, options ...litedb.Option) {
+	t.Helper()
+
+	got, err := DumpDir(dir, options...)
+	if err != nil {
+		t.Fatalf("dumping database: %v", err)
+	}
+	AssertGolden(t, path, got)
+}
+
+// maxDiffLines caps the lines lineDiff shows.
+const maxDiffLines = 60
+
+// lineDiff shows the lines of want and got that differ, marked - and +,
+// with a line of context either side. Inputs too long to compare line by
+// line only show where they start to differ.
+func lineDiff(want, got string) string {
+	a, b := strings.Split(want, "\n"), strings.Split(got, "\n")
+	if len(a)*len(b) > 4<<20 {
+		i := 0
+		for i < len(a) && i < len(b) && a[i] == b[i] {
+			i++
+		}
+		return fmt.Sprintf("first difference at line %d", i+1)
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type line struct {
+		mark byte
+		text string
+	}
+	var lines []line
+	for i, j := 0, 0; i < len(a) || j < len(b); {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, line{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, line{'+', b[j]})
+			j++
+		}
+	}
+
+	var out strings.Builder
+	shown, skipped := 0, false
+	for k, l := range lines {
+		near := l.mark != ' ' ||
+			(k > 0 && lines[k-1].mark != ' ') ||
+			(k+1 < len(lines) && lines[k+1].mark != ' ')
+		if !near {
+			skipped = true
+			continue
+		}
+		if skipped && shown > 0 {
+			out.WriteString("  ...\n")
+		}
+		skipped = false
+		if shown == maxDiffLines {
+			out.WriteString("  ...\n")
+			break
+		}
+		fmt.Fprintf(&out, "%c %s\n", l.mark, l.text)
+		shown++
+	}
+	return out.String()
+}
@@ -0,0 +1,91 @@
+package litedbtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	litedb "github.com/SagarDas211/LiteDB-Go"
+)
+
+// Seed writes records, keyed by resource name, to collection, failing the
+// test on any error.
+func Seed(t testing.TB, db litedb.Writer, collection string, records map[string]interface{}) {
+	t.Helper()
+	for resource, v := range records {
+		if err := db.Write(collection, resource, v); err != nil {
+			t.Fatalf("seeding '%s' in collection '%s': %v", resource, collection, err)
+		}
+	}
+}
+
+// AssertRecord fails the test unless resource in collection exists and
+// holds the same JSON as want encodes to. Formatting and the order of
+// object keys do not matter.
+func AssertRecord(t testing.TB, db litedb.Reader, collection, resource string, want interface{}) {
+	t.Helper()
+
+	b, err := db.ReadBytes(collection, resource)
+	if err != nil {
+		t.Errorf("reading '%s' in collection '%s': %v", resource, collection, err)
+		return
+	}
+	wantJSON, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("encoding expected '%s' in collection '%s': %v", resource, collection, err)
+	}
+
+	got, err := decode(b)
+	if err != nil {
+		t.Errorf("decoding '%s' in collection '%s': %v", resource, collection, err)
+		return
+	}
+	expected, _ := decode(wantJSON)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("record '%s' in collection '%s' is\n\t%s\nwant\n\t%s", resource, collection, compact(b), wantJSON)
+	}
+}
+
+// Snapshot returns every record in db, by collection and resource name,
+// as compact JSON. Snapshots of databases holding the same documents are
+// equal, so a test can compare the state before and after an operation,
+// or a Fake against a real Driver, with reflect.DeepEqual.
+func Snapshot(t testing.TB, db litedb.Reader) map[string]map[string]string {
+	t.Helper()
+
+	collections, err := db.Collections()
+	if err != nil {
+		t.Fatalf("listing collections: %v", err)
+	}
+
+	snapshot := make(map[string]map[string]string, len(collections))
+	for _, collection := range collections {
+		found, err := db.Find(collection, nil)
+		if err != nil {
+			t.Fatalf("reading collection '%s': %v", collection, err)
+		}
+		records := make(map[string]string, len(found))
+		for _, rec := range found {
+			records[rec.ID] = compact(rec.Data)
+		}
+		snapshot[collection] = records
+	}
+	return snapshot
+}
+
+func decode(b []byte) (interface{}, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	err := dec.Decode(&v)
+	return v, err
+}
+
+func compact(b []byte) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, b); err != nil {
+		return string(b)
+	}
+	return buf.String()
+}
@@ -0,0 +1,112 @@
+package litedb
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// lockShards is the number of shards in a lockTable. Collections hash to a
+// shard, so creating or finding one collection's mutex only contends with
+// collections in the same shard.
+const lockShards = 64
+
+type lockShard struct {
+	mutex   sync.Mutex
+	entries map[string]*lockEntry
+}
+
+// lockEntry is a collection's mutex and the counts of its acquisitions,
+// kept as atomics so that taking the lock touches no shared state.
+type lockEntry struct {
+	sync.Mutex
+
+	// ns and collection name the lock's owner, since namespace views
+	// share the table.
+	ns, collection string
+
+	acquires  atomic.Uint64
+	contended atomic.Uint64
+	wait      atomic.Int64
+}
+
+// lockTable holds the per-collection mutexes.
+type lockTable struct {
+	shards [lockShards]lockShard
+}
+
+func (t *lockTable) shard(collection string) *lockShard {
+	return &t.shards[shardOf(collection)]
+}
+
+// shardOf returns the index of collection's shard.
+func shardOf(collection string) int {
+	// FNV-1a, inlined to avoid allocating a hash.Hash per lock.
+	h := uint32(2166136261)
+	for i := 0; i < len(collection); i++ {
+		h ^= uint32(collection[i])
+		h *= 16777619
+	}
+	return int(h % lockShards)
+}
+
+// get returns the entry for collection in namespace ns, creating it if
+// needed.
+func (t *lockTable) get(ns, collection string) *lockEntry {
+	key := ns + collection
+	s := t.shard(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		if s.entries == nil {
+			s.entries = make(map[string]*lockEntry)
+		}
+		e = &lockEntry{ns: ns, collection: collection}
+		s.entries[key] = e
+	}
+	return e
+}
+
+// snapshot copies the table, giving up on shards still locked at
+// deadline. It reports false if a shard could not be locked in time.
+func (t *lockTable) snapshot(deadline time.Time) (map[string]*lockEntry, bool) {
+	entries := make(map[string]*lockEntry)
+	for i := range t.shards {
+		s := &t.shards[i]
+		if !tryLockUntil(&s.mutex, deadline) {
+			return nil, false
+		}
+		for key, e := range s.entries {
+			entries[key] = e
+		}
+		s.mutex.Unlock()
+	}
+	return entries, true
+}
+
+// contention returns the counts of namespace ns's locks that have ever
+// been waited for.
+func (t *lockTable) contention(ns string) []LockStats {
+	var locks []LockStats
+	for i := range t.shards {
+		s := &t.shards[i]
+		s.mutex.Lock()
+		for _, e := range s.entries {
+			if e.ns != ns {
+				continue
+			}
+			if contended := e.contended.Load(); contended > 0 {
+				locks = append(locks, LockStats{
+					Collection: e.collection,
+					Acquires:   e.acquires.Load(),
+					Contended:  contended,
+					WaitTime:   time.Duration(e.wait.Load()),
+				})
+			}
+		}
+		s.mutex.Unlock()
+	}
+	return locks
+}
@@ -0,0 +1,115 @@
+package litedb
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// loggerHandler adapts a printf-style Logger to slog so the driver can log
+// structured fields however it was configured. Fields are rendered as
+// key=value pairs after the message.
+type loggerHandler struct {
+	l      Logger
+	prefix string
+	attrs  []slog.Attr
+}
+
+func newLoggerHandler(l Logger) *loggerHandler {
+	return &loggerHandler{l: l}
+}
+
+type levelChecker interface {
+	IsDebug() bool
+	IsInfo() bool
+	IsWarn() bool
+	IsError() bool
+}
+
+func (h *loggerHandler) Enabled(_ context.Context, level slog.Level) bool {
+	lc, ok := h.l.(levelChecker)
+	if !ok {
+		return true
+	}
+	switch {
+	case level < slog.LevelInfo:
+		return lc.IsDebug()
+	case level < slog.LevelWarn:
+		return lc.IsInfo()
+	case level < slog.LevelError:
+		return lc.IsWarn()
+	}
+	return lc.IsError()
+}
+
+func (h *loggerHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Message)
+	for _, a := range h.attrs {
+		writeAttr(&b, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, h.prefix, a)
+		return true
+	})
+	b.WriteByte('\n')
+
+	switch {
+	case r.Level < slog.LevelInfo:
+		h.l.Debug("%s", b.String())
+	case r.Level < slog.LevelWarn:
+		h.l.Info("%s", b.String())
+	case r.Level < slog.LevelError:
+		h.l.Warn("%s", b.String())
+	default:
+		h.l.Error("%s", b.String())
+	}
+	return nil
+}
+
+func (h *loggerHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		next.attrs = append(next.attrs, slog.Attr{Key: h.prefix + a.Key, Value: a.Value})
+	}
+	return &next
+}
+
+func (h *loggerHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := *h
+	next.prefix = h.prefix + name + "."
+	return &next
+}
+
+func writeAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		for _, ga := range a.Value.Group() {
+			writeAttr(b, prefix+a.Key+".", ga)
+		}
+		return
+	}
+
+	value := a.Value.String()
+	if a.Value.Kind() == slog.KindAny {
+		value = fmt.Sprint(a.Value.Any())
+	}
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		value = strconv.Quote(value)
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(prefix)
+	b.WriteString(a.Key)
+	b.WriteByte('=')
+	b.WriteString(value)
+}
@@ -1,17 +1,28 @@
-package main
+package litedb
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/SagarDas211/LiteDB-Go/litedbsync"
 	"github.com/jcelliott/lumber"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type (
+	// Logger is the leveled logger interface of github.com/jcelliott/lumber,
+	// accepted by Options for compatibility. New code can set Options.Slog.
 	Logger interface {
 		Fatal(string, ...interface{})
 		Error(string, ...interface{})
@@ -21,288 +32,806 @@ type (
 		Trace(string, ...interface{})
 	}
 
+	// Driver is a database: a directory holding a subdirectory per
+	// collection and a JSON file per record. It is safe for concurrent use.
 	Driver struct {
-		mutex   sync.Mutex
-		mutexes map[string]*sync.Mutex
-		dir     string
-		log     Logger
+		locks *lockTable
+		dir   string
+		log   *slog.Logger
+		opts  Options
+
+		// ns is the lock table prefix of a Namespace view, empty for the
+		// driver New returned.
+		ns         string
+		namespaces namespaces
+
+		configMutex *sync.RWMutex
+		configs     map[string]*collectionConfig
+
+		expiryMutex sync.Mutex
+		expiries    map[string]map[string]time.Time
+
+		ulids ulidSource
+
+		accessMutex sync.Mutex
+		access      map[string]map[string]time.Time
+
+		watchers watchers
+		external externalWatch
+		attached attachments
+
+		cdc      cdcFeeds
+		auditLog *auditLog
+		metrics  metrics
+		tracer   trace.Tracer
+
+		middlewareMutex sync.RWMutex
+		middleware      []Middleware
+		acl             *AccessControl
+		limiter         *serverLimiter
+
+		syncOnce sync.Once
+		syncPeer *litedbsync.Local
+
+		tenant *tenantLimits
+
+		edgesMutex sync.Mutex
+		sortedSets sortedSets
+
+		commits     *groupCommit
+		keyCache    keyCache
+		blooms      blooms
+		hot         hotCache
+		dicts       dictionaries
+		readWorkers int
+
+		mmapThreshold int64
+
+		asyncOnce  sync.Once
+		async      atomic.Pointer[asyncWriter]
+		writeQueue int
+
+		codec       Codec
+		compression Compression
+		syncWrites  bool
+		fileMode    fs.FileMode
+		storage     Storage
+		clock       Clock
+
+		missingEmpty bool
+		names        CollectionNamePolicy
+		portable     bool
+		readOnly     bool
+		signing      signingKeys
+
+		slowThreshold time.Duration
+		minFreeBytes  uint64
+
+		closers closers
+		closing atomic.Bool
+		closed  atomic.Bool
 	}
 )
 
+// Version is the driver's version.
 const Version = "1.0.1"
 
+// Options holds the driver's settings. It is itself an Option, setting
+// all of them; the With functions set one at a time. The zero value is
+// usable.
 type Options struct {
 	Logger
+	Slog   *slog.Logger
+	Audit  bool
+	Tracer trace.Tracer
+
+	// SlowThreshold logs any operation taking at least this long as a
+	// warning. Zero disables slow-operation logging.
+	SlowThreshold time.Duration
+
+	// MinFreeBytes is the free disk space below which Healthy reports a
+	// problem.
+	MinFreeBytes uint64
+
+	// CommitWindow turns on group commit: writes return without waiting
+	// for the disk, and everything written within the window is synced
+	// together when it closes. Up to a window of writes can be lost in a
+	// crash; Flush syncs early. Zero leaves syncing to the OS.
+	CommitWindow time.Duration
+
+	// ReadWorkers bounds the files ReadAll and ReadEach read at once. It
+	// defaults to GOMAXPROCS; 1 reads sequentially.
+	ReadWorkers int
+
+	// WriteQueue is how many WriteAsync calls can wait for the background
+	// writer before further calls block. It defaults to 1024.
+	WriteQueue int
+
+	// MmapThreshold is the record size from which Read and ReadAll map
+	// the file into memory instead of copying it onto the heap, which
+	// keeps peak memory down for very large documents. It defaults to
+	// 64 MiB; a negative threshold turns mapping off.
+	MmapThreshold int64
+
+	// HotCacheBytes is the memory to spend keeping frequently read
+	// documents, so reading them skips the disk. Zero disables the cache;
+	// Pin works either way.
+	HotCacheBytes int64
+
+	// Prewarm loads the records that were in the hot cache when the
+	// database was last closed.
+	Prewarm bool
+
+	// Codec encodes and decodes documents. It defaults to encoding/json.
+	Codec Codec
+
+	// Compression is how Archive compresses records. It defaults to
+	// CompressionGzip.
+	Compression Compression
+
+	// Durability is how far writes are synced before they return.
+	// DurabilityGroup or a CommitWindow turns on group commit.
+	Durability Durability
+
+	// FileMode is the permissions of the files the driver creates. It
+	// defaults to 0644.
+	FileMode fs.FileMode
+
+	// CollectionNames restricts the names collections may have.
+	CollectionNames CollectionNamePolicy
+
+	// PortableNames encodes the characters of resource names that Windows
+	// or macOS do not allow in file names, and refuses such collection
+	// names, so the directory can move between operating systems. It is
+	// recorded in the database, and a database that has it keeps it.
+	PortableNames bool
+
+	// SigningKey, if set, signs every record written with an HMAC kept
+	// in its metadata, and Read, ReadAll, ReadEach and Find fail with an
+	// error matching ErrTampered for a record whose content no longer
+	// matches, so edits made to the directory behind the driver's back
+	// are detected. Records written without the key, or with another,
+	// fail too until rewritten. Archived records are not verified.
+	SigningKey []byte
+
+	// PreviousSigningKey is the key RotateKey is replacing, needed to
+	// reopen a database whose rotation has not finished. SigningKey is
+	// then the new key.
+	PreviousSigningKey []byte
+
+	// SigningKeyProvider supplies SigningKey when the database is opened,
+	// if SigningKey is not set.
+	SigningKeyProvider KeyProvider
+
+	// Storage is the file system records and their metadata are written
+	// through. It defaults to DiskStorage; a wrapper can make writes fail
+	// to see how an application copes with a failing disk.
+	Storage Storage
+
+	// Clock is where the driver takes the time from. It defaults to the
+	// system clock.
+	Clock Clock
+
+	// MissingCollectionsEmpty makes ReadAll and the other methods listing
+	// a collection treat one that does not exist as empty instead of
+	// returning ErrCollectionNotFound.
+	MissingCollectionsEmpty bool
+
+	// CDCDropWhenBehind lets writers drop a mutation for a CDC sink whose
+	// buffer is full instead of waiting for it to catch up. Dropped
+	// mutations leave a gap in Seq and are counted in Stats.CDCDropped.
+	CDCDropWhenBehind bool
 }
 
-func New(dir string, options *Options) (*Driver, error) {
+// New opens the database in dir, creating the directory if needed, with
+// the settings of options applied in order.
+func New(dir string, options ...Option) (*Driver, error) {
 	dir = filepath.Clean(dir)
+	opts, logger, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	driver := newDriver(dir, opts, logger)
 
+	if _, err := os.Stat(dir); err == nil {
+		logger.Debug("Using existing database", "dir", dir)
+		if err := driver.checkFormat(); err != nil {
+			return nil, err
+		}
+		if err := driver.openRotation(); err != nil {
+			return nil, err
+		}
+		if opts.Prewarm {
+			if err := driver.prewarm(); err != nil {
+				logger.Warn("Prewarming the hot cache failed", "err", err)
+			}
+		}
+		return driver, nil
+	}
+
+	logger.Info("Creating new database", "dir", dir)
+
+	if err := os.Mkdir(dir, dirMode(driver.fileMode)); err != nil && !os.IsExist(err) {
+		return driver, err
+	}
+	return driver, driver.checkFormat()
+}
+
+// resolveOptions applies options in order and fills in the defaults,
+// fetching keys from their providers.
+func resolveOptions(options []Option) (Options, *slog.Logger, error) {
 	opts := Options{}
 
-	if options != nil {
-		opts = *options
+	for _, o := range options {
+		if o != nil {
+			o.apply(&opts)
+		}
 	}
 
-	if opts.Logger == nil {
-		opts.Logger = lumber.NewConsoleLogger(lumber.INFO)
+	logger := opts.Slog
+	if logger == nil {
+		if opts.Logger == nil {
+			opts.Logger = lumber.NewConsoleLogger(lumber.INFO)
+		}
+		logger = slog.New(newLoggerHandler(opts.Logger))
 	}
 
-	driver := Driver{
-		dir:     dir,
-		log:     opts.Logger,
-		mutexes: make(map[string]*sync.Mutex),
+	if opts.Tracer == nil {
+		opts.Tracer = noopTracer
 	}
 
-	if _, err := os.Stat(dir); err == nil {
-		opts.Logger.Debug("Using '%s' (database already exists)\n", dir)
-		return &driver, nil
+	if opts.ReadWorkers <= 0 {
+		opts.ReadWorkers = runtime.GOMAXPROCS(0)
+	}
+	if opts.WriteQueue <= 0 {
+		opts.WriteQueue = defaultWriteQueue
+	}
+	if opts.MmapThreshold == 0 {
+		opts.MmapThreshold = defaultMmapThreshold
+	}
+	if opts.FileMode == 0 {
+		opts.FileMode = defaultFileMode
+	}
+	if opts.Storage == nil {
+		opts.Storage = DiskStorage{}
+	}
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+	if opts.Durability == DurabilityGroup && opts.CommitWindow <= 0 {
+		opts.CommitWindow = defaultCommitWindow
 	}
 
-	opts.Logger.Info("Creating new database at '%s'...\n", dir)
+	if opts.SigningKey == nil && opts.SigningKeyProvider != nil {
+		key, err := opts.SigningKeyProvider.Key(context.Background())
+		if err != nil {
+			return opts, nil, fmt.Errorf("getting signing key: %w", err)
+		}
+		opts.SigningKey = key
+	}
+
+	return opts, logger, nil
+}
+
+// newDriver builds a Driver for dir from options with their defaults
+// filled in.
+func newDriver(dir string, opts Options, logger *slog.Logger) *Driver {
+	driver := &Driver{
+		locks:       &lockTable{},
+		dir:         dir,
+		log:         logger,
+		opts:        opts,
+		configMutex: &sync.RWMutex{},
+		configs:     make(map[string]*collectionConfig),
+		expiries:    make(map[string]map[string]time.Time),
+		access:      make(map[string]map[string]time.Time),
+		tracer:      opts.Tracer,
+
+		slowThreshold: opts.SlowThreshold,
+		minFreeBytes:  opts.MinFreeBytes,
+		readWorkers:   opts.ReadWorkers,
+		writeQueue:    opts.WriteQueue,
+		mmapThreshold: opts.MmapThreshold,
+
+		codec:       opts.Codec,
+		compression: opts.Compression,
+		syncWrites:  opts.Durability == DurabilitySync,
+		fileMode:    opts.FileMode.Perm(),
+		storage:     opts.Storage,
+		clock:       opts.Clock,
+
+		missingEmpty: opts.MissingCollectionsEmpty,
+		names:        opts.CollectionNames,
+		portable:     opts.PortableNames,
+		signing:      signingKeys{current: opts.SigningKey},
+	}
 
-	return &driver, os.Mkdir(dir, 0755)
+	if opts.CommitWindow > 0 && !driver.syncWrites {
+		driver.commits = &groupCommit{window: opts.CommitWindow}
+	}
+
+	if opts.Audit {
+		driver.auditLog = &auditLog{path: filepath.Join(dir, auditFile), mode: driver.fileMode}
+	}
 
+	driver.hot.init(opts.HotCacheBytes)
+	return driver
 }
 
+// Write stores v, encoded as JSON, as resource in collection, replacing
+// any record of that name.
 func (d *Driver) Write(collection, resource string, v interface{}) error {
-	if collection == "" {
-		return fmt.Errorf("collection name cannot be empty")
+	return d.write(context.Background(), collection, resource, v, 0)
+}
+
+// WriteContext is Write with a context.
+func (d *Driver) WriteContext(ctx context.Context, collection, resource string, v interface{}) error {
+	return d.write(ctx, collection, resource, v, 0)
+}
+
+func (d *Driver) write(ctx context.Context, collection, resource string, v interface{}, ttl time.Duration) error {
+	return d.run(ctx, &Operation{Kind: OpWrite, Collection: collection, Resource: resource, Value: v, TTL: ttl}, d.writeOp)
+}
+
+func (d *Driver) writeOp(ctx context.Context, op *Operation) (err error) {
+	collection, resource, v := op.Collection, op.Resource, op.Value
+
+	ctx, end := d.instrument(ctx, OpWrite, collection, resource)
+	defer end(&err)
+
+	if err := d.validCollection(collection); err != nil {
+		return err
 	}
-	if resource == "" {
-		return fmt.Errorf("resource name cannot be empty")
+	if err := validResource(resource); err != nil {
+		return err
 	}
 
-	mutex := d.getOrCreateMutex(collection)
-	mutex.Lock()
-	defer mutex.Unlock()
+	if s, ok := v.(*streamDocument); ok && d.needsDocument(collection) {
+		if v, err = readDocument(s); err != nil {
+			return fmt.Errorf("record '%s' in collection '%s': %w", resource, collection, err)
+		}
+	}
 
-	dir := filepath.Join(d.dir, collection)
-	fnlPath := filepath.Join(dir, resource+".json")
-	tempPath := fnlPath + ".tmp"
+	if err := d.validate(collection, resource, v); err != nil {
+		return err
+	}
 
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := d.runHooks(beforeWrite, collection, resource, v); err != nil {
 		return err
 	}
 
-	b, err := json.MarshalIndent(v, "", "\t")
+	eviction, err := d.persist(ctx, collection, resource, v, op.TTL)
 	if err != nil {
 		return err
 	}
-
-	b = append(b, byte('\n'))
-	if err := ioutil.WriteFile(tempPath, b, 0644); err != nil {
+	if err := d.evict(ctx, collection, eviction); err != nil {
 		return err
 	}
 
-	return os.Rename(tempPath, fnlPath)
-
+	d.runAfterHooks(afterWrite, collection, resource, v)
+	return nil
 }
 
-func (d *Driver) Read(collection, resource string, v interface{}) error {
-	if collection == "" {
-		return fmt.Errorf("collection name cannot be empty")
+// persist writes the record, returning the records that must be evicted
+// for it to fit the collection's quota.
+func (d *Driver) persist(ctx context.Context, collection, resource string, v interface{}, ttl time.Duration) (*quotaEviction, error) {
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+	release, err := d.lockAttached(collection)
+	if err != nil {
+		return nil, err
 	}
-	if resource == "" {
-		return fmt.Errorf("resource name cannot be empty")
+	defer release()
+	update, err := d.checkExpected(ctx, collection, resource)
+	if err != nil {
+		return nil, err
+	}
+	if update && ttl == 0 {
+		ttl = keepTTL
 	}
 
-	record := filepath.Join(d.dir, collection, resource)
+	dir := d.collectionDir(collection)
+	fnlPath := filepath.Join(dir, d.recordFile(resource))
+	tempPath := fnlPath + ".tmp"
 
-	if _, err := stat(record); err != nil {
-		return err
+	if err := d.storage.MkdirAll(dir, dirMode(d.fileMode)); err != nil {
+		return nil, err
+	}
+
+	event := Updated
+	if _, err := os.Stat(fnlPath); os.IsNotExist(err) {
+		event = Created
 	}
 
-	b, err := ioutil.ReadFile(record + ".json")
+	w := d.documentWriter(collection)
+	defer putRecordWriter(w)
+
+	checksum, size, b, err := w.encodeFile(tempPath, v, d.hasReferences(collection) || d.hasViews(collection) || d.hasTriggers(collection) || d.hasComputed(collection) || d.sortedSets.has(collection) || d.capturing())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return json.Unmarshal(b, &v)
-}
+	if err := d.checkReferences(collection, resource, b); err != nil {
+		d.storage.Remove(tempPath)
+		return nil, err
+	}
+	eviction, err := d.enforceQuota(collection, resource, size)
+	if err != nil {
+		d.storage.Remove(tempPath)
+		return nil, err
+	}
+	computed, err := d.compute(collection, resource, b)
+	if err != nil {
+		d.storage.Remove(tempPath)
+		return nil, err
+	}
 
-func (d *Driver) ReadAll(collection string) ([]string, error) {
-	if collection == "" {
-		return nil, fmt.Errorf("collection name cannot be empty")
+	var triggered *triggerBatch
+	if d.hasTriggers(collection) {
+		after, _ := decodeDocument(b)
+		change := triggerChange{resource: resource, before: d.recordDocument(collection, resource), after: after}
+		if triggered, err = d.runTriggers(ctx, collection, []triggerChange{change}); err != nil {
+			d.storage.Remove(tempPath)
+			return nil, err
+		}
+		defer triggered.release()
 	}
 
-	dir := filepath.Join(d.dir, collection)
+	d.observeBytes(int(size))
+	recordBytes(ctx, int(size))
 
-	if _, err := stat(dir); err != nil {
+	if err := d.saveVersion(collection, resource); err != nil {
+		d.storage.Remove(tempPath)
+		triggered.undo()
 		return nil, err
 	}
 
-	files, _ := ioutil.ReadDir(dir)
+	d.bloomAdd(collection, resource)
+	d.markSelf(fnlPath)
+	if err := d.storage.Rename(tempPath, fnlPath); err != nil {
+		d.storage.Remove(tempPath)
+		triggered.undo()
+		return nil, err
+	}
+	if err := d.commit(fnlPath); err != nil {
+		return nil, err
+	}
+	d.keyCache.added(collection, resource)
+	d.hot.drop(collection, resource)
 
-	var records []string
-	for _, file := range files {
-		b, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
-		if err != nil {
-			return nil, err
-		}
-		records = append(records, string(b))
+	d.touch(collection, resource)
+	if err := d.removeArchived(collection, resource); err != nil {
+		return nil, err
 	}
 
-	return records, nil
+	if err := d.updateMeta(collection, resource, checksum, ttl, computed); err != nil {
+		return nil, err
+	}
+
+	d.emit(ctx, event, collection, resource, b)
+	d.audit(ctx, "write", collection, resource, checksum)
+	return eviction, nil
 
 }
 
-func (d *Driver) Delete(collection, resource string) error {
+// Read decodes resource in collection into v. It returns an error
+// matching ErrNotFound if there is no such record.
+func (d *Driver) Read(collection, resource string, v interface{}) error {
+	return d.ReadContext(context.Background(), collection, resource, v)
+}
+
+// ReadContext is Read with a context.
+func (d *Driver) ReadContext(ctx context.Context, collection, resource string, v interface{}) error {
+	return d.run(ctx, &Operation{Kind: OpRead, Collection: collection, Resource: resource, Value: v}, d.readOp)
+}
 
-	if collection == "" {
-		return fmt.Errorf("collection name cannot be empty")
+// ReadBytes returns resource in collection as the JSON stored, after any
+// defaults, for tools that handle documents without knowing their types.
+func (d *Driver) ReadBytes(collection, resource string) ([]byte, error) {
+	var doc json.RawMessage
+	if err := d.Read(collection, resource, &doc); err != nil {
+		return nil, err
 	}
+	return doc, nil
+}
 
-	path := filepath.Join(collection, resource)
-	mutex := d.getOrCreateMutex(collection)
-	mutex.Lock()
-	defer mutex.Unlock()
+// ReadRaw decodes resource in collection into a generic map. Numbers are
+// json.Numbers, so large integers survive the round trip.
+func (d *Driver) ReadRaw(collection, resource string) (map[string]interface{}, error) {
+	b, err := d.ReadBytes(collection, resource)
+	if err != nil {
+		return nil, err
+	}
 
-	dir := filepath.Join(d.dir, path)
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("record '%s' in collection '%s': %w", resource, collection, err)
+	}
+	return doc, nil
+}
 
-	switch fi, err := stat(dir); {
-	case fi == nil && err != nil:
-		return fmt.Errorf("resource '%s' does not exist in collection '%s'", resource, collection)
-	case fi.Mode().IsDir():
-		return os.RemoveAll(dir)
-	case fi.Mode().IsRegular():
-		return os.Remove(dir + ".json")
+func (d *Driver) readOp(ctx context.Context, op *Operation) (err error) {
+	collection, resource := op.Collection, op.Resource
+
+	ctx, end := d.instrument(ctx, OpRead, collection, resource)
+	defer end(&err)
+
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if err := validResource(resource); err != nil {
+		return err
 	}
 
-	return nil
+	if err := d.expireIfDue(collection, resource); err != nil {
+		return err
+	}
 
-}
+	decode := func(b []byte) error {
+		b, err := d.applyDefaults(collection, b)
+		if err != nil {
+			return err
+		}
 
-func (d *Driver) getOrCreateMutex(collection string) *sync.Mutex {
+		d.touch(collection, resource)
+		recordBytes(ctx, len(b))
+		switch dst := op.Value.(type) {
+		case *json.RawMessage:
+			// The bytes may be pooled or cached, so keep a copy.
+			*dst = append((*dst)[:0], b...)
+			return nil
+		case *streamTarget:
+			_, err := dst.w.Write(b)
+			return err
+		}
+		if d.codec != nil {
+			return d.codec.Unmarshal(b, op.Value)
+		}
+		return json.Unmarshal(b, &op.Value)
+	}
 
-	d.mutex.Lock()
-	defer d.mutex.Unlock()
-	m, ok := d.mutexes[collection]
+	// Others may change an attached collection, so it is not cached.
+	attached := d.isAttached(collection)
+	b, epoch, ok := d.hot.get(collection, resource)
+	if ok && !attached {
+		return decode(b)
+	}
 
-	if !ok {
-		m = &sync.Mutex{}
-		d.mutexes[collection] = m
+	if !d.mightExist(collection, resource) {
+		return errNoRecord(collection, resource)
 	}
 
-	return m
+	// Signatures are checked before anything is handed out.
+	if s, ok := op.Value.(*streamTarget); ok && !d.hasDefaults(collection) && !d.signs() {
+		return d.copyRecord(ctx, collection, resource, s.w)
+	}
+
+	// Unmarshal copies what it keeps, so the bytes can go back to the pool.
+	return d.withRecord(collection, resource, func(b []byte) error {
+		if !attached {
+			d.hot.admit(collection, resource, b, epoch)
+		}
+		return decode(b)
+	})
+}
+
+// ReadAll returns every document in collection, so it needs memory for the
+// whole collection; Records and List read large ones piecemeal.
+// It returns an error matching ErrCollectionNotFound if there is no such
+// collection.
+func (d *Driver) ReadAll(collection string) ([]string, error) {
+	return d.ReadAllContext(context.Background(), collection)
+}
+
+// ReadAllContext is ReadAll with a context.
+func (d *Driver) ReadAllContext(ctx context.Context, collection string) ([]string, error) {
+	op := &Operation{Kind: OpReadAll, Collection: collection}
+	if err := d.run(ctx, op, d.readAllOp); err != nil {
+		return nil, err
+	}
+	return op.Records, nil
 }
 
-func stat(path string) (fi os.FileInfo, err error) {
-	if fi, err = os.Stat(path); os.IsNotExist(err) {
-		fi, err = os.Stat(path + ".json")
+func (d *Driver) readAllOp(ctx context.Context, op *Operation) (err error) {
+	collection := op.Collection
+
+	ctx, end := d.instrument(ctx, OpReadAll, collection, "")
+	defer end(&err)
+
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+
+	names, err := d.liveFiles(collection)
+	if err != nil {
+		return err
+	}
+
+	records := make([]string, 0, len(names))
+	size := 0
+	err = d.readRecords(ctx, collection, names, false, true, func(resource string, b []byte) error {
+		records = append(records, string(b))
+		size += len(b)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-	return
+
+	recordCount(ctx, len(names))
+	recordBytes(ctx, size)
+	op.Records = records
+	return nil
+
 }
 
-type Address struct {
-	City    string
-	State   string
-	Country string
-	Pincode json.Number
+// Delete removes resource from collection, or the whole collection when
+// resource is empty.
+func (d *Driver) Delete(collection, resource string) error {
+	return d.DeleteContext(context.Background(), collection, resource)
 }
 
-type User struct {
-	Name    string
-	Age     json.Number
-	Contact string
-	Company string
-	Address Address
+// DeleteContext is Delete with a context.
+func (d *Driver) DeleteContext(ctx context.Context, collection, resource string) error {
+	return d.run(ctx, &Operation{Kind: OpDelete, Collection: collection, Resource: resource}, d.deleteOp)
 }
 
-func main() {
-	dir := "./"
+func (d *Driver) deleteOp(ctx context.Context, op *Operation) (err error) {
+	collection, resource := op.Collection, op.Resource
+
+	ctx, end := d.instrument(ctx, OpDelete, collection, resource)
+	defer end(&err)
+
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if resource != "" {
+		if err := validResource(resource); err != nil {
+			return err
+		}
+	}
+
+	var doc interface{}
+	if resource != "" && d.hasHooks(collection) {
+		if b, err := d.readRecord(collection, resource); err == nil {
+			doc = json.RawMessage(b)
+		}
+	}
 
-	db, err := New(dir, nil)
+	if err := d.runHooks(beforeDelete, collection, resource, doc); err != nil {
+		return err
+	}
+
+	keys := []string{resource}
+	if resource == "" {
+		var err error
+		if keys, err = d.keys(collection); err != nil {
+			return err
+		}
+	}
+
+	deps, err := d.dependents(collection, keys)
 	if err != nil {
-		fmt.Println("Error creating DB:", err)
-	}
-
-	employee := []User{
-		{
-			Name:    "John Doe",
-			Age:     "30",
-			Contact: "123-456-7890",
-			Company: "TechCorp",
-			Address: Address{
-				City:    "San Francisco",
-				State:   "CA",
-				Country: "USA",
-				Pincode: "94105",
-			},
-		},
-		{
-			Name:    "Jane Smith",
-			Age:     "28",
-			Contact: "987-654-3210",
-			Company: "Innovatech",
-			Address: Address{
-				City:    "New York",
-				State:   "NY",
-				Country: "USA",
-				Pincode: "10001",
-			},
-		},
-		{
-			Name:    "Alice Johnson",
-			Age:     "35",
-			Contact: "555-123-4567",
-			Company: "WebSolutions",
-			Address: Address{
-				City:    "Los Angeles",
-				State:   "CA",
-				Country: "USA",
-				Pincode: "90001",
-			},
-		},
-		{
-			Name:    "Bob Brown",
-			Age:     "40",
-			Contact: "444-555-6666",
-			Company: "DataAnalytics",
-			Address: Address{
-				City:    "Chicago",
-				State:   "IL",
-				Country: "USA",
-				Pincode: "60601",
-			},
-		},
-	}
-
-	for _, value := range employee {
-		db.Write("users", value.Name, User{
-			Name:    value.Name,
-			Age:     value.Age,
-			Contact: value.Contact,
-			Company: value.Company,
-			Address: value.Address,
-		})
-	}
-
-	records, err := db.ReadAll("users")
+		return err
+	}
+	if resource == "" {
+		deps = withoutCollection(deps, collection)
+	}
+	if err := restricted(collection, deps); err != nil {
+		return err
+	}
+
+	if err := d.delete(ctx, collection, resource); err != nil {
+		return err
+	}
+
+	if err := d.applyDeleteActions(ctx, deps); err != nil {
+		return err
+	}
+
+	d.runAfterHooks(afterDelete, collection, resource, doc)
+	return nil
+
+}
+
+func (d *Driver) delete(ctx context.Context, collection, resource string) error {
+	if a, rest := d.attachmentOf(collection); a != nil && rest == "" && resource == "" {
+		return fmt.Errorf("cannot delete attached collection '%s'; detach it instead", collection)
+	}
+
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+	release, err := d.lockAttached(collection)
 	if err != nil {
-		fmt.Println("Error reading records:", err)
+		return err
 	}
+	defer release()
 
-	fmt.Println("All User Records:", records)
+	dir := filepath.Join(d.collectionDir(collection), resource)
+	record := d.recordPath(collection, resource)
+	d.markSelf(dir)
+	d.markSelf(record)
 
-	allusers := []User{}
-	for _, record := range records {
-		employeeFound := User{}
-		err := json.Unmarshal([]byte(record), &employeeFound)
-		if err != nil {
-			fmt.Println("Error unmarshaling record:", err)
+	triggered, err := d.deleteTriggers(ctx, collection, resource)
+	if err != nil {
+		return err
+	}
+	defer triggered.release()
+
+	// A record is removed directly rather than after a stat.
+	removed := false
+	if resource != "" {
+		switch err := d.storage.Remove(record); {
+		case err == nil:
+			removed = true
+			if err := d.commit(record); err != nil {
+				return err
+			}
+			d.emit(ctx, Deleted, collection, resource, nil)
+		case !errors.Is(err, fs.ErrNotExist):
+			triggered.undo()
+			return err
+		}
+	}
+	if !removed {
+		if err := d.deleteDir(ctx, collection, resource, dir); err != nil {
+			triggered.undo()
+			return err
 		}
-		allusers = append(allusers, employeeFound)
 	}
 
-	fmt.Println("All Users Structs:", allusers)
+	d.keyCache.removed(collection, resource)
+	d.hot.drop(collection, resource)
+	d.setExpiry(collection, resource, time.Time{})
+	d.forget(collection, resource)
+	if err := d.removeArchived(collection, resource); err != nil {
+		return err
+	}
+	if err := d.removeMeta(collection, resource); err != nil {
+		return err
+	}
+	if err := d.removeEdges(collection, resource); err != nil {
+		return err
+	}
+
+	action := "delete"
+	if ctx.Value(evictKey{}) != nil {
+		action = "evict"
+	}
+	d.audit(ctx, action, collection, resource, "")
+	return nil
+}
 
-	if err := db.Delete("users", "Alice Johnson"); err != nil {
-		fmt.Println("Error deleting record:", err)
+// deleteDir handles a delete that did not remove a record: dir is a
+// collection, nested or not, or the resource may only be archived.
+func (d *Driver) deleteDir(ctx context.Context, collection, resource, dir string) error {
+	fi, err := os.Stat(dir)
+	switch {
+	case err == nil && fi.IsDir():
+		var keys []string
+		if resource == "" {
+			keys, _ = d.keys(collection)
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			d.emit(ctx, Deleted, collection, key, nil)
+		}
+		return nil
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		return err
 	}
 
-	if err := db.Delete("users", ""); err != nil {
-		fmt.Println("Error deleting all records:", err)
+	if resource == "" || !d.isArchived(collection, resource) {
+		return errNoRecord(collection, resource)
 	}
+	return nil
+}
 
+func (d *Driver) getOrCreateMutex(collection string) *sync.Mutex {
+	return &d.locks.get(d.ns, collection).Mutex
 }
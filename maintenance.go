@@ -0,0 +1,343 @@
+package litedb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Problem is an inconsistency found by Check.
+type Problem struct {
+	Collection string
+	Resource   string
+	Problem    string
+}
+
+func (p Problem) String() string {
+	if p.Resource == "" {
+		return fmt.Sprintf("%s: %s", p.Collection, p.Problem)
+	}
+	return fmt.Sprintf("%s/%s: %s", p.Collection, p.Resource, p.Problem)
+}
+
+// CheckReport is the outcome of Check.
+type CheckReport struct {
+	Collections int
+	Records     int
+	Problems    []Problem
+}
+
+// CompactReport counts what Compact removed.
+type CompactReport struct {
+	TempFiles        int
+	OrphanedMeta     int
+	EmptyCollections int
+}
+
+// Check verifies that every record is a JSON object matching the checksum
+// in its metadata, and its signature under Options.SigningKey, and
+// reports metadata without a record and temp files left behind by
+// interrupted writes.
+func (d *Driver) Check() (*CheckReport, error) {
+	collections, err := d.allCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CheckReport{}
+	for _, collection := range collections {
+		problems, records, err := d.checkCollection(collection)
+		if err != nil {
+			return nil, err
+		}
+		if records > 0 {
+			report.Collections++
+		}
+		report.Records += records
+		report.Problems = append(report.Problems, problems...)
+	}
+
+	return report, nil
+}
+
+func (d *Driver) checkCollection(collection string) ([]Problem, int, error) {
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+
+	var problems []Problem
+	problem := func(resource, format string, args ...interface{}) {
+		problems = append(problems, Problem{Collection: collection, Resource: resource, Problem: fmt.Sprintf(format, args...)})
+	}
+
+	keys, err := d.keys(collection)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, resource := range keys {
+		b, err := d.readRecord(collection, resource)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !json.Valid(b) {
+			problem(resource, "document is not valid JSON")
+			continue
+		}
+
+		m, err := d.readMeta(collection, resource)
+		if err != nil {
+			problem(resource, "unreadable metadata: %s", err)
+			continue
+		}
+		if m != nil && m.Checksum != "" {
+			sum := sha256.Sum256(b)
+			if hex.EncodeToString(sum[:]) != m.Checksum {
+				problem(resource, "checksum mismatch")
+			}
+		}
+		if err := d.verifyRecord(collection, resource, b); err != nil {
+			problem(resource, "missing or bad signature")
+		}
+	}
+
+	orphans, err := d.orphanedMeta(collection)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, resource := range orphans {
+		problem(resource, "metadata without a record")
+	}
+
+	temps, err := d.tempFiles(collection)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, path := range temps {
+		problem("", "leftover temp file %s", filepath.Base(path))
+	}
+
+	return problems, len(keys), nil
+}
+
+// Compact removes temp files left by interrupted writes, metadata for
+// records that no longer exist, and empty collection directories.
+func (d *Driver) Compact() (*CompactReport, error) {
+	if err := d.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	collections, err := d.allCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CompactReport{}
+	for _, collection := range collections {
+		if err := d.compactCollection(collection, report); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+func (d *Driver) compactCollection(collection string, report *CompactReport) error {
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+
+	temps, err := d.tempFiles(collection)
+	if err != nil {
+		return err
+	}
+	for _, path := range temps {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		report.TempFiles++
+	}
+
+	orphans, err := d.orphanedMeta(collection)
+	if err != nil {
+		return err
+	}
+	for _, resource := range orphans {
+		if err := d.removeMeta(collection, resource); err != nil {
+			return err
+		}
+		report.OrphanedMeta++
+	}
+
+	for _, dir := range []string{
+		d.collectionDir(collection),
+		filepath.Join(d.dir, metaDir, collection),
+		filepath.Join(d.dir, archiveDir, collection),
+	} {
+		files, err := os.ReadDir(dir)
+		if err != nil || len(files) > 0 {
+			continue
+		}
+		if err := os.Remove(dir); err != nil {
+			return err
+		}
+		if dir == d.collectionDir(collection) {
+			report.EmptyCollections++
+		}
+	}
+
+	return nil
+}
+
+// allCollections lists collections with records, metadata or archived
+// records.
+func (d *Driver) allCollections() ([]string, error) {
+	seen := make(map[string]bool)
+	for _, dir := range []string{d.dir, filepath.Join(d.dir, metaDir), filepath.Join(d.dir, archiveDir)} {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, file := range files {
+			if file.IsDir() && !reservedDir(file.Name()) {
+				seen[file.Name()] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// orphanedMeta lists the resources in collection that have metadata but
+// neither a record nor an archived copy.
+func (d *Driver) orphanedMeta(collection string) ([]string, error) {
+	files, err := os.ReadDir(filepath.Join(d.dir, metaDir, collection))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var orphans []string
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != recordExt {
+			continue
+		}
+		resource := d.resourceOf(file.Name(), recordExt)
+		if _, err := os.Stat(d.recordPath(collection, resource)); err == nil {
+			continue
+		}
+		if d.isArchived(collection, resource) {
+			continue
+		}
+		orphans = append(orphans, resource)
+	}
+
+	return orphans, nil
+}
+
+// tempFiles lists the temp files of collection's records, metadata and
+// archive. The caller must hold the collection lock.
+func (d *Driver) tempFiles(collection string) ([]string, error) {
+	var temps []string
+	for _, dir := range []string{
+		d.collectionDir(collection),
+		filepath.Join(d.dir, metaDir, collection),
+		filepath.Join(d.dir, archiveDir, collection),
+	} {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+		if err != nil {
+			return nil, err
+		}
+		temps = append(temps, matches...)
+	}
+	return temps, nil
+}
+
+// Backup copies the database into dest, which must not exist yet. Every
+// collection is locked for the duration so the copy is consistent.
+func (d *Driver) Backup(dest string) error {
+	src, err := filepath.Abs(d.dir)
+	if err != nil {
+		return err
+	}
+	if dest, err = filepath.Abs(dest); err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(src, dest); err == nil && !strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("backup destination '%s' is inside the database", dest)
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("backup destination '%s' already exists", dest)
+	}
+
+	collections, err := d.allCollections()
+	if err != nil {
+		return err
+	}
+	for _, collection := range collections {
+		mutex := d.lock(collection)
+		defer mutex.Unlock()
+	}
+
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !entry.Type().IsRegular() || filepath.Ext(path) == ".tmp" {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Chtimes(dest, fi.ModTime(), fi.ModTime())
+}
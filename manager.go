@@ -0,0 +1,266 @@
+package litedb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Manager opens and tracks many databases kept side by side in one
+// directory, one sub-directory each, as for an application with a
+// database per customer. Each of its background workers is one goroutine
+// serving every database it has open, rather than one per database.
+type Manager struct {
+	dir  string
+	opts []Option
+
+	mutex   sync.Mutex
+	drivers map[string]*Driver
+	stops   map[int]func()
+	next    int
+	closed  bool
+}
+
+// ManagerStats sums the Stats of a Manager's open databases.
+type ManagerStats struct {
+	Databases    int
+	Records      int
+	Bytes        int64
+	BytesWritten uint64
+	PerDatabase  map[string]*Stats
+}
+
+// NewManager manages the databases under dir, opening each with options.
+func NewManager(dir string, options ...Option) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		dir:     filepath.Clean(dir),
+		opts:    options,
+		drivers: make(map[string]*Driver),
+		stops:   make(map[int]func()),
+	}, nil
+}
+
+// Open returns the named database, opening it, and creating it if it does
+// not exist, on first use.
+func (m *Manager) Open(name string) (*Driver, error) {
+	if name == "" || name != filepath.Base(name) || reservedDir(name) {
+		return nil, fmt.Errorf("invalid database name '%s'", name)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.closed {
+		return nil, ErrClosed
+	}
+	if d, ok := m.drivers[name]; ok && d.checkOpen() == nil {
+		return d, nil
+	}
+
+	d, err := New(filepath.Join(m.dir, name), m.opts...)
+	if err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+	m.drivers[name] = d
+	return d, nil
+}
+
+// Databases lists the databases under the manager's directory, open or
+// not.
+func (m *Manager) Databases() ([]string, error) {
+	files, err := os.ReadDir(m.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, file := range files {
+		if file.IsDir() && !reservedDir(file.Name()) {
+			names = append(names, file.Name())
+		}
+	}
+	return names, nil
+}
+
+// Opened lists the databases the manager has open.
+func (m *Manager) Opened() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	names := make([]string, 0, len(m.drivers))
+	for name := range m.drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CloseDatabase closes the named database and stops tracking it. Its
+// files are kept, and a later Open opens it again.
+func (m *Manager) CloseDatabase(name string) error {
+	m.mutex.Lock()
+	d, ok := m.drivers[name]
+	delete(m.drivers, name)
+	m.mutex.Unlock()
+
+	if !ok {
+		return fmt.Errorf("database '%s' is not open", name)
+	}
+	if err := d.Close(); err != nil && !errors.Is(err, ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// Close stops the manager's background workers and closes every database
+// it has open, returning the first error. Afterwards Open returns
+// ErrClosed.
+func (m *Manager) Close() error {
+	m.mutex.Lock()
+	if m.closed {
+		m.mutex.Unlock()
+		return ErrClosed
+	}
+	m.closed = true
+	stops, drivers := m.stops, m.drivers
+	m.stops, m.drivers = nil, nil
+	m.mutex.Unlock()
+
+	for _, stop := range stops {
+		stop()
+	}
+
+	var first error
+	for name, d := range drivers {
+		if err := d.Close(); err != nil && !errors.Is(err, ErrClosed) && first == nil {
+			first = fmt.Errorf("database '%s': %w", name, err)
+		}
+	}
+	return first
+}
+
+// StartReaper deletes expired records from every open database each
+// interval, as Driver.StartReaper does for one, until the returned
+// function is called or the manager is closed.
+func (m *Manager) StartReaper(interval time.Duration) func() {
+	return m.every(interval, true, func(_ string, d *Driver) { d.reapOnce() })
+}
+
+// StartArchiver archives records in every open database each interval,
+// as Driver.StartArchiver does for one.
+func (m *Manager) StartArchiver(interval time.Duration) func() {
+	return m.every(interval, true, func(_ string, d *Driver) { d.archiveOnce() })
+}
+
+// StartRetention applies the retention policies of every open database
+// each interval, as Driver.StartRetention does for one.
+func (m *Manager) StartRetention(interval time.Duration) func() {
+	return m.every(interval, true, func(_ string, d *Driver) { d.retainOnce() })
+}
+
+// StartSnapshots backs up every open database each interval into
+// dest/<database>/<UTC time>, as Driver.Backup does. Old snapshots are
+// left for the caller to prune.
+func (m *Manager) StartSnapshots(interval time.Duration, dest string) func() {
+	return m.every(interval, false, func(name string, d *Driver) {
+		path := filepath.Join(dest, name, time.Now().UTC().Format("20060102T150405.000000000Z"))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			d.log.Error("Snapshot failed", "dest", path, "err", err)
+			return
+		}
+		if err := d.Backup(path); err != nil {
+			d.log.Error("Snapshot failed", "dest", path, "err", err)
+			return
+		}
+		d.log.Debug("Snapshot taken", "dest", path)
+	})
+}
+
+// every calls fn for each open database on one goroutine each interval
+// until the returned function is called or the manager is closed.
+// Databases closed in the meantime are skipped, as are read-only ones when
+// writes is set.
+func (m *Manager) every(interval time.Duration, writes bool, fn func(name string, d *Driver)) func() {
+	stop := make(chan struct{})
+	var once sync.Once
+	stopFn := func() { once.Do(func() { close(stop) }) }
+
+	m.mutex.Lock()
+	if m.closed {
+		m.mutex.Unlock()
+		return func() {}
+	}
+	id := m.next
+	m.next++
+	m.stops[id] = stopFn
+	m.mutex.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				for name, d := range m.open() {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					if d.checkOpen() != nil || (writes && d.readOnly) {
+						continue
+					}
+					fn(name, d)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		m.mutex.Lock()
+		delete(m.stops, id)
+		m.mutex.Unlock()
+		stopFn()
+	}
+}
+
+// open returns a copy of the open databases, for workers to range over
+// without holding the mutex.
+func (m *Manager) open() map[string]*Driver {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	drivers := make(map[string]*Driver, len(m.drivers))
+	for name, d := range m.drivers {
+		drivers[name] = d
+	}
+	return drivers
+}
+
+// Stats gathers the Stats of every open database and their totals.
+func (m *Manager) Stats() (*ManagerStats, error) {
+	st := &ManagerStats{PerDatabase: make(map[string]*Stats)}
+	for name, d := range m.open() {
+		if d.checkOpen() != nil {
+			continue
+		}
+		ds, err := d.Stats()
+		if err != nil {
+			return nil, fmt.Errorf("database '%s': %w", name, err)
+		}
+		st.PerDatabase[name] = ds
+		st.Databases++
+		st.Records += ds.Records
+		st.Bytes += ds.Bytes
+		st.BytesWritten += ds.BytesWritten
+	}
+	return st, nil
+}
@@ -0,0 +1,231 @@
+package litedb
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const metaDir = "_meta"
+
+// Metadata is stored in a sidecar file per record under _meta/<collection>
+// so record files stay plain documents.
+type Metadata struct {
+	CreatedAt time.Time  `json:"_createdAt"`
+	UpdatedAt time.Time  `json:"_updatedAt"`
+	ExpiresAt *time.Time `json:"_expiresAt,omitempty"`
+	Version   int64      `json:"_version"`
+	Checksum  string     `json:"_checksum,omitempty"`
+	Signature string     `json:"_signature,omitempty"`
+	Tags      []string   `json:"_tags,omitempty"`
+
+	// Computed holds the values of the collection's computed fields, as
+	// of the last write.
+	Computed map[string]json.RawMessage `json:"_computed,omitempty"`
+}
+
+// RecordStat is what Stat reports about a record.
+type RecordStat struct {
+	Size      int64
+	ModTime   time.Time
+	Version   int64
+	Checksum  string
+	ExpiresAt *time.Time
+	TTL       time.Duration
+}
+
+// Stat reports on a record from its file info and sidecar alone, without
+// reading or decoding the document.
+func (d *Driver) Stat(collection, resource string) (*RecordStat, error) {
+	if err := d.validCollection(collection); err != nil {
+		return nil, err
+	}
+	if err := validResource(resource); err != nil {
+		return nil, err
+	}
+
+	if err := d.expireIfDue(collection, resource); err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(d.recordPath(collection, resource))
+	if err != nil {
+		return nil, err
+	}
+
+	st := &RecordStat{Size: fi.Size(), ModTime: fi.ModTime()}
+
+	m, err := d.readMeta(collection, resource)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		st.Version = m.Version
+		st.Checksum = m.Checksum
+		st.ExpiresAt = m.ExpiresAt
+		if m.ExpiresAt != nil {
+			st.TTL = m.ExpiresAt.Sub(d.now())
+		}
+	}
+
+	return st, nil
+}
+
+// Metadata returns the bookkeeping for a record. Records written before
+// metadata was tracked report their file modification time.
+func (d *Driver) Metadata(collection, resource string) (*Metadata, error) {
+	if err := d.validCollection(collection); err != nil {
+		return nil, err
+	}
+	if err := validResource(resource); err != nil {
+		return nil, err
+	}
+
+	if err := d.expireIfDue(collection, resource); err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(d.recordPath(collection, resource))
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := d.readMeta(collection, resource)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		m = &Metadata{CreatedAt: fi.ModTime(), UpdatedAt: fi.ModTime()}
+	}
+
+	return m, nil
+}
+
+// keepTTL tells updateMeta to leave the record's expiry as it is.
+const keepTTL time.Duration = -1
+
+// updateMeta refreshes the record's sidecar after a successful write,
+// with the values of its computed fields. A positive ttl sets the record
+// to expire, keepTTL keeps its expiry and zero clears it. The caller must
+// hold the collection lock.
+func (d *Driver) updateMeta(collection, resource, checksum string, ttl time.Duration, computed map[string]json.RawMessage) error {
+	m, err := d.readMeta(collection, resource)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		m = &Metadata{}
+	}
+
+	now := d.now()
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = now
+	}
+	m.UpdatedAt = now
+	m.Version++
+	m.Checksum = checksum
+	m.Signature = ""
+	if key, _ := d.signing.keys(); key != nil {
+		m.Signature = sign(key, collection, resource, checksum)
+	}
+	m.Computed = computed
+
+	expiresAt := time.Time{}
+	switch {
+	case ttl > 0:
+		expiresAt = now.Add(ttl)
+		m.ExpiresAt = &expiresAt
+	case ttl == keepTTL && m.ExpiresAt != nil:
+		expiresAt = *m.ExpiresAt
+	default:
+		m.ExpiresAt = nil
+	}
+
+	if err := d.writeMeta(collection, resource, m); err != nil {
+		return err
+	}
+	d.setExpiry(collection, resource, expiresAt)
+
+	return nil
+}
+
+func (d *Driver) metaPath(collection, resource string) string {
+	return filepath.Join(d.dir, metaDir, collection, d.fileStem(resource)+".json")
+}
+
+// readMeta returns the record's metadata, or nil if it has none.
+func (d *Driver) readMeta(collection, resource string) (*Metadata, error) {
+	buf, err := readPooled(d.metaPath(collection, resource))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer putReadBuffer(buf)
+
+	var m Metadata
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func (d *Driver) writeMeta(collection, resource string, m *Metadata) error {
+	path := d.metaPath(collection, resource)
+	tempPath := path + ".tmp"
+
+	if err := d.storage.MkdirAll(filepath.Dir(path), dirMode(d.fileMode)); err != nil {
+		return err
+	}
+
+	w := getRecordWriter()
+	defer putRecordWriter(w)
+	w.mode = d.fileMode
+	w.storage = d.storage
+	if _, _, _, err := w.encodeFile(tempPath, m, false); err != nil {
+		return err
+	}
+
+	if err := d.storage.Rename(tempPath, path); err != nil {
+		d.storage.Remove(tempPath)
+		return err
+	}
+	return d.commit(path)
+}
+
+func (d *Driver) removeMeta(collection, resource string) error {
+	if err := d.removeHistory(collection, resource); err != nil {
+		return err
+	}
+	if resource == "" {
+		d.forgetFormat(collection)
+		return os.RemoveAll(filepath.Join(d.dir, metaDir, collection))
+	}
+	if err := d.storage.Remove(d.metaPath(collection, resource)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// metaCollections lists the collections that have sidecar metadata.
+func (d *Driver) metaCollections() ([]string, error) {
+	files, err := os.ReadDir(filepath.Join(d.dir, metaDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var collections []string
+	for _, file := range files {
+		if file.IsDir() {
+			collections = append(collections, file.Name())
+		}
+	}
+
+	return collections, nil
+}
@@ -0,0 +1,261 @@
+package litedb
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var latencyBuckets = [...]float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(seconds float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(latencyBuckets))
+	}
+	h.count++
+	h.sum += seconds
+	if i := sort.SearchFloat64s(latencyBuckets[:], seconds); i < len(latencyBuckets) {
+		h.counts[i]++
+	}
+}
+
+// waitHistogram is a histogram of lock waits updated with atomics, so
+// taking a lock never queues on a shared mutex. Driver.lock keeps one per
+// lock shard, and Stats and Collect add them up into a histogram.
+type waitHistogram struct {
+	counts    [len(latencyBuckets)]atomic.Uint64
+	count     atomic.Uint64
+	nanos     atomic.Int64
+	contended atomic.Uint64
+}
+
+func (h *waitHistogram) observe(waited time.Duration, contended bool) {
+	h.count.Add(1)
+	h.nanos.Add(int64(waited))
+	if contended {
+		h.contended.Add(1)
+	}
+	if i := sort.SearchFloat64s(latencyBuckets[:], waited.Seconds()); i < len(latencyBuckets) {
+		h.counts[i].Add(1)
+	}
+}
+
+// addTo adds the waits observed so far to sum, returning how many of
+// them were contended.
+func (h *waitHistogram) addTo(sum *histogram) (contended uint64) {
+	if sum.counts == nil {
+		sum.counts = make([]uint64, len(latencyBuckets))
+	}
+	for i := range h.counts {
+		sum.counts[i] += h.counts[i].Load()
+	}
+	sum.count += h.count.Load()
+	sum.sum += time.Duration(h.nanos.Load()).Seconds()
+	return h.contended.Load()
+}
+
+// cumulative returns the bucket counts in the form Prometheus expects.
+func (h *histogram) cumulative() map[float64]uint64 {
+	buckets := make(map[float64]uint64, len(latencyBuckets))
+	var running uint64
+	for i, upper := range latencyBuckets {
+		if h.counts != nil {
+			running += h.counts[i]
+		}
+		buckets[upper] = running
+	}
+	return buckets
+}
+
+type opStats struct {
+	count   uint64
+	errors  uint64
+	latency histogram
+}
+
+type metrics struct {
+	mutex        sync.Mutex
+	ops          map[string]*opStats
+	bytesWritten uint64
+
+	// lockWaits is indexed by lock shard, so collections in different
+	// shards record their waits without sharing a cache line.
+	lockWaits [lockShards]waitHistogram
+}
+
+// lockWait adds up the lock waits of every shard, returning how many
+// were contended.
+func (m *metrics) lockWait() (histogram, uint64) {
+	var sum histogram
+	var contended uint64
+	for i := range m.lockWaits {
+		contended += m.lockWaits[i].addTo(&sum)
+	}
+	return sum, contended
+}
+
+func (m *metrics) opFor(op string) *opStats {
+	if m.ops == nil {
+		m.ops = make(map[string]*opStats)
+	}
+	s, ok := m.ops[op]
+	if !ok {
+		s = &opStats{}
+		m.ops[op] = s
+	}
+	return s
+}
+
+// observe records a finished operation and returns how long it took.
+func (d *Driver) observe(op string, start time.Time, err *error) time.Duration {
+	elapsed := time.Since(start)
+
+	d.metrics.mutex.Lock()
+	defer d.metrics.mutex.Unlock()
+	s := d.metrics.opFor(op)
+	s.count++
+	if *err != nil {
+		s.errors++
+	}
+	s.latency.observe(elapsed.Seconds())
+
+	return elapsed
+}
+
+func (d *Driver) observeBytes(n int) {
+	d.metrics.mutex.Lock()
+	defer d.metrics.mutex.Unlock()
+	d.metrics.bytesWritten += uint64(n)
+}
+
+// lock acquires the collection mutex, recording how long it waited.
+func (d *Driver) lock(collection string) *sync.Mutex {
+	e := d.locks.get(d.ns, collection)
+
+	var waited time.Duration
+	contended := !e.TryLock()
+	if contended {
+		start := time.Now()
+		e.Lock()
+		waited = time.Since(start)
+	}
+
+	d.metrics.lockWaits[shardOf(d.ns+collection)].observe(waited, contended)
+	e.acquires.Add(1)
+	if contended {
+		e.contended.Add(1)
+		e.wait.Add(int64(waited))
+	}
+	return &e.Mutex
+}
+
+// collectionNames lists the collection directories under the database root.
+func (d *Driver) collectionNames() ([]string, error) {
+	files, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, file := range files {
+		if file.IsDir() && !reservedDir(file.Name()) {
+			names = append(names, file.Name())
+		}
+	}
+	return names, nil
+}
+
+var (
+	opsDesc = prometheus.NewDesc("litedb_operations_total",
+		"Driver operations performed, by type.", []string{"op"}, nil)
+	errorsDesc = prometheus.NewDesc("litedb_operation_errors_total",
+		"Driver operations that returned an error, by type.", []string{"op"}, nil)
+	latencyDesc = prometheus.NewDesc("litedb_operation_duration_seconds",
+		"Driver operation latency, by type.", []string{"op"}, nil)
+	bytesDesc = prometheus.NewDesc("litedb_bytes_written_total",
+		"Document bytes written to disk.", nil, nil)
+	recordsDesc = prometheus.NewDesc("litedb_records",
+		"Records stored, by collection.", []string{"collection"}, nil)
+	lockWaitDesc = prometheus.NewDesc("litedb_lock_wait_seconds",
+		"Time spent waiting for collection locks.", nil, nil)
+	lockContendedDesc = prometheus.NewDesc("litedb_lock_contended_total",
+		"Collection lock acquisitions that had to wait, by collection.", []string{"collection"}, nil)
+	lockWaitTotalDesc = prometheus.NewDesc("litedb_lock_wait_seconds_total",
+		"Time spent waiting for collection locks, by collection.", []string{"collection"}, nil)
+)
+
+type collector struct {
+	d *Driver
+}
+
+// Collector exposes the driver's metrics for registration with a
+// Prometheus registry.
+func (d *Driver) Collector() prometheus.Collector {
+	return collector{d: d}
+}
+
+func (c collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- opsDesc
+	ch <- errorsDesc
+	ch <- latencyDesc
+	ch <- bytesDesc
+	ch <- recordsDesc
+	ch <- lockWaitDesc
+	ch <- lockContendedDesc
+	ch <- lockWaitTotalDesc
+}
+
+func (c collector) Collect(ch chan<- prometheus.Metric) {
+	m := &c.d.metrics
+
+	// The metrics are built under the mutex and sent after, so a slow
+	// scrape cannot hold up the operations recording them.
+	var metrics []prometheus.Metric
+	m.mutex.Lock()
+	for op, s := range m.ops {
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(opsDesc, prometheus.CounterValue, float64(s.count), op),
+			prometheus.MustNewConstMetric(errorsDesc, prometheus.CounterValue, float64(s.errors), op),
+			prometheus.MustNewConstHistogram(latencyDesc, s.latency.count, s.latency.sum, s.latency.cumulative(), op))
+	}
+	metrics = append(metrics, prometheus.MustNewConstMetric(bytesDesc, prometheus.CounterValue, float64(m.bytesWritten)))
+	m.mutex.Unlock()
+
+	// Only locks that ever waited are reported, which keeps the series
+	// few; topk over them finds the most contended.
+	for _, s := range c.d.locks.contention(c.d.ns) {
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(lockContendedDesc, prometheus.CounterValue, float64(s.Contended), s.Collection),
+			prometheus.MustNewConstMetric(lockWaitTotalDesc, prometheus.CounterValue, s.WaitTime.Seconds(), s.Collection))
+	}
+
+	waits, _ := m.lockWait()
+	metrics = append(metrics, prometheus.MustNewConstHistogram(lockWaitDesc, waits.count, waits.sum, waits.cumulative()))
+	for _, metric := range metrics {
+		ch <- metric
+	}
+
+	collections, err := c.d.collectionNames()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(recordsDesc, err)
+		return
+	}
+	for _, collection := range collections {
+		keys, err := c.d.keys(collection)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(recordsDesc, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(recordsDesc, prometheus.GaugeValue, float64(len(keys)), collection)
+	}
+}
@@ -0,0 +1,74 @@
+package litedb
+
+import (
+	"context"
+	"time"
+)
+
+// Operation kinds passed to middleware in Operation.Kind.
+const (
+	OpWrite   = "write"
+	OpRead    = "read"
+	OpReadAll = "read_all"
+	OpDelete  = "delete"
+	OpFind    = "find"
+)
+
+// Operation describes a driver call as it passes through the middleware
+// chain. Middleware may inspect or rewrite its fields before calling next.
+type Operation struct {
+	Kind       string
+	Collection string
+	Resource   string
+
+	// Value is the document being written, or the destination of a read.
+	Value interface{}
+
+	// TTL is the time-to-live of a write, zero if it never expires.
+	TTL time.Duration
+
+	// Filter is the filter of a find.
+	Filter Filter
+
+	// Records and Found hold the results of a read_all or find once next
+	// has returned.
+	Records []string
+	Found   []Record
+}
+
+type (
+	// Op performs an operation; middleware calls its next Op to continue.
+	Op func(ctx context.Context, op *Operation) error
+
+	// Middleware wraps an Op, typically to act before or after it.
+	Middleware func(next Op) Op
+)
+
+// Use adds middleware around every Write, Read, ReadAll, Delete and Find. The
+// first middleware registered is the outermost.
+func (d *Driver) Use(mw ...Middleware) {
+	d.middlewareMutex.Lock()
+	defer d.middlewareMutex.Unlock()
+
+	d.middleware = append(d.middleware, mw...)
+}
+
+func (d *Driver) run(ctx context.Context, op *Operation, fn Op) error {
+	if err := d.checkOpen(); err != nil {
+		return err
+	}
+	if op.Kind == OpWrite || op.Kind == OpDelete {
+		if err := d.checkWritable(); err != nil {
+			return err
+		}
+	}
+
+	d.middlewareMutex.RLock()
+	chain := d.middleware
+	d.middlewareMutex.RUnlock()
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		fn = chain[i](fn)
+	}
+	return fn(ctx, op)
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd)
+
+package litedb
+
+import "os"
+
+func mmap(f *os.File, size int64) ([]byte, error) {
+	return nil, errMmapUnsupported
+}
+
+func munmap(b []byte) error {
+	return nil
+}
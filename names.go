@@ -0,0 +1,117 @@
+package litedb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// defaultMaxNameLength is the usual limit on a file name, in bytes.
+const defaultMaxNameLength = 255
+
+// ErrInvalidCollectionName matches every CollectionNameError.
+var ErrInvalidCollectionName = errors.New("invalid collection name")
+
+// CollectionNameError is returned for a collection name the driver
+// refuses, before anything touches the file system.
+type CollectionNameError struct {
+	Name   string
+	Reason string
+}
+
+func (e *CollectionNameError) Error() string {
+	if e.Name == "" {
+		return "collection name cannot be empty"
+	}
+	return fmt.Sprintf("invalid collection name '%s': %s", e.Name, e.Reason)
+}
+
+// Unwrap makes the error match ErrInvalidCollectionName.
+func (e *CollectionNameError) Unwrap() error {
+	return ErrInvalidCollectionName
+}
+
+// CollectionNamePolicy restricts the names collections may have. Whatever
+// the policy, a name must be a single path element and must not start
+// with "_" or ".", which the driver keeps for its own files such as _meta,
+// and _kv, the collection of KV, is always allowed.
+type CollectionNamePolicy struct {
+	// MaxLength is the longest name allowed, in bytes. It defaults to
+	// 255.
+	MaxLength int
+
+	// Pattern, when set, is a pattern every name must match, such as
+	// ^[a-z][a-z0-9-]*$ to keep names portable.
+	Pattern *regexp.Regexp
+
+	// Reserved lists further names to refuse.
+	Reserved []string
+}
+
+// WithCollectionNames restricts collection names to those p allows.
+func WithCollectionNames(p CollectionNamePolicy) Option {
+	return optionFunc(func(o *Options) { o.CollectionNames = p })
+}
+
+// check returns a CollectionNameError if the policy refuses name.
+// Under portable, names that would need encoding as file names are
+// refused too.
+func (p *CollectionNamePolicy) check(name string, portable bool) error {
+	reason := ""
+	max := p.MaxLength
+	if max <= 0 {
+		max = defaultMaxNameLength
+	}
+
+	switch {
+	case name == kvCollection:
+		return nil
+	case name == "":
+		return &CollectionNameError{}
+	case strings.ContainsAny(name, "/\\\x00"):
+		reason = "contains a path separator or NUL"
+	case strings.HasPrefix(name, "_") || strings.HasPrefix(name, "."):
+		reason = "names starting with '_' or '.' are reserved"
+	case len(name) > max:
+		reason = fmt.Sprintf("longer than %d bytes", max)
+	case portable && portableName(name) != name:
+		reason = "not a portable file name"
+	case p.Pattern != nil && !p.Pattern.MatchString(name):
+		reason = fmt.Sprintf("does not match %s", p.Pattern)
+	}
+	if reason == "" {
+		for _, reserved := range p.Reserved {
+			if name == reserved {
+				reason = "reserved"
+				break
+			}
+		}
+	}
+
+	if reason != "" {
+		return &CollectionNameError{Name: name, Reason: reason}
+	}
+	return nil
+}
+
+// validCollection returns a CollectionNameError if collection is not a
+// name the driver accepts.
+func (d *Driver) validCollection(collection string) error {
+	return d.names.check(collection, d.portable)
+}
+
+// validResource refuses resource names that do not name a file directly in
+// the collection's directory, which could reach outside the database.
+func validResource(resource string) error {
+	switch {
+	case resource == "":
+		return fmt.Errorf("resource name cannot be empty")
+	case resource == "." || resource == "..":
+		return fmt.Errorf("resource name '%s' is not allowed", resource)
+	case strings.ContainsAny(resource, "/\x00") || strings.ContainsRune(resource, filepath.Separator):
+		return fmt.Errorf("resource name '%s' cannot contain a path separator or NUL", resource)
+	}
+	return nil
+}
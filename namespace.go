@@ -0,0 +1,68 @@
+package litedb
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// namespaces caches the views Namespace returned, by name.
+type namespaces struct {
+	mutex sync.Mutex
+	views map[string]*Driver
+}
+
+// Namespace returns a view of the database rooted at its sub-directory
+// name, for keeping each tenant of a multi-tenant application apart
+// without opening a Driver per tenant. The view has the driver's Options
+// and shares its lock table and group commit, so a collection in the view
+// is locked as the collection "name/collection" of the driver would be.
+// It starts without the driver's middleware, and has its own caches,
+// watches and metrics.
+//
+// The sub-directory is created if needed, or must exist on a driver from
+// OpenReadOnly. It is listed among the driver's
+// collections, so name must be a valid collection name, and the names of
+// namespaces and collections should not overlap. Repeated calls return
+// the same view, which closes with the driver.
+func (d *Driver) Namespace(name string) (*Driver, error) {
+	if err := d.validCollection(name); err != nil {
+		return nil, err
+	}
+	if err := d.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	d.namespaces.mutex.Lock()
+	defer d.namespaces.mutex.Unlock()
+
+	if view, ok := d.namespaces.views[name]; ok && view.checkOpen() == nil {
+		return view, nil
+	}
+
+	dir := filepath.Join(d.dir, name)
+	if d.readOnly {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, err
+		}
+	} else if err := os.MkdirAll(dir, dirMode(d.fileMode)); err != nil {
+		return nil, err
+	}
+
+	view := newDriver(dir, d.opts, d.log.With("namespace", name))
+	view.ns = d.ns + name + "/"
+	view.portable = d.portable
+	view.readOnly = d.readOnly
+	view.locks = d.locks
+	view.commits = d.commits
+	if err := view.openRotation(); err != nil {
+		return nil, err
+	}
+
+	if d.namespaces.views == nil {
+		d.namespaces.views = make(map[string]*Driver)
+	}
+	d.namespaces.views[name] = view
+	d.onClose(func() { view.Close() })
+	return view, nil
+}
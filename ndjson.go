@@ -0,0 +1,123 @@
+package litedb
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// progressEvery is how many records pass between progress callbacks.
+const progressEvery = 1000
+
+// Progress is called during an NDJSON import or export with the records
+// and bytes handled so far, every thousand records and once at the end.
+type Progress func(records int, bytes int64)
+
+// ExportNDJSON streams the collection to w as newline-delimited JSON, one
+// {"id": ..., "data": ...} object per record, reading one record at a time.
+// It returns how many records were written.
+func (d *Driver) ExportNDJSON(collection string, w io.Writer, progress Progress) (int, error) {
+	keys, err := d.Keys(collection)
+	if err != nil {
+		return 0, err
+	}
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	enc := json.NewEncoder(cw)
+	n := 0
+	for _, resource := range keys {
+		b, err := d.readRecord(collection, resource)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		if b, err = d.applyDefaults(collection, b); err != nil {
+			return n, err
+		}
+		if !json.Valid(b) {
+			return n, fmt.Errorf("record '%s' in collection '%s' is not valid JSON", resource, collection)
+		}
+
+		if err := enc.Encode(Record{ID: resource, Data: b}); err != nil {
+			return n, err
+		}
+		n++
+		if progress != nil && n%progressEvery == 0 {
+			progress(n, cw.n)
+		}
+	}
+
+	if err := cw.w.Flush(); err != nil {
+		return n, err
+	}
+	if progress != nil {
+		progress(n, cw.n)
+	}
+	return n, nil
+}
+
+// ImportNDJSON writes every {"id": ..., "data": ...} object read from r,
+// as produced by ExportNDJSON, decoding one at a time. It returns how many
+// records were written.
+func (d *Driver) ImportNDJSON(collection string, r io.Reader, progress Progress) (int, error) {
+	if err := d.validCollection(collection); err != nil {
+		return 0, err
+	}
+
+	cr := &countingReader{r: r}
+	dec := json.NewDecoder(bufio.NewReader(cr))
+	n := 0
+	for {
+		var rec Record
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return n, fmt.Errorf("record %d: %w", n+1, err)
+		}
+		if rec.ID == "" {
+			return n, fmt.Errorf("record %d: missing id", n+1)
+		}
+		if rec.Data == nil {
+			return n, fmt.Errorf("record %d: missing data", n+1)
+		}
+
+		if err := d.Write(collection, rec.ID, rec.Data); err != nil {
+			return n, fmt.Errorf("record %d: %w", n+1, err)
+		}
+		n++
+		if progress != nil && n%progressEvery == 0 {
+			progress(n, cr.n)
+		}
+	}
+
+	if progress != nil {
+		progress(n, cr.n)
+	}
+	return n, nil
+}
+
+type countingWriter struct {
+	w *bufio.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
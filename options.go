@@ -0,0 +1,194 @@
+package litedb
+
+import (
+	"encoding/json"
+	"io/fs"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultFileMode     fs.FileMode = 0644
+	defaultCommitWindow             = 10 * time.Millisecond
+)
+
+// Option configures New. The With functions each set one setting, and
+// later options override earlier ones, so new settings can be added
+// without changing New's signature.
+type Option interface {
+	apply(*Options)
+}
+
+type optionFunc func(*Options)
+
+func (f optionFunc) apply(o *Options) { f(o) }
+
+// apply makes *Options an Option that replaces every setting at once; a
+// nil *Options changes nothing. Pass it before any With options that
+// should add to it.
+func (o *Options) apply(opts *Options) {
+	if o != nil {
+		*opts = *o
+	}
+}
+
+// Codec encodes and decodes documents, for plugging in a faster JSON
+// implementation. Records are stored as JSON whatever the codec, so it
+// must produce and accept JSON.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Durability is how far a write goes towards stable storage before it
+// returns.
+type Durability int
+
+const (
+	// DurabilityOS leaves flushing to the operating system, so a crash
+	// can lose recent writes. It is the default.
+	DurabilityOS Durability = iota
+
+	// DurabilityGroup syncs writes together at the end of each
+	// CommitWindow, 10ms unless one is set.
+	DurabilityGroup
+
+	// DurabilitySync syncs every record, and the directory entry naming
+	// it, before the write returns.
+	DurabilitySync
+)
+
+// Compression is how Archive compresses records.
+type Compression int
+
+const (
+	// CompressionGzip compresses each archived record on its own. It is
+	// the default.
+	CompressionGzip Compression = iota
+
+	// CompressionZstd compresses archived records with zstd and a
+	// dictionary trained on the collection, as SetArchiveDictionary does
+	// for one collection.
+	CompressionZstd
+)
+
+// WithLogger logs through l.
+func WithLogger(l *slog.Logger) Option {
+	return optionFunc(func(o *Options) { o.Slog = l })
+}
+
+// WithCodec encodes and decodes documents with c instead of
+// encoding/json.
+func WithCodec(c Codec) Option {
+	return optionFunc(func(o *Options) { o.Codec = c })
+}
+
+// WithCompression sets how archived records are compressed. Collections
+// can override it with SetArchiveDictionary.
+func WithCompression(c Compression) Option {
+	return optionFunc(func(o *Options) { o.Compression = c })
+}
+
+// WithDurability sets how far writes are synced before they return.
+func WithDurability(level Durability) Option {
+	return optionFunc(func(o *Options) { o.Durability = level })
+}
+
+// WithCommitWindow turns on group commit with the given window.
+func WithCommitWindow(window time.Duration) Option {
+	return optionFunc(func(o *Options) { o.CommitWindow = window })
+}
+
+// WithFileMode sets the permissions of the files the driver creates.
+// Directories get the same permissions with search added wherever read is
+// allowed, so 0600 gives 0700 directories.
+func WithFileMode(mode fs.FileMode) Option {
+	return optionFunc(func(o *Options) { o.FileMode = mode })
+}
+
+// WithMissingCollectionsEmpty makes listing a collection that does not
+// exist return no records instead of ErrCollectionNotFound.
+func WithMissingCollectionsEmpty() Option {
+	return optionFunc(func(o *Options) { o.MissingCollectionsEmpty = true })
+}
+
+// WithAudit records every mutation in the audit log.
+func WithAudit() Option {
+	return optionFunc(func(o *Options) { o.Audit = true })
+}
+
+// WithTracer traces operations with t.
+func WithTracer(t trace.Tracer) Option {
+	return optionFunc(func(o *Options) { o.Tracer = t })
+}
+
+// WithSlowThreshold logs operations taking at least d as warnings.
+func WithSlowThreshold(d time.Duration) Option {
+	return optionFunc(func(o *Options) { o.SlowThreshold = d })
+}
+
+// WithMinFreeBytes makes Healthy report a problem below n bytes of free
+// disk space.
+func WithMinFreeBytes(n uint64) Option {
+	return optionFunc(func(o *Options) { o.MinFreeBytes = n })
+}
+
+// WithReadWorkers bounds the files ReadAll and ReadEach read at once.
+func WithReadWorkers(n int) Option {
+	return optionFunc(func(o *Options) { o.ReadWorkers = n })
+}
+
+// WithWriteQueue sets how many WriteAsync calls can wait before further
+// calls block.
+func WithWriteQueue(n int) Option {
+	return optionFunc(func(o *Options) { o.WriteQueue = n })
+}
+
+// WithMmapThreshold sets the record size from which reads map files into
+// memory; a negative size turns mapping off.
+func WithMmapThreshold(size int64) Option {
+	return optionFunc(func(o *Options) { o.MmapThreshold = size })
+}
+
+// WithHotCache keeps up to bytes of frequently read documents in memory,
+// reloading the ones cached at the last Close when prewarm is set.
+func WithHotCache(bytes int64, prewarm bool) Option {
+	return optionFunc(func(o *Options) {
+		o.HotCacheBytes = bytes
+		o.Prewarm = prewarm
+	})
+}
+
+// WithCDCDropWhenBehind drops mutations for a CDC sink that has fallen a
+// full buffer behind rather than making writers wait for it.
+func WithCDCDropWhenBehind() Option {
+	return optionFunc(func(o *Options) { o.CDCDropWhenBehind = true })
+}
+
+// dirMode is the file mode with search permission wherever read is
+// allowed.
+func dirMode(mode fs.FileMode) fs.FileMode {
+	return mode | (mode&0444)>>2
+}
+
+// marshal encodes a document with the driver's codec.
+func (d *Driver) marshal(v interface{}) ([]byte, error) {
+	if d.codec != nil {
+		return d.codec.Marshal(v)
+	}
+	return json.Marshal(v)
+}
+
+// documentWriter returns a pooled recordWriter encoding collection's
+// documents with the driver's codec and file mode and the collection's
+// format.
+func (d *Driver) documentWriter(collection string) *recordWriter {
+	w := getRecordWriter()
+	w.codec = d.codec
+	w.mode = d.fileMode
+	w.storage = d.storage
+	w.compact = d.formatOf(collection) == FormatCompact
+	return w
+}
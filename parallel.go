@@ -0,0 +1,241 @@
+package litedb
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// liveFiles lists the record files in collection's directory, expiring
+// any record that is due instead of listing it. Subdirectories are nested
+// collections and are left out; other files are left out with a log
+// line, quietly for the temp files of writes in progress.
+func (d *Driver) liveFiles(collection string) ([]string, error) {
+	dir := d.collectionDir(collection)
+
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		if d.missingEmpty {
+			return nil, nil
+		}
+		return nil, errNoRecord(collection, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	expiries, err := d.expiriesFor(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	now := d.now()
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if filepath.Ext(file.Name()) != recordExt {
+			level := slog.LevelWarn
+			if filepath.Ext(file.Name()) == ".tmp" || file.Name() == attachLockFile {
+				level = slog.LevelDebug
+			}
+			d.log.Log(context.Background(), level, "Skipping file that is not a record", "collection", collection, "file", file.Name())
+			continue
+		}
+		resource := d.resourceOf(file.Name(), recordExt)
+		if expiresAt, ok := expiries[resource]; ok && !now.Before(expiresAt) {
+			if _, err := d.expire(collection, resource); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		names = append(names, file.Name())
+	}
+	return names, nil
+}
+
+type readResult struct {
+	index int
+	b     []byte
+	file  *fileBytes // holds b when reading with reuse
+	err   error
+}
+
+// readRecords reads the named files of collection with up to ReadWorkers
+// goroutines and passes each document, with defaults applied, to fn. In
+// order, fn sees the files in the order given; unordered, as soon as each
+// is read. fn is never called concurrently, and at most twice as many
+// documents as workers are held in memory waiting for it. With reuse, b
+// comes from readTransient and is released when fn returns, so fn must not
+// keep it.
+func (d *Driver) readRecords(ctx context.Context, collection string, names []string, unordered, reuse bool, fn func(resource string, b []byte) error) error {
+	dir := d.collectionDir(collection)
+	read := func(name string) readResult {
+		path := filepath.Join(dir, name)
+		resource := d.resourceOf(name, recordExt)
+		if !reuse {
+			b, err := os.ReadFile(path)
+			if err == nil {
+				err = d.verifyRecord(collection, resource, b)
+			}
+			if err == nil {
+				b, err = d.applyDefaults(collection, b)
+			}
+			return readResult{b: b, err: err}
+		}
+
+		fb, err := d.readTransient(path)
+		if err != nil {
+			return readResult{err: err}
+		}
+		if err := d.verifyRecord(collection, resource, fb.b); err != nil {
+			fb.release()
+			return readResult{err: err}
+		}
+		b, err := d.applyDefaults(collection, fb.b)
+		return readResult{b: b, file: &fb, err: err}
+	}
+	call := func(name string, r readResult) error {
+		if r.file != nil {
+			defer r.file.release()
+		}
+		if r.err != nil {
+			return r.err
+		}
+		return fn(d.resourceOf(name, recordExt), r.b)
+	}
+
+	workers := d.readWorkers
+	if workers > len(names) {
+		workers = len(names)
+	}
+	if workers <= 1 {
+		for _, name := range names {
+			if err := call(name, read(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	results := make(chan readResult, workers)
+	window := make(chan struct{}, 2*workers)
+
+	go func() {
+		defer close(jobs)
+		for i := range names {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				r := read(names[i])
+				r.index = i
+				select {
+				case results <- r:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var firstErr error
+	deliver := func(r readResult) {
+		<-window
+		if firstErr != nil {
+			return
+		}
+		if firstErr = call(names[r.index], r); firstErr != nil {
+			cancel()
+		}
+	}
+
+	pending := make(map[int]readResult)
+	next := 0
+	for r := range results {
+		if unordered {
+			deliver(r)
+			continue
+		}
+
+		pending[r.index] = r
+		for {
+			p, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			deliver(p)
+			next++
+		}
+	}
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return firstErr
+}
+
+// ReadEachOptions configures ReadEach.
+type ReadEachOptions struct {
+	// Unordered delivers records as soon as they are read rather than in
+	// name order.
+	Unordered bool
+}
+
+// ReadEach calls fn with every record in collection, reading them with the
+// driver's ReadWorkers. Only a bounded number of records are in memory at
+// once, however large the collection. fn is never called concurrently;
+// returning an error stops the read.
+func (d *Driver) ReadEach(ctx context.Context, collection string, opts ReadEachOptions, fn func(resource string, doc []byte) error) error {
+	return d.run(ctx, &Operation{Kind: OpReadAll, Collection: collection}, func(ctx context.Context, op *Operation) (err error) {
+		ctx, end := d.instrument(ctx, OpReadAll, collection, "")
+		defer end(&err)
+
+		if err := d.validCollection(collection); err != nil {
+			return err
+		}
+
+		names, err := d.liveFiles(collection)
+		if err != nil {
+			return err
+		}
+
+		size := 0
+		err = d.readRecords(ctx, collection, names, opts.Unordered, false, func(resource string, b []byte) error {
+			size += len(b)
+			return fn(resource, b)
+		})
+		recordCount(ctx, len(names))
+		recordBytes(ctx, size)
+		return err
+	})
+}
@@ -0,0 +1,461 @@
+package litedb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch is returned by Patch for a patch that is malformed
+	// or does not fit the document, such as one removing a field that is
+	// not there.
+	ErrInvalidPatch = errors.New("invalid patch")
+
+	// ErrPatchTest is returned by Patch when a "test" operation fails.
+	ErrPatchTest = errors.New("patch test failed")
+
+	// ErrConflict is returned by Patch and MergePatch when the record
+	// kept being written by others while the patch applied, so it could
+	// not be applied to a current document.
+	ErrConflict = errors.New("record changed while it was being updated")
+)
+
+// updateRetries is how many times update runs again on a record written by
+// someone else in the meantime before giving up.
+const updateRetries = 8
+
+// patchOp is one operation of a JSON Patch. Value is empty if missing,
+// and holds null if that is the value.
+type patchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Patch applies a JSON Patch (RFC 6902) to a record: a JSON array of add,
+// remove, replace, move, copy and test operations, such as
+//
+//	[{"op": "test", "path": "/stock", "value": 3},
+//	 {"op": "replace", "path": "/stock", "value": 2}]
+//
+// The operations apply all together or not at all: a failing test returns
+// an error matching ErrPatchTest and any other failure one matching
+// ErrInvalidPatch, leaving the record as it was. The result is written as
+// by Write, so validators, hooks and middleware see the whole document,
+// except that a record set to expire keeps its expiry.
+// A record written by someone else while the patch applies has it applied
+// again to the new document, up to a few times before Patch gives up with
+// an error matching ErrConflict.
+func (d *Driver) Patch(collection, resource string, patch []byte) error {
+	return d.PatchContext(context.Background(), collection, resource, patch)
+}
+
+// PatchContext is Patch with a context.
+func (d *Driver) PatchContext(ctx context.Context, collection, resource string, patch []byte) error {
+	var ops []patchOp
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	for i, op := range ops {
+		if err := op.check(); err != nil {
+			return fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+		}
+	}
+
+	return d.update(ctx, collection, resource, func(doc interface{}) (interface{}, error) {
+		for i, op := range ops {
+			var err error
+			if doc, err = op.apply(doc); err != nil {
+				if errors.Is(err, ErrPatchTest) {
+					return nil, fmt.Errorf("operation %d: %w", i, err)
+				}
+				return nil, fmt.Errorf("%w: operation %d (%s %s): %v", ErrInvalidPatch, i, op.Op, op.Path, err)
+			}
+		}
+		return doc, nil
+	})
+}
+
+// MergePatch applies a JSON Merge Patch (RFC 7386) to a record: a JSON
+// object whose members replace those of the document, recursively, with
+// null removing a member. So {"stock": 2, "discount": null} sets stock and
+// drops discount. A patch that is not an object replaces the whole
+// document. It is written as by Patch, keeping the record's expiry, and
+// returns an error matching ErrInvalidPatch if it is not valid JSON.
+func (d *Driver) MergePatch(collection, resource string, patch []byte) error {
+	return d.MergePatchContext(context.Background(), collection, resource, patch)
+}
+
+// MergePatchContext is MergePatch with a context.
+func (d *Driver) MergePatchContext(ctx context.Context, collection, resource string, patch []byte) error {
+	merge, err := decodeJSON(patch)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return d.update(ctx, collection, resource, func(doc interface{}) (interface{}, error) {
+		return mergePatch(doc, merge), nil
+	})
+}
+
+// mergePatch applies merge to target as RFC 7386 describes. Objects of
+// target may be changed in place; merge is left as it is, so the same
+// patch can be applied again.
+func mergePatch(target, merge interface{}) interface{} {
+	patch, ok := merge.(map[string]interface{})
+	if !ok {
+		return merge
+	}
+	doc, ok := target.(map[string]interface{})
+	if !ok {
+		doc = make(map[string]interface{}, len(patch))
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(doc, k)
+		} else {
+			doc[k] = mergePatch(doc[k], v)
+		}
+	}
+	return doc
+}
+
+// update replaces a record with what fn makes of its document. The write
+// only goes ahead if the record is still the one fn was given; if not, fn
+// runs again on the new document.
+func (d *Driver) update(ctx context.Context, collection, resource string, fn func(doc interface{}) (interface{}, error)) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if err := validResource(resource); err != nil {
+		return err
+	}
+	if err := d.expireIfDue(collection, resource); err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		var base []byte
+		err := d.withRecord(collection, resource, func(b []byte) error {
+			base = bytes.Clone(b)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		doc, err := decodeJSON(base)
+		if err != nil {
+			return fmt.Errorf("record '%s' in '%s': %w", resource, collection, err)
+		}
+
+		v, err := fn(doc)
+		if err != nil {
+			return err
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		expect := &expectation{collection: collection, resource: resource, base: base}
+		err = d.WriteContext(context.WithValue(ctx, expectKey{}, expect), collection, resource, json.RawMessage(b))
+		if !errors.Is(err, ErrConflict) || attempt == updateRetries {
+			return err
+		}
+	}
+}
+
+type expectKey struct{}
+
+// expectation is the record a write made by update was based on.
+type expectation struct {
+	collection, resource string
+	base                 []byte
+}
+
+// checkExpected returns ErrConflict if ctx carries an expectation for the
+// record being written that it no longer meets. It reports whether there
+// was one, as an update keeps the record's expiry. The caller must hold
+// the collection lock.
+func (d *Driver) checkExpected(ctx context.Context, collection, resource string) (bool, error) {
+	e, ok := ctx.Value(expectKey{}).(*expectation)
+	if !ok || e.collection != collection || e.resource != resource {
+		return false, nil
+	}
+	return true, d.withRecord(collection, resource, func(b []byte) error {
+		if !bytes.Equal(b, e.base) {
+			return ErrConflict
+		}
+		return nil
+	})
+}
+
+// decodeJSON decodes any JSON value, keeping numbers as json.Number.
+func decodeJSON(b []byte) (interface{}, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (op patchOp) check() error {
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return fmt.Errorf("%s needs a value", op.Op)
+		}
+	case "move", "copy":
+		if _, err := parsePointer(op.From); err != nil {
+			return fmt.Errorf("from: %v", err)
+		}
+	case "remove":
+	default:
+		return fmt.Errorf("unknown op %q", op.Op)
+	}
+	_, err := parsePointer(op.Path)
+	return err
+}
+
+func (op patchOp) apply(doc interface{}) (interface{}, error) {
+	path, _ := parsePointer(op.Path)
+	var value interface{}
+	if len(op.Value) > 0 {
+		var err error
+		if value, err = decodeJSON(op.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		return pointerAdd(doc, path, value)
+	case "remove":
+		doc, _, err := pointerRemove(doc, path)
+		return doc, err
+	case "replace":
+		if len(path) == 0 {
+			return value, nil
+		}
+		doc, _, err := pointerRemove(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, value)
+	case "move":
+		if op.Path == op.From {
+			_, err := pointerGet(doc, path)
+			return doc, err
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("cannot move '%s' into itself", op.From)
+		}
+		from, _ := parsePointer(op.From)
+		doc, moved, err := pointerRemove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, moved)
+	case "copy":
+		from, _ := parsePointer(op.From)
+		v, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		// The copy must not share maps or slices with the original.
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if v, err = decodeJSON(b); err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, v)
+	default: // test
+		v, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(v, value) {
+			return nil, fmt.Errorf("%w: '%s' does not hold the value tested for", ErrPatchTest, op.Path)
+		}
+		return doc, nil
+	}
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped
+// reference tokens. The empty pointer is the whole document.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if p[0] != '/' {
+		return nil, fmt.Errorf("pointer %q does not start with '/'", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses token as an index of an array of n elements, or one
+// past the end if end is set.
+func arrayIndex(token string, n int, end bool) (int, error) {
+	if token == "-" && end {
+		return n, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') || token[0] == '+' {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i > n || (i == n && !end) {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func pointerGet(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		var err error
+		if doc, err = pointerChild(doc, token); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func pointerChild(doc interface{}, token string) (interface{}, error) {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		child, ok := v[token]
+		if !ok {
+			return nil, fmt.Errorf("no member %q", token)
+		}
+		return child, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(v), false)
+		if err != nil {
+			return nil, err
+		}
+		return v[i], nil
+	}
+	return nil, fmt.Errorf("cannot look up %q in a value that is neither an object nor an array", token)
+}
+
+// pointerAt calls fn with the object or array holding the last token of
+// path and that token, and returns doc with fn's result in its place.
+func pointerAt(doc interface{}, path []string, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	child, err := pointerChild(doc, path[0])
+	if err != nil {
+		return nil, err
+	}
+	if child, err = pointerAt(child, path[1:], fn); err != nil {
+		return nil, err
+	}
+
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		v[path[0]] = child
+	case []interface{}:
+		i, _ := arrayIndex(path[0], len(v), false)
+		v[i] = child
+	}
+	return doc, nil
+}
+
+func pointerAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return pointerAt(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch v := parent.(type) {
+		case map[string]interface{}:
+			v[token] = value
+			return v, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(v), true)
+			if err != nil {
+				return nil, err
+			}
+			return append(v[:i], append([]interface{}{value}, v[i:]...)...), nil
+		}
+		return nil, fmt.Errorf("cannot add %q to a value that is neither an object nor an array", token)
+	})
+}
+
+// pointerRemove removes the value at path, returning it with the new
+// document.
+func pointerRemove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the whole document")
+	}
+	var removed interface{}
+	doc, err := pointerAt(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		child, err := pointerChild(parent, token)
+		if err != nil {
+			return nil, err
+		}
+		removed = child
+		switch v := parent.(type) {
+		case map[string]interface{}:
+			delete(v, token)
+			return v, nil
+		default:
+			a := v.([]interface{})
+			i, _ := arrayIndex(token, len(a), false)
+			return append(a[:i], a[i+1:]...), nil
+		}
+	})
+	return doc, removed, err
+}
+
+// jsonEqual reports whether two decoded JSON values are equal, comparing
+// numbers by value, so that 1 equals 1.0.
+func jsonEqual(a, b interface{}) bool {
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		rx, okx := new(big.Rat).SetString(string(x))
+		ry, oky := new(big.Rat).SetString(string(y))
+		return okx && oky && rx.Cmp(ry) == 0
+	}
+	return a == b
+}
@@ -0,0 +1,236 @@
+package litedb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	litedb "github.com/SagarDas211/LiteDB-Go"
+	"github.com/SagarDas211/LiteDB-Go/litedbtest"
+)
+
+func TestPatchKeepsExpiry(t *testing.T) {
+	patches := map[string]func(db *litedb.Driver) error{
+		"Patch": func(db *litedb.Driver) error {
+			return db.Patch("sessions", "s1", []byte(`[{"op": "replace", "path": "/hits", "value": 2}]`))
+		},
+		"MergePatch": func(db *litedb.Driver) error {
+			return db.MergePatch("sessions", "s1", []byte(`{"hits": 2}`))
+		},
+	}
+
+	for name, patch := range patches {
+		t.Run(name, func(t *testing.T) {
+			clock := litedbtest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			db, err := litedb.New(t.TempDir(), litedb.WithClock(clock))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			if err := db.WriteWithTTL("sessions", "s1", map[string]int{"hits": 1}, time.Hour); err != nil {
+				t.Fatal(err)
+			}
+			clock.Advance(10 * time.Minute)
+
+			if err := patch(db); err != nil {
+				t.Fatal(err)
+			}
+			litedbtest.AssertRecord(t, db, "sessions", "s1", map[string]int{"hits": 2})
+
+			m, err := db.Metadata("sessions", "s1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+			if m.ExpiresAt == nil || !m.ExpiresAt.Equal(want) {
+				t.Fatalf("ExpiresAt = %v after %s, want %v", m.ExpiresAt, name, want)
+			}
+
+			clock.Advance(time.Hour)
+			var v map[string]int
+			if err := db.Read("sessions", "s1", &v); !errors.Is(err, litedb.ErrNotFound) {
+				t.Fatalf("Read after expiry = %v, want not found", err)
+			}
+		})
+	}
+}
+
+func TestPatchOperations(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  interface{}
+		err   error
+	}{
+		{
+			name:  "add",
+			patch: `[{"op": "add", "path": "/tags/1", "value": "b"}, {"op": "add", "path": "/price", "value": 5}]`,
+			want:  map[string]interface{}{"stock": 3, "tags": []string{"a", "b", "c"}, "price": 5},
+		},
+		{
+			name:  "remove",
+			patch: `[{"op": "remove", "path": "/tags/0"}]`,
+			want:  map[string]interface{}{"stock": 3, "tags": []string{"c"}},
+		},
+		{
+			name:  "replace",
+			patch: `[{"op": "replace", "path": "/stock", "value": 2}]`,
+			want:  map[string]interface{}{"stock": 2, "tags": []string{"a", "c"}},
+		},
+		{
+			name:  "move",
+			patch: `[{"op": "move", "from": "/stock", "path": "/count"}]`,
+			want:  map[string]interface{}{"count": 3, "tags": []string{"a", "c"}},
+		},
+		{
+			name:  "copy",
+			patch: `[{"op": "copy", "from": "/tags/0", "path": "/tags/-"}]`,
+			want:  map[string]interface{}{"stock": 3, "tags": []string{"a", "c", "a"}},
+		},
+		{
+			name:  "test",
+			patch: `[{"op": "test", "path": "/stock", "value": 3}, {"op": "replace", "path": "/stock", "value": 2}]`,
+			want:  map[string]interface{}{"stock": 2, "tags": []string{"a", "c"}},
+		},
+		{
+			name:  "failed test",
+			patch: `[{"op": "replace", "path": "/stock", "value": 2}, {"op": "test", "path": "/stock", "value": 3}]`,
+			err:   litedb.ErrPatchTest,
+		},
+		{
+			name:  "missing path",
+			patch: `[{"op": "replace", "path": "/missing", "value": 1}]`,
+			err:   litedb.ErrInvalidPatch,
+		},
+		{
+			name:  "unknown op",
+			patch: `[{"op": "frob", "path": "/stock"}]`,
+			err:   litedb.ErrInvalidPatch,
+		},
+		{
+			name:  "not a patch",
+			patch: `{"stock": 2}`,
+			err:   litedb.ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := litedb.New(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			original := map[string]interface{}{"stock": 3, "tags": []string{"a", "c"}}
+			litedbtest.Seed(t, db, "items", map[string]interface{}{"i1": original})
+
+			err = db.Patch("items", "i1", []byte(tt.patch))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Patch = %v, want %v", err, tt.err)
+				}
+				litedbtest.AssertRecord(t, db, "items", "i1", original)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			litedbtest.AssertRecord(t, db, "items", "i1", tt.want)
+		})
+	}
+}
+
+func TestPatchMissingRecord(t *testing.T) {
+	db, err := litedb.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = db.Patch("items", "missing", []byte(`[{"op": "add", "path": "/stock", "value": 1}]`))
+	if !errors.Is(err, litedb.ErrNotFound) {
+		t.Fatalf("Patch = %v, want not found", err)
+	}
+}
+
+// rewriting is a context key marking the writes racingWrites makes, so it
+// does not race with itself.
+type rewriting struct{}
+
+// racingWrites rewrites collection's records just before every other write
+// to them lands, as a concurrent writer would, and counts the writes.
+func racingWrites(db *litedb.Driver, collection string, writes *int) litedb.Middleware {
+	return func(next litedb.Op) litedb.Op {
+		return func(ctx context.Context, op *litedb.Operation) error {
+			if op.Kind == litedb.OpWrite && op.Collection == collection && ctx.Value(rewriting{}) == nil {
+				*writes++
+				doc := map[string]int{"stock": 100 + *writes}
+				if err := db.WriteContext(context.WithValue(ctx, rewriting{}, true), collection, op.Resource, doc); err != nil {
+					return err
+				}
+			}
+			return next(ctx, op)
+		}
+	}
+}
+
+func TestPatchConflict(t *testing.T) {
+	patches := map[string]func(db *litedb.Driver) error{
+		"Patch": func(db *litedb.Driver) error {
+			return db.Patch("items", "i1", []byte(`[{"op": "replace", "path": "/stock", "value": 2}]`))
+		},
+		"MergePatch": func(db *litedb.Driver) error {
+			return db.MergePatch("items", "i1", []byte(`{"stock": 2}`))
+		},
+	}
+
+	for name, patch := range patches {
+		t.Run(name, func(t *testing.T) {
+			db, err := litedb.New(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			litedbtest.Seed(t, db, "items", map[string]interface{}{"i1": map[string]int{"stock": 3}})
+
+			var writes int
+			db.Use(racingWrites(db, "items", &writes))
+
+			if err := patch(db); !errors.Is(err, litedb.ErrConflict) {
+				t.Fatalf("%s = %v, want ErrConflict", name, err)
+			}
+			if writes < 2 {
+				t.Fatalf("%s gave up after %d attempts, want retries", name, writes)
+			}
+			litedbtest.AssertRecord(t, db, "items", "i1", map[string]int{"stock": 100 + writes})
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	db, err := litedb.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	litedbtest.Seed(t, db, "items", map[string]interface{}{
+		"i1": map[string]interface{}{"stock": 3, "discount": 10, "dims": map[string]int{"w": 1, "h": 2}},
+	})
+
+	if err := db.MergePatch("items", "i1", []byte(`{"stock": 2, "discount": null, "dims": {"h": 4}}`)); err != nil {
+		t.Fatal(err)
+	}
+	litedbtest.AssertRecord(t, db, "items", "i1", map[string]interface{}{"stock": 2, "dims": map[string]int{"w": 1, "h": 4}})
+
+	if err := db.MergePatch("items", "i1", []byte(`[1, 2]`)); err != nil {
+		t.Fatal(err)
+	}
+	litedbtest.AssertRecord(t, db, "items", "i1", []int{1, 2})
+
+	if err := db.MergePatch("items", "i1", []byte(`{`)); !errors.Is(err, litedb.ErrInvalidPatch) {
+		t.Fatalf("MergePatch = %v, want ErrInvalidPatch", err)
+	}
+}
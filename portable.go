@@ -0,0 +1,75 @@
+package litedb
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// windowsDevices are the names Windows reserves whatever their extension.
+var windowsDevices = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// WithPortableNames stores records under file names that Windows and
+// macOS accept too, so the database directory can be copied between
+// operating systems. See Options.PortableNames.
+func WithPortableNames() Option {
+	return optionFunc(func(o *Options) { o.PortableNames = true })
+}
+
+// portableName encodes as %XX the characters of name that Windows or macOS
+// do not allow in a file name, '%' itself, a trailing dot or space, and
+// the first character of a Windows device name such as CON.
+func portableName(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		last := i == len(name)-1
+		if c < 0x20 || c == 0x7f || strings.IndexByte(`"*:<>?\|%`, c) >= 0 ||
+			(last && (c == '.' || c == ' ')) ||
+			(i == 0 && isWindowsDevice(name)) {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// isWindowsDevice reports whether Windows reserves name for a device.
+func isWindowsDevice(name string) bool {
+	stem, _, _ := strings.Cut(name, ".")
+	return windowsDevices[strings.ToUpper(strings.TrimRight(stem, " "))]
+}
+
+// recordFile is the name of resource's record file.
+func (d *Driver) recordFile(resource string) string {
+	return d.fileStem(resource) + recordExt
+}
+
+// fileStem is the file name resource is stored under, before the
+// extension.
+func (d *Driver) fileStem(resource string) string {
+	if d.portable {
+		return portableName(resource)
+	}
+	return resource
+}
+
+// resourceOf is the resource held by the file named name, which has the
+// extension ext.
+func (d *Driver) resourceOf(name, ext string) string {
+	stem := strings.TrimSuffix(name, ext)
+	if !d.portable {
+		return stem
+	}
+	if resource, err := url.PathUnescape(stem); err == nil {
+		return resource
+	}
+	return stem
+}
@@ -0,0 +1,189 @@
+package litedb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// queuesLock prefixes the lock serializing a queue's Pop, Ack and Nack.
+const queuesLock = "_queues/"
+
+// DefaultVisibilityTimeout is how long a popped message stays hidden from
+// other consumers unless its Queue says otherwise.
+const DefaultVisibilityTimeout = 30 * time.Second
+
+// ErrQueueEmpty is returned by Pop when no message is ready.
+var ErrQueueEmpty = errors.New("queue is empty")
+
+// Queue is a durable first-in, first-out queue kept in a collection of the
+// same name, one record per message, returned by Driver.Queue. Popping a
+// message hides it for VisibilityTimeout rather than removing it; it is
+// removed by Ack, and if it is not acknowledged in time, because the
+// consumer failed or the process crashed, it is handed out again. Every
+// message is therefore delivered at least once.
+type Queue struct {
+	d    *Driver
+	name string
+
+	// VisibilityTimeout is how long a popped message is hidden before it
+	// is handed out again. Zero means DefaultVisibilityTimeout.
+	VisibilityTimeout time.Duration
+}
+
+// Message is a message popped from a Queue.
+type Message struct {
+	ID       string
+	Value    json.RawMessage
+	PushedAt time.Time
+
+	// Attempts counts the times the message has been popped, this one
+	// included.
+	Attempts int
+
+	receipt string
+}
+
+// queued is a message as stored in the queue's collection.
+type queued struct {
+	Value     json.RawMessage `json:"value"`
+	PushedAt  time.Time       `json:"pushedAt"`
+	VisibleAt time.Time       `json:"visibleAt"`
+	Attempts  int             `json:"attempts"`
+	Receipt   string          `json:"receipt,omitempty"`
+}
+
+// Queue returns the queue kept in collection name. Messages are ordered
+// by ULIDs, which follow the driver's clock.
+func (d *Driver) Queue(name string) *Queue {
+	return &Queue{d: d, name: name}
+}
+
+// Push adds v to the back of the queue and returns its message ID.
+func (q *Queue) Push(v interface{}) (string, error) {
+	if err := q.d.validCollection(q.name); err != nil {
+		return "", err
+	}
+	if err := q.d.checkWritable(); err != nil {
+		return "", err
+	}
+
+	value, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("queue '%s': %w", q.name, err)
+	}
+	now := q.d.now()
+	id, err := q.d.ulids.next(now)
+	if err != nil {
+		return "", err
+	}
+	return id, q.d.Write(q.name, id, queued{Value: value, PushedAt: now, VisibleAt: now})
+}
+
+// Pop hands out the oldest message that is not hidden, hiding it for the
+// visibility timeout. It returns ErrQueueEmpty if there is none.
+func (q *Queue) Pop() (*Message, error) {
+	if err := q.d.validCollection(q.name); err != nil {
+		return nil, err
+	}
+	if err := q.d.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	mutex := q.d.lock(queuesLock + q.name)
+	defer mutex.Unlock()
+
+	keys, err := q.d.keys(q.name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := q.d.now()
+	for _, id := range keys {
+		var m queued
+		if err := q.d.Read(q.name, id, &m); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if now.Before(m.VisibleAt) {
+			continue
+		}
+
+		receipt, err := newUUID()
+		if err != nil {
+			return nil, err
+		}
+		m.Attempts++
+		m.Receipt = receipt
+		m.VisibleAt = now.Add(q.visibility())
+		if err := q.d.Write(q.name, id, m); err != nil {
+			return nil, err
+		}
+		return &Message{ID: id, Value: m.Value, PushedAt: m.PushedAt, Attempts: m.Attempts, receipt: receipt}, nil
+	}
+	return nil, ErrQueueEmpty
+}
+
+// Ack removes msg from the queue once it has been handled. It fails if
+// the message's visibility timeout ran out and it was popped again since.
+func (q *Queue) Ack(msg *Message) error {
+	return q.settle(msg, func(id string, m *queued) error {
+		if err := q.d.Delete(q.name, id); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	})
+}
+
+// Nack hands msg back, making it ready to be popped again at once.
+func (q *Queue) Nack(msg *Message) error {
+	return q.settle(msg, func(id string, m *queued) error {
+		m.Receipt = ""
+		m.VisibleAt = q.d.now()
+		return q.d.Write(q.name, id, m)
+	})
+}
+
+func (q *Queue) settle(msg *Message, fn func(id string, m *queued) error) error {
+	if err := q.d.validCollection(q.name); err != nil {
+		return err
+	}
+	if err := q.d.checkWritable(); err != nil {
+		return err
+	}
+
+	mutex := q.d.lock(queuesLock + q.name)
+	defer mutex.Unlock()
+
+	var m queued
+	if err := q.d.Read(q.name, msg.ID, &m); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("message '%s' is no longer in queue '%s'", msg.ID, q.name)
+		}
+		return err
+	}
+	if m.Receipt != msg.receipt {
+		return fmt.Errorf("message '%s' in queue '%s' was popped again after its visibility timeout", msg.ID, q.name)
+	}
+	return fn(msg.ID, &m)
+}
+
+// Len returns the number of messages in the queue, hidden ones included.
+func (q *Queue) Len() (int, error) {
+	if err := q.d.validCollection(q.name); err != nil {
+		return 0, err
+	}
+	keys, err := q.d.keys(q.name)
+	return len(keys), err
+}
+
+func (q *Queue) visibility() time.Duration {
+	if q.VisibilityTimeout > 0 {
+		return q.VisibilityTimeout
+	}
+	return DefaultVisibilityTimeout
+}
@@ -0,0 +1,254 @@
+package litedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ErrQuotaExceeded is returned by writes a RejectWrites quota refuses.
+var ErrQuotaExceeded = errors.New("collection quota exceeded")
+
+// QuotaPolicy is what a write over a collection's Quota does.
+type QuotaPolicy int
+
+const (
+	// RejectWrites fails the write with ErrQuotaExceeded.
+	RejectWrites QuotaPolicy = iota
+	// EvictOldest deletes the earliest created records to make room.
+	EvictOldest
+	// EvictLRU deletes the least recently read or written records.
+	EvictLRU
+)
+
+func (p QuotaPolicy) String() string {
+	switch p {
+	case RejectWrites:
+		return "reject"
+	case EvictOldest:
+		return "evict-oldest"
+	case EvictLRU:
+		return "evict-lru"
+	}
+	return fmt.Sprintf("QuotaPolicy(%d)", int(p))
+}
+
+// Quota bounds a collection. Zero limits are unbounded.
+type Quota struct {
+	MaxRecords int
+	MaxBytes   int64
+	Policy     QuotaPolicy
+}
+
+// SetQuota bounds collection. A quota with both limits zero removes it.
+func (d *Driver) SetQuota(collection string, q Quota) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if q.MaxRecords < 0 || q.MaxBytes < 0 {
+		return fmt.Errorf("quota limits cannot be negative")
+	}
+	if q.Policy < RejectWrites || q.Policy > EvictLRU {
+		return fmt.Errorf("unknown quota policy %v", q.Policy)
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	c := d.configFor(collection)
+	if q.MaxRecords == 0 && q.MaxBytes == 0 {
+		c.quota = nil
+	} else {
+		c.quota = &q
+	}
+
+	return nil
+}
+
+func (d *Driver) quotaFor(collection string) *Quota {
+	d.configMutex.RLock()
+	defer d.configMutex.RUnlock()
+	if c, ok := d.configs[collection]; ok {
+		return c.quota
+	}
+	return nil
+}
+
+type quotaCandidate struct {
+	resource string
+	size     int64
+	modTime  time.Time
+}
+
+// quotaEviction is the room a write needs made once it has committed:
+// the records that may go, first to go first, and the collection's size
+// counting the write.
+type quotaEviction struct {
+	quota      *Quota
+	candidates []quotaCandidate
+	count      int
+	total      int64
+}
+
+// enforceQuota checks that size bytes under resource fit the collection's
+// quota. If records must be evicted to make room, it returns what to
+// evict, which evict does once the write has committed, so that a write
+// failing later evicts nothing. The caller must hold the collection lock.
+func (d *Driver) enforceQuota(collection, resource string, size int64) (*quotaEviction, error) {
+	q := d.quotaFor(collection)
+	if q == nil {
+		return nil, nil
+	}
+
+	files, err := readDirInfo(d.collectionDir(collection))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	e := &quotaEviction{quota: q, count: 1, total: size}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != recordExt {
+			continue
+		}
+		name := d.resourceOf(file.Name(), recordExt)
+		if name == resource {
+			continue
+		}
+		e.count++
+		e.total += file.Size()
+		e.candidates = append(e.candidates, quotaCandidate{resource: name, size: file.Size(), modTime: file.ModTime()})
+	}
+
+	if e.fits() {
+		return nil, nil
+	}
+	if q.Policy == RejectWrites || (q.MaxBytes > 0 && size > q.MaxBytes) {
+		return nil, fmt.Errorf("writing '%s' to collection '%s': %w", resource, collection, ErrQuotaExceeded)
+	}
+
+	if err := d.orderForEviction(collection, q.Policy, e.candidates); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *quotaEviction) fits() bool {
+	q := e.quota
+	return (q.MaxRecords == 0 || e.count <= q.MaxRecords) && (q.MaxBytes == 0 || e.total <= q.MaxBytes)
+}
+
+type evictKey struct{}
+
+// evict deletes records until the collection fits its quota again. Each
+// goes through Delete, so hooks, triggers and references apply as to any
+// delete; records a Restrict reference keeps are passed over. It must be
+// called without the collection lock.
+func (d *Driver) evict(ctx context.Context, collection string, e *quotaEviction) error {
+	if e == nil {
+		return nil
+	}
+	ctx = context.WithValue(ctx, evictKey{}, true)
+
+	for _, victim := range e.candidates {
+		if e.fits() {
+			return nil
+		}
+		deps, err := d.dependents(collection, []string{victim.resource})
+		if err != nil {
+			return err
+		}
+		if restricted(collection, deps) != nil {
+			continue
+		}
+		switch err := d.DeleteContext(ctx, collection, victim.resource); {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return fmt.Errorf("evicting '%s' from collection '%s': %w", victim.resource, collection, err)
+		default:
+			d.log.Debug("Evicted record", "collection", collection, "resource", victim.resource, "policy", e.quota.Policy.String())
+		}
+		e.count--
+		e.total -= victim.size
+	}
+
+	if !e.fits() {
+		d.log.Warn("Collection is over its quota; the records left cannot be evicted", "collection", collection)
+	}
+	return nil
+}
+
+// orderForEviction sorts candidates so the first should go first: by
+// creation time for EvictOldest, by last access for EvictLRU. Records with
+// no better information fall back to their modification time.
+func (d *Driver) orderForEviction(collection string, policy QuotaPolicy, candidates []quotaCandidate) error {
+	keys := make(map[string]time.Time, len(candidates))
+
+	switch policy {
+	case EvictOldest:
+		for _, c := range candidates {
+			m, err := d.readMeta(collection, c.resource)
+			if err != nil {
+				return err
+			}
+			keys[c.resource] = c.modTime
+			if m != nil && !m.CreatedAt.IsZero() {
+				keys[c.resource] = m.CreatedAt
+			}
+		}
+	case EvictLRU:
+		accessed := d.accessTimes(collection)
+		for _, c := range candidates {
+			keys[c.resource] = c.modTime
+			if t, ok := accessed[c.resource]; ok {
+				keys[c.resource] = t
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return keys[candidates[i].resource].Before(keys[candidates[j].resource])
+	})
+
+	return nil
+}
+
+// touch records an access for LRU eviction. Only collections with an LRU
+// quota are tracked.
+func (d *Driver) touch(collection, resource string) {
+	if q := d.quotaFor(collection); q == nil || q.Policy != EvictLRU {
+		return
+	}
+
+	d.accessMutex.Lock()
+	defer d.accessMutex.Unlock()
+	times, ok := d.access[collection]
+	if !ok {
+		times = make(map[string]time.Time)
+		d.access[collection] = times
+	}
+	times[resource] = d.now()
+}
+
+func (d *Driver) forget(collection, resource string) {
+	d.accessMutex.Lock()
+	defer d.accessMutex.Unlock()
+	if resource == "" {
+		delete(d.access, collection)
+		return
+	}
+	delete(d.access[collection], resource)
+}
+
+func (d *Driver) accessTimes(collection string) map[string]time.Time {
+	d.accessMutex.Lock()
+	defer d.accessMutex.Unlock()
+	times := make(map[string]time.Time, len(d.access[collection]))
+	for resource, t := range d.access[collection] {
+		times[resource] = t
+	}
+	return times
+}
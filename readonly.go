@@ -0,0 +1,66 @@
+package litedb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrReadOnly is returned for mutations of a database opened with
+// OpenReadOnly.
+var ErrReadOnly = errors.New("database is opened read-only")
+
+// OpenReadOnly opens the existing database in dir for reading only, for
+// reporting jobs and other tools that must not change it. Writes,
+// deletes and every other mutation fail with ErrReadOnly; nothing in dir
+// is created or changed, not even _litedb.meta, and dir must already
+// exist. Expired records read as missing but stay on disk, and the
+// background jobs of StartReaper, StartArchiver and StartRetention do not
+// run.
+//
+// It is safe to open a directory another process is writing, or a
+// mounted snapshot: the driver keeps no listings, filters or documents
+// cached between calls, since its own writes are what keep those
+// current, and Options.HotCacheBytes and Options.Prewarm are ignored.
+func OpenReadOnly(dir string, options ...Option) (*Driver, error) {
+	dir = filepath.Clean(dir)
+	opts, logger, err := resolveOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	opts.HotCacheBytes = 0
+	opts.Prewarm = false
+
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	driver := newDriver(dir, opts, logger)
+	driver.readOnly = true
+	logger.Debug("Using existing database read-only", "dir", dir)
+	if err := driver.checkFormat(); err != nil {
+		return nil, err
+	}
+	if err := driver.openRotation(); err != nil {
+		return nil, err
+	}
+	return driver, nil
+}
+
+// ReadOnly reports whether the database was opened with OpenReadOnly.
+func (d *Driver) ReadOnly() bool {
+	return d.readOnly
+}
+
+// checkWritable returns ErrReadOnly for a driver opened with OpenReadOnly.
+func (d *Driver) checkWritable() error {
+	if d.readOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package litedb
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrNotFound is returned by Read and Delete for records and collections
+// that do not exist. It wraps fs.ErrNotExist, so checks for either match.
+var ErrNotFound error = errNotFound{}
+
+type errNotFound struct{}
+
+func (errNotFound) Error() string { return "not found" }
+func (errNotFound) Unwrap() error { return fs.ErrNotExist }
+
+// ErrCollectionNotFound is returned by ReadAll, ReadEach, List and
+// Records for a collection that does not exist, and by Delete of a whole
+// collection. It wraps ErrNotFound. A collection that exists but holds no
+// records is not an error: ReadAll returns an empty slice.
+var ErrCollectionNotFound error = errCollectionNotFound{}
+
+type errCollectionNotFound struct{}
+
+func (errCollectionNotFound) Error() string { return "collection not found" }
+func (errCollectionNotFound) Unwrap() error { return ErrNotFound }
+
+type notFoundError struct {
+	collection, resource string
+}
+
+func (e *notFoundError) Error() string {
+	if e.resource == "" {
+		return fmt.Sprintf("collection '%s' does not exist", e.collection)
+	}
+	return fmt.Sprintf("resource '%s' does not exist in collection '%s'", e.resource, e.collection)
+}
+
+func (e *notFoundError) Unwrap() error {
+	if e.resource == "" {
+		return ErrCollectionNotFound
+	}
+	return ErrNotFound
+}
+
+// errNoRecord reports that resource, or collection if resource is empty,
+// does not exist.
+func errNoRecord(collection, resource string) error {
+	return &notFoundError{collection: collection, resource: resource}
+}
+
+// recordExt is the extension of record files. Anything else in a
+// collection directory, such as a temp file or an editor's backup, is not
+// a record.
+const recordExt = ".json"
+
+func (d *Driver) recordPath(collection, resource string) string {
+	return filepath.Join(d.collectionDir(collection), d.recordFile(resource))
+}
+
+// keys lists the resource names stored in collection. A missing collection
+// has no keys.
+func (d *Driver) keys(collection string) ([]string, error) {
+	if keys, ok := d.keyCache.cachedKeys(collection); ok {
+		return keys, nil
+	}
+
+	files, err := os.ReadDir(d.collectionDir(collection))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var keys []string
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != recordExt {
+			continue
+		}
+		keys = append(keys, d.resourceOf(file.Name(), recordExt))
+	}
+
+	if !d.readOnly && !d.isAttached(collection) {
+		d.keyCache.fill(collection, keys)
+	}
+	return keys, nil
+}
+
+// readDirInfo lists dir sorted by name with each entry's file info,
+// skipping entries removed while it runs.
+func readDirInfo(dir string) ([]fs.FileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]fs.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		fi, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, fi)
+	}
+	return infos, nil
+}
+
+// loadRecord reads a record for Read, falling back to the slower archive
+// tier when it is no longer in the collection. It reads without a stat
+// first, so a missing record costs one failed open.
+func (d *Driver) loadRecord(collection, resource string) ([]byte, error) {
+	b, err := os.ReadFile(d.recordPath(collection, resource))
+	if !errors.Is(err, fs.ErrNotExist) {
+		return b, err
+	}
+
+	if b, aerr := d.readArchived(collection, resource); aerr == nil {
+		return b, nil
+	}
+	return nil, errNoRecord(collection, resource)
+}
+
+// withRecord is loadRecord for callers that are done with the bytes when
+// fn returns, which lets them come from a pooled buffer or a mapping (see
+// readTransient).
+func (d *Driver) withRecord(collection, resource string, fn func(b []byte) error) error {
+	fb, err := d.readTransient(d.recordPath(collection, resource))
+	if errors.Is(err, fs.ErrNotExist) {
+		b, aerr := d.readArchived(collection, resource)
+		if aerr != nil {
+			return errNoRecord(collection, resource)
+		}
+		return fn(b)
+	}
+	if err != nil {
+		return err
+	}
+	defer fb.release()
+	if err := d.verifyRecord(collection, resource, fb.b); err != nil {
+		return err
+	}
+	return fn(fb.b)
+}
+
+func (d *Driver) readRecord(collection, resource string) ([]byte, error) {
+	return os.ReadFile(d.recordPath(collection, resource))
+}
+
+// removeRecord deletes a single record together with its sidecar and any
+// in-memory bookkeeping. The caller must hold the collection lock.
+func (d *Driver) removeRecord(collection, resource string) error {
+	path := d.recordPath(collection, resource)
+	d.markSelf(path)
+	if err := d.storage.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := d.commit(path); err != nil {
+		return err
+	}
+	d.keyCache.removed(collection, resource)
+	d.hot.drop(collection, resource)
+	d.setExpiry(collection, resource, time.Time{})
+	d.forget(collection, resource)
+	return d.removeMeta(collection, resource)
+}
@@ -0,0 +1,162 @@
+package litedb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// Redacted replaces the values of sensitive fields in redacted output.
+const Redacted = "[REDACTED]"
+
+// SetSensitive marks fields of collection's documents, as dotted paths
+// into nested objects, as sensitive, replacing any marked before. Their
+// values are shown as Redacted by Redact and RedactedValue and in the
+// driver's own log lines and errors; fields tagged `litedb:"sensitive"`
+// in a type given to RegisterType are marked too. Audit entries hold
+// hashes, never document values.
+func (d *Driver) SetSensitive(collection string, fields ...string) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	for _, field := range fields {
+		if field == "" {
+			return fmt.Errorf("sensitive field cannot be empty")
+		}
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	d.configFor(collection).sensitive = append([]string(nil), fields...)
+	return nil
+}
+
+// sensitiveFields returns the paths marked sensitive in collection.
+func (d *Driver) sensitiveFields(collection string) []string {
+	d.configMutex.RLock()
+	defer d.configMutex.RUnlock()
+	c, ok := d.configs[collection]
+	if !ok {
+		return nil
+	}
+	return append(append([]string(nil), c.sensitive...), c.typeSensitive...)
+}
+
+// isSensitive reports whether field of collection is marked sensitive.
+func (d *Driver) isSensitive(collection, field string) bool {
+	for _, f := range d.sensitiveFields(collection) {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns doc, a JSON document of collection, with the values of
+// its sensitive fields replaced by Redacted, for debug output. Documents
+// of collections without sensitive fields are returned as they are.
+func (d *Driver) Redact(collection string, doc []byte) ([]byte, error) {
+	fields := d.sensitiveFields(collection)
+	if len(fields) == 0 {
+		return doc, nil
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		redactPath(v, strings.Split(field, "."))
+	}
+	return json.Marshal(v)
+}
+
+// RedactedValue returns v, a document of collection, for logging with
+// slog: it logs as the document's JSON with sensitive fields redacted.
+func (d *Driver) RedactedValue(collection string, v interface{}) slog.LogValuer {
+	return redactedValue{d: d, collection: collection, v: v}
+}
+
+type redactedValue struct {
+	d          *Driver
+	collection string
+	v          interface{}
+}
+
+func (r redactedValue) LogValue() slog.Value {
+	b, err := r.d.marshal(r.v)
+	if err == nil {
+		b, err = r.d.Redact(r.collection, b)
+	}
+	if err != nil {
+		return slog.StringValue(Redacted)
+	}
+	return slog.StringValue(string(b))
+}
+
+// redactPath replaces the value at path below v, looking into every
+// element of arrays on the way.
+func redactPath(v interface{}, path []string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		child, ok := v[path[0]]
+		if !ok {
+			return
+		}
+		if len(path) == 1 {
+			v[path[0]] = Redacted
+			return
+		}
+		redactPath(child, path[1:])
+	case []interface{}:
+		for _, elem := range v {
+			redactPath(elem, path)
+		}
+	}
+}
+
+// taggedSensitive lists the JSON paths of t's fields tagged
+// `litedb:"sensitive"`, including those of nested structs.
+func taggedSensitive(t reflect.Type, prefix string, seen map[reflect.Type]bool) []string {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	var paths []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" && f.Anonymous {
+			// Embedded fields are promoted into the parent object.
+			paths = append(paths, taggedSensitive(f.Type, prefix, seen)...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		path := prefix + name
+
+		if f.Tag.Get("litedb") == "sensitive" {
+			paths = append(paths, path)
+			continue
+		}
+		paths = append(paths, taggedSensitive(f.Type, path+".", seen)...)
+	}
+	return paths
+}
@@ -0,0 +1,290 @@
+package litedb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ReferenceAction is what deleting a referenced record does to the
+// records referring to it.
+type ReferenceAction int
+
+const (
+	// Restrict fails the delete while references remain.
+	Restrict ReferenceAction = iota
+	// Cascade deletes the referring records too.
+	Cascade
+	// SetNull sets the referring field to null.
+	SetNull
+)
+
+func (a ReferenceAction) String() string {
+	switch a {
+	case Restrict:
+		return "restrict"
+	case Cascade:
+		return "cascade"
+	case SetNull:
+		return "set-null"
+	}
+	return fmt.Sprintf("ReferenceAction(%d)", int(a))
+}
+
+// Reference is a reference declared with AddReference.
+type Reference struct {
+	Collection string
+	Field      string
+	Target     string
+	OnDelete   ReferenceAction
+}
+
+// AddReference declares that field of every record in collection must name
+// an existing resource in target. Field may be a dotted path into nested
+// objects. Null, empty, or missing values are not checked.
+func (d *Driver) AddReference(collection, field, target string, onDelete ReferenceAction) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if err := d.validCollection(target); err != nil {
+		return err
+	}
+	if field == "" {
+		return fmt.Errorf("reference field cannot be empty")
+	}
+	if onDelete < Restrict || onDelete > SetNull {
+		return fmt.Errorf("unknown reference action %v", onDelete)
+	}
+
+	ref := Reference{Collection: collection, Field: field, Target: target, OnDelete: onDelete}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	src := d.configFor(collection)
+	src.references = append(src.references, ref)
+	dst := d.configFor(target)
+	dst.referencedBy = append(dst.referencedBy, ref)
+
+	return nil
+}
+
+func (d *Driver) hasReferences(collection string) bool {
+	d.configMutex.RLock()
+	defer d.configMutex.RUnlock()
+	c, ok := d.configs[collection]
+	return ok && len(c.references) > 0
+}
+
+func (d *Driver) checkReferences(collection, resource string, b []byte) error {
+	d.configMutex.RLock()
+	var refs []Reference
+	if c, ok := d.configs[collection]; ok {
+		refs = c.references
+	}
+	d.configMutex.RUnlock()
+
+	if len(refs) == 0 {
+		return nil
+	}
+
+	doc, err := decodeDocument(b)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		key, err := referenceKey(doc, ref.Field)
+		if err != nil {
+			return fmt.Errorf("reference '%s' of '%s' in collection '%s': %w", ref.Field, resource, collection, err)
+		}
+		if key == "" {
+			continue
+		}
+		if _, err := os.Stat(d.recordPath(ref.Target, key)); err != nil {
+			if d.isSensitive(collection, ref.Field) {
+				key = Redacted
+			}
+			return fmt.Errorf("reference '%s' of '%s' in collection '%s': resource '%s' does not exist in collection '%s'", ref.Field, resource, collection, key, ref.Target)
+		}
+	}
+
+	return nil
+}
+
+type dependent struct {
+	ref       Reference
+	resources []string
+}
+
+// dependents finds the records that reference any of keys in collection,
+// grouped by the reference that links them.
+func (d *Driver) dependents(collection string, keys []string) ([]dependent, error) {
+	d.configMutex.RLock()
+	var refs []Reference
+	if c, ok := d.configs[collection]; ok {
+		refs = c.referencedBy
+	}
+	d.configMutex.RUnlock()
+
+	if len(refs) == 0 || len(keys) == 0 {
+		return nil, nil
+	}
+
+	deleted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		deleted[key] = true
+	}
+
+	var deps []dependent
+	for _, ref := range refs {
+		resources, err := d.keys(ref.Collection)
+		if err != nil {
+			return nil, err
+		}
+
+		dep := dependent{ref: ref}
+		for _, resource := range resources {
+			b, err := d.readRecord(ref.Collection, resource)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			doc, err := decodeDocument(b)
+			if err != nil {
+				continue
+			}
+			if key, err := referenceKey(doc, ref.Field); err == nil && deleted[key] {
+				dep.resources = append(dep.resources, resource)
+			}
+		}
+
+		if len(dep.resources) > 0 {
+			deps = append(deps, dep)
+		}
+	}
+
+	return deps, nil
+}
+
+// withoutCollection drops dependents that live in collection itself; they
+// go away with it when the whole collection is deleted.
+func withoutCollection(deps []dependent, collection string) []dependent {
+	var kept []dependent
+	for _, dep := range deps {
+		if dep.ref.Collection != collection {
+			kept = append(kept, dep)
+		}
+	}
+	return kept
+}
+
+func restricted(collection string, deps []dependent) error {
+	for _, dep := range deps {
+		if dep.ref.OnDelete == Restrict {
+			return fmt.Errorf("cannot delete from collection '%s': referenced by '%s' in collection '%s' via '%s'", collection, dep.resources[0], dep.ref.Collection, dep.ref.Field)
+		}
+	}
+	return nil
+}
+
+func (d *Driver) applyDeleteActions(ctx context.Context, deps []dependent) error {
+	for _, dep := range deps {
+		for _, resource := range dep.resources {
+			switch dep.ref.OnDelete {
+			case Cascade:
+				if _, err := os.Stat(d.recordPath(dep.ref.Collection, resource)); err != nil {
+					continue
+				}
+				if err := d.DeleteContext(ctx, dep.ref.Collection, resource); err != nil {
+					return err
+				}
+			case SetNull:
+				if err := d.nullifyReference(ctx, dep.ref, resource); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Driver) nullifyReference(ctx context.Context, ref Reference, resource string) error {
+	b, err := d.readRecord(ref.Collection, resource)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	doc, err := decodeDocument(b)
+	if err != nil {
+		return err
+	}
+
+	path := strings.Split(ref.Field, ".")
+	parent := doc
+	for _, name := range path[:len(path)-1] {
+		next, ok := parent[name].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		parent = next
+	}
+	parent[path[len(path)-1]] = nil
+
+	return d.WriteContext(ctx, ref.Collection, resource, doc)
+}
+
+func decodeDocument(b []byte) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("document is not a JSON object")
+	}
+	return doc, nil
+}
+
+// referenceKey extracts the resource name stored at the dotted field path.
+// An empty key means the reference is unset.
+func referenceKey(doc map[string]interface{}, field string) (string, error) {
+	value, ok := lookupField(doc, field)
+	if !ok {
+		return "", nil
+	}
+
+	switch key := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return key, nil
+	case json.Number:
+		return key.String(), nil
+	}
+
+	return "", fmt.Errorf("value must be a string or number")
+}
+
+// lookupField follows a dotted path through nested objects.
+func lookupField(doc map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = doc
+	for _, name := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = obj[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
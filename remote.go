@@ -0,0 +1,411 @@
+package litedb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/SagarDas211/LiteDB-Go/litedbgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// Remote is a database served by another process through Handler or
+// RegisterGRPC. It has the Driver's read and write methods, so code can
+// move between embedded and client-server use unchanged. Errors wrap the
+// same sentinels as the Driver's: ErrNotFound, ErrQuotaExceeded,
+// ErrReadOnly, ErrReadOnlyReplica, ErrUnauthenticated,
+// ErrPermissionDenied, ErrRateLimited and ErrRequestTooLarge.
+type Remote struct {
+	transport remoteTransport
+	close     func() error
+}
+
+// ConnectOptions configures Connect. The zero value is usable.
+type ConnectOptions struct {
+	// HTTPClient makes requests to http:// and https:// servers. It
+	// defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Header is sent with every HTTP request, for example to carry
+	// credentials.
+	Header http.Header
+
+	// DialOptions are used for grpc:// servers. Without any, the
+	// connection is unencrypted.
+	DialOptions []grpc.DialOption
+
+	// APIKey authenticates to a server under UseAccessControl.
+	APIKey string
+}
+
+// apiKeyCredentials sends an API key with every gRPC call.
+type apiKeyCredentials string
+
+func (k apiKeyCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"x-api-key": string(k)}, nil
+}
+
+// RequireTransportSecurity leaves encryption to the DialOptions, which
+// are unencrypted by default.
+func (k apiKeyCredentials) RequireTransportSecurity() bool { return false }
+
+type remoteTransport interface {
+	collections(ctx context.Context) ([]string, error)
+	get(ctx context.Context, collection, resource string) (json.RawMessage, error)
+	put(ctx context.Context, collection, resource string, doc json.RawMessage) error
+	delete(ctx context.Context, collection, resource string) error
+	find(ctx context.Context, collection string, filter Filter) ([]Record, error)
+}
+
+// Connect returns a client for the server at addr: an http:// or https://
+// URL for the HTTP API, or grpc://host:port for gRPC.
+func Connect(addr string, options *ConnectOptions) (*Remote, error) {
+	opts := ConnectOptions{}
+	if options != nil {
+		opts = *options
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		client := opts.HTTPClient
+		if client == nil {
+			client = http.DefaultClient
+		}
+		header := opts.Header
+		if opts.APIKey != "" {
+			header = header.Clone()
+			if header == nil {
+				header = make(http.Header)
+			}
+			header.Set("X-API-Key", opts.APIKey)
+		}
+		t := &httpTransport{base: strings.TrimRight(addr, "/"), client: client, header: header}
+		return &Remote{transport: t, close: func() error { return nil }}, nil
+
+	case "grpc":
+		dial := opts.DialOptions
+		if len(dial) == 0 {
+			dial = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+		}
+		if opts.APIKey != "" {
+			dial = append(dial[:len(dial):len(dial)], grpc.WithPerRPCCredentials(apiKeyCredentials(opts.APIKey)))
+		}
+		conn, err := grpc.NewClient(u.Host, dial...)
+		if err != nil {
+			return nil, err
+		}
+		return &Remote{transport: grpcTransport{litedbgrpc.NewClient(conn)}, close: conn.Close}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported scheme '%s' in %s", u.Scheme, addr)
+}
+
+// Close releases the connection.
+func (r *Remote) Close() error {
+	return r.close()
+}
+
+// Write stores v as resource in collection on the server.
+func (r *Remote) Write(collection, resource string, v interface{}) error {
+	return r.WriteContext(context.Background(), collection, resource, v)
+}
+
+// WriteContext is Write with a context.
+func (r *Remote) WriteContext(ctx context.Context, collection, resource string, v interface{}) error {
+	if collection == "" {
+		return fmt.Errorf("missing collection - no place to save record")
+	}
+	if resource == "" {
+		return fmt.Errorf("missing resource - unable to save record (no name)")
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return r.transport.put(ctx, collection, resource, b)
+}
+
+// Read decodes resource in collection on the server into v.
+func (r *Remote) Read(collection, resource string, v interface{}) error {
+	return r.ReadContext(context.Background(), collection, resource, v)
+}
+
+// ReadContext is Read with a context.
+func (r *Remote) ReadContext(ctx context.Context, collection, resource string, v interface{}) error {
+	if collection == "" {
+		return fmt.Errorf("collection name cannot be empty")
+	}
+	if resource == "" {
+		return fmt.Errorf("resource name cannot be empty")
+	}
+
+	b, err := r.transport.get(ctx, collection, resource)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// ReadAll returns every document in collection on the server.
+func (r *Remote) ReadAll(collection string) ([]string, error) {
+	return r.ReadAllContext(context.Background(), collection)
+}
+
+// ReadAllContext is ReadAll with a context.
+func (r *Remote) ReadAllContext(ctx context.Context, collection string) ([]string, error) {
+	if collection == "" {
+		return nil, fmt.Errorf("collection name cannot be empty")
+	}
+
+	found, err := r.transport.find(ctx, collection, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]string, len(found))
+	for i, rec := range found {
+		records[i] = string(rec.Data)
+	}
+	return records, nil
+}
+
+// Delete removes resource from collection on the server, or the whole
+// collection when resource is empty.
+func (r *Remote) Delete(collection, resource string) error {
+	return r.DeleteContext(context.Background(), collection, resource)
+}
+
+// DeleteContext is Delete with a context.
+func (r *Remote) DeleteContext(ctx context.Context, collection, resource string) error {
+	if collection == "" {
+		return fmt.Errorf("collection name cannot be empty")
+	}
+	return r.transport.delete(ctx, collection, resource)
+}
+
+// Collections lists the collections on the server.
+func (r *Remote) Collections() ([]string, error) {
+	return r.transport.collections(context.Background())
+}
+
+// Find returns the records in collection on the server matching filter.
+func (r *Remote) Find(collection string, filter Filter) ([]Record, error) {
+	return r.FindContext(context.Background(), collection, filter)
+}
+
+// FindContext is Find with a context.
+func (r *Remote) FindContext(ctx context.Context, collection string, filter Filter) ([]Record, error) {
+	if collection == "" {
+		return nil, fmt.Errorf("collection name cannot be empty")
+	}
+	return r.transport.find(ctx, collection, filter)
+}
+
+// remoteError carries the server's message while matching the sentinel
+// the server's status stands for.
+type remoteError struct {
+	msg string
+	err error
+}
+
+func (e *remoteError) Error() string { return e.msg }
+func (e *remoteError) Unwrap() error { return e.err }
+
+type httpTransport struct {
+	base   string
+	client *http.Client
+	header http.Header
+}
+
+func (t *httpTransport) collections(ctx context.Context) ([]string, error) {
+	var res struct {
+		Collections []string `json:"collections"`
+	}
+	return res.Collections, t.do(ctx, http.MethodGet, "/collections", nil, &res)
+}
+
+func (t *httpTransport) get(ctx context.Context, collection, resource string) (json.RawMessage, error) {
+	var doc json.RawMessage
+	return doc, t.do(ctx, http.MethodGet, recordURL(collection, resource), nil, &doc)
+}
+
+func (t *httpTransport) put(ctx context.Context, collection, resource string, doc json.RawMessage) error {
+	return t.do(ctx, http.MethodPut, recordURL(collection, resource), doc, nil)
+}
+
+func (t *httpTransport) delete(ctx context.Context, collection, resource string) error {
+	if resource == "" {
+		return t.do(ctx, http.MethodDelete, "/collections/"+url.PathEscape(collection), nil, nil)
+	}
+	return t.do(ctx, http.MethodDelete, recordURL(collection, resource), nil, nil)
+}
+
+func (t *httpTransport) find(ctx context.Context, collection string, filter Filter) ([]Record, error) {
+	var res struct {
+		Records []Record `json:"records"`
+	}
+	path := "/collections/" + url.PathEscape(collection)
+	if len(filter) == 0 {
+		return res.Records, t.do(ctx, http.MethodGet, path+"/records", nil, &res)
+	}
+
+	b, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+	return res.Records, t.do(ctx, http.MethodPost, path+"/query", b, &res)
+}
+
+func recordURL(collection, resource string) string {
+	return "/collections/" + url.PathEscape(collection) + "/records/" + url.PathEscape(resource)
+}
+
+func (t *httpTransport) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, t.base+path, r)
+	if err != nil {
+		return err
+	}
+	for k, v := range t.header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(io.LimitReader(res.Body, 4096)).Decode(&e) != nil || e.Error == "" {
+			e.Error = res.Status
+		}
+		return &remoteError{msg: e.Error, err: httpSentinel(res.StatusCode, e.Error)}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// httpSentinel tells the errors sent as 403 apart by their message.
+func httpSentinel(code int, msg string) error {
+	switch code {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusInsufficientStorage:
+		return ErrQuotaExceeded
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusRequestEntityTooLarge:
+		return ErrRequestTooLarge
+	case http.StatusUnauthorized:
+		return ErrUnauthenticated
+	case http.StatusForbidden:
+		if strings.HasSuffix(msg, ErrPermissionDenied.Error()) {
+			return ErrPermissionDenied
+		}
+		return readOnlySentinel(msg)
+	}
+	return nil
+}
+
+// exhaustedSentinel tells the errors sent as ResourceExhausted apart.
+func exhaustedSentinel(msg string) error {
+	switch {
+	case strings.HasSuffix(msg, ErrRateLimited.Error()):
+		return ErrRateLimited
+	case strings.HasSuffix(msg, ErrRequestTooLarge.Error()):
+		return ErrRequestTooLarge
+	}
+	return ErrQuotaExceeded
+}
+
+// readOnlySentinel tells a read-only database from a replica.
+func readOnlySentinel(msg string) error {
+	if strings.HasSuffix(msg, ErrReadOnly.Error()) {
+		return ErrReadOnly
+	}
+	return ErrReadOnlyReplica
+}
+
+type grpcTransport struct {
+	client *litedbgrpc.Client
+}
+
+func (t grpcTransport) collections(ctx context.Context) ([]string, error) {
+	names, err := t.client.Collections(ctx)
+	return names, grpcError(err)
+}
+
+func (t grpcTransport) get(ctx context.Context, collection, resource string) (json.RawMessage, error) {
+	var doc json.RawMessage
+	return doc, grpcError(t.client.Read(ctx, collection, resource, &doc))
+}
+
+func (t grpcTransport) put(ctx context.Context, collection, resource string, doc json.RawMessage) error {
+	return grpcError(t.client.Write(ctx, collection, resource, doc))
+}
+
+func (t grpcTransport) delete(ctx context.Context, collection, resource string) error {
+	return grpcError(t.client.Remove(ctx, collection, resource))
+}
+
+func (t grpcTransport) find(ctx context.Context, collection string, filter Filter) ([]Record, error) {
+	found, err := t.client.Query(ctx, collection, filter)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	records := make([]Record, len(found))
+	for i, rec := range found {
+		records[i] = Record{ID: rec.Id, Data: rec.Data}
+	}
+	return records, nil
+}
+
+func grpcError(err error) error {
+	st, ok := status.FromError(err)
+	if err == nil || !ok {
+		return err
+	}
+
+	var sentinel error
+	switch st.Code() {
+	case codes.NotFound:
+		sentinel = ErrNotFound
+	case codes.ResourceExhausted:
+		sentinel = exhaustedSentinel(st.Message())
+	case codes.FailedPrecondition:
+		sentinel = readOnlySentinel(st.Message())
+	case codes.Unauthenticated:
+		sentinel = ErrUnauthenticated
+	case codes.PermissionDenied:
+		sentinel = ErrPermissionDenied
+	}
+	return &remoteError{msg: st.Message(), err: sentinel}
+}
@@ -0,0 +1,61 @@
+package litedb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/SagarDas211/LiteDB-Go/litedbrepl"
+)
+
+// ErrReadOnlyReplica is returned for writes to a replica that has not been
+// promoted.
+var ErrReadOnlyReplica = errors.New("database is a read-only replica")
+
+type replicatingKey struct{}
+
+// ReplicationPrimary serves this database's mutations to replicas; mount
+// the primary as an http.Handler. The CancelFunc detaches it.
+func (d *Driver) ReplicationPrimary() (*litedbrepl.Primary, CancelFunc) {
+	p := litedbrepl.NewPrimary(replStore{serverStore{d}})
+	return p, d.AddCDCSink(p)
+}
+
+// Replicate makes this database a replica of the primary at primaryURL.
+// Writes from anywhere but the primary fail with ErrReadOnlyReplica until
+// the replica is promoted. Call Run on the result to start following.
+func (d *Driver) Replicate(primaryURL string) *litedbrepl.Replica {
+	r := litedbrepl.NewReplica(primaryURL, replStore{serverStore{d}})
+	r.Logger = d.log
+
+	d.Use(func(next Op) Op {
+		return func(ctx context.Context, op *Operation) error {
+			if (op.Kind == OpWrite || op.Kind == OpDelete) && ctx.Value(replicatingKey{}) == nil && !r.Promoted() {
+				return ErrReadOnlyReplica
+			}
+			return next(ctx, op)
+		}
+	})
+	return r
+}
+
+type replStore struct {
+	serverStore
+}
+
+func (s replStore) Keys(collection string) ([]string, error) {
+	return s.d.Keys(collection)
+}
+
+func (s replStore) Put(ctx context.Context, collection, resource string, doc json.RawMessage) error {
+	return s.d.WriteContext(context.WithValue(ctx, replicatingKey{}, true), collection, resource, doc)
+}
+
+func (s replStore) Delete(ctx context.Context, collection, resource string) error {
+	if _, err := os.Stat(s.d.recordPath(collection, resource)); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return s.d.DeleteContext(context.WithValue(ctx, replicatingKey{}, true), collection, resource)
+}
@@ -0,0 +1,39 @@
+package litedb
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/SagarDas211/LiteDB-Go/litedbresp"
+)
+
+// RESPServer returns a server speaking a subset of the Redis protocol;
+// see package litedbresp.
+func (d *Driver) RESPServer() *litedbresp.Server {
+	return litedbresp.New(respStore{serverStore{d}})
+}
+
+type respStore struct {
+	serverStore
+}
+
+func (s respStore) Keys(collection string) ([]string, error) {
+	return s.d.Keys(collection)
+}
+
+func (s respStore) Put(ctx context.Context, collection, resource string, doc json.RawMessage, ttl time.Duration) error {
+	return s.d.write(ctx, collection, resource, doc, ttl)
+}
+
+func (s respStore) TTL(collection, resource string) (time.Duration, error) {
+	st, err := s.d.Stat(collection, resource)
+	if err != nil {
+		return 0, err
+	}
+	return st.TTL, nil
+}
+
+func (s respStore) SetTTL(collection, resource string, ttl time.Duration) error {
+	return s.d.SetTTL(collection, resource, ttl)
+}
@@ -0,0 +1,176 @@
+package litedb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// RetentionPolicy deletes records whose Field timestamp is older than
+// MaxAge. Field is a dotted path holding an RFC 3339 string or Unix seconds.
+type RetentionPolicy struct {
+	Field  string
+	MaxAge time.Duration
+}
+
+// RetentionReport lists the records a retention run expired, or would
+// have on a dry run.
+type RetentionReport struct {
+	Collection string
+	Cutoff     time.Time
+	DryRun     bool
+	Expired    []string
+	Failed     map[string]error
+
+	// Points is how many time series points went with the partitions
+	// dropped, for a collection set up with SetSeries.
+	Points int
+}
+
+// SetRetention sets collection's retention policy. A zero MaxAge removes
+// it.
+func (d *Driver) SetRetention(collection string, p RetentionPolicy) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if p.MaxAge < 0 {
+		return fmt.Errorf("retention age cannot be negative")
+	}
+	if p.MaxAge > 0 && p.Field == "" {
+		return fmt.Errorf("retention field cannot be empty")
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	c := d.configFor(collection)
+	if p.MaxAge == 0 {
+		c.retention = nil
+	} else {
+		c.retention = &p
+	}
+
+	return nil
+}
+
+// ApplyRetention deletes the records that fall outside the collection's
+// retention policy, and the time series partitions outside its
+// SeriesOptions.Retention. With dryRun it only reports what would be
+// deleted.
+func (d *Driver) ApplyRetention(collection string, dryRun bool) (*RetentionReport, error) {
+	if err := d.validCollection(collection); err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		if err := d.checkWritable(); err != nil {
+			return nil, err
+		}
+	}
+
+	d.configMutex.RLock()
+	var policy *RetentionPolicy
+	if c, ok := d.configs[collection]; ok {
+		policy = c.retention
+	}
+	d.configMutex.RUnlock()
+
+	report := &RetentionReport{Collection: collection, DryRun: dryRun}
+	if err := d.applySeriesRetention(collection, report); err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return report, nil
+	}
+	report.Cutoff = d.now().Add(-policy.MaxAge)
+
+	keys, err := d.keys(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resource := range keys {
+		b, err := d.readRecord(collection, resource)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		doc, err := decodeDocument(b)
+		if err != nil {
+			continue
+		}
+		value, ok := lookupField(doc, policy.Field)
+		if !ok {
+			continue
+		}
+		t, ok := parseTimestamp(value)
+		if !ok || !t.Before(report.Cutoff) {
+			continue
+		}
+
+		report.Expired = append(report.Expired, resource)
+		if dryRun {
+			continue
+		}
+		if err := d.Delete(collection, resource); err != nil {
+			if report.Failed == nil {
+				report.Failed = make(map[string]error)
+			}
+			report.Failed[resource] = err
+		}
+	}
+
+	return report, nil
+}
+
+// StartRetention applies every registered retention policy each interval
+// until the returned function is called.
+func (d *Driver) StartRetention(interval time.Duration) func() {
+	return d.every(interval, d.retainOnce)
+}
+
+// retainOnce is one pass of StartRetention, logging what it did.
+func (d *Driver) retainOnce() {
+	d.configMutex.RLock()
+	var collections []string
+	for name, c := range d.configs {
+		if c.retention != nil || (c.series != nil && c.series.Retention > 0) {
+			collections = append(collections, name)
+		}
+	}
+	d.configMutex.RUnlock()
+
+	for _, collection := range collections {
+		report, err := d.ApplyRetention(collection, false)
+		if err != nil {
+			d.log.Error("Applying retention failed", "collection", collection, "err", err)
+			continue
+		}
+		for resource, err := range report.Failed {
+			d.log.Warn("Retention could not delete record", "collection", collection, "resource", resource, "err", err)
+		}
+		if n := len(report.Expired) - len(report.Failed); n > 0 {
+			d.log.Debug("Retention deleted records", "collection", collection, "records", n)
+		}
+		if report.Points > 0 {
+			d.log.Debug("Retention dropped series points", "collection", collection, "points", report.Points)
+		}
+	}
+}
+
+func parseTimestamp(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	case json.Number:
+		if secs, err := v.Int64(); err == nil {
+			return time.Unix(secs, 0), true
+		}
+		if secs, err := v.Float64(); err == nil {
+			return time.Unix(0, int64(secs*float64(time.Second))), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,219 @@
+package litedb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// rotationFile records an unfinished RotateKey, so it resumes when the
+// database is next opened.
+const rotationFile = "_rotation.json"
+
+// ErrRotating is returned by RotateKey while an earlier rotation is still
+// re-signing records.
+var ErrRotating = errors.New("key rotation already in progress")
+
+// rotationState is the progress of a rotation. Keys are identified by a
+// fingerprint, never stored.
+type rotationState struct {
+	From string   `json:"from"`
+	To   string   `json:"to"`
+	Done []string `json:"done,omitempty"`
+}
+
+// RotateKey replaces the signing key with newKey. New writes are signed
+// with newKey at once, and a background goroutine re-signs the existing
+// records a collection at a time; until it finishes, records signed with
+// either key verify. Progress is saved in the database, so a rotation cut
+// short by Close or a crash carries on when the database is opened again
+// with SigningKey set to newKey and PreviousSigningKey to the old key.
+// Records whose signature matches neither key are left as they are, and
+// still fail with ErrTampered.
+//
+// Namespace views keep their own signing key and are rotated by calling
+// RotateKey on each view.
+func (d *Driver) RotateKey(newKey []byte) error {
+	if err := d.checkOpen(); err != nil {
+		return err
+	}
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	if len(newKey) == 0 {
+		return fmt.Errorf("signing key cannot be empty")
+	}
+
+	d.signing.mutex.Lock()
+	if d.signing.current == nil {
+		d.signing.mutex.Unlock()
+		return fmt.Errorf("records are not signed: set Options.SigningKey")
+	}
+	if d.signing.previous != nil {
+		d.signing.mutex.Unlock()
+		return ErrRotating
+	}
+	state := &rotationState{From: keyID(d.signing.current), To: keyID(newKey)}
+	if err := d.writeStateFile(d.rotationPath(), state); err != nil {
+		d.signing.mutex.Unlock()
+		return err
+	}
+	d.signing.previous = d.signing.current
+	d.signing.current = append([]byte(nil), newKey...)
+	d.signing.mutex.Unlock()
+
+	d.log.Info("Rotating signing key", "dir", d.dir)
+	d.startRotation(state)
+	return nil
+}
+
+// openRotation picks up a rotation left unfinished, taking the old key
+// from Options.PreviousSigningKey. A writable driver resumes re-signing.
+func (d *Driver) openRotation() error {
+	state := &rotationState{}
+	if err := readStateFile(d.rotationPath(), state); err != nil {
+		return err
+	}
+	if state.To == "" {
+		return nil
+	}
+
+	current := d.opts.SigningKey
+	if current == nil || keyID(current) != state.To {
+		return fmt.Errorf("signing key rotation in progress: SigningKey must be the key being rotated to")
+	}
+	previous := d.opts.PreviousSigningKey
+	if previous == nil || keyID(previous) != state.From {
+		return fmt.Errorf("signing key rotation in progress: PreviousSigningKey must be the key being replaced")
+	}
+
+	d.signing.mutex.Lock()
+	d.signing.previous = previous
+	d.signing.mutex.Unlock()
+
+	if !d.readOnly {
+		d.startRotation(state)
+	}
+	return nil
+}
+
+// startRotation re-signs records on a goroutine until every collection
+// is done, or the driver is closed.
+func (d *Driver) startRotation(state *rotationState) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		defer close(done)
+		if err := d.rotate(state, stop); err != nil {
+			d.log.Error("Signing key rotation stopped", "dir", d.dir, "err", err)
+		}
+	}()
+
+	d.onClose(func() {
+		once.Do(func() { close(stop) })
+		<-done
+	})
+}
+
+// rotate re-signs the records of each collection not yet done, saving
+// progress after each, and ends the rotation once all are.
+func (d *Driver) rotate(state *rotationState, stop <-chan struct{}) error {
+	collections, err := d.metaCollections()
+	if err != nil {
+		return err
+	}
+
+	done := make(map[string]bool, len(state.Done))
+	for _, collection := range state.Done {
+		done[collection] = true
+	}
+
+	for _, collection := range collections {
+		if done[collection] {
+			continue
+		}
+		finished, err := d.resignCollection(collection, stop)
+		if err != nil || !finished {
+			return err
+		}
+		state.Done = append(state.Done, collection)
+		if err := d.writeStateFile(d.rotationPath(), state); err != nil {
+			return err
+		}
+	}
+
+	d.signing.mutex.Lock()
+	defer d.signing.mutex.Unlock()
+	if err := d.removeStateFile(d.rotationPath()); err != nil {
+		return err
+	}
+	d.signing.previous = nil
+	d.log.Info("Signing key rotation finished", "dir", d.dir)
+	return nil
+}
+
+// resignCollection re-signs the collection's records signed with the
+// previous key. It reports false if stop closed first.
+func (d *Driver) resignCollection(collection string, stop <-chan struct{}) (bool, error) {
+	files, err := os.ReadDir(filepath.Join(d.dir, metaDir, collection))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	for _, file := range files {
+		select {
+		case <-stop:
+			return false, nil
+		default:
+		}
+		if file.IsDir() || filepath.Ext(file.Name()) != recordExt {
+			continue
+		}
+		if err := d.resign(collection, d.resourceOf(file.Name(), recordExt)); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// resign moves one record's signature from the previous key to the
+// current one.
+func (d *Driver) resign(collection, resource string) error {
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+
+	m, err := d.readMeta(collection, resource)
+	if err != nil || m == nil || m.Signature == "" {
+		return err
+	}
+
+	current, previous := d.signing.keys()
+	if previous == nil || !signedWith(previous, collection, resource, m.Checksum, m.Signature) {
+		// Already re-signed, rewritten since, or tampered with.
+		return nil
+	}
+	m.Signature = sign(current, collection, resource, m.Checksum)
+	return d.writeMeta(collection, resource, m)
+}
+
+func (d *Driver) rotationPath() string {
+	return filepath.Join(d.dir, rotationFile)
+}
+
+// keyID fingerprints a key, so the rotation state can name keys without
+// revealing them.
+func keyID(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("litedb signing key"))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
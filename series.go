@@ -0,0 +1,464 @@
+package litedb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	seriesDir = "_series"
+	seriesExt = ".ndjson"
+
+	// partitionLayout names a partition by the UTC start and end of its
+	// window, so Range and retention pick partitions without opening them.
+	partitionLayout = "20060102T150405Z"
+)
+
+// SeriesOptions makes a collection a time series. See SetSeries.
+type SeriesOptions struct {
+	// Window is the span of time each partition holds. It must be a whole
+	// number of seconds and defaults to an hour. Changing it later leaves
+	// the partitions already written as they are.
+	Window time.Duration
+
+	// Retention drops whole partitions once their window ended longer ago
+	// than this, when ApplyRetention runs. Zero keeps every point.
+	Retention time.Duration
+}
+
+// Point is a reading in a time series.
+type Point struct {
+	Time  time.Time       `json:"t"`
+	Value json.RawMessage `json:"v"`
+}
+
+// Aggregation is how Downsample combines the points in a bucket.
+type Aggregation int
+
+const (
+	// Mean is the average of the values.
+	Mean Aggregation = iota
+	// Min is the smallest value.
+	Min
+	// Max is the largest value.
+	Max
+	// Sum adds the values up.
+	Sum
+	// Count is how many points there are, whatever their values.
+	Count
+	// First is the earliest point's value.
+	First
+	// Last is the latest point's value.
+	Last
+)
+
+func (a Aggregation) String() string {
+	switch a {
+	case Mean:
+		return "mean"
+	case Min:
+		return "min"
+	case Max:
+		return "max"
+	case Sum:
+		return "sum"
+	case Count:
+		return "count"
+	case First:
+		return "first"
+	case Last:
+		return "last"
+	}
+	return fmt.Sprintf("Aggregation(%d)", int(a))
+}
+
+// Bucket is one step of a downsampled series: the points from Start up to
+// the next bucket, combined. Count is how many points went into Value.
+type Bucket struct {
+	Start time.Time
+	Count int
+	Value float64
+}
+
+// SetSeries makes collection a time series, for readings such as metrics
+// that would be far too many to keep as a record each. Append adds points
+// to files under _series, one per window of time, one JSON line per point,
+// and Range and Downsample read them back; the collection's records, if
+// it has any, are unaffected. Like the collection's other settings it is
+// not stored, so set it each time the database is opened. The zero
+// SeriesOptions turns it off, leaving the points on disk.
+func (d *Driver) SetSeries(collection string, opts SeriesOptions) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if opts.Window < 0 || opts.Retention < 0 {
+		return fmt.Errorf("series window and retention cannot be negative")
+	}
+	if opts.Window%time.Second != 0 {
+		return fmt.Errorf("series window must be a whole number of seconds")
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	c := d.configFor(collection)
+	if opts == (SeriesOptions{}) {
+		c.series = nil
+		return nil
+	}
+	if opts.Window == 0 {
+		opts.Window = time.Hour
+	}
+	c.series = &opts
+
+	return nil
+}
+
+func (d *Driver) seriesFor(collection string) (*SeriesOptions, error) {
+	if err := d.validCollection(collection); err != nil {
+		return nil, err
+	}
+	d.configMutex.RLock()
+	defer d.configMutex.RUnlock()
+	if c, ok := d.configs[collection]; ok && c.series != nil {
+		return c.series, nil
+	}
+	return nil, fmt.Errorf("collection '%s' is not a time series", collection)
+}
+
+// Append adds a point with value v at t to the series in collection. It
+// costs one appended line, not a file.
+func (d *Driver) Append(collection string, t time.Time, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return d.AppendPoints(collection, Point{Time: t, Value: b})
+}
+
+// AppendPoints adds points to the series in collection, in any order,
+// syncing each partition they fall in once rather than once a point.
+func (d *Driver) AppendPoints(collection string, points ...Point) error {
+	opts, err := d.seriesFor(collection)
+	if err != nil {
+		return err
+	}
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	partitions := make(map[time.Time][]byte)
+	var starts []time.Time
+	for _, p := range points {
+		if p.Time.IsZero() {
+			return fmt.Errorf("point in series '%s' has no time", collection)
+		}
+		if !json.Valid(p.Value) {
+			return fmt.Errorf("point in series '%s' at %s is not valid JSON", collection, p.Time.Format(time.RFC3339Nano))
+		}
+		line, err := json.Marshal(Point{Time: p.Time.UTC(), Value: p.Value})
+		if err != nil {
+			return err
+		}
+		start := p.Time.UTC().Truncate(opts.Window)
+		if _, ok := partitions[start]; !ok {
+			starts = append(starts, start)
+		}
+		partitions[start] = append(append(partitions[start], line...), '\n')
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	mutex := d.lock(seriesDir + "/" + collection)
+	defer mutex.Unlock()
+
+	dir := filepath.Join(d.dir, seriesDir, collection)
+	if err := os.MkdirAll(dir, dirMode(d.fileMode)); err != nil {
+		return err
+	}
+	for _, start := range starts {
+		path := filepath.Join(dir, partitionName(start, start.Add(opts.Window)))
+		if err := appendFile(path, partitions[start], d.fileMode); err != nil {
+			return fmt.Errorf("appending to series '%s': %w", collection, err)
+		}
+		if err := d.commit(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendFile appends b to path, first ending the line an earlier append
+// cut short by a crash left unfinished, if there is one.
+func appendFile(path string, b []byte, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, mode)
+	if err != nil {
+		return err
+	}
+	if fi, err := f.Stat(); err == nil && fi.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, fi.Size()-1); err == nil && last[0] != '\n' {
+			b = append([]byte{'\n'}, b...)
+		}
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// partition is a file of points and the window it holds.
+type partition struct {
+	path       string
+	start, end time.Time
+}
+
+func partitionName(start, end time.Time) string {
+	return start.Format(partitionLayout) + "-" + end.Format(partitionLayout) + seriesExt
+}
+
+// partitions lists collection's partitions, earliest first.
+func (d *Driver) partitions(collection string) ([]partition, error) {
+	dir := filepath.Join(d.dir, seriesDir, collection)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var parts []partition
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, seriesExt) {
+			continue
+		}
+		from, to, ok := strings.Cut(strings.TrimSuffix(name, seriesExt), "-")
+		if !ok {
+			continue
+		}
+		start, err := time.Parse(partitionLayout, from)
+		if err != nil {
+			continue
+		}
+		end, err := time.Parse(partitionLayout, to)
+		if err != nil {
+			continue
+		}
+		parts = append(parts, partition{path: filepath.Join(dir, name), start: start, end: end})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].start.Before(parts[j].start) })
+	return parts, nil
+}
+
+// Range returns the points in the series in collection from from up to,
+// but not including, to, in time order. Points at the same time keep the
+// order they were appended in.
+func (d *Driver) Range(collection string, from, to time.Time) ([]Point, error) {
+	if _, err := d.seriesFor(collection); err != nil {
+		return nil, err
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("series range must end after it starts")
+	}
+
+	parts, err := d.partitions(collection)
+	if err != nil {
+		return nil, err
+	}
+	var points []Point
+	for _, part := range parts {
+		if !part.start.Before(to) || !part.end.After(from) {
+			continue
+		}
+		if points, err = readPartition(part.path, from, to, points); err != nil {
+			return nil, fmt.Errorf("reading series '%s': %w", collection, err)
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+	return points, nil
+}
+
+// readPartition appends the points in path within [from, to) to points.
+// Lines that do not parse are appends a crash cut short, and are skipped.
+func readPartition(path string, from, to time.Time, points []Point) ([]Point, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return points, nil
+		}
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(nil, len(b)+1)
+	for scanner.Scan() {
+		var p Point
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			continue
+		}
+		if p.Time.Before(from) || !p.Time.Before(to) {
+			continue
+		}
+		p.Value = append(json.RawMessage(nil), p.Value...)
+		points = append(points, p)
+	}
+	return points, scanner.Err()
+}
+
+// Downsample combines the points in the series in collection from from up
+// to to into buckets step long, starting at from, with agg. The value
+// combined is the point's number, or the number at the dotted path field
+// in it when it is a document. Points without a number there are left
+// out, and so are buckets with no points.
+func (d *Driver) Downsample(collection string, from, to time.Time, step time.Duration, field string, agg Aggregation) ([]Bucket, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("downsample step must be positive")
+	}
+	if agg < Mean || agg > Last {
+		return nil, fmt.Errorf("unknown aggregation %v", agg)
+	}
+	points, err := d.Range(collection, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var buckets []Bucket
+	for _, p := range points {
+		v, ok := pointNumber(p.Value, field)
+		if !ok {
+			continue
+		}
+		start := from.Add(p.Time.Sub(from) / step * step)
+		if len(buckets) == 0 || !buckets[len(buckets)-1].Start.Equal(start) {
+			buckets = append(buckets, Bucket{Start: start, Value: v})
+			if agg == Count {
+				buckets[len(buckets)-1].Value = 0
+			}
+		}
+		b := &buckets[len(buckets)-1]
+		b.Count++
+		switch agg {
+		case Mean:
+			b.Value += (v - b.Value) / float64(b.Count)
+		case Min:
+			b.Value = math.Min(b.Value, v)
+		case Max:
+			b.Value = math.Max(b.Value, v)
+		case Sum:
+			if b.Count > 1 {
+				b.Value += v
+			}
+		case Count:
+			b.Value++
+		case Last:
+			b.Value = v
+		}
+	}
+	return buckets, nil
+}
+
+func pointNumber(value json.RawMessage, field string) (float64, bool) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return 0, false
+	}
+	if field != "" {
+		doc, ok := v.(map[string]interface{})
+		if !ok {
+			return 0, false
+		}
+		if v, ok = lookupField(doc, field); !ok {
+			return 0, false
+		}
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+// TruncateSeries deletes the partitions of the series in collection whose
+// window ended at or before before, returning how many points went with
+// them. Partitions are dropped whole, so points in the window before
+// falls in are kept.
+func (d *Driver) TruncateSeries(collection string, before time.Time) (int, error) {
+	if err := d.validCollection(collection); err != nil {
+		return 0, err
+	}
+	if err := d.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	mutex := d.lock(seriesDir + "/" + collection)
+	defer mutex.Unlock()
+
+	parts, err := d.partitions(collection)
+	if err != nil {
+		return 0, err
+	}
+	dropped := 0
+	for _, part := range parts {
+		if part.end.After(before) {
+			break
+		}
+		points, err := readPartition(part.path, part.start, part.end, nil)
+		if err != nil {
+			return dropped, err
+		}
+		if err := os.Remove(part.path); err != nil && !os.IsNotExist(err) {
+			return dropped, err
+		}
+		dropped += len(points)
+	}
+	return dropped, nil
+}
+
+// applySeriesRetention drops the partitions outside collection's series
+// retention, into report, if it is a series with one.
+func (d *Driver) applySeriesRetention(collection string, report *RetentionReport) error {
+	d.configMutex.RLock()
+	var opts *SeriesOptions
+	if c, ok := d.configs[collection]; ok {
+		opts = c.series
+	}
+	d.configMutex.RUnlock()
+	if opts == nil || opts.Retention == 0 {
+		return nil
+	}
+
+	cutoff := d.now().Add(-opts.Retention)
+	if report.DryRun {
+		parts, err := d.partitions(collection)
+		if err != nil {
+			return err
+		}
+		for _, part := range parts {
+			if part.end.After(cutoff) {
+				break
+			}
+			points, err := readPartition(part.path, part.start, part.end, nil)
+			if err != nil {
+				return err
+			}
+			report.Points += len(points)
+		}
+		return nil
+	}
+
+	n, err := d.TruncateSeries(collection, cutoff)
+	report.Points += n
+	return err
+}
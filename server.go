@@ -0,0 +1,227 @@
+package litedb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/SagarDas211/LiteDB-Go/litedbserver"
+)
+
+// Handler serves the database over HTTP; see package litedbserver for the
+// routes. Under UseAccessControl requests must authenticate, and
+// SetServerLimits bounds what each client may send.
+func (d *Driver) Handler() http.Handler {
+	return d.protect(d.server())
+}
+
+// AdminHandler is Handler with the admin dashboard at /admin/.
+func (d *Driver) AdminHandler() http.Handler {
+	srv := d.server()
+	srv.EnableAdmin()
+	return d.protect(srv)
+}
+
+// protect authenticates requests once UseAccessControl has been called,
+// serving them with the user in their context, and applies
+// SetServerLimits. Routes that do not reach the middleware chain are
+// checked here: the stats and the dashboard need admin access to every
+// collection, a watch read access to its collection, and a topic's
+// subscribers read access to its collection, as publishing writes to it.
+// Any user may list the collections.
+func (d *Driver) protect(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a := d.accessControl()
+		if a == nil {
+			if d.admitHTTP(w, r, clientOf("", false, r.RemoteAddr)) {
+				h.ServeHTTP(w, r)
+			}
+			return
+		}
+
+		user, err := a.authenticateHTTP(r)
+		if err != nil {
+			// Failed attempts count against the address they come from.
+			if d.admitHTTP(w, r, clientOf("", false, r.RemoteAddr)) {
+				w.Header().Set("WWW-Authenticate", `Basic realm="litedb"`)
+				writeHTTPError(w, http.StatusUnauthorized, err)
+			}
+			return
+		}
+		if !d.admitHTTP(w, r, clientOf(user, true, r.RemoteAddr)) {
+			return
+		}
+
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case parts[0] == "stats" || parts[0] == "admin":
+			err = a.check(user, "*", AdminAccess)
+		case len(parts) == 3 && parts[0] == "collections" && parts[2] == "watch":
+			err = a.check(user, parts[1], ReadAccess)
+		case len(parts) >= 4 && parts[0] == "topics" && parts[2] == "subscribers":
+			err = a.check(user, parts[1], ReadAccess)
+		}
+		if err != nil {
+			writeHTTPError(w, http.StatusForbidden, err)
+			return
+		}
+
+		h.ServeHTTP(w, r.WithContext(ContextWithUser(r.Context(), user)))
+	})
+}
+
+// admitHTTP applies SetServerLimits to a request from client, answering
+// it and returning false if it is refused. Bodies are cut off at the
+// size limit, which the server reports as 413.
+func (d *Driver) admitHTTP(w http.ResponseWriter, r *http.Request, client string) bool {
+	l := d.serverLimiter()
+	if l == nil {
+		return true
+	}
+
+	if ok, wait := l.allow(client); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeHTTPError(w, http.StatusTooManyRequests, ErrRateLimited)
+		return false
+	}
+	if l.tooLarge(r.ContentLength) {
+		writeHTTPError(w, http.StatusRequestEntityTooLarge, ErrRequestTooLarge)
+		return false
+	}
+	if l.limits.MaxRequestBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, l.limits.MaxRequestBytes)
+	}
+	return true
+}
+
+func (d *Driver) server() *litedbserver.Server {
+	srv := litedbserver.New(serverStore{d})
+	srv.Status = serverStatus
+	return srv
+}
+
+type serverStore struct {
+	d *Driver
+}
+
+func (s serverStore) Collections() ([]string, error) {
+	return s.d.Collections()
+}
+
+func (s serverStore) Stats() (interface{}, error) {
+	return s.d.Stats()
+}
+
+func (s serverStore) Get(ctx context.Context, collection, resource string) (json.RawMessage, error) {
+	var doc json.RawMessage
+	if err := s.d.ReadContext(ctx, collection, resource, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (s serverStore) Put(ctx context.Context, collection, resource string, doc json.RawMessage) error {
+	return s.d.WriteContext(ctx, collection, resource, doc)
+}
+
+func (s serverStore) Patch(ctx context.Context, collection, resource string, patch json.RawMessage) error {
+	return s.d.PatchContext(ctx, collection, resource, patch)
+}
+
+func (s serverStore) MergePatch(ctx context.Context, collection, resource string, patch json.RawMessage) error {
+	return s.d.MergePatchContext(ctx, collection, resource, patch)
+}
+
+func (s serverStore) Delete(ctx context.Context, collection, resource string) error {
+	return s.d.DeleteContext(ctx, collection, resource)
+}
+
+func (s serverStore) Query(ctx context.Context, collection string, filter map[string]interface{}) ([]litedbserver.Record, error) {
+	found, err := s.d.FindContext(ctx, collection, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]litedbserver.Record, len(found))
+	for i, r := range found {
+		records[i] = litedbserver.Record{ID: r.ID, Data: r.Data}
+	}
+	return records, nil
+}
+
+func serverStatus(err error) int {
+	if errors.Is(err, ErrQuotaExceeded) {
+		return http.StatusInsufficientStorage
+	}
+	if errors.Is(err, ErrReadOnlyReplica) || errors.Is(err, ErrReadOnly) || errors.Is(err, ErrPermissionDenied) {
+		return http.StatusForbidden
+	}
+	if errors.Is(err, ErrUnauthenticated) {
+		return http.StatusUnauthorized
+	}
+	if errors.Is(err, ErrPatchTest) || errors.Is(err, ErrConflict) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, ErrInvalidPatch) {
+		return http.StatusUnprocessableEntity
+	}
+	return litedbserver.DefaultStatus(err)
+}
+
+func (s serverStore) Watch(collection string) (<-chan litedbserver.Event, func()) {
+	events, cancel := s.d.Watch(collection)
+
+	out := make(chan litedbserver.Event)
+	done := make(chan struct{})
+	go func() {
+		defer close(out)
+		for ev := range events {
+			select {
+			case out <- litedbserver.Event{Type: ev.Type.String(), Collection: ev.Collection, Resource: ev.Resource, Time: ev.Time}:
+			case <-done:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return out, func() {
+		once.Do(func() {
+			close(done)
+			cancel()
+		})
+	}
+}
+
+func (s serverStore) Publish(ctx context.Context, topic string, value json.RawMessage) (string, error) {
+	return s.d.Topic(topic).PublishContext(ctx, value)
+}
+
+func (s serverStore) Subscribe(ctx context.Context, topic, subscriber string) error {
+	return s.d.Topic(topic).SubscribeContext(ctx, subscriber)
+}
+
+func (s serverStore) Unsubscribe(ctx context.Context, topic, subscriber string) error {
+	return s.d.Topic(topic).UnsubscribeContext(ctx, subscriber)
+}
+
+func (s serverStore) Fetch(ctx context.Context, topic, subscriber string, max int) ([]litedbserver.TopicMessage, error) {
+	messages, err := s.d.Topic(topic).FetchContext(ctx, subscriber, max)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]litedbserver.TopicMessage, len(messages))
+	for i, m := range messages {
+		out[i] = litedbserver.TopicMessage{ID: m.ID, Value: m.Value, PublishedAt: m.PublishedAt}
+	}
+	return out, nil
+}
+
+func (s serverStore) Ack(ctx context.Context, topic, subscriber, id string) error {
+	return s.d.Topic(topic).AckContext(ctx, subscriber, id)
+}
@@ -0,0 +1,95 @@
+package litedb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrTampered is returned for reads of a record whose signature is missing
+// or does not match, under Options.SigningKey.
+var ErrTampered = errors.New("record signature does not match")
+
+// WithSigningKey signs every record written with key and verifies the
+// signature on reading. See Options.SigningKey.
+func WithSigningKey(key []byte) Option {
+	return optionFunc(func(o *Options) { o.SigningKey = key })
+}
+
+// WithPreviousSigningKey supplies the key an unfinished RotateKey is
+// replacing. See Options.PreviousSigningKey.
+func WithPreviousSigningKey(key []byte) Option {
+	return optionFunc(func(o *Options) { o.PreviousSigningKey = key })
+}
+
+// signingKeys holds the key records are signed with and, while RotateKey
+// is re-signing them, the key it replaces, which still verifies.
+type signingKeys struct {
+	mutex    sync.RWMutex
+	current  []byte
+	previous []byte
+}
+
+func (k *signingKeys) keys() (current, previous []byte) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return k.current, k.previous
+}
+
+// signs reports whether records are signed.
+func (d *Driver) signs() bool {
+	current, _ := d.signing.keys()
+	return current != nil
+}
+
+// sign returns the HMAC under key of a record with the given checksum.
+// The names are signed too, so a record cannot be passed off as another.
+func sign(key []byte, collection, resource, checksum string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(collection))
+	mac.Write([]byte{0})
+	mac.Write([]byte(resource))
+	mac.Write([]byte{0})
+	mac.Write([]byte(checksum))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signedWith reports whether signature is key's for the record.
+func signedWith(key []byte, collection, resource, checksum, signature string) bool {
+	want, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	got, _ := hex.DecodeString(sign(key, collection, resource, checksum))
+	return hmac.Equal(got, want)
+}
+
+// verifyRecord checks b, the content of resource's file, against the
+// signature in its metadata. It does nothing without a signing key.
+func (d *Driver) verifyRecord(collection, resource string, b []byte) error {
+	current, previous := d.signing.keys()
+	if current == nil {
+		return nil
+	}
+
+	m, err := d.readMeta(collection, resource)
+	if err != nil {
+		return err
+	}
+	if m == nil || m.Signature == "" {
+		return fmt.Errorf("record '%s' in collection '%s' is not signed: %w", resource, collection, ErrTampered)
+	}
+
+	sum := sha256.Sum256(b)
+	checksum := hex.EncodeToString(sum[:])
+	if signedWith(current, collection, resource, checksum, m.Signature) {
+		return nil
+	}
+	if previous != nil && signedWith(previous, collection, resource, checksum, m.Signature) {
+		return nil
+	}
+	return fmt.Errorf("record '%s' in collection '%s': %w", resource, collection, ErrTampered)
+}
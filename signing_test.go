@@ -0,0 +1,165 @@
+package litedb_test
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	litedb "github.com/SagarDas211/LiteDB-Go"
+	"github.com/SagarDas211/LiteDB-Go/litedbtest"
+)
+
+func TestSigningDetectsTampering(t *testing.T) {
+	dir := t.TempDir()
+	db, err := litedb.New(dir, litedb.WithSigningKey([]byte("key")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	litedbtest.Seed(t, db, "users", map[string]interface{}{
+		"alice": map[string]string{"role": "user"},
+		"bob":   map[string]string{"role": "user"},
+	})
+	litedbtest.AssertRecord(t, db, "users", "alice", map[string]string{"role": "user"})
+
+	if err := os.WriteFile(filepath.Join(dir, "users", "alice.json"), []byte(`{"role":"admin"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var v map[string]string
+	if err := db.Read("users", "alice", &v); !errors.Is(err, litedb.ErrTampered) {
+		t.Fatalf("Read of edited record = %v, want ErrTampered", err)
+	}
+	if _, err := db.Find("users", nil); !errors.Is(err, litedb.ErrTampered) {
+		t.Fatalf("Find over edited record = %v, want ErrTampered", err)
+	}
+
+	// Rewriting through the driver signs it again.
+	if err := db.Write("users", "alice", map[string]string{"role": "user"}); err != nil {
+		t.Fatal(err)
+	}
+	litedbtest.AssertRecord(t, db, "users", "alice", map[string]string{"role": "user"})
+}
+
+func TestSigningWithAnotherKey(t *testing.T) {
+	dir := t.TempDir()
+	db, err := litedb.New(dir, litedb.WithSigningKey([]byte("key")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	litedbtest.Seed(t, db, "users", map[string]interface{}{"alice": map[string]string{"role": "user"}})
+	db.Close()
+
+	other, err := litedb.New(dir, litedb.WithSigningKey([]byte("other")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	var v map[string]string
+	if err := other.Read("users", "alice", &v); !errors.Is(err, litedb.ErrTampered) {
+		t.Fatalf("Read with another key = %v, want ErrTampered", err)
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	dir := t.TempDir()
+	oldKey, newKey := []byte("old"), []byte("new")
+
+	db, err := litedb.New(dir, litedb.WithSigningKey(oldKey), quiet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	litedbtest.Seed(t, db, "users", map[string]interface{}{
+		"alice": map[string]string{"role": "user"},
+		"bob":   map[string]string{"role": "admin"},
+	})
+	litedbtest.Seed(t, db, "orders", map[string]interface{}{"o1": map[string]int{"total": 3}})
+
+	if err := db.RotateKey(newKey); err != nil {
+		t.Fatal(err)
+	}
+	// Records verify while the rotation runs, whichever key signed them.
+	litedbtest.AssertRecord(t, db, "users", "alice", map[string]string{"role": "user"})
+	if err := db.Write("users", "carol", map[string]string{"role": "user"}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForRotation(t, dir)
+	db.Close()
+
+	// Every record was re-signed, so the old key is no longer needed.
+	reopened, err := litedb.New(dir, litedb.WithSigningKey(newKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	litedbtest.AssertRecord(t, reopened, "users", "alice", map[string]string{"role": "user"})
+	litedbtest.AssertRecord(t, reopened, "users", "bob", map[string]string{"role": "admin"})
+	litedbtest.AssertRecord(t, reopened, "users", "carol", map[string]string{"role": "user"})
+	litedbtest.AssertRecord(t, reopened, "orders", "o1", map[string]int{"total": 3})
+}
+
+func TestRotateKeyTwice(t *testing.T) {
+	db, err := litedb.New(t.TempDir(), litedb.WithSigningKey([]byte("old")), quiet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// A rotation in progress has to finish before another starts; hold the
+	// collection so it cannot.
+	l, err := db.BulkLoad("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Add("alice", map[string]string{"role": "user"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l, err = db.BulkLoad("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if err := db.RotateKey([]byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RotateKey([]byte("newer")); !errors.Is(err, litedb.ErrRotating) {
+		t.Fatalf("second RotateKey = %v, want ErrRotating", err)
+	}
+}
+
+func TestRotateKeyUnsigned(t *testing.T) {
+	db, err := litedb.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.RotateKey([]byte("new")); err == nil {
+		t.Fatal("RotateKey without a signing key succeeded")
+	}
+}
+
+// quiet discards the driver's log.
+var quiet = litedb.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+// waitForRotation waits for a rotation under way in dir to finish.
+func waitForRotation(t *testing.T, dir string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "_rotation.json")); os.IsNotExist(err) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("key rotation did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
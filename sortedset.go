@@ -0,0 +1,384 @@
+package litedb
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"sort"
+	"sync"
+)
+
+// sortedSetsLock prefixes the lock serializing changes to a sorted set's
+// scores.
+const sortedSetsLock = "_sortedsets/"
+
+// SortedSet is a set of members ranked by score, as for a leaderboard,
+// kept in a collection of the same name and returned by Driver.SortedSet.
+// Each member is a record {"score": x}, so members can also be read with
+// Read or Find. An ordered index of the collection is built in memory the
+// first time it is needed and kept current by every write and delete to
+// the collection from then on.
+type SortedSet struct {
+	d    *Driver
+	name string
+}
+
+// ScoredMember is a member of a SortedSet with its score and its rank,
+// counted from 0 for the highest score.
+type ScoredMember struct {
+	Member string
+	Score  float64
+	Rank   int
+}
+
+// scored is a member as stored in the set's collection.
+type scored struct {
+	Score float64 `json:"score"`
+}
+
+// SortedSet returns the sorted set kept in collection name.
+func (d *Driver) SortedSet(name string) *SortedSet {
+	return &SortedSet{d: d, name: name}
+}
+
+// SetScore sets member's score, adding it if it is not in the set.
+func (s *SortedSet) SetScore(member string, score float64) error {
+	_, err := s.update(member, func(float64, bool) float64 { return score })
+	return err
+}
+
+// AddScore adds delta to member's score, adding it with a score of delta
+// if it is not in the set, and returns the new score.
+func (s *SortedSet) AddScore(member string, delta float64) (float64, error) {
+	return s.update(member, func(old float64, _ bool) float64 { return old + delta })
+}
+
+func (s *SortedSet) update(member string, fn func(old float64, ok bool) float64) (float64, error) {
+	if err := s.check(member); err != nil {
+		return 0, err
+	}
+
+	mutex := s.d.lock(sortedSetsLock + s.name)
+	defer mutex.Unlock()
+
+	index, err := s.index()
+	if err != nil {
+		return 0, err
+	}
+	old, ok := index.score(member)
+	score := fn(old, ok)
+	if math.IsNaN(score) || math.IsInf(score, 0) {
+		return 0, fmt.Errorf("score of '%s' in sorted set '%s' must be a finite number", member, s.name)
+	}
+	return score, s.d.Write(s.name, member, scored{Score: score})
+}
+
+// Remove takes member out of the set.
+func (s *SortedSet) Remove(member string) error {
+	if err := s.check(member); err != nil {
+		return err
+	}
+	return s.d.Delete(s.name, member)
+}
+
+// Score returns member's score. It returns an error matching ErrNotFound
+// if member is not in the set.
+func (s *SortedSet) Score(member string) (float64, error) {
+	if err := s.check(member); err != nil {
+		return 0, err
+	}
+	index, err := s.index()
+	if err != nil {
+		return 0, err
+	}
+	score, ok := index.score(member)
+	if !ok {
+		return 0, errNoRecord(s.name, member)
+	}
+	return score, nil
+}
+
+// Rank returns member's rank: 0 for the highest score, 1 for the next and
+// so on. Members with equal scores are ranked by name. It returns an error
+// matching ErrNotFound if member is not in the set.
+func (s *SortedSet) Rank(member string) (int, error) {
+	if err := s.check(member); err != nil {
+		return 0, err
+	}
+	index, err := s.index()
+	if err != nil {
+		return 0, err
+	}
+	rank, ok := index.rank(member)
+	if !ok {
+		return 0, errNoRecord(s.name, member)
+	}
+	return rank, nil
+}
+
+// TopN returns the n members with the highest scores, highest first.
+func (s *SortedSet) TopN(n int) ([]ScoredMember, error) {
+	if err := s.d.validCollection(s.name); err != nil {
+		return nil, err
+	}
+	index, err := s.index()
+	if err != nil {
+		return nil, err
+	}
+	return index.slice(0, n), nil
+}
+
+// RangeByScore returns the members scoring from min to max inclusive,
+// highest first.
+func (s *SortedSet) RangeByScore(min, max float64) ([]ScoredMember, error) {
+	if err := s.d.validCollection(s.name); err != nil {
+		return nil, err
+	}
+	index, err := s.index()
+	if err != nil {
+		return nil, err
+	}
+	return index.byScore(min, max), nil
+}
+
+// Len returns the number of members in the set.
+func (s *SortedSet) Len() (int, error) {
+	if err := s.d.validCollection(s.name); err != nil {
+		return 0, err
+	}
+	index, err := s.index()
+	if err != nil {
+		return 0, err
+	}
+	return index.len(), nil
+}
+
+func (s *SortedSet) check(member string) error {
+	if err := s.d.validCollection(s.name); err != nil {
+		return err
+	}
+	return validResource(member)
+}
+
+// index returns the set's index, building it from the collection if it
+// has none.
+func (s *SortedSet) index() (*sortedIndex, error) {
+	if index := s.d.sortedSets.get(s.name); index != nil {
+		return index, nil
+	}
+
+	// The collection lock keeps writes out while the index is built, so
+	// none is missed between reading the records and registering it.
+	mutex := s.d.lock(s.name)
+	defer mutex.Unlock()
+	if index := s.d.sortedSets.get(s.name); index != nil {
+		return index, nil
+	}
+
+	keys, err := s.d.keys(s.name)
+	if err != nil {
+		return nil, err
+	}
+	index := &sortedIndex{scores: make(map[string]float64, len(keys))}
+	for _, member := range keys {
+		var score float64
+		var ok bool
+		err := s.d.withRecord(s.name, member, func(b []byte) error {
+			score, ok = recordScore(b)
+			return nil
+		})
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		if ok {
+			index.scores[member] = score
+			index.order = append(index.order, member)
+		}
+	}
+	sort.Slice(index.order, func(i, j int) bool {
+		return index.less(index.order[i], index.order[j])
+	})
+	s.d.sortedSets.put(s.name, index)
+	return index, nil
+}
+
+// recordScore returns the score of a sorted set member's record, and false
+// if it has none.
+func recordScore(b []byte) (float64, bool) {
+	doc, err := decodeDocument(b)
+	if err != nil {
+		return 0, false
+	}
+	return documentScore(doc)
+}
+
+func documentScore(doc map[string]interface{}) (float64, bool) {
+	value, ok := doc["score"]
+	if !ok {
+		return 0, false
+	}
+	f, ok := value.(interface{ Float64() (float64, error) })
+	if !ok {
+		return 0, false
+	}
+	score, err := f.Float64()
+	return score, err == nil
+}
+
+// sortedSets holds the indexes of the sorted sets in use.
+type sortedSets struct {
+	mutex   sync.Mutex
+	indexes map[string]*sortedIndex
+}
+
+func (ss *sortedSets) get(collection string) *sortedIndex {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	return ss.indexes[collection]
+}
+
+func (ss *sortedSets) put(collection string, index *sortedIndex) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if ss.indexes == nil {
+		ss.indexes = make(map[string]*sortedIndex)
+	}
+	ss.indexes[collection] = index
+}
+
+// has reports whether collection has an index to keep current.
+func (ss *sortedSets) has(collection string) bool {
+	return ss.get(collection) != nil
+}
+
+// changed brings collection's index, if it has one, up to date with a
+// change to resource, given the document written or nil after a delete.
+// A write whose document was not kept drops the index, to be rebuilt when
+// next needed.
+func (ss *sortedSets) changed(t EventType, collection, resource string, data []byte) {
+	index := ss.get(collection)
+	if index == nil {
+		return
+	}
+	if t != Deleted && data == nil {
+		ss.mutex.Lock()
+		delete(ss.indexes, collection)
+		ss.mutex.Unlock()
+		return
+	}
+
+	score, ok := 0.0, false
+	if t != Deleted {
+		score, ok = recordScore(data)
+	}
+	index.set(resource, score, ok)
+}
+
+// sortedIndex orders a sorted set's members by descending score, then by
+// name.
+type sortedIndex struct {
+	mutex  sync.RWMutex
+	scores map[string]float64
+	order  []string
+}
+
+// less reports whether member a ranks before b. The caller must hold the
+// mutex, or own the index.
+func (x *sortedIndex) less(a, b string) bool {
+	sa, sb := x.scores[a], x.scores[b]
+	if sa != sb {
+		return sa > sb
+	}
+	return a < b
+}
+
+// position returns where member with score belongs in order.
+func (x *sortedIndex) position(member string, score float64) int {
+	return sort.Search(len(x.order), func(i int) bool {
+		other := x.order[i]
+		so := x.scores[other]
+		if so != score {
+			return so < score
+		}
+		return other >= member
+	})
+}
+
+// set puts member in the index with score, or takes it out if ok is false.
+func (x *sortedIndex) set(member string, score float64, ok bool) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	if old, had := x.scores[member]; had {
+		i := x.position(member, old)
+		x.order = append(x.order[:i], x.order[i+1:]...)
+		delete(x.scores, member)
+	}
+	if !ok {
+		return
+	}
+	i := x.position(member, score)
+	x.order = append(x.order, "")
+	copy(x.order[i+1:], x.order[i:])
+	x.order[i] = member
+	x.scores[member] = score
+}
+
+func (x *sortedIndex) score(member string) (float64, bool) {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+	score, ok := x.scores[member]
+	return score, ok
+}
+
+func (x *sortedIndex) rank(member string) (int, bool) {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+	score, ok := x.scores[member]
+	if !ok {
+		return 0, false
+	}
+	return x.position(member, score), true
+}
+
+func (x *sortedIndex) len() int {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+	return len(x.order)
+}
+
+// slice returns up to n members from rank start on.
+func (x *sortedIndex) slice(start, n int) []ScoredMember {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+	end := start + max(n, 0)
+	if end > len(x.order) {
+		end = len(x.order)
+	}
+	return x.members(start, end)
+}
+
+func (x *sortedIndex) byScore(min, max float64) []ScoredMember {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+	start := sort.Search(len(x.order), func(i int) bool { return x.scores[x.order[i]] <= max })
+	end := sort.Search(len(x.order), func(i int) bool { return x.scores[x.order[i]] < min })
+	return x.members(start, end)
+}
+
+// members returns the members ranked start up to end. The caller must
+// hold the mutex.
+func (x *sortedIndex) members(start, end int) []ScoredMember {
+	if start >= end {
+		return []ScoredMember{}
+	}
+	out := make([]ScoredMember, 0, end-start)
+	for i := start; i < end; i++ {
+		out = append(out, ScoredMember{Member: x.order[i], Score: x.scores[x.order[i]], Rank: i})
+	}
+	return out
+}
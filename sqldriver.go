@@ -0,0 +1,475 @@
+package litedb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The database/sql driver is registered as "litedb" and takes the database
+// directory as its data source name:
+//
+//	db, err := sql.Open("litedb", "./data")
+//
+// Every collection reads as a table whose columns are "id" and the
+// documents' fields. See sqlparse.go for the supported statements.
+func init() {
+	sql.Register("litedb", &sqlDriver{})
+}
+
+type sqlDriver struct {
+	mutex sync.Mutex
+	dbs   map[string]*Driver
+}
+
+func (s *sqlDriver) Open(name string) (driver.Conn, error) {
+	c, err := s.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Connect(context.Background())
+}
+
+// OpenConnector shares one Driver between every connection to a directory
+// so they also share its locks.
+func (s *sqlDriver) OpenConnector(name string) (driver.Connector, error) {
+	dir, err := filepath.Abs(name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	db, ok := s.dbs[dir]
+	if !ok {
+		if db, err = New(dir); err != nil && !os.IsExist(err) {
+			return nil, err
+		}
+		if s.dbs == nil {
+			s.dbs = make(map[string]*Driver)
+		}
+		s.dbs[dir] = db
+	}
+
+	return &sqlConnector{db: db, driver: s}, nil
+}
+
+// SQL returns a database/sql handle onto d.
+func (d *Driver) SQL() *sql.DB {
+	return sql.OpenDB(&sqlConnector{db: d})
+}
+
+type sqlConnector struct {
+	db     *Driver
+	driver driver.Driver
+}
+
+func (c *sqlConnector) Connect(context.Context) (driver.Conn, error) {
+	return &sqlConn{db: c.db}, nil
+}
+
+func (c *sqlConnector) Driver() driver.Driver {
+	if c.driver == nil {
+		return &sqlDriver{}
+	}
+	return c.driver
+}
+
+type sqlConn struct {
+	db *Driver
+}
+
+func (c *sqlConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *sqlConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := parseSQL(query)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlStmt{db: c.db, stmt: stmt}, nil
+}
+
+func (c *sqlConn) Close() error {
+	return nil
+}
+
+func (c *sqlConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions are not supported")
+}
+
+type sqlStmt struct {
+	db   *Driver
+	stmt *sqlStatement
+}
+
+func (s *sqlStmt) Close() error {
+	return nil
+}
+
+func (s *sqlStmt) NumInput() int {
+	return s.stmt.params
+}
+
+func (s *sqlStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *sqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *sqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	switch s.stmt.kind {
+	case "insert":
+		return s.insert(ctx, args)
+	case "delete":
+		return s.delete(ctx, args)
+	}
+	return nil, fmt.Errorf("use Query for SELECT")
+}
+
+func (s *sqlStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if s.stmt.kind != "select" {
+		return nil, fmt.Errorf("use Exec for %s", strings.ToUpper(s.stmt.kind))
+	}
+
+	matches, err := s.match(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(s.stmt.orderBy) - 1; i >= 0; i-- {
+		order := s.stmt.orderBy[i]
+		sort.SliceStable(matches, func(a, b int) bool {
+			c := compareValues(matches[a].field(order.field), matches[b].field(order.field))
+			if order.desc {
+				return c > 0
+			}
+			return c < 0
+		})
+	}
+
+	if s.stmt.offset >= len(matches) {
+		matches = nil
+	} else {
+		matches = matches[s.stmt.offset:]
+	}
+	if s.stmt.limit >= 0 && s.stmt.limit < len(matches) {
+		matches = matches[:s.stmt.limit]
+	}
+
+	columns := s.stmt.fields
+	if columns == nil {
+		columns = allColumns(matches)
+	}
+	return &sqlRows{columns: columns, matches: matches}, nil
+}
+
+func (s *sqlStmt) insert(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	var n int64
+	for _, row := range s.stmt.rows {
+		doc := make(map[string]interface{})
+		resource := ""
+		for i, field := range s.stmt.fields {
+			v, err := row[i].resolve(args)
+			if err != nil {
+				return nil, err
+			}
+			if field == "id" {
+				resource = fmt.Sprint(v)
+				continue
+			}
+			setField(doc, field, v)
+		}
+
+		var err error
+		if resource == "" {
+			_, err = s.db.Insert(s.stmt.collection, doc)
+		} else {
+			err = s.db.WriteContext(ctx, s.stmt.collection, resource, doc)
+		}
+		if err != nil {
+			return driver.RowsAffected(n), err
+		}
+		n++
+	}
+	return driver.RowsAffected(n), nil
+}
+
+func (s *sqlStmt) delete(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	matches, err := s.match(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.stmt.where) == 0 {
+		if len(matches) == 0 {
+			return driver.RowsAffected(0), nil
+		}
+		return driver.RowsAffected(len(matches)), s.db.DeleteContext(ctx, s.stmt.collection, "")
+	}
+
+	var n int64
+	for _, m := range matches {
+		if err := s.db.DeleteContext(ctx, s.stmt.collection, m.id); err != nil {
+			return driver.RowsAffected(n), err
+		}
+		n++
+	}
+	return driver.RowsAffected(n), nil
+}
+
+type sqlMatch struct {
+	id  string
+	doc map[string]interface{}
+}
+
+func (m sqlMatch) field(name string) interface{} {
+	if name == "id" {
+		return m.id
+	}
+	v, _ := lookupField(m.doc, name)
+	return v
+}
+
+// match returns the records satisfying the WHERE clause. Equality
+// conditions are handed to Find; the rest are checked here.
+func (s *sqlStmt) match(ctx context.Context, args []driver.NamedValue) ([]sqlMatch, error) {
+	filter := Filter{}
+	var conditions []sqlCondition
+	for _, c := range s.stmt.where {
+		v, err := c.value.resolve(args)
+		if err != nil {
+			return nil, err
+		}
+		if v, err = normalizeValue(v); err != nil {
+			return nil, err
+		}
+		if c.op == "=" && c.field != "id" && v != nil {
+			filter[c.field] = v
+			continue
+		}
+		conditions = append(conditions, sqlCondition{field: c.field, op: c.op, value: sqlValue{literal: v, param: -1}})
+	}
+
+	records, err := s.db.FindContext(ctx, s.stmt.collection, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []sqlMatch
+	for _, r := range records {
+		// Documents that are not objects read as rows with only an id.
+		doc, _ := decodeDocument(r.Data)
+		m := sqlMatch{id: r.ID, doc: doc}
+		if satisfies(m, conditions) {
+			matches = append(matches, m)
+		}
+	}
+	return matches, nil
+}
+
+func satisfies(m sqlMatch, conditions []sqlCondition) bool {
+	for _, c := range conditions {
+		got, want := m.field(c.field), c.value.literal
+		var ok bool
+		switch c.op {
+		case "=":
+			ok = equalValues(got, want)
+		case "!=":
+			ok = !equalValues(got, want)
+		default:
+			if !orderable(got, want) {
+				return false
+			}
+			cmp := compareValues(got, want)
+			switch c.op {
+			case "<":
+				ok = cmp < 0
+			case "<=":
+				ok = cmp <= 0
+			case ">":
+				ok = cmp > 0
+			case ">=":
+				ok = cmp >= 0
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (v sqlValue) resolve(args []driver.NamedValue) (interface{}, error) {
+	if v.param < 0 {
+		return v.literal, nil
+	}
+	if v.param >= len(args) {
+		return nil, fmt.Errorf("missing argument %d", v.param+1)
+	}
+
+	switch a := args[v.param].Value.(type) {
+	case time.Time:
+		return a.Format(time.RFC3339Nano), nil
+	case []byte:
+		return string(a), nil
+	default:
+		return a, nil
+	}
+}
+
+// normalizeValue converts v to the form decoded documents use.
+func normalizeValue(v interface{}) (interface{}, error) {
+	want, err := normalizeFilter(Filter{"v": v})
+	if err != nil {
+		return nil, err
+	}
+	return want["v"], nil
+}
+
+func setField(doc map[string]interface{}, path string, v interface{}) {
+	names := strings.Split(path, ".")
+	for _, name := range names[:len(names)-1] {
+		next, ok := doc[name].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			doc[name] = next
+		}
+		doc = next
+	}
+	doc[names[len(names)-1]] = v
+}
+
+// typeRank orders values of different JSON types: null, booleans,
+// numbers, strings, then objects and arrays.
+func typeRank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case json.Number:
+		return 2
+	case string:
+		return 3
+	}
+	return 4
+}
+
+func orderable(a, b interface{}) bool {
+	r := typeRank(a)
+	return r == typeRank(b) && (r == 2 || r == 3)
+}
+
+func compareValues(a, b interface{}) int {
+	ra, rb := typeRank(a), typeRank(b)
+	if ra != rb {
+		return ra - rb
+	}
+
+	switch a := a.(type) {
+	case bool:
+		if a == b.(bool) {
+			return 0
+		} else if !a {
+			return -1
+		}
+		return 1
+	case json.Number:
+		af, _ := a.Float64()
+		bf, _ := b.(json.Number).Float64()
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+	return 0
+}
+
+func allColumns(matches []sqlMatch) []string {
+	seen := make(map[string]bool)
+	var fields []string
+	for _, m := range matches {
+		for field := range m.doc {
+			if !seen[field] && field != "id" {
+				seen[field] = true
+				fields = append(fields, field)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return append([]string{"id"}, fields...)
+}
+
+type sqlRows struct {
+	columns []string
+	matches []sqlMatch
+	next    int
+}
+
+func (r *sqlRows) Columns() []string {
+	return r.columns
+}
+
+func (r *sqlRows) Close() error {
+	r.matches = nil
+	return nil
+}
+
+func (r *sqlRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.matches) {
+		return io.EOF
+	}
+	m := r.matches[r.next]
+	r.next++
+
+	for i, column := range r.columns {
+		v, err := sqlColumnValue(m.field(column))
+		if err != nil {
+			return err
+		}
+		dest[i] = v
+	}
+	return nil
+}
+
+// sqlColumnValue converts a document value to a driver.Value. Objects and
+// arrays come back as JSON.
+func sqlColumnValue(v interface{}) (driver.Value, error) {
+	switch v := v.(type) {
+	case nil, bool, string:
+		return v, nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		return v.Float64()
+	}
+	return json.Marshal(v)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
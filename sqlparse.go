@@ -0,0 +1,407 @@
+package litedb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The SQL subset understood by the database/sql driver:
+//
+//	SELECT * | field, ... FROM collection
+//	    [WHERE field op value [AND ...]]
+//	    [ORDER BY field [ASC | DESC], ...] [LIMIT n [OFFSET m]]
+//	INSERT INTO collection [(field, ...)] VALUES (value, ...), ...
+//	DELETE FROM collection [WHERE field op value [AND ...]]
+//
+// Fields are dotted paths into the document; "id" is the record's
+// resource name. Values are string or number literals, TRUE, FALSE, NULL
+// or ? placeholders, and op is one of = != <> < <= > >=.
+
+type sqlStatement struct {
+	kind       string // "select", "insert" or "delete"
+	collection string
+	fields     []string
+	where      []sqlCondition
+	orderBy    []sqlOrder
+	limit      int
+	offset     int
+	rows       [][]sqlValue
+	params     int
+}
+
+type sqlCondition struct {
+	field string
+	op    string
+	value sqlValue
+}
+
+type sqlOrder struct {
+	field string
+	desc  bool
+}
+
+// sqlValue is a literal, or the index of a placeholder when param >= 0.
+type sqlValue struct {
+	literal interface{}
+	param   int
+}
+
+type sqlToken struct {
+	kind string // "ident", "string", "number", "param" or "punct"
+	text string
+}
+
+func tokenizeSQL(query string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	r := []rune(query)
+	for i := 0; i < len(r); {
+		c := r[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '\'':
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(r) {
+					return nil, fmt.Errorf("unterminated string in SQL")
+				}
+				if r[i] == '\'' {
+					if i+1 < len(r) && r[i+1] == '\'' {
+						sb.WriteRune('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteRune(r[i])
+				i++
+			}
+			tokens = append(tokens, sqlToken{"string", sb.String()})
+		case c == '"' || c == '`':
+			end := i + 1
+			for end < len(r) && r[end] != c {
+				end++
+			}
+			if end >= len(r) {
+				return nil, fmt.Errorf("unterminated identifier in SQL")
+			}
+			tokens = append(tokens, sqlToken{"ident", string(r[i+1 : end])})
+			i = end + 1
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(r) && unicode.IsDigit(r[i+1])):
+			end := i + 1
+			for end < len(r) && (unicode.IsDigit(r[end]) || r[end] == '.' || r[end] == 'e' || r[end] == 'E') {
+				end++
+			}
+			tokens = append(tokens, sqlToken{"number", string(r[i:end])})
+			i = end
+		case unicode.IsLetter(c) || c == '_':
+			end := i + 1
+			for end < len(r) && (unicode.IsLetter(r[end]) || unicode.IsDigit(r[end]) || r[end] == '_' || r[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, sqlToken{"ident", string(r[i:end])})
+			i = end
+		case c == '?':
+			tokens = append(tokens, sqlToken{"param", "?"})
+			i++
+		case strings.ContainsRune("<>!", c) && i+1 < len(r) && (r[i+1] == '=' || (c == '<' && r[i+1] == '>')):
+			tokens = append(tokens, sqlToken{"punct", string(r[i : i+2])})
+			i += 2
+		case strings.ContainsRune("(),*=<>;", c):
+			tokens = append(tokens, sqlToken{"punct", string(c)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' in SQL", c)
+		}
+	}
+	return tokens, nil
+}
+
+type sqlParser struct {
+	tokens []sqlToken
+	pos    int
+	stmt   *sqlStatement
+}
+
+func parseSQL(query string) (*sqlStatement, error) {
+	tokens, err := tokenizeSQL(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &sqlParser{tokens: tokens, stmt: &sqlStatement{limit: -1}}
+	switch {
+	case p.keyword("SELECT"):
+		err = p.parseSelect()
+	case p.keyword("INSERT"):
+		err = p.parseInsert()
+	case p.keyword("DELETE"):
+		err = p.parseDelete()
+	default:
+		err = fmt.Errorf("only SELECT, INSERT and DELETE are supported")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.punct(";")
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%s' in SQL", p.tokens[p.pos].text)
+	}
+	return p.stmt, nil
+}
+
+func (p *sqlParser) parseSelect() error {
+	p.stmt.kind = "select"
+	if !p.punct("*") {
+		for {
+			field, err := p.ident()
+			if err != nil {
+				return err
+			}
+			p.stmt.fields = append(p.stmt.fields, field)
+			if !p.punct(",") {
+				break
+			}
+		}
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return err
+	}
+	var err error
+	if p.stmt.collection, err = p.ident(); err != nil {
+		return err
+	}
+	if err := p.parseWhere(); err != nil {
+		return err
+	}
+
+	if p.keyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return err
+		}
+		for {
+			field, err := p.ident()
+			if err != nil {
+				return err
+			}
+			order := sqlOrder{field: field}
+			if p.keyword("DESC") {
+				order.desc = true
+			} else {
+				p.keyword("ASC")
+			}
+			p.stmt.orderBy = append(p.stmt.orderBy, order)
+			if !p.punct(",") {
+				break
+			}
+		}
+	}
+
+	if p.keyword("LIMIT") {
+		if p.stmt.limit, err = p.integer(); err != nil {
+			return err
+		}
+		if p.keyword("OFFSET") {
+			if p.stmt.offset, err = p.integer(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *sqlParser) parseInsert() error {
+	p.stmt.kind = "insert"
+	if err := p.expectKeyword("INTO"); err != nil {
+		return err
+	}
+	var err error
+	if p.stmt.collection, err = p.ident(); err != nil {
+		return err
+	}
+
+	if p.punct("(") {
+		for {
+			field, err := p.ident()
+			if err != nil {
+				return err
+			}
+			p.stmt.fields = append(p.stmt.fields, field)
+			if !p.punct(",") {
+				break
+			}
+		}
+		if !p.punct(")") {
+			return fmt.Errorf("expected ')' after column list")
+		}
+	}
+
+	if err := p.expectKeyword("VALUES"); err != nil {
+		return err
+	}
+	for {
+		if !p.punct("(") {
+			return fmt.Errorf("expected '(' before values")
+		}
+		var row []sqlValue
+		for {
+			v, err := p.value()
+			if err != nil {
+				return err
+			}
+			row = append(row, v)
+			if !p.punct(",") {
+				break
+			}
+		}
+		if !p.punct(")") {
+			return fmt.Errorf("expected ')' after values")
+		}
+		if p.stmt.fields != nil && len(row) != len(p.stmt.fields) {
+			return fmt.Errorf("INSERT has %d columns but %d values", len(p.stmt.fields), len(row))
+		}
+		p.stmt.rows = append(p.stmt.rows, row)
+		if !p.punct(",") {
+			break
+		}
+	}
+
+	if p.stmt.fields == nil {
+		return fmt.Errorf("INSERT requires a column list")
+	}
+	return nil
+}
+
+func (p *sqlParser) parseDelete() error {
+	p.stmt.kind = "delete"
+	if err := p.expectKeyword("FROM"); err != nil {
+		return err
+	}
+	var err error
+	if p.stmt.collection, err = p.ident(); err != nil {
+		return err
+	}
+	return p.parseWhere()
+}
+
+func (p *sqlParser) parseWhere() error {
+	if !p.keyword("WHERE") {
+		return nil
+	}
+	for {
+		field, err := p.ident()
+		if err != nil {
+			return err
+		}
+
+		var op string
+		if p.pos < len(p.tokens) && p.tokens[p.pos].kind == "punct" {
+			switch op = p.tokens[p.pos].text; op {
+			case "=", "!=", "<>", "<", "<=", ">", ">=":
+				p.pos++
+			default:
+				op = ""
+			}
+		}
+		if op == "" {
+			return fmt.Errorf("expected comparison after '%s'", field)
+		}
+		if op == "<>" {
+			op = "!="
+		}
+
+		v, err := p.value()
+		if err != nil {
+			return err
+		}
+		p.stmt.where = append(p.stmt.where, sqlCondition{field: field, op: op, value: v})
+
+		if !p.keyword("AND") {
+			return nil
+		}
+	}
+}
+
+func (p *sqlParser) keyword(kw string) bool {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == "ident" && strings.EqualFold(p.tokens[p.pos].text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expectKeyword(kw string) error {
+	if !p.keyword(kw) {
+		return fmt.Errorf("expected %s", kw)
+	}
+	return nil
+}
+
+func (p *sqlParser) punct(s string) bool {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == "punct" && p.tokens[p.pos].text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) ident() (string, error) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != "ident" {
+		return "", fmt.Errorf("expected a name")
+	}
+	p.pos++
+	return p.tokens[p.pos-1].text, nil
+}
+
+func (p *sqlParser) integer() (int, error) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != "number" {
+		return 0, fmt.Errorf("expected a number")
+	}
+	n, err := strconv.Atoi(p.tokens[p.pos].text)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid count '%s'", p.tokens[p.pos].text)
+	}
+	p.pos++
+	return n, nil
+}
+
+func (p *sqlParser) value() (sqlValue, error) {
+	if p.pos >= len(p.tokens) {
+		return sqlValue{}, fmt.Errorf("expected a value")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+
+	switch t.kind {
+	case "param":
+		p.stmt.params++
+		return sqlValue{param: p.stmt.params - 1}, nil
+	case "string":
+		return sqlValue{literal: t.text, param: -1}, nil
+	case "number":
+		if n, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return sqlValue{literal: n, param: -1}, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return sqlValue{}, fmt.Errorf("invalid number '%s'", t.text)
+		}
+		return sqlValue{literal: f, param: -1}, nil
+	case "ident":
+		switch strings.ToUpper(t.text) {
+		case "TRUE":
+			return sqlValue{literal: true, param: -1}, nil
+		case "FALSE":
+			return sqlValue{literal: false, param: -1}, nil
+		case "NULL":
+			return sqlValue{literal: nil, param: -1}, nil
+		}
+	}
+	return sqlValue{}, fmt.Errorf("unexpected '%s' where a value was expected", t.text)
+}
@@ -0,0 +1,172 @@
+package litedb
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// OperationStats counts one kind of operation.
+type OperationStats struct {
+	Count        uint64
+	Errors       uint64
+	TotalLatency time.Duration
+}
+
+// CollectionStats is a collection's share of Stats.
+type CollectionStats struct {
+	Name          string
+	Records       int
+	Bytes         int64
+	LargestRecord string
+	LargestBytes  int64
+	ExpiryIndex   int
+}
+
+// LockStats is how much one collection's lock was waited for.
+type LockStats struct {
+	Collection string
+	Acquires   uint64
+	Contended  uint64
+	WaitTime   time.Duration
+}
+
+// topContended is how many collections Stats lists in Contention.
+const topContended = 10
+
+// Stats is the snapshot returned by Driver.Stats.
+type Stats struct {
+	Collections   []CollectionStats
+	Records       int
+	Bytes         int64
+	Operations    map[string]OperationStats
+	BytesWritten  uint64
+	LockAcquires  uint64
+	LockContended uint64
+	LockWaitTime  time.Duration
+	Watchers      int
+
+	// Contention lists the collections whose locks were waited for
+	// longest, most first, to show where writes queue up.
+	Contention []LockStats
+
+	// HotCache is the hot record cache's size and hit counts, as
+	// CacheStats reports them.
+	HotCache CacheStats
+
+	// KeyCacheHits and KeyCacheMisses count the listings and existence
+	// checks the key cache answered and those that read the directory.
+	KeyCacheHits   uint64
+	KeyCacheMisses uint64
+
+	// CDCDropped counts the mutations dropped for CDC sinks that fell
+	// too far behind.
+	CDCDropped uint64
+}
+
+// HitRate is the share of reads the hot cache served, zero before any.
+func (s CacheStats) HitRate() float64 {
+	return hitRate(s.Hits, s.Misses)
+}
+
+// KeyCacheHitRate is the share of key lookups the key cache served, zero
+// before any.
+func (s *Stats) KeyCacheHitRate() float64 {
+	return hitRate(s.KeyCacheHits, s.KeyCacheMisses)
+}
+
+func hitRate(hits, misses uint64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// Stats gathers a point-in-time health snapshot: on-disk sizes per
+// collection alongside the counters the driver keeps in memory.
+func (d *Driver) Stats() (*Stats, error) {
+	st := &Stats{Operations: make(map[string]OperationStats)}
+
+	d.metrics.mutex.Lock()
+	for op, s := range d.metrics.ops {
+		st.Operations[op] = OperationStats{
+			Count:        s.count,
+			Errors:       s.errors,
+			TotalLatency: time.Duration(s.latency.sum * float64(time.Second)),
+		}
+	}
+	st.BytesWritten = d.metrics.bytesWritten
+	d.metrics.mutex.Unlock()
+
+	st.Contention = d.locks.contention(d.ns)
+
+	waits, contended := d.metrics.lockWait()
+	st.LockAcquires = waits.count
+	st.LockContended = contended
+	st.LockWaitTime = time.Duration(waits.sum * float64(time.Second))
+
+	sort.Slice(st.Contention, func(i, j int) bool {
+		a, b := st.Contention[i], st.Contention[j]
+		if a.WaitTime != b.WaitTime {
+			return a.WaitTime > b.WaitTime
+		}
+		return a.Collection < b.Collection
+	})
+	if len(st.Contention) > topContended {
+		st.Contention = st.Contention[:topContended]
+	}
+
+	d.watchers.mutex.Lock()
+	st.Watchers = len(d.watchers.subs)
+	d.watchers.mutex.Unlock()
+
+	st.HotCache = d.CacheStats()
+	st.KeyCacheHits, st.KeyCacheMisses = d.keyCache.counts()
+	st.CDCDropped = d.cdc.dropped.Load()
+
+	collections, err := d.collectionNames()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(collections)
+
+	for _, collection := range collections {
+		cs, err := d.collectionStats(collection)
+		if err != nil {
+			return nil, err
+		}
+		st.Collections = append(st.Collections, cs)
+		st.Records += cs.Records
+		st.Bytes += cs.Bytes
+	}
+
+	return st, nil
+}
+
+func (d *Driver) collectionStats(collection string) (CollectionStats, error) {
+	cs := CollectionStats{Name: collection}
+
+	files, err := readDirInfo(d.collectionDir(collection))
+	if err != nil && !os.IsNotExist(err) {
+		return cs, err
+	}
+
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != recordExt {
+			continue
+		}
+		cs.Records++
+		cs.Bytes += file.Size()
+		if file.Size() > cs.LargestBytes {
+			cs.LargestBytes = file.Size()
+			cs.LargestRecord = d.resourceOf(file.Name(), recordExt)
+		}
+	}
+
+	d.expiryMutex.Lock()
+	cs.ExpiryIndex = len(d.expiries[collection])
+	d.expiryMutex.Unlock()
+
+	return cs, nil
+}
@@ -0,0 +1,59 @@
+package litedb
+
+import (
+	"io"
+	"io/fs"
+	"os"
+)
+
+// Storage is the file system the driver writes records and their metadata
+// through. Other files, such as the archive, ID counters, and files bulk
+// loads write, go straight to the operating system, as do reads. Paths are
+// those the driver would use with the os package. Errors should be
+// returned as the os package would return them, so that the driver, for
+// instance, still recognizes a missing file with fs.ErrNotExist.
+type Storage interface {
+	// Create opens name for writing, truncating it or creating it with
+	// perm.
+	Create(name string, perm fs.FileMode) (io.WriteCloser, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	MkdirAll(path string, perm fs.FileMode) error
+	// Sync flushes name, a file or a directory, to stable storage. A
+	// name that no longer exists is not an error.
+	Sync(name string) error
+}
+
+// DiskStorage is the Storage the driver uses unless Options.Storage is
+// set: the operating system's file system.
+type DiskStorage struct{}
+
+// Create implements Storage.
+func (DiskStorage) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+}
+
+// Rename implements Storage.
+func (DiskStorage) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+// Remove implements Storage.
+func (DiskStorage) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// MkdirAll implements Storage.
+func (DiskStorage) MkdirAll(path string, perm fs.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+// Sync implements Storage.
+func (DiskStorage) Sync(name string) error {
+	return syncPath(name)
+}
+
+// WithStorage writes records and metadata through s. See Options.Storage.
+func WithStorage(s Storage) Option {
+	return optionFunc(func(o *Options) { o.Storage = s })
+}
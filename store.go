@@ -0,0 +1,186 @@
+package litedb
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"iter"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/SagarDas211/LiteDB-Go/litedbrepl"
+	"github.com/SagarDas211/LiteDB-Go/litedbresp"
+	"github.com/SagarDas211/LiteDB-Go/litedbsync"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+)
+
+// Reader is the core of the read API, for code that only looks records
+// up. It and Writer are small enough to fake; litedbtest.Fake implements
+// both.
+type Reader interface {
+	Read(collection, resource string, v interface{}) error
+	ReadContext(ctx context.Context, collection, resource string, v interface{}) error
+	ReadBytes(collection, resource string) ([]byte, error)
+	ReadAll(collection string) ([]string, error)
+	ReadAllContext(ctx context.Context, collection string) ([]string, error)
+	Find(collection string, filter Filter) ([]Record, error)
+	FindContext(ctx context.Context, collection string, filter Filter) ([]Record, error)
+	Collections() ([]string, error)
+	Keys(collection string) ([]string, error)
+	Count(collection string) (int, error)
+	Exists(collection, resource string) (bool, error)
+}
+
+// Writer is the core of the write API.
+type Writer interface {
+	Write(collection, resource string, v interface{}) error
+	WriteContext(ctx context.Context, collection, resource string, v interface{}) error
+	Delete(collection, resource string) error
+	DeleteContext(ctx context.Context, collection, resource string) error
+}
+
+// Store is the whole public API of *Driver, so applications can depend on
+// an interface: to mock the database in tests, or to decorate it by
+// embedding a Store in a type that overrides some methods, for example to
+// log every Write. Code that needs less should take a Reader or Writer.
+type Store interface {
+	Reader
+	Writer
+
+	// Records and documents.
+	ReadRaw(collection, resource string) (map[string]interface{}, error)
+	ReadTo(collection, resource string, w io.Writer) error
+	ReadToContext(ctx context.Context, collection, resource string, w io.Writer) error
+	ReadEach(ctx context.Context, collection string, opts ReadEachOptions, fn func(resource string, doc []byte) error) error
+	Records(ctx context.Context, collection string) iter.Seq2[Record, error]
+	List(collection string) ([]RecordRef, error)
+	WriteWithTTL(collection, resource string, v interface{}, ttl time.Duration) error
+	WriteAsync(collection, resource string, v interface{}) error
+	WriteAsyncContext(ctx context.Context, collection, resource string, v interface{}) error
+	WriteFrom(collection, resource string, r io.Reader) error
+	WriteFromContext(ctx context.Context, collection, resource string, r io.Reader) error
+	Patch(collection, resource string, patch []byte) error
+	PatchContext(ctx context.Context, collection, resource string, patch []byte) error
+	MergePatch(collection, resource string, patch []byte) error
+	MergePatchContext(ctx context.Context, collection, resource string, patch []byte) error
+	Diff(collection, resource string, v interface{}) ([]Change, error)
+	Insert(collection string, v interface{}) (string, error)
+	Generate(collection string, n int, seed int64) ([]string, error)
+	BulkLoad(collection string) (*BulkLoader, error)
+	Stat(collection, resource string) (*RecordStat, error)
+	Metadata(collection, resource string) (*Metadata, error)
+	SetTTL(collection, resource string, ttl time.Duration) error
+	Tag(collection, resource string, tags ...string) error
+	Untag(collection, resource string, tags ...string) error
+	Tags(collection, resource string) ([]string, error)
+	FindByTag(collection, tag string) ([]string, error)
+	Pin(collection, resource string) error
+	Unpin(collection, resource string)
+	Redact(collection string, doc []byte) ([]byte, error)
+	RedactedValue(collection string, v interface{}) slog.LogValuer
+
+	// Edges between records.
+	Link(collection, resource, label, toCollection, toResource string) error
+	Unlink(collection, resource, label, toCollection, toResource string) error
+	Edges(collection, resource string) ([]Edge, error)
+	Neighbors(collection, resource, label string, dir Direction) ([]Node, error)
+	Walk(collection, resource, label string, dir Direction, hops int) ([]Hop, error)
+
+	// Time series.
+	Append(collection string, t time.Time, v interface{}) error
+	AppendPoints(collection string, points ...Point) error
+	Range(collection string, from, to time.Time) ([]Point, error)
+	Downsample(collection string, from, to time.Time, step time.Duration, field string, agg Aggregation) ([]Bucket, error)
+	TruncateSeries(collection string, before time.Time) (int, error)
+
+	// Queues, topics, sorted sets and the key-value namespace.
+	Queue(name string) *Queue
+	Topic(name string) *Topic
+	SortedSet(name string) *SortedSet
+	KV() *KV
+
+	// Materialized views.
+	DefineView(name string, v View) error
+	RebuildView(name string) error
+	DropView(name string) error
+
+	// Collection settings.
+	DescribeCollection(collection string) (*CollectionInfo, error)
+	RegisterType(collection string, v interface{}) error
+	AddValidator(collection string, fn Validator) error
+	AddComputed(collection, field string, fn ComputeFunc) error
+	Recompute(collection string) error
+	AddTrigger(collection string, t Trigger) error
+	AddReference(collection, field, target string, onDelete ReferenceAction) error
+	SetDefaults(collection string, defaults map[string]interface{}) error
+	SetFormat(collection string, f Format) error
+	SetIDStrategy(collection string, strategy IDStrategy) error
+	SetQuota(collection string, q Quota) error
+	SetSensitive(collection string, fields ...string) error
+	SetArchivePolicy(collection string, maxAge time.Duration) error
+	SetArchiveDictionary(collection string, enabled bool) error
+	SetRetention(collection string, p RetentionPolicy) error
+	SetSeries(collection string, opts SeriesOptions) error
+	BeforeWrite(collection string, fn Hook) error
+	AfterWrite(collection string, fn Hook) error
+	BeforeDelete(collection string, fn Hook) error
+	AfterDelete(collection string, fn Hook) error
+
+	// Background work and housekeeping.
+	Archive(collection string) (int, error)
+	TrainArchiveDictionary(collection string) (uint32, error)
+	ApplyRetention(collection string, dryRun bool) (*RetentionReport, error)
+	Reap() (int, error)
+	StartArchiver(interval time.Duration) func()
+	StartReaper(interval time.Duration) func()
+	StartRetention(interval time.Duration) func()
+	Check() (*CheckReport, error)
+	Compact() (*CompactReport, error)
+	Backup(dest string) error
+	Flush() error
+
+	// Import and export.
+	Export(w io.Writer) error
+	ExportCSV(collection string, w io.Writer, fields ...string) error
+	ImportCSV(collection string, r io.Reader, keyColumn string) (int, error)
+	ExportNDJSON(collection string, w io.Writer, progress Progress) (int, error)
+	ImportNDJSON(collection string, r io.Reader, progress Progress) (int, error)
+
+	// Changes, replication and sync.
+	Watch(collection string) (<-chan Event, CancelFunc)
+	WatchExternal() (CancelFunc, error)
+	AddCDCSink(sink CDCSink) CancelFunc
+	ReplicationPrimary() (*litedbrepl.Primary, CancelFunc)
+	Replicate(primaryURL string) *litedbrepl.Replica
+	Sync(ctx context.Context, remote litedbsync.Peer, resolve litedbsync.Resolver) (*litedbsync.Report, error)
+	SyncPeer() *litedbsync.Local
+	SyncHandler() http.Handler
+
+	// Servers.
+	Handler() http.Handler
+	AdminHandler() http.Handler
+	GraphQLHandler() (http.Handler, error)
+	RegisterGRPC(s grpc.ServiceRegistrar)
+	RESPServer() *litedbresp.Server
+	SQL() *sql.DB
+	UseAccessControl(a *AccessControl)
+	SetServerLimits(l ServerLimits) error
+
+	// Middleware, observability and lifecycle.
+	Use(mw ...Middleware)
+	Namespace(name string) (*Driver, error)
+	Stats() (*Stats, error)
+	CacheStats() CacheStats
+	CacheEntries() []CacheEntry
+	Collector() prometheus.Collector
+	AuditLog(filter AuditFilter) ([]AuditEntry, error)
+	ExportAudit(w io.Writer) error
+	Healthy() *HealthReport
+	Ping() error
+	ReadOnly() bool
+	Close() error
+}
+
+var _ Store = (*Driver)(nil)
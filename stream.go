@@ -0,0 +1,149 @@
+package litedb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// streamDocument is the Operation Value of WriteFrom: a JSON document
+// copied to disk from r as it is checked.
+type streamDocument struct {
+	r io.Reader
+}
+
+// streamTarget is the Operation Value of ReadTo: the writer a record is
+// copied to.
+type streamTarget struct {
+	w io.Writer
+}
+
+// WriteFrom stores the JSON document read from r as resource in
+// collection, checking that it is valid JSON as it is copied to disk, so a
+// very large document never has to be in memory whole. It is stored as
+// read, whatever the collection's Format.
+//
+// Validators and write hooks are given the whole document, so in a
+// collection with any it is read into memory first, as it is when the
+// collection has references to check or a CDC sink is capturing changes.
+func (d *Driver) WriteFrom(collection, resource string, r io.Reader) error {
+	return d.WriteFromContext(context.Background(), collection, resource, r)
+}
+
+// WriteFromContext is WriteFrom with a context.
+func (d *Driver) WriteFromContext(ctx context.Context, collection, resource string, r io.Reader) error {
+	return d.run(ctx, &Operation{Kind: OpWrite, Collection: collection, Resource: resource, Value: &streamDocument{r: r}}, d.writeOp)
+}
+
+// ReadTo copies resource in collection to w, as ReadBytes would return
+// it, without reading it into memory first. Records answered from the hot
+// cache or the archive, and those in collections with defaults, are the
+// exception. Nothing is written to w if the record does not exist.
+func (d *Driver) ReadTo(collection, resource string, w io.Writer) error {
+	return d.ReadToContext(context.Background(), collection, resource, w)
+}
+
+// ReadToContext is ReadTo with a context.
+func (d *Driver) ReadToContext(ctx context.Context, collection, resource string, w io.Writer) error {
+	return d.run(ctx, &Operation{Kind: OpRead, Collection: collection, Resource: resource, Value: &streamTarget{w: w}}, d.readOp)
+}
+
+// needsDocument reports whether writing to collection has to see the
+// whole document rather than stream it.
+func (d *Driver) needsDocument(collection string) bool {
+	d.configMutex.RLock()
+	c, ok := d.configs[collection]
+	needs := ok && (len(c.validators) > 0 || len(c.hooks[beforeWrite]) > 0 || len(c.hooks[afterWrite]) > 0)
+	d.configMutex.RUnlock()
+	return needs
+}
+
+// hasDefaults reports whether reads from collection fill in defaults.
+func (d *Driver) hasDefaults(collection string) bool {
+	d.configMutex.RLock()
+	defer d.configMutex.RUnlock()
+	c, ok := d.configs[collection]
+	return ok && len(c.defaults) > 0
+}
+
+// readDocument reads and checks a streamed document for a collection that
+// needs it whole.
+func readDocument(s *streamDocument) (json.RawMessage, error) {
+	b, err := io.ReadAll(s.r)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(b) {
+		return nil, fmt.Errorf("document is not valid JSON")
+	}
+	return b, nil
+}
+
+// copyJSON copies one JSON document from r through w, failing if it is
+// not valid JSON or is followed by anything but white space. The decoder
+// only holds a token at a time.
+func (w *recordWriter) copyJSON(r io.Reader) error {
+	dec := json.NewDecoder(io.TeeReader(r, w))
+	for depth := 0; ; {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			if depth > 0 {
+				return io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("document is empty")
+		}
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+
+	switch _, err := dec.Token(); err {
+	case io.EOF:
+		return nil
+	case nil:
+		return fmt.Errorf("document is followed by more data")
+	default:
+		return err
+	}
+}
+
+// copyRecord copies a record's file to w for ReadTo, falling back to the
+// archive tier as withRecord does.
+func (d *Driver) copyRecord(ctx context.Context, collection, resource string, w io.Writer) error {
+	f, err := os.Open(d.recordPath(collection, resource))
+	if errors.Is(err, fs.ErrNotExist) {
+		b, aerr := d.readArchived(collection, resource)
+		if aerr != nil {
+			return errNoRecord(collection, resource)
+		}
+		d.touch(collection, resource)
+		recordBytes(ctx, len(b))
+		_, err = w.Write(b)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := io.Copy(w, f)
+	if err != nil {
+		return err
+	}
+	d.touch(collection, resource)
+	recordBytes(ctx, int(n))
+	return nil
+}
@@ -0,0 +1,444 @@
+// Package stress hammers a store with concurrent writes, reads, deletes
+// and listings of a small set of records, then checks what every read saw
+// and what was left against the order the operations ran in: no torn or
+// mixed-up documents, no read of a value already overwritten, and a final
+// state some serial order of the operations could have produced. The
+// litedb CLI runs it with "litedb stress".
+package stress
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Store is the part of the driver the stress test exercises. Reads of a
+// missing record must fail with an error matching fs.ErrNotExist, as the
+// driver's ErrNotFound does.
+type Store interface {
+	Write(collection, resource string, v interface{}) error
+	ReadBytes(collection, resource string) ([]byte, error)
+	ReadAll(collection string) ([]string, error)
+	Delete(collection, resource string) error
+}
+
+// Options sets the shape of a run. The zero value runs for five seconds.
+type Options struct {
+	// Duration is how long the workers run, unless the context ends
+	// first. It defaults to 5s.
+	Duration time.Duration
+
+	// Workers is how many goroutines issue operations. It defaults to
+	// twice GOMAXPROCS.
+	Workers int
+
+	// Collections and Keys set how many records the workers contend for:
+	// Keys resources in each of Collections collections. They default to
+	// 4 and 32; fewer keys mean more operations on the same record.
+	Collections int
+	Keys        int
+
+	// Seed seeds the choice of operations and documents.
+	Seed int64
+}
+
+// maxViolations caps the violations a Report describes; the rest are only
+// counted.
+const maxViolations = 50
+
+// Report is the outcome of Run.
+type Report struct {
+	Writes, Reads, Deletes, Lists int
+	Elapsed                       time.Duration
+
+	// Violations describes the first problems found; ViolationCount
+	// counts all of them.
+	Violations     []string
+	ViolationCount int
+}
+
+// OK reports whether the run found no problems.
+func (r *Report) OK() bool {
+	return r.ViolationCount == 0
+}
+
+func (r *Report) String() string {
+	s := fmt.Sprintf("%d writes, %d reads, %d deletes, %d listings in %s: ", r.Writes, r.Reads, r.Deletes, r.Lists, r.Elapsed.Round(time.Millisecond))
+	if r.OK() {
+		return s + "no violations"
+	}
+	return s + fmt.Sprintf("%d violations", r.ViolationCount)
+}
+
+// Run runs the stress test against store, which should be empty, and
+// checks the results once every worker has stopped.
+func Run(ctx context.Context, store Store, opts Options) *Report {
+	if opts.Duration <= 0 {
+		opts.Duration = 5 * time.Second
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 2 * runtime.GOMAXPROCS(0)
+	}
+	if opts.Collections <= 0 {
+		opts.Collections = 4
+	}
+	if opts.Keys <= 0 {
+		opts.Keys = 32
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	r := &run{store: store, opts: opts, report: &Report{}, history: make(map[target][]event)}
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func(rng *rand.Rand) {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				r.step(rng)
+			}
+		}(rand.New(rand.NewSource(opts.Seed + int64(i))))
+	}
+	wg.Wait()
+
+	r.report.Elapsed = time.Since(start)
+	r.verify()
+	return r.report
+}
+
+// target is one record the workers contend for.
+type target struct {
+	collection, resource string
+}
+
+func (t target) String() string {
+	return t.collection + "/" + t.resource
+}
+
+// Document is what the stress test writes. Sum is the hash of the rest,
+// so a torn or mixed-up document shows.
+type Document struct {
+	Collection string `json:"collection"`
+	Resource   string `json:"resource"`
+	Write      uint64 `json:"write"`
+	Payload    string `json:"payload"`
+	Sum        string `json:"sum"`
+}
+
+func (doc Document) sum() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%d\x00%s", doc.Collection, doc.Resource, doc.Write, doc.Payload)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type eventKind int
+
+const (
+	writeEvent eventKind = iota
+	deleteEvent
+	readEvent
+)
+
+// event is an operation on one record. Start and end are ticks of a
+// shared clock, so an event whose start is after another's end ran
+// strictly after it. For writes and reads, write is the Document.Write
+// written or seen; zero for a read that found nothing.
+type event struct {
+	kind       eventKind
+	start, end uint64
+	write      uint64
+	failed     bool
+}
+
+type run struct {
+	store  Store
+	opts   Options
+	clock  atomic.Uint64
+	writes atomic.Uint64
+
+	mutex   sync.Mutex
+	report  *Report
+	history map[target][]event
+}
+
+func (r *run) violation(format string, args ...interface{}) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.report.ViolationCount++
+	if len(r.report.Violations) < maxViolations {
+		r.report.Violations = append(r.report.Violations, fmt.Sprintf(format, args...))
+	}
+}
+
+func (r *run) record(t target, e event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.history[t] = append(r.history[t], e)
+	switch e.kind {
+	case writeEvent:
+		r.report.Writes++
+	case deleteEvent:
+		r.report.Deletes++
+	case readEvent:
+		r.report.Reads++
+	}
+}
+
+func (r *run) collection(i int) string {
+	return fmt.Sprintf("stress%d", i)
+}
+
+// step runs one operation picked at random: 40% writes, 40% reads, 10%
+// deletes and 10% listings.
+func (r *run) step(rng *rand.Rand) {
+	t := target{r.collection(rng.Intn(r.opts.Collections)), fmt.Sprintf("k%03d", rng.Intn(r.opts.Keys))}
+
+	switch n := rng.Intn(10); {
+	case n < 4:
+		doc := Document{Collection: t.collection, Resource: t.resource, Write: r.writes.Add(1), Payload: payload(rng)}
+		doc.Sum = doc.sum()
+		e := event{kind: writeEvent, write: doc.Write, start: r.clock.Add(1)}
+		err := r.store.Write(t.collection, t.resource, doc)
+		e.end = r.clock.Add(1)
+		if err != nil {
+			e.failed = true
+			r.violation("writing %s: %v", t, err)
+		}
+		r.record(t, e)
+	case n < 8:
+		e := event{kind: readEvent, start: r.clock.Add(1)}
+		b, err := r.store.ReadBytes(t.collection, t.resource)
+		e.end = r.clock.Add(1)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				r.violation("reading %s: %v", t, err)
+				return
+			}
+		} else if doc, ok := r.check(t, b); ok {
+			e.write = doc.Write
+		} else {
+			return
+		}
+		r.record(t, e)
+	case n < 9:
+		e := event{kind: deleteEvent, start: r.clock.Add(1)}
+		err := r.store.Delete(t.collection, t.resource)
+		e.end = r.clock.Add(1)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			e.failed = true
+			r.violation("deleting %s: %v", t, err)
+		}
+		r.record(t, e)
+	default:
+		docs, err := r.store.ReadAll(t.collection)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			r.violation("listing %s: %v", t.collection, err)
+			return
+		}
+		for _, b := range docs {
+			var doc Document
+			if err := json.Unmarshal([]byte(b), &doc); err != nil || doc.Collection != t.collection {
+				r.violation("listing %s: bad document %q", t.collection, truncate(b))
+				continue
+			}
+			r.check(target{doc.Collection, doc.Resource}, []byte(b))
+		}
+		r.mutex.Lock()
+		r.report.Lists++
+		r.mutex.Unlock()
+	}
+}
+
+// check decodes b, read as t, and reports whether it is a whole document
+// written to t.
+func (r *run) check(t target, b []byte) (Document, bool) {
+	var doc Document
+	if err := json.Unmarshal(b, &doc); err != nil {
+		r.violation("%s holds invalid JSON: %v: %q", t, err, truncate(string(b)))
+		return doc, false
+	}
+	if doc.Collection != t.collection || doc.Resource != t.resource {
+		r.violation("%s holds the document written to %s/%s", t, doc.Collection, doc.Resource)
+		return doc, false
+	}
+	if doc.Sum != doc.sum() {
+		r.violation("%s holds a torn document from write %d", t, doc.Write)
+		return doc, false
+	}
+	return doc, true
+}
+
+// verify checks the history of every record once the workers are done.
+func (r *run) verify() {
+	keys := make([]target, 0, len(r.history))
+	for t := range r.history {
+		keys = append(keys, t)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	for _, t := range keys {
+		r.verifyReads(t, r.history[t])
+		r.verifyFinal(t, r.history[t])
+	}
+
+	for i := 0; i < r.opts.Collections; i++ {
+		collection := r.collection(i)
+		if _, err := r.store.ReadAll(collection); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			r.violation("listing %s after the run: %v", collection, err)
+		}
+	}
+}
+
+// verifyReads reports reads that saw a value from the future, or one that
+// a later write or delete had replaced before the read began.
+func (r *run) verifyReads(t target, events []event) {
+	writes := make(map[uint64]event)
+	var mutations, deletes []event
+	for _, e := range events {
+		switch e.kind {
+		case writeEvent:
+			writes[e.write] = e
+			if !e.failed {
+				mutations = append(mutations, e)
+			}
+		case deleteEvent:
+			if !e.failed {
+				mutations = append(mutations, e)
+				deletes = append(deletes, e)
+			}
+		}
+	}
+
+	// latestEnd[i] is the latest end of the first i+1 deletes by start.
+	sort.Slice(deletes, func(i, j int) bool { return deletes[i].start < deletes[j].start })
+	latestEnd := make([]uint64, len(deletes))
+	for i, e := range deletes {
+		latestEnd[i] = e.end
+		if i > 0 && latestEnd[i-1] > e.end {
+			latestEnd[i] = latestEnd[i-1]
+		}
+	}
+
+	// latestStart[i] is the latest start of the first i+1 mutations by end,
+	// so a binary search finds the newest mutation finished before a read.
+	sort.Slice(mutations, func(i, j int) bool { return mutations[i].end < mutations[j].end })
+	latestStart := make([]uint64, len(mutations))
+	for i, m := range mutations {
+		latestStart[i] = m.start
+		if i > 0 && latestStart[i-1] > m.start {
+			latestStart[i] = latestStart[i-1]
+		}
+	}
+	replacedAfter := func(read event) uint64 {
+		i := sort.Search(len(mutations), func(i int) bool { return mutations[i].end >= read.start })
+		if i == 0 {
+			return 0
+		}
+		return latestStart[i-1]
+	}
+
+	for _, read := range events {
+		if read.kind != readEvent {
+			continue
+		}
+
+		var seenEnd uint64
+		if read.write != 0 {
+			w, ok := writes[read.write]
+			if !ok || w.start > read.end {
+				r.violation("read of %s saw write %d before it began", t, read.write)
+				continue
+			}
+			seenEnd = w.end
+		} else {
+			// Nothing found: the latest delete that could have come first
+			// explains it best, or the record never having existed.
+			if i := sort.Search(len(deletes), func(i int) bool { return deletes[i].start >= read.end }); i > 0 {
+				seenEnd = latestEnd[i-1]
+			}
+		}
+
+		if after := replacedAfter(read); after > seenEnd {
+			if read.write == 0 {
+				r.violation("read of %s found nothing after a write had finished", t)
+			} else {
+				r.violation("read of %s saw write %d after it had been replaced", t, read.write)
+			}
+		}
+	}
+}
+
+// verifyFinal checks that what is left of t is what one of its last
+// mutations left: those that nothing else started after.
+func (r *run) verifyFinal(t target, events []event) {
+	var lastStart uint64
+	for _, e := range events {
+		if e.kind != readEvent && !e.failed && e.start > lastStart {
+			lastStart = e.start
+		}
+	}
+
+	allowed := make(map[uint64]bool)
+	for _, e := range events {
+		if e.kind == readEvent {
+			continue
+		}
+		// Failed mutations may or may not have happened.
+		if e.failed || e.end > lastStart {
+			allowed[e.write] = true
+		}
+	}
+	if lastStart == 0 {
+		allowed[0] = true
+	}
+
+	var found uint64
+	b, err := r.store.ReadBytes(t.collection, t.resource)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		r.violation("reading %s after the run: %v", t, err)
+		return
+	default:
+		doc, ok := r.check(t, b)
+		if !ok {
+			return
+		}
+		found = doc.Write
+	}
+
+	if !allowed[found] {
+		if found == 0 {
+			r.violation("%s is missing after the run, but its last mutations were writes", t)
+		} else {
+			r.violation("%s holds write %d after the run, which later mutations replaced", t, found)
+		}
+	}
+}
+
+// payload returns filler text from a few bytes to several kilobytes, so
+// some writes span more than one buffer.
+func payload(rng *rand.Rand) string {
+	return strings.Repeat(string(rune('a'+rng.Intn(26))), 1<<uint(rng.Intn(14)))
+}
+
+func truncate(s string) string {
+	if len(s) > 80 {
+		return s[:80] + "..."
+	}
+	return s
+}
@@ -0,0 +1,58 @@
+package litedb
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/SagarDas211/LiteDB-Go/litedbsync"
+)
+
+const syncFile = "_sync.json"
+
+// SyncPeer returns this database as one side of a sync; see package
+// litedbsync.
+func (d *Driver) SyncPeer() *litedbsync.Local {
+	d.syncOnce.Do(func() {
+		d.syncPeer = litedbsync.NewLocal(syncStore{serverStore{d}}, filepath.Join(d.dir, syncFile))
+	})
+	return d.syncPeer
+}
+
+// Sync exchanges changes with remote. A nil resolve keeps the most recent
+// write when both sides changed a record.
+func (d *Driver) Sync(ctx context.Context, remote litedbsync.Peer, resolve litedbsync.Resolver) (*litedbsync.Report, error) {
+	return litedbsync.Sync(ctx, d.SyncPeer(), remote, resolve)
+}
+
+// SyncHandler serves this database to remote peers.
+func (d *Driver) SyncHandler() http.Handler {
+	return litedbsync.Handler(d.SyncPeer())
+}
+
+type syncStore struct {
+	serverStore
+}
+
+func (s syncStore) Keys(collection string) ([]string, error) {
+	return s.d.Keys(collection)
+}
+
+func (s syncStore) Delete(ctx context.Context, collection, resource string) error {
+	if _, err := os.Stat(s.d.recordPath(collection, resource)); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return s.d.DeleteContext(ctx, collection, resource)
+}
+
+func (s syncStore) Modified(collection, resource string) (time.Time, error) {
+	m, err := s.d.Metadata(collection, resource)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return m.UpdatedAt, nil
+}
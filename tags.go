@@ -0,0 +1,129 @@
+package litedb
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Tag attaches labels to a record without touching its document.
+func (d *Driver) Tag(collection, resource string, tags ...string) error {
+	return d.retag(collection, resource, func(set map[string]bool) {
+		for _, tag := range tags {
+			set[tag] = true
+		}
+	}, tags)
+}
+
+// Untag removes labels from a record.
+func (d *Driver) Untag(collection, resource string, tags ...string) error {
+	return d.retag(collection, resource, func(set map[string]bool) {
+		for _, tag := range tags {
+			delete(set, tag)
+		}
+	}, tags)
+}
+
+// Tags returns a record's labels.
+func (d *Driver) Tags(collection, resource string) ([]string, error) {
+	m, err := d.Metadata(collection, resource)
+	if err != nil {
+		return nil, err
+	}
+	return m.Tags, nil
+}
+
+// FindByTag lists the resources in collection carrying tag.
+func (d *Driver) FindByTag(collection, tag string) ([]string, error) {
+	if err := d.validCollection(collection); err != nil {
+		return nil, err
+	}
+
+	keys, err := d.keys(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	expiries, err := d.expiriesFor(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	now := d.now()
+	var found []string
+	for _, resource := range keys {
+		if expiresAt, ok := expiries[resource]; ok && !now.Before(expiresAt) {
+			continue
+		}
+		m, err := d.readMeta(collection, resource)
+		if err != nil {
+			return nil, err
+		}
+		if m == nil {
+			continue
+		}
+		i := sort.SearchStrings(m.Tags, tag)
+		if i < len(m.Tags) && m.Tags[i] == tag {
+			found = append(found, resource)
+		}
+	}
+
+	return found, nil
+}
+
+func (d *Driver) retag(collection, resource string, change func(map[string]bool), tags []string) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if err := validResource(resource); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if tag == "" {
+			return fmt.Errorf("tag cannot be empty")
+		}
+	}
+	if err := d.checkOpen(); err != nil {
+		return err
+	}
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	if err := d.expireIfDue(collection, resource); err != nil {
+		return err
+	}
+
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+
+	fi, err := os.Stat(d.recordPath(collection, resource))
+	if err != nil {
+		return err
+	}
+
+	m, err := d.readMeta(collection, resource)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		m = &Metadata{CreatedAt: fi.ModTime(), UpdatedAt: fi.ModTime()}
+	}
+
+	set := make(map[string]bool, len(m.Tags))
+	for _, tag := range m.Tags {
+		set[tag] = true
+	}
+	change(set)
+
+	m.Tags = m.Tags[:0]
+	for tag := range set {
+		m.Tags = append(m.Tags, tag)
+	}
+	sort.Strings(m.Tags)
+	if len(m.Tags) == 0 {
+		m.Tags = nil
+	}
+
+	return d.writeMeta(collection, resource, m)
+}
@@ -0,0 +1,243 @@
+package litedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Tenant is one application hosted by Tenants, isolated in its own
+// sub-directory with its own Driver, quotas and stats. Zero limits are
+// unbounded.
+type Tenant struct {
+	Name       string `json:"name"`
+	MaxRecords int    `json:"maxRecords,omitempty"`
+	MaxBytes   int64  `json:"maxBytes,omitempty"`
+}
+
+// Tenants serves many small databases from one directory, choosing the
+// database for each request by its API key.
+type Tenants struct {
+	dir  string
+	opts []Option
+
+	mutex    sync.Mutex
+	keys     map[string]Tenant
+	drivers  map[string]*Driver
+	handlers map[string]http.Handler
+}
+
+// NewTenants hosts tenants under dir, opening each tenant's database with
+// options.
+func NewTenants(dir string, options ...Option) (*Tenants, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Tenants{
+		dir:      filepath.Clean(dir),
+		opts:     options,
+		keys:     make(map[string]Tenant),
+		drivers:  make(map[string]*Driver),
+		handlers: make(map[string]http.Handler),
+	}, nil
+}
+
+// Add grants apiKey access to tenant. Several keys may share a tenant;
+// the limits of the most recently added key apply.
+func (t *Tenants) Add(apiKey string, tenant Tenant) error {
+	if apiKey == "" {
+		return fmt.Errorf("API key cannot be empty")
+	}
+	name := tenant.Name
+	if name == "" || name != filepath.Base(name) || reservedDir(name) {
+		return fmt.Errorf("invalid tenant name '%s'", name)
+	}
+	if tenant.MaxRecords < 0 || tenant.MaxBytes < 0 {
+		return fmt.Errorf("quota limits cannot be negative")
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.keys[apiKey] = tenant
+	t.setLimits(tenant)
+	return nil
+}
+
+// Remove revokes apiKey. The tenant's data is kept.
+func (t *Tenants) Remove(apiKey string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.keys, apiKey)
+}
+
+// Load adds the keys in a JSON file mapping each API key to a Tenant.
+func (t *Tenants) Load(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var keys map[string]Tenant
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for key, tenant := range keys {
+		if err := t.Add(key, tenant); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Driver returns the named tenant's database, opening it on first use.
+func (t *Tenants) Driver(name string) (*Driver, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, tenant := range t.keys {
+		if tenant.Name == name {
+			return t.open(tenant)
+		}
+	}
+	return nil, fmt.Errorf("unknown tenant '%s'", name)
+}
+
+// open returns the tenant's database. The caller must hold mutex.
+func (t *Tenants) open(tenant Tenant) (*Driver, error) {
+	if d, ok := t.drivers[tenant.Name]; ok {
+		return d, nil
+	}
+
+	d, err := New(filepath.Join(t.dir, tenant.Name), t.opts...)
+	if err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+
+	limits := &tenantLimits{}
+	d.Use(limits.middleware(d))
+	d.tenant = limits
+
+	t.drivers[tenant.Name] = d
+	t.handlers[tenant.Name] = d.Handler()
+	t.setLimits(tenant)
+	return d, nil
+}
+
+// setLimits applies tenant's quotas to its open database. The caller must
+// hold mutex.
+func (t *Tenants) setLimits(tenant Tenant) {
+	if d, ok := t.drivers[tenant.Name]; ok {
+		d.tenant.set(tenant)
+	}
+}
+
+// Handler serves each tenant's database, as Handler does for a single
+// one, to requests carrying its API key in an "Authorization: Bearer"
+// or "X-API-Key" header.
+func (t *Tenants) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if auth := r.Header.Get("Authorization"); key == "" && strings.HasPrefix(auth, "Bearer ") {
+			key = strings.TrimPrefix(auth, "Bearer ")
+		}
+
+		t.mutex.Lock()
+		tenant, ok := t.keys[key]
+		var h http.Handler
+		var err error
+		if ok {
+			if _, err = t.open(tenant); err == nil {
+				h = t.handlers[tenant.Name]
+			}
+		}
+		t.mutex.Unlock()
+
+		switch {
+		case !ok:
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeHTTPError(w, http.StatusUnauthorized, fmt.Errorf("missing or unknown API key"))
+		case err != nil:
+			writeHTTPError(w, http.StatusInternalServerError, err)
+		default:
+			h.ServeHTTP(w, r)
+		}
+	})
+}
+
+func writeHTTPError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// tenantLimits enforces a tenant's quotas across all its collections.
+type tenantLimits struct {
+	mutex  sync.Mutex
+	tenant Tenant
+}
+
+func (l *tenantLimits) set(tenant Tenant) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.tenant = tenant
+}
+
+func (l *tenantLimits) middleware(d *Driver) Middleware {
+	return func(next Op) Op {
+		return func(ctx context.Context, op *Operation) error {
+			if op.Kind == OpWrite {
+				if err := l.check(d, op); err != nil {
+					return err
+				}
+			}
+			return next(ctx, op)
+		}
+	}
+}
+
+// check estimates whether op would take the tenant over its limits. It is
+// advisory under concurrent writes, which may overshoot by the writes in
+// flight.
+func (l *tenantLimits) check(d *Driver, op *Operation) error {
+	l.mutex.Lock()
+	tenant := l.tenant
+	l.mutex.Unlock()
+
+	if tenant.MaxRecords == 0 && tenant.MaxBytes == 0 {
+		return nil
+	}
+
+	collections, err := d.collectionNames()
+	if err != nil {
+		return err
+	}
+
+	records, size := 0, int64(0)
+	for _, collection := range collections {
+		cs, err := d.collectionStats(collection)
+		if err != nil {
+			return err
+		}
+		records += cs.Records
+		size += cs.Bytes
+	}
+
+	if fi, err := os.Stat(d.recordPath(op.Collection, op.Resource)); err == nil {
+		size -= fi.Size()
+	} else {
+		records++
+	}
+	if b, err := json.MarshalIndent(op.Value, "", "\t"); err == nil {
+		size += int64(len(b)) + 1
+	}
+
+	if (tenant.MaxRecords > 0 && records > tenant.MaxRecords) || (tenant.MaxBytes > 0 && size > tenant.MaxBytes) {
+		return fmt.Errorf("tenant '%s' writing '%s' to collection '%s': %w", tenant.Name, op.Resource, op.Collection, ErrQuotaExceeded)
+	}
+	return nil
+}
@@ -0,0 +1,326 @@
+package litedb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const topicsDir = "_topics"
+
+// Topic is a publish/subscribe topic kept in a collection of the same
+// name, returned by Driver.Topic. Every subscriber receives every message
+// published after it subscribed, in order, and a message is kept until
+// each subscriber has acknowledged it. Subscribers are durable: each one's
+// position is stored under _topics, so it carries on after a restart.
+type Topic struct {
+	d    *Driver
+	name string
+}
+
+// TopicMessage is a message published to a Topic.
+type TopicMessage struct {
+	ID          string          `json:"id"`
+	Value       json.RawMessage `json:"value"`
+	PublishedAt time.Time       `json:"publishedAt"`
+}
+
+// published is a message as stored in the topic's collection.
+type published struct {
+	Value       json.RawMessage `json:"value"`
+	PublishedAt time.Time       `json:"publishedAt"`
+}
+
+// topicCursor is a subscriber's position: the last message it
+// acknowledged.
+type topicCursor struct {
+	Acked string `json:"acked"`
+}
+
+// Topic returns the topic kept in collection name. Messages are ordered
+// by ULIDs, which follow the driver's clock.
+func (d *Driver) Topic(name string) *Topic {
+	return &Topic{d: d, name: name}
+}
+
+// Publish adds v to the topic for every current subscriber and returns its
+// message ID. With no subscribers nobody can receive it, so it is not
+// kept.
+func (t *Topic) Publish(v interface{}) (string, error) {
+	return t.PublishContext(context.Background(), v)
+}
+
+// PublishContext is Publish with a context.
+func (t *Topic) PublishContext(ctx context.Context, v interface{}) (string, error) {
+	if err := t.d.validCollection(t.name); err != nil {
+		return "", err
+	}
+	if err := t.d.checkWritable(); err != nil {
+		return "", err
+	}
+
+	value, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("topic '%s': %w", t.name, err)
+	}
+
+	mutex := t.d.lock(topicsDir + "/" + t.name)
+	defer mutex.Unlock()
+
+	now := t.d.now()
+	id, err := t.d.ulids.next(now)
+	if err != nil {
+		return "", err
+	}
+	subscribers, err := t.subscribers()
+	if err != nil || len(subscribers) == 0 {
+		return id, err
+	}
+	return id, t.d.WriteContext(ctx, t.name, id, published{Value: value, PublishedAt: now})
+}
+
+// Subscribe registers subscriber, which then receives the messages
+// published from now on. Subscribing again leaves its position alone.
+func (t *Topic) Subscribe(subscriber string) error {
+	return t.SubscribeContext(context.Background(), subscriber)
+}
+
+// SubscribeContext is Subscribe with a context. Under UseAccessControl the
+// user in ctx needs write access to the topic's collection.
+func (t *Topic) SubscribeContext(ctx context.Context, subscriber string) error {
+	if err := t.checkWrite(ctx, subscriber); err != nil {
+		return err
+	}
+
+	mutex := t.d.lock(topicsDir + "/" + t.name)
+	defer mutex.Unlock()
+
+	path := t.cursorPath(subscriber)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return err
+	}
+	keys, err := t.d.keys(t.name)
+	if err != nil {
+		return err
+	}
+	var cursor topicCursor
+	if len(keys) > 0 {
+		cursor.Acked = keys[len(keys)-1]
+	}
+	return t.d.writeStateFile(path, cursor)
+}
+
+// Unsubscribe removes subscriber, dropping the messages only it had still
+// to acknowledge.
+func (t *Topic) Unsubscribe(subscriber string) error {
+	return t.UnsubscribeContext(context.Background(), subscriber)
+}
+
+// UnsubscribeContext is Unsubscribe with a context. Under UseAccessControl
+// the user in ctx needs write access to the topic's collection.
+func (t *Topic) UnsubscribeContext(ctx context.Context, subscriber string) error {
+	if err := t.checkWrite(ctx, subscriber); err != nil {
+		return err
+	}
+
+	mutex := t.d.lock(topicsDir + "/" + t.name)
+	defer mutex.Unlock()
+
+	path := t.cursorPath(subscriber)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return t.notSubscribed(subscriber)
+		}
+		return err
+	}
+	if err := t.d.removeStateFile(path); err != nil {
+		return err
+	}
+	return t.prune(ctx)
+}
+
+// Subscribers lists the topic's subscribers.
+func (t *Topic) Subscribers() ([]string, error) {
+	if err := t.d.validCollection(t.name); err != nil {
+		return nil, err
+	}
+	return t.subscribers()
+}
+
+// Fetch returns up to max of the messages subscriber has not yet
+// acknowledged, oldest first, or all of them if max is not positive.
+// Fetching does not move the subscriber on, so the same messages come back
+// until Ack.
+func (t *Topic) Fetch(subscriber string, max int) ([]TopicMessage, error) {
+	return t.FetchContext(context.Background(), subscriber, max)
+}
+
+// FetchContext is Fetch with a context.
+func (t *Topic) FetchContext(ctx context.Context, subscriber string, max int) ([]TopicMessage, error) {
+	if err := t.check(subscriber); err != nil {
+		return nil, err
+	}
+
+	cursor, err := t.cursor(subscriber)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := t.d.keys(t.name)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []TopicMessage{}
+	for _, id := range keys {
+		if id <= cursor.Acked {
+			continue
+		}
+		if max > 0 && len(messages) == max {
+			break
+		}
+		var m published
+		if err := t.d.ReadContext(ctx, t.name, id, &m); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		messages = append(messages, TopicMessage{ID: id, Value: m.Value, PublishedAt: m.PublishedAt})
+	}
+	return messages, nil
+}
+
+// Ack acknowledges every message up to and including id for subscriber.
+// Messages every subscriber has acknowledged are deleted.
+func (t *Topic) Ack(subscriber, id string) error {
+	return t.AckContext(context.Background(), subscriber, id)
+}
+
+// AckContext is Ack with a context. Under UseAccessControl the user in
+// ctx needs write access to the topic's collection.
+func (t *Topic) AckContext(ctx context.Context, subscriber, id string) error {
+	if err := validResource(id); err != nil {
+		return err
+	}
+	if err := t.checkWrite(ctx, subscriber); err != nil {
+		return err
+	}
+
+	mutex := t.d.lock(topicsDir + "/" + t.name)
+	defer mutex.Unlock()
+
+	cursor, err := t.cursor(subscriber)
+	if err != nil {
+		return err
+	}
+	if id <= cursor.Acked {
+		return nil
+	}
+	cursor.Acked = id
+	if err := t.d.writeStateFile(t.cursorPath(subscriber), cursor); err != nil {
+		return err
+	}
+	return t.prune(ctx)
+}
+
+// prune deletes the messages every subscriber has acknowledged. The
+// caller must hold the topic's lock.
+func (t *Topic) prune(ctx context.Context) error {
+	subscribers, err := t.subscribers()
+	if err != nil {
+		return err
+	}
+	var oldest string
+	for i, subscriber := range subscribers {
+		cursor, err := t.cursor(subscriber)
+		if err != nil {
+			return err
+		}
+		if i == 0 || cursor.Acked < oldest {
+			oldest = cursor.Acked
+		}
+	}
+
+	keys, err := t.d.keys(t.name)
+	if err != nil {
+		return err
+	}
+	for _, id := range keys {
+		if len(subscribers) > 0 && id > oldest {
+			break
+		}
+		if err := t.d.DeleteContext(ctx, t.name, id); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Topic) check(subscriber string) error {
+	if err := t.d.validCollection(t.name); err != nil {
+		return err
+	}
+	if err := validResource(subscriber); err != nil {
+		return fmt.Errorf("subscriber: %w", err)
+	}
+	return nil
+}
+
+// checkWrite is check for the operations that change the topic, which
+// must be allowed before anything is touched.
+func (t *Topic) checkWrite(ctx context.Context, subscriber string) error {
+	if err := t.check(subscriber); err != nil {
+		return err
+	}
+	if err := t.d.checkWritable(); err != nil {
+		return err
+	}
+	return t.d.authorize(ctx, t.name, WriteAccess)
+}
+
+func (t *Topic) subscribers() ([]string, error) {
+	files, err := os.ReadDir(filepath.Join(t.d.dir, topicsDir, t.name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var subscribers []string
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != recordExt {
+			continue
+		}
+		subscribers = append(subscribers, t.d.resourceOf(file.Name(), recordExt))
+	}
+	return subscribers, nil
+}
+
+func (t *Topic) cursor(subscriber string) (*topicCursor, error) {
+	b, err := os.ReadFile(t.cursorPath(subscriber))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, t.notSubscribed(subscriber)
+		}
+		return nil, err
+	}
+	var cursor topicCursor
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return nil, fmt.Errorf("cursor of '%s' on topic '%s': %w", subscriber, t.name, err)
+	}
+	return &cursor, nil
+}
+
+func (t *Topic) cursorPath(subscriber string) string {
+	return filepath.Join(t.d.dir, topicsDir, t.name, t.d.fileStem(subscriber)+recordExt)
+}
+
+func (t *Topic) notSubscribed(subscriber string) error {
+	return fmt.Errorf("'%s' is not subscribed to topic '%s': %w", subscriber, t.name, ErrNotFound)
+}
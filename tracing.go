@@ -0,0 +1,96 @@
+package litedb
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	attrCollection = attribute.Key("litedb.collection")
+	attrResource   = attribute.Key("litedb.resource")
+	attrBytes      = attribute.Key("litedb.bytes")
+	attrRecords    = attribute.Key("litedb.records")
+)
+
+var noopTracer = noop.NewTracerProvider().Tracer("")
+
+// operation collects what an instrumented call touched so it can be
+// reported once the call finishes.
+type operation struct {
+	bytes   int
+	records int
+}
+
+type operationKey struct{}
+
+// instrument opens a span for op and returns a function, deferred with a
+// pointer to the operation's error result, that ends it, records metrics
+// and logs the call if it was slow.
+func (d *Driver) instrument(ctx context.Context, op, collection, resource string) (context.Context, func(*error)) {
+	start := time.Now()
+
+	attrs := []attribute.KeyValue{attribute.String("db.system", "litedb"), attrCollection.String(collection)}
+	if resource != "" {
+		attrs = append(attrs, attrResource.String(resource))
+	}
+	ctx, span := d.tracer.Start(ctx, "litedb."+op, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+
+	info := &operation{records: -1}
+	ctx = context.WithValue(ctx, operationKey{}, info)
+
+	return ctx, func(err *error) {
+		elapsed := d.observe(op, start, err)
+
+		level := slog.LevelDebug
+		msg := "Operation finished"
+		if d.slowThreshold > 0 && elapsed >= d.slowThreshold {
+			level = slog.LevelWarn
+			msg = "Slow operation"
+		}
+		if d.log.Enabled(ctx, level) {
+			fields := []slog.Attr{slog.String("op", op), slog.String("collection", collection)}
+			if resource != "" {
+				fields = append(fields, slog.String("resource", resource))
+			}
+			fields = append(fields, slog.Duration("duration", elapsed))
+			if info.records >= 0 {
+				fields = append(fields, slog.Int("records", info.records))
+			}
+			if info.bytes > 0 {
+				fields = append(fields, slog.Int("bytes", info.bytes))
+			}
+			if *err != nil {
+				fields = append(fields, slog.Any("err", *err))
+			}
+			d.log.LogAttrs(ctx, level, msg, fields...)
+		}
+
+		if *err != nil {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+		span.End()
+	}
+}
+
+// recordBytes notes the document bytes handled by the operation in ctx.
+func recordBytes(ctx context.Context, n int) {
+	if info, ok := ctx.Value(operationKey{}).(*operation); ok {
+		info.bytes = n
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attrBytes.Int(n))
+}
+
+// recordCount notes how many records the operation in ctx visited.
+func recordCount(ctx context.Context, n int) {
+	if info, ok := ctx.Value(operationKey{}).(*operation); ok {
+		info.records = n
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attrRecords.Int(n))
+}
@@ -0,0 +1,354 @@
+package litedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// triggersLock prefixes the lock held while a target collection's
+// documents are read, updated and written back by triggers.
+const triggersLock = "_triggers/"
+
+// Trigger keeps a document in another collection in step with a
+// collection, as a counter or total in "stats" follows writes to
+// "orders". Triggers are added with AddTrigger.
+type Trigger struct {
+	// Target is the collection of the document the trigger updates.
+	Target string
+
+	// Resource names the document in Target.
+	Resource string
+
+	// Key, when set, names the document in Target from each source
+	// record instead of Resource, so records can keep documents of their
+	// own, such as a total per customer. An empty name leaves the record
+	// out. A record whose name changes in a write is taken out of the old
+	// document and added to the new one.
+	Key func(collection, resource string, doc map[string]interface{}) string
+
+	// Update changes doc, the target document, for a source record going
+	// from before to after. before is nil for a new record, after is nil
+	// for a deleted one, and doc is empty when the target does not exist
+	// yet. Documents are decoded with numbers as json.Number. See
+	// TriggerCount and TriggerSum.
+	Update func(doc, before, after map[string]interface{}) error
+}
+
+// TriggerCount returns a Trigger's Update keeping the number of source
+// records in field: one more for each new record, one fewer for each
+// deleted one.
+func TriggerCount(field string) func(doc, before, after map[string]interface{}) error {
+	return func(doc, before, after map[string]interface{}) error {
+		switch {
+		case before == nil && after != nil:
+			return addNumber(doc, field, 1)
+		case before != nil && after == nil:
+			return addNumber(doc, field, -1)
+		}
+		return nil
+	}
+}
+
+// TriggerSum returns a Trigger's Update keeping the total of the numbers
+// at the dotted path from in the source records in field. Records without
+// a number there count as zero.
+func TriggerSum(field, from string) func(doc, before, after map[string]interface{}) error {
+	return func(doc, before, after map[string]interface{}) error {
+		delta := documentNumber(after, from) - documentNumber(before, from)
+		if delta == 0 {
+			return nil
+		}
+		return addNumber(doc, field, delta)
+	}
+}
+
+func documentNumber(doc map[string]interface{}, path string) float64 {
+	if doc == nil {
+		return 0
+	}
+	value, ok := lookupField(doc, path)
+	if !ok {
+		return 0
+	}
+	n, ok := value.(json.Number)
+	if !ok {
+		return 0
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+func addNumber(doc map[string]interface{}, field string, delta float64) error {
+	var current float64
+	switch v := doc[field].(type) {
+	case nil:
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return fmt.Errorf("field '%s': %w", field, err)
+		}
+		current = f
+	case float64:
+		current = v
+	default:
+		return fmt.Errorf("field '%s' is not a number", field)
+	}
+	doc[field] = current + delta
+	return nil
+}
+
+// AddTrigger runs t on every write to and delete from collection. The
+// target documents are updated before the change itself is made, with the
+// collection lock held: if an Update fails, or a target cannot be written,
+// the change is refused and any targets already written are put back. If
+// the change then fails, the targets are put back too, so summaries match
+// their sources. Targets are written with Write, so their validators,
+// hooks and views apply, and may have triggers of their own, but triggers
+// cannot lead back to collection. Records removed by expiry or retention
+// do not run triggers.
+func (d *Driver) AddTrigger(collection string, t Trigger) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if err := d.validCollection(t.Target); err != nil {
+		return err
+	}
+	if t.Key == nil {
+		if err := validResource(t.Resource); err != nil {
+			return err
+		}
+	}
+	if t.Update == nil {
+		return fmt.Errorf("trigger on '%s' needs an Update", collection)
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	if d.triggersReach(t.Target, collection, make(map[string]bool)) {
+		return fmt.Errorf("trigger on '%s' updating '%s' would lead back to '%s'", collection, t.Target, collection)
+	}
+	c := d.configFor(collection)
+	c.triggers = append(c.triggers, t)
+	return nil
+}
+
+// triggersReach reports whether writing to from runs triggers that lead,
+// directly or not, to a write to to. The caller must hold d.configMutex.
+func (d *Driver) triggersReach(from, to string, seen map[string]bool) bool {
+	if from == to {
+		return true
+	}
+	if seen[from] {
+		return false
+	}
+	seen[from] = true
+	if c, ok := d.configs[from]; ok {
+		for _, t := range c.triggers {
+			if d.triggersReach(t.Target, to, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d *Driver) triggersFor(collection string) []Trigger {
+	d.configMutex.RLock()
+	defer d.configMutex.RUnlock()
+	if c, ok := d.configs[collection]; ok {
+		return c.triggers
+	}
+	return nil
+}
+
+func (d *Driver) hasTriggers(collection string) bool {
+	return len(d.triggersFor(collection)) > 0
+}
+
+// triggerChange is a source record going from before to after.
+type triggerChange struct {
+	resource      string
+	before, after map[string]interface{}
+}
+
+// triggerBatch is the target documents a change updated. It holds the
+// targets' trigger locks until released.
+type triggerBatch struct {
+	d       *Driver
+	ctx     context.Context
+	locks   []*sync.Mutex
+	targets []*triggerTarget
+}
+
+type triggerTarget struct {
+	collection, resource string
+	doc                  map[string]interface{}
+
+	// prev is the document before the batch, nil if there was none.
+	prev    map[string]interface{}
+	written bool
+}
+
+// recordDocument returns resource in collection decoded, or nil if there
+// is no such record or it is not a JSON object.
+func (d *Driver) recordDocument(collection, resource string) map[string]interface{} {
+	var doc map[string]interface{}
+	d.withRecord(collection, resource, func(b []byte) error {
+		doc, _ = decodeDocument(b)
+		return nil
+	})
+	return doc
+}
+
+// runTriggers applies collection's triggers to changes and writes the
+// targets. On error nothing is left changed and no locks are held;
+// otherwise the caller must release the batch, after undoing it if the
+// change itself fails.
+func (d *Driver) runTriggers(ctx context.Context, collection string, changes []triggerChange) (*triggerBatch, error) {
+	triggers := d.triggersFor(collection)
+	if len(triggers) == 0 {
+		return nil, nil
+	}
+
+	targets := make([]string, 0, len(triggers))
+	for _, t := range triggers {
+		targets = append(targets, t.Target)
+	}
+	sort.Strings(targets)
+
+	b := &triggerBatch{d: d, ctx: ctx}
+	for i, target := range targets {
+		if i == 0 || target != targets[i-1] {
+			b.locks = append(b.locks, d.lock(triggersLock+target))
+		}
+	}
+
+	byName := make(map[string]*triggerTarget)
+	apply := func(t Trigger, key string, before, after map[string]interface{}) error {
+		if key == "" {
+			return nil
+		}
+		if err := validResource(key); err != nil {
+			return fmt.Errorf("trigger on '%s' updating '%s': %w", collection, t.Target, err)
+		}
+		name := t.Target + "/" + key
+		target, ok := byName[name]
+		if !ok {
+			prev := d.recordDocument(t.Target, key)
+			doc := make(map[string]interface{}, len(prev))
+			for field, value := range prev {
+				doc[field] = value
+			}
+			target = &triggerTarget{collection: t.Target, resource: key, doc: doc, prev: prev}
+			byName[name] = target
+			b.targets = append(b.targets, target)
+		}
+		if err := t.Update(target.doc, before, after); err != nil {
+			return fmt.Errorf("trigger on '%s' updating '%s' in '%s': %w", collection, key, t.Target, err)
+		}
+		return nil
+	}
+
+	for _, change := range changes {
+		if change.before == nil && change.after == nil {
+			continue
+		}
+		for _, t := range triggers {
+			keyOf := func(doc map[string]interface{}) string {
+				if doc == nil {
+					return ""
+				}
+				if t.Key == nil {
+					return t.Resource
+				}
+				return t.Key(collection, change.resource, doc)
+			}
+			from, to := keyOf(change.before), keyOf(change.after)
+			var err error
+			switch {
+			case change.before == nil || change.after == nil || from == to:
+				key := from
+				if key == "" {
+					key = to
+				}
+				err = apply(t, key, change.before, change.after)
+			default:
+				if err = apply(t, from, change.before, nil); err == nil {
+					err = apply(t, to, nil, change.after)
+				}
+			}
+			if err != nil {
+				b.release()
+				return nil, err
+			}
+		}
+	}
+
+	for _, target := range b.targets {
+		if err := d.WriteContext(ctx, target.collection, target.resource, target.doc); err != nil {
+			b.undo()
+			b.release()
+			return nil, err
+		}
+		target.written = true
+	}
+	return b, nil
+}
+
+// undo puts back the targets the batch wrote. Failures are logged, as
+// the caller is already returning an error.
+func (b *triggerBatch) undo() {
+	if b == nil {
+		return
+	}
+	for _, target := range b.targets {
+		if !target.written {
+			continue
+		}
+		var err error
+		if target.prev == nil {
+			err = b.d.DeleteContext(b.ctx, target.collection, target.resource)
+		} else {
+			err = b.d.WriteContext(b.ctx, target.collection, target.resource, target.prev)
+		}
+		if err != nil {
+			b.d.log.Error("Undoing trigger failed", "collection", target.collection, "resource", target.resource, "err", err)
+		}
+		target.written = false
+	}
+}
+
+func (b *triggerBatch) release() {
+	if b == nil {
+		return
+	}
+	for i := len(b.locks) - 1; i >= 0; i-- {
+		b.locks[i].Unlock()
+	}
+	b.locks = nil
+}
+
+// deleteTriggers runs collection's triggers for deleting resource, or
+// every record when resource is empty.
+func (d *Driver) deleteTriggers(ctx context.Context, collection, resource string) (*triggerBatch, error) {
+	if !d.hasTriggers(collection) {
+		return nil, nil
+	}
+
+	keys := []string{resource}
+	if resource == "" {
+		var err error
+		if keys, err = d.keys(collection); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := make([]triggerChange, 0, len(keys))
+	for _, key := range keys {
+		changes = append(changes, triggerChange{resource: key, before: d.recordDocument(collection, key)})
+	}
+	return d.runTriggers(ctx, collection, changes)
+}
@@ -0,0 +1,285 @@
+package litedb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WriteWithTTL is Write for a record that expires after ttl.
+func (d *Driver) WriteWithTTL(collection, resource string, v interface{}, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("ttl must be positive")
+	}
+	return d.write(context.Background(), collection, resource, v, ttl)
+}
+
+// SetTTL changes when an existing record expires without rewriting it. A
+// ttl of zero makes the record permanent.
+func (d *Driver) SetTTL(collection, resource string, ttl time.Duration) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if err := validResource(resource); err != nil {
+		return err
+	}
+	if ttl < 0 {
+		return fmt.Errorf("ttl cannot be negative")
+	}
+	if err := d.checkOpen(); err != nil {
+		return err
+	}
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	if err := d.expireIfDue(collection, resource); err != nil {
+		return err
+	}
+
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+
+	fi, err := os.Stat(d.recordPath(collection, resource))
+	if err != nil {
+		return err
+	}
+
+	m, err := d.readMeta(collection, resource)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		m = &Metadata{CreatedAt: fi.ModTime(), UpdatedAt: fi.ModTime()}
+	}
+
+	expiresAt := time.Time{}
+	m.ExpiresAt = nil
+	if ttl > 0 {
+		expiresAt = d.now().Add(ttl)
+		m.ExpiresAt = &expiresAt
+	}
+
+	if err := d.writeMeta(collection, resource, m); err != nil {
+		return err
+	}
+	d.setExpiry(collection, resource, expiresAt)
+
+	return nil
+}
+
+// Reap deletes every expired record and reports how many were removed.
+func (d *Driver) Reap() (int, error) {
+	if err := d.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	collections, err := d.metaCollections()
+	if err != nil {
+		return 0, err
+	}
+
+	reaped := 0
+	now := d.now()
+	for _, collection := range collections {
+		expiries, err := d.expiriesFor(collection)
+		if err != nil {
+			return reaped, err
+		}
+		for resource, expiresAt := range expiries {
+			if now.Before(expiresAt) {
+				continue
+			}
+			ok, err := d.expire(collection, resource)
+			if err != nil {
+				return reaped, err
+			}
+			if ok {
+				reaped++
+			}
+		}
+	}
+
+	return reaped, nil
+}
+
+// StartReaper runs Reap every interval until the returned function is
+// called.
+func (d *Driver) StartReaper(interval time.Duration) func() {
+	return d.every(interval, d.reapOnce)
+}
+
+// reapOnce is one pass of the reaper, logging what it did.
+func (d *Driver) reapOnce() {
+	n, err := d.Reap()
+	if err != nil {
+		d.log.Error("Reaping expired records failed", "err", err)
+		return
+	}
+	if n > 0 {
+		d.log.Debug("Reaped expired records", "records", n)
+	}
+}
+
+func (d *Driver) isExpired(collection, resource string) (bool, error) {
+	if d.readOnly {
+		// Another process may change expiries, so skip the index.
+		m, err := d.readMeta(collection, resource)
+		if err != nil || m == nil || m.ExpiresAt == nil {
+			return false, err
+		}
+		return !d.now().Before(*m.ExpiresAt), nil
+	}
+
+	expiresAt, ok, err := d.expiryOf(collection, resource)
+	if err != nil {
+		return false, err
+	}
+	return ok && !d.now().Before(expiresAt), nil
+}
+
+// expireIfDue lazily removes the record if it has expired, so the caller
+// sees it as missing.
+func (d *Driver) expireIfDue(collection, resource string) error {
+	expired, err := d.isExpired(collection, resource)
+	if err != nil || !expired {
+		return err
+	}
+	if d.readOnly {
+		return errNoRecord(collection, resource)
+	}
+	_, err = d.expire(collection, resource)
+	return err
+}
+
+// expire removes the record if its sidecar still says it has expired; a
+// concurrent Write may have refreshed it since the index was consulted.
+func (d *Driver) expire(collection, resource string) (bool, error) {
+	if d.readOnly {
+		// Expired records are left for a writable driver to remove.
+		return false, nil
+	}
+
+	mutex := d.lock(collection)
+	defer mutex.Unlock()
+
+	m, err := d.readMeta(collection, resource)
+	if err != nil {
+		return false, err
+	}
+	if m == nil || m.ExpiresAt == nil || d.now().Before(*m.ExpiresAt) {
+		return false, nil
+	}
+
+	if err := d.removeRecord(collection, resource); err != nil {
+		return false, err
+	}
+	if err := d.removeEdges(collection, resource); err != nil {
+		return false, err
+	}
+
+	d.emit(context.Background(), Deleted, collection, resource, nil)
+	d.audit(context.Background(), "expire", collection, resource, "")
+	return true, nil
+}
+
+// expiriesFor returns a snapshot of the collection's expiry index, loading
+// it from the sidecar files on first use.
+func (d *Driver) expiriesFor(collection string) (map[string]time.Time, error) {
+	d.expiryMutex.Lock()
+	defer d.expiryMutex.Unlock()
+
+	index, err := d.expiryIndex(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(map[string]time.Time, len(index))
+	for resource, expiresAt := range index {
+		snapshot[resource] = expiresAt
+	}
+
+	return snapshot, nil
+}
+
+// expiryOf looks up one resource's expiry without copying the index.
+func (d *Driver) expiryOf(collection, resource string) (time.Time, bool, error) {
+	d.expiryMutex.Lock()
+	defer d.expiryMutex.Unlock()
+
+	index, err := d.expiryIndex(collection)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	expiresAt, ok := index[resource]
+	return expiresAt, ok, nil
+}
+
+// expiryIndex returns the collection's expiry index, loading it on first
+// use. A read-only driver loads it afresh every time instead, since
+// another process may change expiries. The caller holds expiryMutex.
+func (d *Driver) expiryIndex(collection string) (map[string]time.Time, error) {
+	if d.readOnly {
+		return d.loadExpiries(collection)
+	}
+	if index, ok := d.expiries[collection]; ok {
+		return index, nil
+	}
+	index, err := d.loadExpiries(collection)
+	if err != nil {
+		return nil, err
+	}
+	d.expiries[collection] = index
+	return index, nil
+}
+
+func (d *Driver) loadExpiries(collection string) (map[string]time.Time, error) {
+	index := make(map[string]time.Time)
+
+	files, err := os.ReadDir(filepath.Join(d.dir, metaDir, collection))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return index, nil
+		}
+		return nil, err
+	}
+
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != recordExt {
+			continue
+		}
+		resource := d.resourceOf(file.Name(), recordExt)
+		m, err := d.readMeta(collection, resource)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil && m.ExpiresAt != nil {
+			index[resource] = *m.ExpiresAt
+		}
+	}
+
+	return index, nil
+}
+
+// setExpiry records expiresAt in a loaded index; the zero time clears it.
+// Unloaded indexes are left alone and pick the change up from disk.
+func (d *Driver) setExpiry(collection, resource string, expiresAt time.Time) {
+	d.expiryMutex.Lock()
+	defer d.expiryMutex.Unlock()
+
+	index, ok := d.expiries[collection]
+	if !ok {
+		return
+	}
+	if resource == "" {
+		delete(d.expiries, collection)
+		return
+	}
+	if expiresAt.IsZero() {
+		delete(index, resource)
+		return
+	}
+	index[resource] = expiresAt
+}
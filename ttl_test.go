@@ -0,0 +1,179 @@
+package litedb_test
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	litedb "github.com/SagarDas211/LiteDB-Go"
+	"github.com/SagarDas211/LiteDB-Go/litedbtest"
+)
+
+func newClock() *litedbtest.Clock {
+	return litedbtest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func assertKeys(t *testing.T, db *litedb.Driver, collection string, want ...string) {
+	t.Helper()
+	keys, err := db.Keys(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("Keys(%q) = %v, want %v", collection, keys, want)
+	}
+}
+
+func TestTTLExpiry(t *testing.T) {
+	clock := newClock()
+	db, err := litedb.New(t.TempDir(), litedb.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.WriteWithTTL("sessions", "short", map[string]int{"n": 1}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WriteWithTTL("sessions", "long", map[string]int{"n": 2}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Write("sessions", "forever", map[string]int{"n": 3}); err != nil {
+		t.Fatal(err)
+	}
+	assertKeys(t, db, "sessions", "forever", "long", "short")
+
+	clock.Advance(2 * time.Minute)
+	var v map[string]int
+	if err := db.Read("sessions", "short", &v); !errors.Is(err, litedb.ErrNotFound) {
+		t.Fatalf("Read of expired record = %v, want not found", err)
+	}
+	assertKeys(t, db, "sessions", "forever", "long")
+
+	// A plain write clears the expiry; SetTTL sets it again.
+	if err := db.Write("sessions", "long", map[string]int{"n": 4}); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Hour)
+	litedbtest.AssertRecord(t, db, "sessions", "long", map[string]int{"n": 4})
+	if err := db.SetTTL("sessions", "long", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	assertKeys(t, db, "sessions", "forever")
+}
+
+func TestReap(t *testing.T) {
+	clock := newClock()
+	db, err := litedb.New(t.TempDir(), litedb.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, resource := range []string{"a", "b", "c"} {
+		if err := db.WriteWithTTL("sessions", resource, map[string]int{}, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.WriteWithTTL("sessions", "d", map[string]int{}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := db.Reap(); err != nil || n != 0 {
+		t.Fatalf("Reap before expiry = %d, %v, want 0", n, err)
+	}
+	clock.Advance(time.Minute)
+	if n, err := db.Reap(); err != nil || n != 3 {
+		t.Fatalf("Reap = %d, %v, want 3", n, err)
+	}
+	if n, err := db.Reap(); err != nil || n != 0 {
+		t.Fatalf("second Reap = %d, %v, want 0", n, err)
+	}
+	assertKeys(t, db, "sessions", "d")
+}
+
+func TestStartReaper(t *testing.T) {
+	clock := newClock()
+	db, err := litedb.New(t.TempDir(), litedb.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	events, cancel := db.Watch("sessions")
+	defer cancel()
+
+	if err := db.WriteWithTTL("sessions", "s1", map[string]int{}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	<-events
+
+	stop := db.StartReaper(5 * time.Millisecond)
+	defer stop()
+	clock.Advance(time.Minute)
+
+	select {
+	case e := <-events:
+		if e.Type != litedb.Deleted || e.Resource != "s1" {
+			t.Fatalf("event = %+v, want s1 deleted", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the reaper did not remove the expired record")
+	}
+}
+
+func TestReadOnlyListingSeesNewExpiries(t *testing.T) {
+	dir := t.TempDir()
+	clock := newClock()
+	db, err := litedb.New(dir, litedb.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	litedbtest.Seed(t, db, "sessions", map[string]interface{}{
+		"a": map[string]int{},
+		"b": map[string]int{},
+	})
+
+	ro, err := litedb.OpenReadOnly(dir, litedb.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+	assertKeys(t, ro, "sessions", "a", "b")
+
+	// Expiries the writer sets after the reader first listed the
+	// collection still apply to the reader's listings.
+	if err := db.SetTTL("sessions", "a", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WriteWithTTL("sessions", "c", map[string]int{}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	assertKeys(t, ro, "sessions", "a", "b", "c")
+
+	clock.Advance(time.Minute)
+	assertKeys(t, ro, "sessions", "b")
+	all, err := ro.ReadAll("sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("ReadAll returned %d records, want 1", len(all))
+	}
+
+	// Nothing is removed until a writable driver reaps it.
+	if n, err := ro.Reap(); !errors.Is(err, litedb.ErrReadOnly) {
+		t.Fatalf("Reap on read-only driver = %d, %v, want ErrReadOnly", n, err)
+	}
+	if n, err := db.Reap(); err != nil || n != 2 {
+		t.Fatalf("Reap = %d, %v, want 2", n, err)
+	}
+}
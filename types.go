@@ -0,0 +1,45 @@
+package litedb
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// RegisterType records the Go type stored in collection, so APIs such as
+// GraphQL can describe its documents. v is a value or pointer of the type.
+// Its fields tagged `litedb:"sensitive"` are redacted as SetSensitive's
+// are.
+func (d *Driver) RegisterType(collection string, v interface{}) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("collection type must be a struct, got %T", v)
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	c := d.configFor(collection)
+	c.docType = t
+	c.typeSensitive = taggedSensitive(t, "", make(map[reflect.Type]bool))
+
+	return nil
+}
+
+func (d *Driver) registeredTypes() map[string]reflect.Type {
+	d.configMutex.RLock()
+	defer d.configMutex.RUnlock()
+
+	types := make(map[string]reflect.Type)
+	for collection, c := range d.configs {
+		if c.docType != nil {
+			types[collection] = c.docType
+		}
+	}
+	return types
+}
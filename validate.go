@@ -0,0 +1,44 @@
+package litedb
+
+import "fmt"
+
+// Validator checks a value before it is written; an error rejects the
+// write.
+type Validator func(resource string, v interface{}) error
+
+// AddValidator registers fn to check every write to collection.
+func (d *Driver) AddValidator(collection string, fn Validator) error {
+	if err := d.validCollection(collection); err != nil {
+		return err
+	}
+	if fn == nil {
+		return fmt.Errorf("validator cannot be nil")
+	}
+
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+	c := d.configFor(collection)
+	c.validators = append(c.validators, fn)
+
+	return nil
+}
+
+// validate runs the collection's validators in registration order and
+// stops at the first failure. Validators run before the collection lock is
+// taken so they may safely call back into the driver.
+func (d *Driver) validate(collection, resource string, v interface{}) error {
+	d.configMutex.RLock()
+	var validators []Validator
+	if c, ok := d.configs[collection]; ok {
+		validators = c.validators
+	}
+	d.configMutex.RUnlock()
+
+	for _, fn := range validators {
+		if err := fn(resource, v); err != nil {
+			return fmt.Errorf("validation failed for '%s' in collection '%s': %w", resource, collection, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,469 @@
+package litedb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	viewsDir = "_views"
+
+	// viewBuilt marks a view's state as complete, so an interrupted
+	// RebuildView is started over when the view is next defined.
+	viewBuilt = "built"
+)
+
+// Row is one row a View's Map makes from a source record: Value goes into
+// the row named Key in the view.
+type Row struct {
+	Key   string
+	Value interface{}
+}
+
+// View is a materialized view, defined with DefineView: a collection whose
+// records are computed from those of other collections and kept up to date
+// as they change.
+type View struct {
+	// Sources are the collections the view is computed from.
+	Sources []string
+
+	// Map returns the rows a source record contributes to. doc is the
+	// record decoded with numbers as json.Number. A record that is not a
+	// JSON object contributes nothing.
+	Map func(collection, resource string, doc map[string]interface{}) []Row
+
+	// Reduce combines every value contributed to the row key into the
+	// row's document, in the order of the records they came from. Nil
+	// makes the row {"values": [...]}. See ReduceCount and ReduceSum.
+	Reduce func(key string, values []json.RawMessage) (interface{}, error)
+}
+
+// ReduceCount is a View's Reduce making each row {"count": n}, the
+// number of values contributed to it.
+func ReduceCount(key string, values []json.RawMessage) (interface{}, error) {
+	return map[string]interface{}{"count": len(values)}, nil
+}
+
+// ReduceSum returns a View's Reduce making each row {"sum": x, "count":
+// n}: the total of the numbers at the dotted path field in the values
+// contributed to it, or of the values themselves when field is empty, and
+// how many there were. Values without a number there are left out.
+func ReduceSum(field string) func(key string, values []json.RawMessage) (interface{}, error) {
+	return func(key string, values []json.RawMessage) (interface{}, error) {
+		sum, n := 0.0, 0
+		for _, v := range values {
+			if f, ok := pointNumber(v, field); ok {
+				sum += f
+				n++
+			}
+		}
+		return map[string]interface{}{"sum": sum, "count": n}, nil
+	}
+}
+
+// DefineView makes name a materialized view of v. Its rows are records in
+// collection name, read like any other, and each write or delete in a
+// source updates only the rows that record contributes to. What each
+// record contributed is kept on disk under _views, so a view defined again
+// when the database is reopened picks up where it left off; it is only
+// built from scratch the first time, or by RebuildView. Like the
+// collection's other settings the definition itself is not stored, so
+// define views each time the database is opened, before it is written to.
+// Writing to the view's collection directly is not prevented, but the next
+// change to a row overwrites it.
+func (d *Driver) DefineView(name string, v View) error {
+	if err := d.validCollection(name); err != nil {
+		return err
+	}
+	if len(v.Sources) == 0 {
+		return fmt.Errorf("view '%s' needs at least one source", name)
+	}
+	if v.Map == nil {
+		return fmt.Errorf("view '%s' needs a Map", name)
+	}
+	for _, source := range v.Sources {
+		if err := d.validCollection(source); err != nil {
+			return err
+		}
+		if source == name {
+			return fmt.Errorf("view '%s' cannot be its own source", name)
+		}
+	}
+
+	if err := d.addView(name, v); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(d.dir, viewsDir, name, viewBuilt)); !os.IsNotExist(err) {
+		return err
+	}
+	return d.RebuildView(name)
+}
+
+func (d *Driver) addView(name string, v View) error {
+	d.configMutex.Lock()
+	defer d.configMutex.Unlock()
+
+	if c, ok := d.configs[name]; ok {
+		if c.view != nil {
+			return fmt.Errorf("view '%s' is already defined", name)
+		}
+		if len(c.viewsFrom) > 0 {
+			return fmt.Errorf("collection '%s' is a view's source, so it cannot be a view", name)
+		}
+	}
+	for _, source := range v.Sources {
+		if c, ok := d.configs[source]; ok && c.view != nil {
+			return fmt.Errorf("view '%s' cannot be a source of view '%s'", source, name)
+		}
+	}
+
+	view := v
+	view.Sources = append([]string(nil), v.Sources...)
+	d.configFor(name).view = &view
+	for _, source := range view.Sources {
+		c := d.configFor(source)
+		c.viewsFrom = append(c.viewsFrom[:len(c.viewsFrom):len(c.viewsFrom)], name)
+	}
+	return nil
+}
+
+// DropView stops maintaining view name and deletes its rows and what its
+// sources contributed.
+func (d *Driver) DropView(name string) error {
+	if err := d.validCollection(name); err != nil {
+		return err
+	}
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	d.configMutex.Lock()
+	c, ok := d.configs[name]
+	if !ok || c.view == nil {
+		d.configMutex.Unlock()
+		return fmt.Errorf("no view named '%s'", name)
+	}
+	for _, source := range c.view.Sources {
+		src := d.configFor(source)
+		var kept []string
+		for _, v := range src.viewsFrom {
+			if v != name {
+				kept = append(kept, v)
+			}
+		}
+		src.viewsFrom = kept
+	}
+	c.view = nil
+	d.configMutex.Unlock()
+
+	mutex := d.lock(viewsDir + "/" + name)
+	defer mutex.Unlock()
+	return d.clearView(name)
+}
+
+// clearView deletes view name's rows and state. The caller must hold the
+// view's lock.
+func (d *Driver) clearView(name string) error {
+	if err := os.RemoveAll(filepath.Join(d.dir, viewsDir, name)); err != nil {
+		return err
+	}
+	if err := d.Delete(name, ""); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// RebuildView recomputes view name from every record in its sources, as
+// after its Map or Reduce changed.
+func (d *Driver) RebuildView(name string) error {
+	v, err := d.viewNamed(name)
+	if err != nil {
+		return err
+	}
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+
+	mutex := d.lock(viewsDir + "/" + name)
+	defer mutex.Unlock()
+
+	if err := d.clearView(name); err != nil {
+		return err
+	}
+
+	state := make(map[string]map[string]json.RawMessage)
+	for _, source := range v.Sources {
+		keys, err := d.keys(source)
+		if err != nil {
+			return err
+		}
+		for _, resource := range keys {
+			b, err := d.readRecord(source, resource)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return err
+			}
+			rows, err := viewRows(v, source, resource, b)
+			if err != nil {
+				return err
+			}
+			id := viewSource(source, resource)
+			var keys []string
+			for key, value := range rows {
+				if state[key] == nil {
+					state[key] = make(map[string]json.RawMessage)
+				}
+				state[key][id] = value
+				keys = append(keys, key)
+			}
+			if len(keys) > 0 {
+				sort.Strings(keys)
+				if err := d.writeStateFile(d.viewSourcePath(name, source, resource), keys); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for key, contributions := range state {
+		if err := d.writeStateFile(d.viewRowPath(name, key), contributions); err != nil {
+			return err
+		}
+		if err := d.writeViewRow(name, v, key, contributions); err != nil {
+			return err
+		}
+	}
+	return d.writeStateFile(filepath.Join(d.dir, viewsDir, name, viewBuilt), v.Sources)
+}
+
+func (d *Driver) viewNamed(name string) (*View, error) {
+	if err := d.validCollection(name); err != nil {
+		return nil, err
+	}
+	d.configMutex.RLock()
+	defer d.configMutex.RUnlock()
+	if c, ok := d.configs[name]; ok && c.view != nil {
+		return c.view, nil
+	}
+	return nil, fmt.Errorf("no view named '%s'", name)
+}
+
+func (d *Driver) viewsFrom(collection string) []string {
+	d.configMutex.RLock()
+	defer d.configMutex.RUnlock()
+	if c, ok := d.configs[collection]; ok {
+		return c.viewsFrom
+	}
+	return nil
+}
+
+func (d *Driver) hasViews(collection string) bool {
+	return len(d.viewsFrom(collection)) > 0
+}
+
+// maintainViews brings the views sourced from collection up to date with
+// a change to resource, given the document written, if the writer kept
+// it, or nil after a delete. It runs with the collection lock held, so
+// changes to a record reach its views in order. A view that cannot be
+// updated is logged, as the change itself has already been made.
+func (d *Driver) maintainViews(t EventType, collection, resource string, data []byte) {
+	for _, name := range d.viewsFrom(collection) {
+		if err := d.maintainView(name, t, collection, resource, data); err != nil {
+			d.log.Error("Updating view failed", "view", name, "collection", collection, "resource", resource, "err", err)
+		}
+	}
+}
+
+func (d *Driver) maintainView(name string, t EventType, collection, resource string, data []byte) error {
+	v, err := d.viewNamed(name)
+	if err != nil {
+		return nil
+	}
+
+	var rows map[string]json.RawMessage
+	if t != Deleted {
+		if data == nil {
+			if data, err = d.readRecord(collection, resource); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if data != nil {
+			if rows, err = viewRows(v, collection, resource, data); err != nil {
+				return err
+			}
+		}
+	}
+
+	mutex := d.lock(viewsDir + "/" + name)
+	defer mutex.Unlock()
+
+	sourcePath := d.viewSourcePath(name, collection, resource)
+	var old []string
+	if err := readStateFile(sourcePath, &old); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(rows))
+	for key := range rows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	id := viewSource(collection, resource)
+	for _, key := range mergeKeys(old, keys) {
+		rowPath := d.viewRowPath(name, key)
+		contributions := make(map[string]json.RawMessage)
+		if err := readStateFile(rowPath, &contributions); err != nil {
+			return err
+		}
+		if value, ok := rows[key]; ok {
+			contributions[id] = value
+		} else {
+			delete(contributions, id)
+		}
+
+		if len(contributions) == 0 {
+			if err := d.removeStateFile(rowPath); err != nil {
+				return err
+			}
+			if err := d.Delete(name, key); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			continue
+		}
+		if err := d.writeStateFile(rowPath, contributions); err != nil {
+			return err
+		}
+		if err := d.writeViewRow(name, v, key, contributions); err != nil {
+			return err
+		}
+	}
+
+	if len(keys) == 0 {
+		return d.removeStateFile(sourcePath)
+	}
+	return d.writeStateFile(sourcePath, keys)
+}
+
+// viewRows maps the record b through v, with each row's value encoded.
+func viewRows(v *View, collection, resource string, b []byte) (map[string]json.RawMessage, error) {
+	doc, err := decodeDocument(b)
+	if err != nil {
+		return nil, nil
+	}
+	rows := make(map[string]json.RawMessage)
+	for _, row := range v.Map(collection, resource, doc) {
+		if err := validResource(row.Key); err != nil {
+			return nil, fmt.Errorf("row of '%s' in collection '%s': %w", resource, collection, err)
+		}
+		value, err := json.Marshal(row.Value)
+		if err != nil {
+			return nil, fmt.Errorf("row '%s' of '%s' in collection '%s': %w", row.Key, resource, collection, err)
+		}
+		rows[row.Key] = value
+	}
+	return rows, nil
+}
+
+// writeViewRow reduces a row's contributions and writes it to the view.
+func (d *Driver) writeViewRow(name string, v *View, key string, contributions map[string]json.RawMessage) error {
+	ids := make([]string, 0, len(contributions))
+	for id := range contributions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	values := make([]json.RawMessage, len(ids))
+	for i, id := range ids {
+		values[i] = contributions[id]
+	}
+
+	var row interface{} = map[string]interface{}{"values": values}
+	if v.Reduce != nil {
+		var err error
+		if row, err = v.Reduce(key, values); err != nil {
+			return fmt.Errorf("reducing row '%s' of view '%s': %w", key, name, err)
+		}
+	}
+	return d.Write(name, key, row)
+}
+
+// viewSource names a source record in a row's contributions. Collection
+// names cannot hold a slash, so the first one splits it.
+func viewSource(collection, resource string) string {
+	return collection + "/" + resource
+}
+
+func (d *Driver) viewRowPath(name, key string) string {
+	return filepath.Join(d.dir, viewsDir, name, "rows", d.fileStem(key)+recordExt)
+}
+
+func (d *Driver) viewSourcePath(name, collection, resource string) string {
+	return filepath.Join(d.dir, viewsDir, name, "sources", collection, d.fileStem(resource)+recordExt)
+}
+
+// readStateFile decodes the JSON state file at path into v, such as a
+// view's or topic's bookkeeping, leaving v alone if there is none.
+func readStateFile(path string, v interface{}) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("state file %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// writeStateFile replaces the state file at path with v as JSON.
+func (d *Driver) writeStateFile(path string, v interface{}) error {
+	if err := d.storage.MkdirAll(filepath.Dir(path), dirMode(d.fileMode)); err != nil {
+		return err
+	}
+	tempPath := path + ".tmp"
+	w := getRecordWriter()
+	defer putRecordWriter(w)
+	w.mode = d.fileMode
+	w.storage = d.storage
+	if _, _, _, err := w.encodeFile(tempPath, v, false); err != nil {
+		return err
+	}
+	if err := d.storage.Rename(tempPath, path); err != nil {
+		d.storage.Remove(tempPath)
+		return err
+	}
+	return d.commit(path)
+}
+
+func (d *Driver) removeStateFile(path string) error {
+	if err := d.storage.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// mergeKeys returns the sorted keys in either of a and b, which are sorted.
+func mergeKeys(a, b []string) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for len(a) > 0 || len(b) > 0 {
+		switch {
+		case len(b) == 0 || (len(a) > 0 && a[0] < b[0]):
+			keys, a = append(keys, a[0]), a[1:]
+		case len(a) == 0 || b[0] < a[0]:
+			keys, b = append(keys, b[0]), b[1:]
+		default:
+			keys, a, b = append(keys, a[0]), a[1:], b[1:]
+		}
+	}
+	return keys
+}
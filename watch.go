@@ -0,0 +1,110 @@
+package litedb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EventType is the kind of mutation an Event reports.
+type EventType int
+
+const (
+	Created EventType = iota
+	Updated
+	Deleted
+)
+
+func (t EventType) String() string {
+	switch t {
+	case Created:
+		return "create"
+	case Updated:
+		return "update"
+	case Deleted:
+		return "delete"
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// Event is a mutation reported by Watch. External is set for changes
+// made to the files by another process.
+type Event struct {
+	Type       EventType
+	Collection string
+	Resource   string
+	Time       time.Time
+	External   bool
+}
+
+// CancelFunc stops a watch and closes its channel.
+type CancelFunc func()
+
+const watchBuffer = 64
+
+type watcher struct {
+	collection string
+	ch         chan Event
+}
+
+type watchers struct {
+	mutex sync.Mutex
+	next  int
+	subs  map[int]*watcher
+}
+
+// Watch streams the mutations this Driver makes to collection, or to every
+// collection when it is empty. Events are delivered in commit order per
+// collection; a watcher that falls more than a buffer behind misses events
+// rather than stalling writers. The channel is closed by the CancelFunc,
+// or by Close.
+func (d *Driver) Watch(collection string) (<-chan Event, CancelFunc) {
+	w := &watcher{collection: collection, ch: make(chan Event, watchBuffer)}
+
+	d.watchers.mutex.Lock()
+	if d.watchers.subs == nil {
+		d.watchers.subs = make(map[int]*watcher)
+	}
+	id := d.watchers.next
+	d.watchers.next++
+	d.watchers.subs[id] = w
+	d.watchers.mutex.Unlock()
+
+	var once sync.Once
+	return w.ch, d.onClose(func() {
+		once.Do(func() {
+			d.watchers.mutex.Lock()
+			delete(d.watchers.subs, id)
+			d.watchers.mutex.Unlock()
+			close(w.ch)
+		})
+	})
+}
+
+// emit announces a committed mutation to watchers and CDC sinks. Callers
+// hold the collection lock so events for a collection leave in commit
+// order. data is the written document, nil for deletes.
+func (d *Driver) emit(ctx context.Context, t EventType, collection, resource string, data []byte) {
+	now := d.now()
+	d.publish(Event{Type: t, Collection: collection, Resource: resource, Time: now})
+	d.capture(ctx, t, collection, resource, data, now)
+	d.maintainViews(t, collection, resource, data)
+	d.sortedSets.changed(t, collection, resource, data)
+}
+
+func (d *Driver) publish(e Event) {
+	d.watchers.mutex.Lock()
+	defer d.watchers.mutex.Unlock()
+
+	for _, w := range d.watchers.subs {
+		if w.collection != "" && w.collection != e.Collection {
+			continue
+		}
+		select {
+		case w.ch <- e:
+		default:
+			d.log.Warn("Dropped event for a watcher that is not keeping up", "event", e.Type.String(), "collection", e.Collection, "resource", e.Resource)
+		}
+	}
+}